@@ -1,12 +1,12 @@
 package api
 
 import (
-	"database/sql"
 	"net/http"
 
+	"github.com/ShubhKanodia/GoBank/apperror"
 	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/rbac"
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
 )
 
 // what is ctx *gin.Context? -> it is a context object that contains information about the HTTP request and response.
@@ -17,9 +17,11 @@ import (
 // CreateAccountRequest contains the request parameters for creating a new account.
 // Server serves HTTP requests for our banking service.
 
+// the owner is the authenticated user, tellers can name a customer instead. a body naming the caller, as the
+// unversioned API required, is still accepted
 type CreateAccountRequest struct {
-	Owner    string `json:"owner" binding:"required"`
 	Currency string `json:"currency" binding:"required,currency"`
+	Owner    string `json:"owner" binding:"omitempty,alphanum"`
 }
 
 type GetAccountRequest struct {
@@ -32,6 +34,8 @@ type listAccountsRequest struct {
 	// PageID is the page number for pagination, starting from 1
 	//pageid and pagesize are query parameters that are used for pagination, whereas id is a uri parameter
 	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	// Owner lists the accounts of another user, for tellers
+	Owner string `form:"owner" binding:"omitempty,alphanum"`
 }
 
 func (server *Server) createAccount(ctx *gin.Context) {
@@ -39,31 +43,27 @@ func (server *Server) createAccount(ctx *gin.Context) {
 	//Bind validation rules to the request body
 	// If the request body is not valid, it will return a 400 Bad Request error
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		renderError(ctx, bindingError(err))
 		// ShouldBindJSON binds the request body to the struct and validates it
 		return
 	}
 
+	authPayload := authPayload(ctx)
 	arg := db.CreateAccountParams{
-		Owner:    req.Owner,
+		Owner:    authPayload.Username,
 		Currency: req.Currency,
 	}
+	if req.Owner != "" && req.Owner != authPayload.Username {
+		if !server.customer(ctx, req.Owner) {
+			return
+		}
+		arg.Owner = req.Owner
+	}
 
-	account, err := server.store.CreateAccount(ctx.Request.Context(), arg)
+	account, err := server.store.CreateAccountTx(ctx.Request.Context(), arg)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			// Check if the error is a PostgreSQL error
-			switch pqErr.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				// Handle specific PostgreSQL error codes
-				ctx.JSON(http.StatusConflict, gin.H{"error": pqErr.Message}) // 409 Conflict for unique constraint violations
-			default:
-				// For other PostgreSQL errors, return a generic internal server error
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": pqErr.Message})
-
-			}
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		// the store already translated foreign key and unique violations into conflicts (409)
+		renderError(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, account)
@@ -73,39 +73,93 @@ func (server *Server) getAccount(ctx *gin.Context) {
 	var req GetAccountRequest
 	// Bind the request parameters to the struct
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		renderError(ctx, bindingError(err))
 		return
 	}
 	id := req.ID
 	account, err := server.store.GetAccount(ctx.Request.Context(), id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		renderError(ctx, err)
+		return
+	}
+
+	if !ownerOrPermitted(ctx, account.Owner, rbac.AccountsReadAny) {
+		renderError(ctx, apperror.Forbidden("account doesn't belong to the authenticated user"))
 		return
 	}
 	ctx.JSON(http.StatusOK, account)
 }
 
+// getAccountLimits returns the daily and monthly transfer limits of an account and how much of them is used
+func (server *Server) getAccountLimits(ctx *gin.Context) {
+	var req GetAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	account, err := server.store.GetAccount(ctx.Request.Context(), req.ID)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	if !ownerOrPermitted(ctx, account.Owner, rbac.AccountsReadAny) {
+		renderError(ctx, apperror.Forbidden("account doesn't belong to the authenticated user"))
+		return
+	}
+
+	usage, err := server.store.GetTransferLimitUsage(ctx.Request.Context(), account.ID)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, usage)
+}
+
 func (server *Server) ListAccounts(ctx *gin.Context) {
 	var req listAccountsRequest
 	// Bind the request parameters to the struct
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		renderError(ctx, bindingError(err))
 		return
 	}
 	// List accounts with pagination
 
+	authPayload := authPayload(ctx)
 	arg := db.ListAccountsParams{
+		Owner:  authPayload.Username, // users only ever see their own accounts, tellers those of anyone
 		Limit:  req.PageSize,
 		Offset: (req.PageID - 1) * req.PageSize, // Offset is calculated based on the page number and page size
 	}
+	if req.Owner != "" {
+		if !ownerOrPermitted(ctx, req.Owner, rbac.AccountsReadAny) {
+			renderError(ctx, apperror.Forbidden("only tellers can list the accounts of another user"))
+			return
+		}
+		arg.Owner = req.Owner
+	}
 	accounts, err := server.store.ListAccounts(ctx.Request.Context(), arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		renderError(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, accounts)
 }
+
+// customer checks the caller may open accounts for others and that username is a customer,
+// it renders the error itself when not
+func (server *Server) customer(ctx *gin.Context, username string) bool {
+	if !rbac.Permits(authPayload(ctx), rbac.AccountsCreateAny) {
+		renderError(ctx, apperror.Forbidden("only tellers can open accounts for another user"))
+		return false
+	}
+	user, err := server.store.GetUser(ctx.Request.Context(), username)
+	if err != nil {
+		renderError(ctx, err)
+		return false
+	}
+	if user.Role != db.UserRoleCustomer {
+		renderError(ctx, apperror.Forbidden("accounts are opened for customers only"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// freezeAccount stops an account from sending and receiving transfers until it is unfrozen
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.setFrozen(ctx, server.store.FreezeAccountTx)
+}
+
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.setFrozen(ctx, server.store.UnfreezeAccountTx)
+}
+
+// setFrozen runs update on the account of the request, an account already in the state asked for is a conflict
+func (server *Server) setFrozen(ctx *gin.Context, update func(ctx context.Context, id int64) (db.Account, error)) {
+	var req GetAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	account, err := update(ctx.Request.Context(), req.ID)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, account)
+}
+
+type adjustBalanceRequest struct {
+	// Amount is credited when positive and debited when negative
+	Amount int64  `json:"amount" binding:"required"`
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// adjustBalance books a correction on an account, outside of any transfer
+func (server *Server) adjustBalance(ctx *gin.Context) {
+	var uri GetAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	var req adjustBalanceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	result, err := server.store.AdjustBalanceTx(ctx.Request.Context(), db.AdjustBalanceTxParams{
+		AccountID: uri.ID,
+		Amount:    req.Amount,
+		Reason:    req.Reason,
+		CreatedBy: authPayload(ctx).Username,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (server *Server) listBalanceAdjustments(ctx *gin.Context) {
+	var uri GetAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	var req pageQuery
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	adjustments, err := server.store.ListBalanceAdjustments(ctx.Request.Context(), db.ListBalanceAdjustmentsParams{
+		AccountID: uri.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, adjustments)
+}
+
+type transferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// reverseTransfer moves the amount of a transfer back, once
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	var req transferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx.Request.Context(), req.ID)
+	if err != nil {
+		// reversing a reversal or a transfer twice is a conflict
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFreezeAccountAPI(t *testing.T) {
+	account := randomAccount(util.RandomOwner())
+
+	testCases := []struct {
+		name       string
+		action     string
+		buildStubs func(store *mockdb.MockStore)
+		status     int
+	}{
+		{
+			name:   "OK",
+			action: "freeze",
+			buildStubs: func(store *mockdb.MockStore) {
+				frozen := account
+				frozen.FrozenAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().FreezeAccountTx(gomock.Any(), gomock.Eq(account.ID)).Times(1).
+					DoAndReturn(func(ctx context.Context, _ int64) (db.Account, error) {
+						// the admin is the actor of the audit event
+						require.Equal(t, "admin", db.AuditorFrom(ctx).Actor)
+						return frozen, nil
+					})
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "AlreadyFrozen",
+			action: "freeze",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().FreezeAccountTx(gomock.Any(), gomock.Eq(account.ID)).Times(1).
+					Return(db.Account{}, apperror.Conflict("account is already frozen"))
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "NotFound",
+			action: "freeze",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().FreezeAccountTx(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "Unfreeze",
+			action: "unfreeze",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().FreezeAccountTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UnfreezeAccountTx(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "NotFrozen",
+			action: "unfreeze",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UnfreezeAccountTx(gomock.Any(), gomock.Eq(account.ID)).Times(1).
+					Return(db.Account{}, apperror.Conflict("account is already unfrozen"))
+			},
+			status: http.StatusConflict,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/v1/admin/accounts/%d/%s", account.ID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "admin", db.UserRoleAdmin, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
+
+func TestAdjustBalanceAPI(t *testing.T) {
+	account := randomAccount(util.RandomOwner())
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"amount": -25, "reason": "duplicate card fee"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().AdjustBalanceTx(gomock.Any(), gomock.Eq(db.AdjustBalanceTxParams{
+					AccountID: account.ID,
+					Amount:    -25,
+					Reason:    "duplicate card fee",
+					CreatedBy: "admin",
+				})).Times(1).Return(db.AdjustBalanceTxResult{
+					Adjustment: db.BalanceAdjustment{ID: 1, AccountID: account.ID, Amount: -25},
+					Account:    account,
+				}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.AdjustBalanceTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, int64(-25), got.Adjustment.Amount)
+			},
+		},
+		{
+			name: "MissingReason",
+			body: gin.H{"amount": 25},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().AdjustBalanceTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "NegativeBalance",
+			body: gin.H{"amount": -account.Balance - 1, "reason": "chargeback"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().AdjustBalanceTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.AdjustBalanceTxResult{}, apperror.InsufficientFunds("the adjustment would leave a negative balance"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/v1/admin/accounts/%d/adjustments", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "admin", db.UserRoleAdmin, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestReverseTransferAPI(t *testing.T) {
+	transferID := util.RandomInt(1, 1000)
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		status     int
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(transferID)).Times(1).
+					Return(db.TransferTxResult{Transfer: db.Transfer{ID: transferID + 1}}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "AlreadyReversed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(transferID)).Times(1).
+					Return(db.TransferTxResult{}, apperror.Conflict("transfer is already reversed"))
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name: "Blocked",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(transferID)).Times(1).
+					Return(db.TransferTxResult{}, apperror.TransferBlocked("transfer blocked by sanctions screening"))
+			},
+			status: http.StatusForbidden,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/v1/admin/transfers/%d/reverse", transferID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "admin", db.UserRoleAdmin, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/rbac"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// lastEventIDHeader is sent by EventSource when it reconnects
+	lastEventIDHeader = "Last-Event-ID"
+	// eventBatchSize bounds how many events are read from the database at once
+	eventBatchSize = 100
+	// heartbeatInterval keeps idle streams from being cut by proxies
+	heartbeatInterval = 15 * time.Second
+)
+
+// EventSubscriber tells streams when an account may have new events, events.Broker implements it
+type EventSubscriber interface {
+	Subscribe(accountID int64) (notifications <-chan struct{}, unsubscribe func())
+}
+
+type streamAccountEventsRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+	// LastEventID resumes after that event, the Last-Event-ID header takes precedence
+	LastEventID int64 `form:"last_event_id" binding:"min=0"`
+}
+
+// streamAccountEvents pushes the events of an account as Server-Sent Events.
+// it replays everything after the last event id, then waits for notifications and reads new events from the database
+func (server *Server) streamAccountEvents(ctx *gin.Context) {
+	var req streamAccountEventsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if header := ctx.GetHeader(lastEventIDHeader); header != "" {
+		lastEventID, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || lastEventID < 0 {
+			renderError(ctx, apperror.Validation("invalid Last-Event-ID header"))
+			return
+		}
+		req.LastEventID = lastEventID
+	}
+
+	account, err := server.store.GetAccount(ctx.Request.Context(), req.ID)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	if !ownerOrPermitted(ctx, account.Owner, rbac.AccountsReadAny) {
+		renderError(ctx, apperror.Forbidden("account doesn't belong to the authenticated user"))
+		return
+	}
+
+	// subscribe before the first read so nothing committed in between is missed
+	notifications, unsubscribe := server.events.Subscribe(account.ID)
+	defer unsubscribe()
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// stops nginx from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	lastEventID := req.LastEventID
+	for {
+		lastEventID, err = server.sendAccountEvents(ctx, account.ID, lastEventID)
+		if err != nil {
+			// the status is already sent, the client reconnects with the last id it got
+			slog.ErrorContext(ctx.Request.Context(), "account event stream failed",
+				slog.Int64("account_id", account.ID), slog.String("error", err.Error()))
+			return
+		}
+
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-server.streamsDone.Done():
+			// shutting down, clients resume on another replica
+			return
+		case <-notifications:
+		case <-heartbeat.C:
+			fmt.Fprint(ctx.Writer, ": heartbeat\n\n")
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+// sendAccountEvents writes every event after afterID and returns the id of the last one written
+func (server *Server) sendAccountEvents(ctx *gin.Context, accountID int64, afterID int64) (int64, error) {
+	for {
+		events, err := server.store.ListAccountEvents(ctx.Request.Context(), db.ListAccountEventsParams{
+			AccountID: accountID,
+			AfterID:   afterID,
+			Limit:     eventBatchSize,
+		})
+		if err != nil {
+			return afterID, err
+		}
+		for _, event := range events {
+			ctx.Render(-1, sse.Event{
+				Id:    strconv.FormatInt(event.ID, 10),
+				Event: event.Type,
+				Data:  event,
+			})
+			afterID = event.ID
+		}
+		ctx.Writer.Flush()
+		if len(events) < eventBatchSize {
+			return afterID, nil
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/events"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStreamAccountEventsAPI(t *testing.T) {
+	account := randomAccount(util.RandomOwner())
+	event1 := randomAccountEvent(t, account.ID, 6)
+	event2 := randomAccountEvent(t, account.ID, 7)
+
+	testCases := []struct {
+		name        string
+		username    string
+		lastEventID string
+		// buildStubs gets the broker and a func that ends the request, as the client closing the stream would
+		buildStubs    func(store *mockdb.MockStore, broker *events.Broker, disconnect func())
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "ResumeFromLastEventID",
+			username:    account.Owner,
+			lastEventID: "5",
+			buildStubs: func(store *mockdb.MockStore, broker *events.Broker, disconnect func()) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.ListAccountEventsParams{AccountID: account.ID, AfterID: 5, Limit: eventBatchSize}
+				store.EXPECT().ListAccountEvents(gomock.Any(), gomock.Eq(arg)).Times(1).
+					DoAndReturn(func(context.Context, db.ListAccountEventsParams) ([]db.AccountEvent, error) {
+						disconnect()
+						return []db.AccountEvent{event1, event2}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Type"), "text/event-stream")
+				requireStreamedEvents(t, recorder, event1, event2)
+			},
+		},
+		{
+			name:     "PushedOnNotification",
+			username: account.Owner,
+			buildStubs: func(store *mockdb.MockStore, broker *events.Broker, disconnect func()) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				gomock.InOrder(
+					store.EXPECT().ListAccountEvents(gomock.Any(), gomock.Eq(db.ListAccountEventsParams{AccountID: account.ID, AfterID: 0, Limit: eventBatchSize})).
+						DoAndReturn(func(context.Context, db.ListAccountEventsParams) ([]db.AccountEvent, error) {
+							// a transfer commits while the stream is idle
+							broker.Publish(account.ID)
+							return []db.AccountEvent{}, nil
+						}),
+					store.EXPECT().ListAccountEvents(gomock.Any(), gomock.Eq(db.ListAccountEventsParams{AccountID: account.ID, AfterID: 0, Limit: eventBatchSize})).
+						DoAndReturn(func(context.Context, db.ListAccountEventsParams) ([]db.AccountEvent, error) {
+							disconnect()
+							return []db.AccountEvent{event1}, nil
+						}),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireStreamedEvents(t, recorder, event1)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: "unauthorized_user",
+			buildStubs: func(store *mockdb.MockStore, broker *events.Broker, disconnect func()) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			buildStubs: func(store *mockdb.MockStore, broker *events.Broker, disconnect func()) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: account.Owner,
+			buildStubs: func(store *mockdb.MockStore, broker *events.Broker, disconnect func()) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "InvalidLastEventID",
+			username:    account.Owner,
+			lastEventID: "abc",
+			buildStubs: func(store *mockdb.MockStore, broker *events.Broker, disconnect func()) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			ctx, disconnect := context.WithCancel(context.Background())
+			defer disconnect()
+			tc.buildStubs(store, server.events.(*events.Broker), disconnect)
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/v1/accounts/%d/events", account.ID)
+			request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			require.NoError(t, err)
+			if tc.lastEventID != "" {
+				request.Header.Set(lastEventIDHeader, tc.lastEventID)
+			}
+			if tc.username != "" {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, db.UserRoleCustomer, time.Minute)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func randomAccountEvent(t *testing.T, accountID int64, id int64) db.AccountEvent {
+	data, err := json.Marshal(db.EntryCreatedEvent{
+		Entry:      db.Entry{ID: util.RandomInt(1, 1000), AccountID: accountID, Amount: util.RandomMoney()},
+		TransferID: util.RandomInt(1, 1000),
+		Balance:    util.RandomMoney(),
+	})
+	require.NoError(t, err)
+
+	return db.AccountEvent{
+		ID:        id,
+		AccountID: accountID,
+		Type:      db.AccountEventEntryCreated,
+		Data:      data,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// requireStreamedEvents checks the body holds exactly events, in order, in the text/event-stream format
+func requireStreamedEvents(t *testing.T, recorder *httptest.ResponseRecorder, events ...db.AccountEvent) {
+	var expected string
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		require.NoError(t, err)
+		expected += fmt.Sprintf("id:%d\nevent:%s\ndata:%s\n\n", event.ID, event.Type, data)
+	}
+	require.Equal(t, expected, recorder.Body.String())
+}
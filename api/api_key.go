@@ -0,0 +1,182 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apikey"
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+type createAPIKeyRequest struct {
+	// Name tells the keys of a user apart, e.g. the service using it
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,unique,dive,api_key_scope"`
+	// AllowedIPs are the IPs or CIDRs the key is accepted from, any address when empty
+	AllowedIPs []string `json:"allowed_ips" binding:"omitempty,max=20"`
+	// ExpiresAt is when the key stops working, never when left out
+	ExpiresAt time.Time `json:"expires_at" binding:"omitempty,future"`
+}
+
+// apiKeyResponse never includes the key, it is only returned once when it is issued
+type apiKeyResponse struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Prefix string `json:"prefix"`
+	// Scopes narrow what the key may do within the permissions of the user's role
+	Scopes     []string       `json:"scopes"`
+	AllowedIPs []string       `json:"allowed_ips"`
+	ExpiresAt  sql.NullTime   `json:"expires_at"`
+	LastUsedAt sql.NullTime   `json:"last_used_at"`
+	LastUsedIP sql.NullString `json:"last_used_ip"`
+	RevokedAt  sql.NullTime   `json:"revoked_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type createAPIKeyResponse struct {
+	apiKeyResponse
+	// Key goes in the authorization header as "ApiKey <key>"
+	Key string `json:"key"`
+}
+
+// rotateAPIKeyResponse is the new key and the key it replaces, which works until its expires_at
+type rotateAPIKeyResponse struct {
+	Key    createAPIKeyResponse `json:"key"`
+	OldKey apiKeyResponse       `json:"old_key"`
+}
+
+func newAPIKeyResponse(key db.ApiKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		AllowedIPs: key.AllowedIps,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		LastUsedIP: key.LastUsedIp,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+type apiKeyRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) createAPIKey(ctx *gin.Context) {
+	var req createAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	allowedIPs, err := apikey.ParseAllowedIPs(req.AllowedIPs)
+	if err != nil {
+		renderError(ctx, apperror.Validation(err.Error()))
+		return
+	}
+
+	newKey, err := apikey.Generate()
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	key, err := server.store.CreateAPIKeyTx(ctx.Request.Context(), db.CreateAPIKeyParams{
+		Username:   authPayload(ctx).Username,
+		Name:       req.Name,
+		Prefix:     newKey.Prefix,
+		SecretHash: newKey.SecretHash,
+		Scopes:     req.Scopes,
+		AllowedIps: allowedIPs,
+		ExpiresAt:  sql.NullTime{Time: req.ExpiresAt, Valid: !req.ExpiresAt.IsZero()},
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, createAPIKeyResponse{
+		apiKeyResponse: newAPIKeyResponse(key),
+		Key:            newKey.Key,
+	})
+}
+
+func (server *Server) listAPIKeys(ctx *gin.Context) {
+	keys, err := server.store.ListAPIKeys(ctx.Request.Context(), authPayload(ctx).Username)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	rsp := make([]apiKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		rsp = append(rsp, newAPIKeyResponse(key))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// rotateAPIKey issues a new key in place of one, the old key keeps working for the rotation grace period so
+// that the clients using it can switch over
+func (server *Server) rotateAPIKey(ctx *gin.Context) {
+	var req apiKeyRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if _, ok := server.ownAPIKey(ctx, req.ID); !ok {
+		return
+	}
+
+	newKey, err := apikey.Generate()
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	result, err := server.store.RotateAPIKeyTx(ctx.Request.Context(), db.RotateAPIKeyTxParams{
+		ID:           req.ID,
+		Prefix:       newKey.Prefix,
+		SecretHash:   newKey.SecretHash,
+		OldExpiresAt: time.Now().Add(server.config.APIKeyRotationGrace),
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, rotateAPIKeyResponse{
+		Key:    createAPIKeyResponse{apiKeyResponse: newAPIKeyResponse(result.Key), Key: newKey.Key},
+		OldKey: newAPIKeyResponse(result.OldKey),
+	})
+}
+
+func (server *Server) revokeAPIKey(ctx *gin.Context) {
+	var req apiKeyRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if _, ok := server.ownAPIKey(ctx, req.ID); !ok {
+		return
+	}
+
+	key, err := server.store.RevokeAPIKeyTx(ctx.Request.Context(), req.ID)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newAPIKeyResponse(key))
+}
+
+// ownAPIKey loads the key and checks it belongs to the caller, it renders the error itself when not
+func (server *Server) ownAPIKey(ctx *gin.Context, id int64) (db.ApiKey, bool) {
+	key, err := server.store.GetAPIKey(ctx.Request.Context(), id)
+	if err != nil {
+		renderError(ctx, err)
+		return key, false
+	}
+	if key.Username != authPayload(ctx).Username {
+		renderError(ctx, apperror.Forbidden("api key doesn't belong to the authenticated user"))
+		return key, false
+	}
+	return key, true
+}
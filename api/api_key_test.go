@@ -0,0 +1,491 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apikey"
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/rbac"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateAPIKeyAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	key := randomAPIKey(user.Username)
+	expiresAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"name":        key.Name,
+				"scopes":      key.Scopes,
+				"allowed_ips": []string{"192.0.2.7", "198.51.100.0/24"},
+				"expires_at":  expiresAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAPIKeyTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, arg db.CreateAPIKeyParams) (db.ApiKey, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, key.Name, arg.Name)
+						require.Equal(t, key.Scopes, arg.Scopes)
+						require.Equal(t, []string{"192.0.2.7/32", "198.51.100.0/24"}, arg.AllowedIps)
+						require.True(t, arg.ExpiresAt.Valid)
+						require.True(t, expiresAt.Equal(arg.ExpiresAt.Time))
+						require.Len(t, arg.Prefix, 16)
+						require.NotEmpty(t, arg.SecretHash)
+						key.Prefix = arg.Prefix
+						return key, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var rsp createAPIKeyResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, key.ID, rsp.ID)
+				require.True(t, strings.HasPrefix(rsp.Key, "gbk_"+key.Prefix+"_"))
+			},
+		},
+		{
+			name: "UnknownScope",
+			body: gin.H{
+				"name":   key.Name,
+				"scopes": []string{string(rbac.ScopeAccountsRead), "accounts:freeze"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAPIKeyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "NoScopes",
+			body: gin.H{
+				"name":   key.Name,
+				"scopes": []string{},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAPIKeyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAllowedIP",
+			body: gin.H{
+				"name":        key.Name,
+				"scopes":      key.Scopes,
+				"allowed_ips": []string{"example.com"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAPIKeyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "ExpiresInThePast",
+			body: gin.H{
+				"name":       key.Name,
+				"scopes":     key.Scopes,
+				"expires_at": time.Now().Add(-time.Hour),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAPIKeyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/api-keys", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListAPIKeysAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	keys := []db.ApiKey{randomAPIKey(user.Username), randomAPIKey(user.Username)}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListAPIKeys(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(keys, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/v1/api-keys", nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	// neither the keys nor their hashes are ever listed
+	require.NotContains(t, recorder.Body.String(), keys[0].SecretHash)
+	var rsp []apiKeyResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp, 2)
+	require.Equal(t, keys[1].Prefix, rsp[1].Prefix)
+}
+
+func TestRotateAPIKeyAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	key := randomAPIKey(user.Username)
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKey(gomock.Any(), gomock.Eq(key.ID)).Times(1).Return(key, nil)
+				store.EXPECT().RotateAPIKeyTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, arg db.RotateAPIKeyTxParams) (db.RotateAPIKeyTxResult, error) {
+						require.Equal(t, key.ID, arg.ID)
+						require.NotEqual(t, key.Prefix, arg.Prefix)
+						// the test server has no grace period
+						require.WithinDuration(t, time.Now(), arg.OldExpiresAt, time.Second)
+						newKey := key
+						newKey.ID++
+						newKey.Prefix = arg.Prefix
+						oldKey := key
+						oldKey.ExpiresAt = sql.NullTime{Time: arg.OldExpiresAt, Valid: true}
+						return db.RotateAPIKeyTxResult{Key: newKey, OldKey: oldKey}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var rsp rotateAPIKeyResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, key.ID+1, rsp.Key.ID)
+				require.True(t, strings.HasPrefix(rsp.Key.Key, "gbk_"+rsp.Key.Prefix+"_"))
+				require.Equal(t, key.ID, rsp.OldKey.ID)
+				require.True(t, rsp.OldKey.ExpiresAt.Valid)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: "unauthorized_user",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKey(gomock.Any(), gomock.Eq(key.ID)).Times(1).Return(key, nil)
+				store.EXPECT().RotateAPIKeyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "Revoked",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKey(gomock.Any(), gomock.Eq(key.ID)).Times(1).Return(key, nil)
+				store.EXPECT().RotateAPIKeyTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.RotateAPIKeyTxResult{}, apperror.Conflict("api key is revoked or expired"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeConflict)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/v1/api-keys/%d/rotate", key.ID), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, db.UserRoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRevokeAPIKeyAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	key := randomAPIKey(user.Username)
+	revoked := key
+	revoked.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAPIKey(gomock.Any(), gomock.Eq(key.ID)).Times(1).Return(key, nil)
+	store.EXPECT().RevokeAPIKeyTx(gomock.Any(), gomock.Eq(key.ID)).Times(1).Return(revoked, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/v1/api-keys/%d/revoke", key.ID), nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var rsp apiKeyResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.True(t, rsp.RevokedAt.Valid)
+}
+
+// TestAPIKeyAuthentication calls routes with an API key in place of an access token, the key acts as its user
+// within its scopes
+func TestAPIKeyAuthentication(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	newKey, err := apikey.Generate()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		key           string
+		scopes        []string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			method: http.MethodGet,
+			path:   fmt.Sprintf("/v1/accounts/%d", account.ID),
+			key:    newKey.Key,
+			scopes: []string{string(rbac.ScopeAccountsRead)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "MissingScope",
+			method: http.MethodGet,
+			path:   fmt.Sprintf("/v1/accounts/%d", account.ID),
+			key:    newKey.Key,
+			scopes: []string{string(rbac.ScopeTransfersWrite)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeForbidden)
+			},
+		},
+		{
+			// keys never manage keys, whatever their scopes
+			name:   "ManageKeys",
+			method: http.MethodGet,
+			path:   "/v1/api-keys",
+			key:    newKey.Key,
+			scopes: []string{string(rbac.ScopeAccountsRead), string(rbac.ScopeTransfersWrite)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAPIKeys(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "WrongSecret",
+			method: http.MethodGet,
+			path:   fmt.Sprintf("/v1/accounts/%d", account.ID),
+			key:    newKey.Key + "x",
+			scopes: []string{string(rbac.ScopeAccountsRead)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeUnauthenticated)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAPIKeyCaller(gomock.Any(), gomock.Eq(newKey.Prefix)).Times(1).Return(db.GetAPIKeyCallerRow{
+				ID:         util.RandomInt(1, 1000),
+				Username:   user.Username,
+				Prefix:     newKey.Prefix,
+				SecretHash: newKey.SecretHash,
+				Scopes:     tc.scopes,
+				AllowedIps: []string{},
+				Role:       db.UserRoleCustomer,
+			}, nil)
+			store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(tc.method, tc.path, nil)
+			require.NoError(t, err)
+
+			request.Header.Set(authorizationHeaderKey, "ApiKey "+tc.key)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestAPIKeyAllowedIPs(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	newKey, err := apikey.Generate()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		trustedProxies string
+		remoteAddr     string
+		forwardedFor   string
+		buildStubs     func(store *mockdb.MockStore)
+		checkResponse  func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "AllowedPeer",
+			remoteAddr: "203.0.113.5:4321",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			// any client can send the header, it is only believed from a trusted proxy
+			name:         "SpoofedForwardedFor",
+			remoteAddr:   "198.51.100.7:4321",
+			forwardedFor: "203.0.113.5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:           "TrustedProxy",
+			trustedProxies: "10.0.0.0/8, 198.51.100.7",
+			remoteAddr:     "198.51.100.7:4321",
+			forwardedFor:   "203.0.113.5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAPIKeyCaller(gomock.Any(), gomock.Eq(newKey.Prefix)).Times(1).Return(db.GetAPIKeyCallerRow{
+				ID:         util.RandomInt(1, 1000),
+				Username:   user.Username,
+				Prefix:     newKey.Prefix,
+				SecretHash: newKey.SecretHash,
+				Scopes:     []string{string(rbac.ScopeAccountsRead)},
+				AllowedIps: []string{"203.0.113.0/24"},
+				Role:       db.UserRoleCustomer,
+			}, nil)
+			store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+			tc.buildStubs(store)
+
+			server := newTestServerWithConfig(t, store, func(config *util.Config) {
+				config.TrustedProxies = tc.trustedProxies
+			})
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/v1/accounts/%d", account.ID), nil)
+			require.NoError(t, err)
+			request.RemoteAddr = tc.remoteAddr
+			if tc.forwardedFor != "" {
+				request.Header.Set("X-Forwarded-For", tc.forwardedFor)
+			}
+
+			request.Header.Set(authorizationHeaderKey, "ApiKey "+newKey.Key)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomAPIKey(username string) db.ApiKey {
+	return db.ApiKey{
+		ID:         util.RandomInt(1, 1000),
+		Username:   username,
+		Name:       "payroll " + util.RandomString(6),
+		Prefix:     util.RandomString(16),
+		SecretHash: util.RandomString(64),
+		Scopes:     []string{string(rbac.ScopeAccountsRead), string(rbac.ScopeTransfersWrite)},
+		AllowedIps: []string{},
+		CreatedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+type listAuditEventsRequest struct {
+	pageQuery
+	// every filter is optional, actor is the username of who made the change
+	Actor      string    `form:"actor" binding:"omitempty,alphanum"`
+	Action     string    `form:"action" binding:"omitempty,max=100"`
+	TargetType string    `form:"target_type" binding:"omitempty,max=100"`
+	TargetID   string    `form:"target_id" binding:"omitempty,max=100"`
+	Since      time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until      time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00" binding:"omitempty,gtfield=Since"`
+}
+
+// auditEventResponse is an audit event, actor is null for changes the system made on its own
+type auditEventResponse struct {
+	ID         int64           `json:"id"`
+	Actor      *string         `json:"actor"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  *string         `json:"request_id"`
+	IP         *string         `json:"ip"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func newAuditEventResponse(event db.AuditEvent) auditEventResponse {
+	return auditEventResponse{
+		ID:         event.ID,
+		Actor:      nullString(event.Actor),
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Before:     event.Before,
+		After:      event.After,
+		RequestID:  nullString(event.RequestID),
+		IP:         nullString(event.Ip),
+		CreatedAt:  event.CreatedAt,
+	}
+}
+
+// listAuditEvents searches the audit log, newest first
+func (server *Server) listAuditEvents(ctx *gin.Context) {
+	var req listAuditEventsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	events, err := server.store.ListAuditEvents(ctx.Request.Context(), db.ListAuditEventsParams{
+		Actor:      sql.NullString{String: req.Actor, Valid: req.Actor != ""},
+		Action:     sql.NullString{String: req.Action, Valid: req.Action != ""},
+		TargetType: sql.NullString{String: req.TargetType, Valid: req.TargetType != ""},
+		TargetID:   sql.NullString{String: req.TargetID, Valid: req.TargetID != ""},
+		Since:      sql.NullTime{Time: req.Since, Valid: !req.Since.IsZero()},
+		Until:      sql.NullTime{Time: req.Until, Valid: !req.Until.IsZero()},
+		PageSize:   req.PageSize,
+		PageOffset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	rsp := make([]auditEventResponse, 0, len(events))
+	for _, event := range events {
+		rsp = append(rsp, newAuditEventResponse(event))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestListAuditEventsAPI(t *testing.T) {
+	event := db.AuditEvent{
+		ID:         1,
+		Actor:      sql.NullString{String: "admin", Valid: true},
+		Action:     db.AuditAccountFrozen,
+		TargetType: db.AuditTargetAccount,
+		TargetID:   "7",
+		Before:     json.RawMessage(`{"frozen_at":null}`),
+		After:      json.RawMessage(`{"frozen_at":"2026-01-02T15:04:05Z"}`),
+		CreatedAt:  time.Now(),
+	}
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Filters",
+			query: "page_id=2&page_size=10&actor=admin&target_type=account&target_id=7&since=2026-01-01T00:00:00Z",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Eq(db.ListAuditEventsParams{
+					Actor:      sql.NullString{String: "admin", Valid: true},
+					TargetType: sql.NullString{String: db.AuditTargetAccount, Valid: true},
+					TargetID:   sql.NullString{String: "7", Valid: true},
+					Since:      sql.NullTime{Time: since, Valid: true},
+					PageSize:   10,
+					PageOffset: 10,
+				})).Times(1).Return([]db.AuditEvent{event}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got []auditEventResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got, 1)
+				require.Equal(t, "admin", *got[0].Actor)
+				require.Nil(t, got[0].RequestID)
+				require.JSONEq(t, string(event.After), string(got[0].After))
+			},
+		},
+		{
+			name:  "UntilBeforeSince",
+			query: "page_id=1&page_size=5&since=2026-01-02T00:00:00Z&until=2026-01-01T00:00:00Z",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeValidation)
+			},
+		},
+		{
+			name:  "InvalidTime",
+			query: "page_id=1&page_size=5&since=yesterday",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/v1/admin/audit-events?"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "admin", db.UserRoleAdmin, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
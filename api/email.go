@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// sendVerification mails a new user the link that verifies their email. the user exists either way, a
+// failure is only logged and they can ask for the email again
+func (server *Server) sendVerification(ctx *gin.Context, user db.User) {
+	if err := server.tokenSender.SendVerification(ctx.Request.Context(), user); err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "queueing the verification email failed",
+			slog.String("username", user.Username), slog.String("error", err.Error()))
+	}
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required,max=100"`
+}
+
+// verifyEmail marks the email of a user verified with the token of the link mailed to it
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	auditCtx := db.WithAuditor(ctx.Request.Context(), db.Auditor{IP: ctx.ClientIP()})
+	user, err := server.store.VerifyEmailTx(auditCtx, token.HashOpaque(req.Token))
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// resendVerification mails the caller a new verification link
+func (server *Server) resendVerification(ctx *gin.Context) {
+	user, err := server.store.GetUser(ctx.Request.Context(), authPayload(ctx).Username)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	if user.EmailVerifiedAt.Valid {
+		renderError(ctx, apperror.Conflict("email is already verified"))
+		return
+	}
+	if err := server.tokenSender.SendVerification(ctx.Request.Context(), user); err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusAccepted)
+}
+
+type requestPasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// requestPasswordReset mails a link that sets a new password to the user with the email. the answer is the
+// same whether such a user exists or not, so that emails cannot be enumerated
+func (server *Server) requestPasswordReset(ctx *gin.Context) {
+	var req requestPasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	user, err := server.store.GetUserByEmail(ctx.Request.Context(), req.Email)
+	if err != nil {
+		if errors.Is(db.TranslateError(err), apperror.ErrNotFound) {
+			ctx.Status(http.StatusAccepted)
+			return
+		}
+		renderError(ctx, err)
+		return
+	}
+	if err := server.tokenSender.SendPasswordReset(ctx.Request.Context(), user); err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusAccepted)
+}
+
+type confirmPasswordResetRequest struct {
+	Token    string `json:"token" binding:"required,max=100"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// confirmPasswordReset sets a new password with the token of the link mailed to the user
+func (server *Server) confirmPasswordReset(ctx *gin.Context) {
+	var req confirmPasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.Password)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+
+	auditCtx := db.WithAuditor(ctx.Request.Context(), db.Auditor{IP: ctx.ClientIP()})
+	user, err := server.store.ResetPasswordTx(auditCtx, db.ResetPasswordTxParams{
+		TokenHash:      token.HashOpaque(req.Token),
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
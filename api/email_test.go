@@ -0,0 +1,270 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"token": "raw-token"},
+			buildStubs: func(store *mockdb.MockStore) {
+				verified := user
+				verified.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				// only the hash of the token is looked up
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Eq(token.HashOpaque("raw-token"))).Times(1).Return(verified, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got UserResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.True(t, got.EmailVerified)
+			},
+		},
+		{
+			name: "InvalidToken",
+			body: gin.H{"token": "raw-token"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.User{}, apperror.Validation("invalid or expired token"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "MissingToken",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/users/verify_email", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestResendVerificationAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		status     int
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateEmailToken(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CreateJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+			status: http.StatusAccepted,
+		},
+		{
+			name: "AlreadyVerified",
+			buildStubs: func(store *mockdb.MockStore) {
+				verified := user
+				verified.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(verified, nil)
+				store.EXPECT().CreateEmailToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusConflict,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/users/verify_email/resend", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
+
+func TestRequestPasswordResetAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		body       gin.H
+		buildStubs func(store *mockdb.MockStore)
+		status     int
+	}{
+		{
+			name: "OK",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().CreateEmailToken(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateEmailTokenParams) (db.EmailToken, error) {
+						require.Equal(t, db.EmailTokenResetPassword, arg.Purpose)
+						return db.EmailToken{ID: 1}, nil
+					})
+				store.EXPECT().CreateJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+			status: http.StatusAccepted,
+		},
+		{
+			// same answer, so that emails cannot be enumerated
+			name: "UnknownEmail",
+			body: gin.H{"email": "nobody@example.com"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreateEmailToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusAccepted,
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{"email": "not-an-email"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/users/password_reset/request", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
+
+func TestConfirmPasswordResetAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	password := util.RandomString(8)
+
+	testCases := []struct {
+		name       string
+		body       gin.H
+		buildStubs func(store *mockdb.MockStore)
+		status     int
+	}{
+		{
+			name: "OK",
+			body: gin.H{"token": "raw-token", "password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ResetPasswordTxParams) (db.User, error) {
+						require.Equal(t, token.HashOpaque("raw-token"), arg.TokenHash)
+						require.NoError(t, util.CheckPassword(password, arg.HashedPassword))
+						return user, nil
+					})
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "InvalidToken",
+			body: gin.H{"token": "raw-token", "password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.User{}, apperror.Validation("invalid or expired token"))
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "TooShortPassword",
+			body: gin.H{"token": "raw-token", "password": "123"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/users/password_reset/confirm", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
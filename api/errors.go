@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// errorBody is the single error shape returned by every endpoint
+type errorBody struct {
+	Code      apperror.Code `json:"code"`
+	Message   string        `json:"message"`
+	Details   any           `json:"details,omitempty"`
+	RequestID string        `json:"request_id"`
+}
+
+// fieldError describes one failed validation rule of a request field
+type fieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+var statusByCode = map[apperror.Code]int{
+	apperror.CodeNotFound:          http.StatusNotFound,
+	apperror.CodeConflict:          http.StatusConflict,
+	apperror.CodeInsufficientFunds: http.StatusUnprocessableEntity,
+	apperror.CodeCurrencyMismatch:  http.StatusBadRequest,
+	apperror.CodeLimitExceeded:     http.StatusUnprocessableEntity,
+	apperror.CodeTransferBlocked:   http.StatusForbidden,
+	apperror.CodeForbidden:         http.StatusForbidden,
+	apperror.CodeUnauthenticated:   http.StatusUnauthorized,
+	apperror.CodeValidation:        http.StatusBadRequest,
+	apperror.CodeTooManyRequests:   http.StatusTooManyRequests,
+	apperror.CodeInternal:          http.StatusInternalServerError,
+}
+
+// renderError writes err as an errorBody with the status matching its code.
+// errors that are not domain errors are reported as internal without leaking their message,
+// the cause is attached to the gin context so the access log records it
+func renderError(ctx *gin.Context, err error) {
+	appErr := apperror.As(db.TranslateError(err))
+	status, ok := statusByCode[appErr.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	if status == http.StatusInternalServerError {
+		ctx.Error(err)
+	}
+	if appErr.RetryAfter > 0 {
+		ctx.Header("Retry-After", retryAfterSeconds(appErr.RetryAfter))
+	}
+
+	ctx.AbortWithStatusJSON(status, errorBody{
+		Code:      appErr.Code,
+		Message:   appErr.Message,
+		Details:   appErr.Details,
+		RequestID: logging.RequestID(ctx.Request.Context()),
+	})
+}
+
+// retryAfterSeconds formats d for the Retry-After header, in whole seconds rounded up
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
+
+// bindingError turns an error from ShouldBind* into a validation error listing the failed fields
+func bindingError(err error) error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		// malformed JSON or a value of the wrong type
+		return apperror.Wrap(apperror.CodeValidation, err, "invalid request")
+	}
+
+	details := make([]fieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		details = append(details, fieldError{
+			Field: fe.Field(),
+			Rule:  fe.Tag(),
+			Param: fe.Param(),
+		})
+	}
+	return apperror.Validation("invalid request").WithDetails(details)
+}
+
+// invalidField is a validation error for a rule checked in the handler rather than by the binding tags
+func invalidField(field string, rule string, param string) error {
+	return apperror.Validation("invalid request").WithDetails([]fieldError{{Field: field, Rule: rule, Param: param}})
+}
+
+// fieldName reports fields by the name clients send (json, uri or form tag) instead of the go field name
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "uri", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/db/migration"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds how long a readiness probe may wait on the database
+const readinessTimeout = 2 * time.Second
+
+type versionResponse struct {
+	Commit           string `json:"commit"`
+	MigrationVersion uint   `json:"migration_version"`
+	GoVersion        string `json:"go_version"`
+}
+
+// healthz reports that the process is alive, it never touches the database
+// so a slow database does not get the pod restarted
+func (server *Server) healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyz reports whether this instance should receive traffic:
+// the database must be reachable, migrated to the version the binary expects and the server must not be draining
+func (server *Server) readyz(ctx *gin.Context) {
+	if server.draining.Load() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+
+	c, cancel := context.WithTimeout(ctx.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := server.store.Ping(c); err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": err.Error()})
+		return
+	}
+
+	expected, err := migration.LatestVersion()
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	version, dirty, err := server.store.MigrationVersion(c)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": err.Error()})
+		return
+	}
+	if dirty || version != expected {
+		err := fmt.Errorf("database migration version %d (dirty: %t), expected %d", version, dirty, expected)
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// version reports what the running binary was built from
+func (server *Server) version(ctx *gin.Context) {
+	migrationVersion, err := migration.LatestVersion()
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, versionResponse{
+		Commit:           util.BuildCommit(),
+		MigrationVersion: migrationVersion,
+		GoVersion:        util.GoVersion(),
+	})
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ShubhKanodia/GoBank/db/migration"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHealthzAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	// liveness must not depend on the database
+	store.EXPECT().Ping(gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestReadyzAPI(t *testing.T) {
+	latest, err := migration.LatestVersion()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		draining      bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(1).Return(latest, false, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "DatabaseDown",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(sql.ErrConnDone)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			},
+		},
+		{
+			name: "MigrationBehind",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(1).Return(latest-1, false, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			},
+		},
+		{
+			name: "MigrationDirty",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(1).Return(latest, true, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			},
+		},
+		{
+			name:     "Draining",
+			draining: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(0)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.draining.Store(tc.draining)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestVersionAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/version", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got versionResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+
+	latest, err := migration.LatestVersion()
+	require.NoError(t, err)
+	require.Equal(t, latest, got.MigrationVersion)
+	require.Equal(t, util.GoVersion(), got.GoVersion)
+	require.NotEmpty(t, got.Commit)
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// minimumAge is how old a user must be to pass KYC
+const minimumAge = 18
+
+type kycDocumentRequest struct {
+	Type string `json:"type" binding:"required,oneof=passport national_id driving_licence proof_of_address"`
+	// Reference is the key of the document in document storage, the file itself is uploaded there
+	Reference string `json:"reference" binding:"required,max=200"`
+}
+
+type submitKYCRequest struct {
+	DateOfBirth string               `json:"date_of_birth" binding:"required,datetime=2006-01-02"`
+	AddressLine string               `json:"address_line" binding:"required,max=200"`
+	City        string               `json:"city" binding:"required,max=100"`
+	PostalCode  string               `json:"postal_code" binding:"required,max=20"`
+	Country     string               `json:"country" binding:"required,iso3166_1_alpha2"`
+	Documents   []kycDocumentRequest `json:"documents" binding:"required,min=1,max=5,dive"`
+}
+
+// KYCResponse is where a user stands with KYC, the details they submitted and their documents
+type KYCResponse struct {
+	Username    string           `json:"username"`
+	Status      string           `json:"status"`
+	Tier        int32            `json:"tier"`
+	DateOfBirth *string          `json:"date_of_birth"`
+	AddressLine *string          `json:"address_line"`
+	City        *string          `json:"city"`
+	PostalCode  *string          `json:"postal_code"`
+	Country     *string          `json:"country"`
+	Documents   []db.KycDocument `json:"documents,omitempty"`
+	// Events is the audit trail, only admins see it
+	Events []db.KycEvent `json:"events,omitempty"`
+}
+
+func newKYCResponse(user db.User, documents []db.KycDocument) KYCResponse {
+	rsp := KYCResponse{
+		Username:    user.Username,
+		Status:      user.KycStatus,
+		Tier:        user.KycTier,
+		AddressLine: nullString(user.AddressLine),
+		City:        nullString(user.City),
+		PostalCode:  nullString(user.PostalCode),
+		Country:     nullString(user.Country),
+		Documents:   documents,
+	}
+	if user.DateOfBirth.Valid {
+		dateOfBirth := user.DateOfBirth.Time.Format(time.DateOnly)
+		rsp.DateOfBirth = &dateOfBirth
+	}
+	return rsp
+}
+
+func nullString(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+// submitKYC puts the details and documents of the caller up for review, an unverified or rejected user can submit
+func (server *Server) submitKYC(ctx *gin.Context) {
+	var req submitKYCRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	dateOfBirth, _ := time.Parse(time.DateOnly, req.DateOfBirth)
+	if dateOfBirth.AddDate(minimumAge, 0, 0).After(time.Now()) {
+		renderError(ctx, invalidField("date_of_birth", "min_age", strconv.Itoa(minimumAge)))
+		return
+	}
+
+	documents := make([]db.CreateKYCDocumentParams, 0, len(req.Documents))
+	for _, document := range req.Documents {
+		documents = append(documents, db.CreateKYCDocumentParams{
+			DocumentType: document.Type,
+			Reference:    document.Reference,
+		})
+	}
+	result, err := server.store.SubmitKYCTx(ctx.Request.Context(), db.SubmitKYCTxParams{
+		UpdateKYCDetailsParams: db.UpdateKYCDetailsParams{
+			Username:    authPayload(ctx).Username,
+			DateOfBirth: dateOfBirth,
+			AddressLine: req.AddressLine,
+			City:        req.City,
+			PostalCode:  req.PostalCode,
+			Country:     req.Country,
+		},
+		Documents: documents,
+	})
+	if err != nil {
+		// a pending or verified user gets a conflict
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newKYCResponse(result.User, result.Documents))
+}
+
+// getKYC is the KYC of the caller
+func (server *Server) getKYC(ctx *gin.Context) {
+	username := authPayload(ctx).Username
+	user, err := server.store.GetUser(ctx.Request.Context(), username)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	documents, err := server.store.ListKYCDocuments(ctx.Request.Context(), username)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newKYCResponse(user, documents))
+}
+
+type listKYCRequest struct {
+	pageQuery
+	// Status defaults to pending, the users waiting for a decision
+	Status string `form:"status" binding:"omitempty,oneof=unverified pending verified rejected"`
+}
+
+// listKYC is the KYC queue of admins, the users who have waited longest first
+func (server *Server) listKYC(ctx *gin.Context) {
+	var req listKYCRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if req.Status == "" {
+		req.Status = db.KYCPending
+	}
+
+	users, err := server.store.ListUsersByKYCStatus(ctx.Request.Context(), db.ListUsersByKYCStatusParams{
+		KycStatus: req.Status,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	rsp := make([]KYCResponse, 0, len(users))
+	for _, user := range users {
+		// the queue leaves the documents out, getUserKYC has them
+		rsp = append(rsp, newKYCResponse(user, nil))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type userKYCRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// getUserKYC is the KYC of any user with its audit trail, for admins
+func (server *Server) getUserKYC(ctx *gin.Context) {
+	var req userKYCRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx.Request.Context(), req.Username)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	documents, err := server.store.ListKYCDocuments(ctx.Request.Context(), req.Username)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	events, err := server.store.ListKYCEvents(ctx.Request.Context(), req.Username)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	rsp := newKYCResponse(user, documents)
+	rsp.Events = events
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type transitionKYCRequest struct {
+	// Status is verified, with a tier, or rejected. verifying a verified user again changes their tier
+	Status string `json:"status" binding:"required,oneof=verified rejected"`
+	// Tier 1 keeps the default transfer limits of each currency, tier 2 has higher ones
+	Tier int32  `json:"tier" binding:"required_if=Status verified,omitempty,min=1,max=2"`
+	Note string `json:"note" binding:"max=500"`
+}
+
+// transitionKYC is the decision of an admin on the KYC of a user, it lands in the audit trail
+func (server *Server) transitionKYC(ctx *gin.Context) {
+	var uri userKYCRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	var req transitionKYCRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	admin := authPayload(ctx)
+
+	user, _, err := server.store.TransitionKYCTx(ctx.Request.Context(), db.TransitionKYCTxParams{
+		Username: uri.Username,
+		Status:   req.Status,
+		Tier:     req.Tier,
+		Actor:    admin.Username,
+		Note:     req.Note,
+	})
+	if err != nil {
+		// a move the status does not allow is a conflict
+		renderError(ctx, err)
+		return
+	}
+	documents, err := server.store.ListKYCDocuments(ctx.Request.Context(), user.Username)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newKYCResponse(user, documents))
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSubmitKYCAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	validBody := func() gin.H {
+		return gin.H{
+			"date_of_birth": "1990-04-12",
+			"address_line":  "221B Baker Street",
+			"city":          "London",
+			"postal_code":   "NW1 6XE",
+			"country":       "GB",
+			"documents": []gin.H{
+				{"type": "passport", "reference": "kyc/" + user.Username + "/passport.pdf"},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		body          func() gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SubmitKYCTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.SubmitKYCTxParams) (db.SubmitKYCTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, "1990-04-12", arg.DateOfBirth.Format(time.DateOnly))
+						require.Equal(t, "GB", arg.Country)
+						require.Len(t, arg.Documents, 1)
+						require.Equal(t, db.DocumentPassport, arg.Documents[0].DocumentType)
+
+						pending := user
+						pending.KycStatus = db.KYCPending
+						return db.SubmitKYCTxResult{
+							User:      pending,
+							Documents: []db.KycDocument{{ID: 1, Username: user.Username, DocumentType: db.DocumentPassport}},
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got KYCResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, db.KYCPending, got.Status)
+				require.Zero(t, got.Tier)
+				require.Len(t, got.Documents, 1)
+			},
+		},
+		{
+			name: "UnderAge",
+			body: func() gin.H {
+				body := validBody()
+				body["date_of_birth"] = time.Now().AddDate(-17, 0, 0).Format(time.DateOnly)
+				return body
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SubmitKYCTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "NoDocuments",
+			body: func() gin.H {
+				body := validBody()
+				body["documents"] = []gin.H{}
+				return body
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SubmitKYCTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCountry",
+			body: func() gin.H {
+				body := validBody()
+				body["country"] = "England"
+				return body
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SubmitKYCTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyPending",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SubmitKYCTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.SubmitKYCTxResult{}, apperror.Conflict("KYC cannot move from pending to pending"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body())
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/users/kyc", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestTransitionKYCAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = db.UserRoleAdmin
+	customer, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Verify",
+			user: admin,
+			body: gin.H{"status": "verified", "tier": 1, "note": "passport checked"},
+			buildStubs: func(store *mockdb.MockStore) {
+				verified := customer
+				verified.KycStatus = db.KYCVerified
+				verified.KycTier = 1
+				store.EXPECT().TransitionKYCTx(gomock.Any(), gomock.Eq(db.TransitionKYCTxParams{
+					Username: customer.Username,
+					Status:   db.KYCVerified,
+					Tier:     1,
+					Actor:    admin.Username,
+					Note:     "passport checked",
+				})).Times(1).Return(verified, db.KycEvent{ID: 1}, nil)
+				store.EXPECT().ListKYCDocuments(gomock.Any(), gomock.Eq(customer.Username)).Times(1).Return([]db.KycDocument{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got KYCResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, db.KYCVerified, got.Status)
+				require.Equal(t, int32(1), got.Tier)
+			},
+		},
+		{
+			name: "VerifyWithoutTier",
+			user: admin,
+			body: gin.H{"status": "verified"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransitionKYCTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Reject",
+			user: admin,
+			body: gin.H{"status": "rejected", "note": "document expired"},
+			buildStubs: func(store *mockdb.MockStore) {
+				rejected := customer
+				rejected.KycStatus = db.KYCRejected
+				store.EXPECT().TransitionKYCTx(gomock.Any(), gomock.Any()).Times(1).Return(rejected, db.KycEvent{ID: 2}, nil)
+				store.EXPECT().ListKYCDocuments(gomock.Any(), gomock.Eq(customer.Username)).Times(1).Return([]db.KycDocument{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotAllowed",
+			user: admin,
+			body: gin.H{"status": "rejected"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransitionKYCTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.User{}, db.KycEvent{}, apperror.Conflict("KYC cannot move from unverified to rejected"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			user: customer,
+			body: gin.H{"status": "verified", "tier": 2},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransitionKYCTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/v1/admin/users/%s/kyc", customer.Username)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user.Username, tc.user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/logging"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	account := randomAccount(util.RandomOwner())
+
+	testCases := []struct {
+		name      string
+		requestID string
+		check     func(t *testing.T, got string)
+	}{
+		{
+			name:      "Propagated",
+			requestID: "caller-request-42",
+			check: func(t *testing.T, got string) {
+				require.Equal(t, "caller-request-42", got)
+			},
+		},
+		{
+			name: "Generated",
+			check: func(t *testing.T, got string) {
+				require.NotEmpty(t, got)
+			},
+		},
+		{
+			name:      "InvalidReplaced",
+			requestID: "evil\nline",
+			check: func(t *testing.T, got string) {
+				require.NotEmpty(t, got)
+				require.NotContains(t, got, "evil")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			defaultLogger := slog.Default()
+			slog.SetDefault(logging.New(&logs, slog.LevelInfo))
+			defer slog.SetDefault(defaultLogger)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var storeRequestID string
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).
+				DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+					storeRequestID = logging.RequestID(ctx)
+					return account, nil
+				})
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/v1/accounts/%d", account.ID), nil)
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				request.Header.Set(requestIDHeader, tc.requestID)
+			}
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, account.Owner, db.UserRoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			got := recorder.Header().Get(requestIDHeader)
+			tc.check(t, got)
+			// the same id reaches the store and the access log
+			require.Equal(t, got, storeRequestID)
+
+			var line map[string]any
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(logs.String())), &line))
+			require.Equal(t, got, line["request_id"])
+			require.Equal(t, "/v1/accounts/:id", line["route"])
+			require.EqualValues(t, http.StatusOK, line["status"])
+		})
+	}
+}
+
+func TestLoggerRedactsQuery(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(logging.New(&logs, slog.LevelInfo))
+	defer slog.SetDefault(defaultLogger)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	request, err := http.NewRequest(http.MethodGet, "/healthz?token=supersecret", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(httptest.NewRecorder(), request)
+
+	require.NotContains(t, logs.String(), "supersecret")
+	require.Contains(t, logs.String(), "REDACTED")
+}
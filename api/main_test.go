@@ -1,12 +1,60 @@
 package api
 
 import (
+	"net/http"
 	"os"
 	"testing"
+	"time"
 
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/events"
+	"github.com/ShubhKanodia/GoBank/ratelimit"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/ShubhKanodia/GoBank/util"
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
 )
 
+func newTestServer(t *testing.T, store db.Store) *Server {
+	return newTestServerWithConfig(t, store, func(*util.Config) {})
+}
+
+// newTestServerWithConfig lets configure change the config of the test server before it is built
+func newTestServerWithConfig(t *testing.T, store db.Store, configure func(config *util.Config)) *Server {
+	config := util.Config{
+		TokenSymmetricKey:     util.RandomString(32),
+		AccessTokenDuration:   time.Minute,
+		LoginMaxFailures:      5,
+		LoginMaxFailuresPerIP: 50,
+		LoginFailureWindow:    15 * time.Minute,
+		LoginLockoutDuration:  15 * time.Minute,
+		LoginBaseDelay:        time.Second,
+	}
+	configure(&config)
+
+	server, err := NewServer(config, store, events.NewBroker(), ratelimit.NewMemory())
+	require.NoError(t, err)
+
+	return server
+}
+
+func addAuthorization(
+	t *testing.T,
+	request *http.Request,
+	tokenMaker token.Maker,
+	authorizationType string,
+	username string,
+	role string,
+	duration time.Duration,
+) {
+	token, payload, err := tokenMaker.CreateToken(username, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	authorizationHeader := authorizationType + " " + token
+	request.Header.Set(authorizationHeaderKey, authorizationHeader)
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode) //gin.TestMode is used to set the mode to test
 	//in test mode, gin will not listen and serve on a random port
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// metricsMiddleware records the latency of every request, labelled by route template and status
+func metricsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		// FullPath is the registered template (/accounts/:id), so ids do not explode the label cardinality
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequestDuration.
+			WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/ShubhKanodia/GoBank/apikey"
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/ratelimit"
+	"github.com/ShubhKanodia/GoBank/rbac"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+	// authorizationTypeAPIKey carries an API key instead of an access token, for services (see apikey)
+	authorizationTypeAPIKey = "apikey"
+	// authorizationPayloadKey is where authMiddleware stores the *token.Payload of the caller
+	authorizationPayloadKey = "authorization_payload"
+)
+
+// authMiddleware rejects requests without a valid bearer token or API key and stores the payload of the caller
+// in the context, the caller goes into the request context too, as the actor of the audit events the request
+// writes
+func authMiddleware(tokenMaker token.Maker, apiKeys *apikey.Authenticator) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
+		if len(authorizationHeader) == 0 {
+			renderError(ctx, apperror.Unauthenticated("authorization header is not provided"))
+			return
+		}
+
+		fields := strings.Fields(authorizationHeader)
+		if len(fields) != 2 {
+			renderError(ctx, apperror.Unauthenticated("invalid authorization header format"))
+			return
+		}
+
+		var payload *token.Payload
+		var err error
+		switch authorizationType := strings.ToLower(fields[0]); authorizationType {
+		case authorizationTypeBearer:
+			payload, err = tokenMaker.VerifyToken(fields[1])
+			if err != nil {
+				err = apperror.Wrap(apperror.CodeUnauthenticated, err, err.Error())
+			}
+		case authorizationTypeAPIKey:
+			payload, err = apiKeys.Authenticate(ctx.Request.Context(), fields[1], ctx.ClientIP())
+		default:
+			err = apperror.Unauthenticated(fmt.Sprintf("unsupported authorization type %s", authorizationType))
+		}
+		if err != nil {
+			renderError(ctx, err)
+			return
+		}
+
+		ctx.Set(authorizationPayloadKey, payload)
+		ctx.Set(logUserKey, payload.Username)
+		ctx.Request = ctx.Request.WithContext(db.WithAuditor(ctx.Request.Context(), db.Auditor{
+			Actor: payload.Username,
+			IP:    ctx.ClientIP(),
+		}))
+		ctx.Next()
+	}
+}
+
+// authPayload returns the payload stored by authMiddleware, only call it from authenticated routes
+func authPayload(ctx *gin.Context) *token.Payload {
+	return ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+}
+
+// RequirePermission lets a request through when the role claimed by its token holds every one of permissions,
+// within the scopes of the API key for callers with one. it runs after authMiddleware, the handler still checks
+// the caller owns what they touch
+func RequirePermission(permissions ...rbac.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := authPayload(ctx)
+		for _, permission := range permissions {
+			if rbac.Permits(payload, permission) {
+				continue
+			}
+			if rbac.Can(payload.Role, permission) {
+				renderError(ctx, apperror.Forbidden(fmt.Sprintf("the API key has no scope granting the %s permission", permission)))
+			} else {
+				renderError(ctx, apperror.Forbidden(fmt.Sprintf("the %s role does not have the %s permission", payload.Role, permission)))
+			}
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// ownerOrPermitted reports whether the caller is owner or holds permission over anyone's resources
+func ownerOrPermitted(ctx *gin.Context, owner string, permission rbac.Permission) bool {
+	payload := authPayload(ctx)
+	return owner == payload.Username || rbac.Permits(payload, permission)
+}
+
+// rateLimitMiddleware takes a token from the caller's bucket of the route group of the request and turns the
+// request away while the bucket is empty. on authenticated routes it runs after authMiddleware, so that users
+// are limited by who they are rather than by their IP. the IP comes from forwarding headers only when the peer is
+// one of the TRUSTED_PROXIES, so a client cannot get fresh buckets by rotating X-Forwarded-For
+func rateLimitMiddleware(limiter ratelimit.Limiter, limits ratelimit.Limits, groups map[string]string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		group, ok := groups[ctx.Request.Method+" "+ctx.FullPath()]
+		if !ok {
+			group = ratelimit.GroupDefault
+		}
+		caller, role := "ip:"+ctx.ClientIP(), ""
+		if value, ok := ctx.Get(authorizationPayloadKey); ok {
+			payload := value.(*token.Payload)
+			caller, role = "user:"+payload.Username, payload.Role
+		}
+		limit, ok := limits.For(group, role)
+		if !ok {
+			ctx.Next()
+			return
+		}
+
+		result, err := limiter.Take(ctx.Request.Context(), group+":"+caller, limit)
+		if err != nil {
+			// a broken limiter lets requests through rather than taking the API down with it
+			slog.WarnContext(ctx.Request.Context(), "rate limiter failed", slog.String("error", err.Error()))
+			ctx.Next()
+			return
+		}
+		ctx.Header("RateLimit-Limit", strconv.Itoa(limit.Burst))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.Header("RateLimit-Reset", retryAfterSeconds(result.Reset))
+		if !result.Allowed {
+			renderError(ctx, apperror.TooManyRequests("rate limit exceeded, slow down", result.RetryAfter))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// rateLimitGroups maps the method and full path of each route of operations to its route group
+func rateLimitGroups(operations []operation) map[string]string {
+	groups := make(map[string]string, len(operations))
+	for _, op := range operations {
+		groups[op.Method+" "+apiVersion+op.Path] = rateLimitGroup(op)
+	}
+	return groups
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/ratelimit"
+	"github.com/ShubhKanodia/GoBank/rbac"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", db.UserRoleCustomer, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UnsupportedAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "unsupported", "user", db.UserRoleCustomer, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAuthorizationFormat",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "", "user", db.UserRoleCustomer, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", db.UserRoleCustomer, -time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := newTestServer(t, mockdb.NewMockStore(ctrl))
+
+			authPath := "/auth"
+			server.router.GET(authPath, authMiddleware(server.tokenMaker, server.apiKeys), func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, gin.H{"username": authPayload(ctx).Username})
+			})
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// unimplementedStore panics on every call, the recovery middleware turns that into a 500
+type unimplementedStore struct {
+	db.Store
+}
+
+// TestRoutePermissions sends every authenticated route a request from each role, the roles without the permission
+// documented for the route must be refused before the handler runs
+func TestRoutePermissions(t *testing.T) {
+	params := strings.NewReplacer(":id", "1", ":delivery_id", "1", ":username", util.RandomOwner())
+
+	for _, op := range operations {
+		if !op.Auth {
+			continue
+		}
+		require.NotEmpty(t, op.Permission, "%s %s", op.Method, op.Path)
+
+		for _, role := range rbac.Roles() {
+			t.Run(fmt.Sprintf("%s %s as %s", op.Method, op.Path, role), func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				var store db.Store = mockdb.NewMockStore(ctrl)
+				allowed := rbac.Can(role, op.Permission)
+				if allowed {
+					store = unimplementedStore{}
+				}
+				server := newTestServer(t, store)
+
+				recorder := httptest.NewRecorder()
+				request, err := http.NewRequest(op.Method, apiVersion+params.Replace(op.Path), strings.NewReader("{}"))
+				require.NoError(t, err)
+				request.Header.Set("Content-Type", "application/json")
+
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, util.RandomOwner(), role, time.Minute)
+				server.router.ServeHTTP(recorder, request)
+				if allowed {
+					require.NotEqual(t, http.StatusForbidden, recorder.Code)
+				} else {
+					require.Equal(t, http.StatusForbidden, recorder.Code)
+				}
+			})
+		}
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	limits, err := ratelimit.ParseLimits("default=100/s,auth=2/m,auth@admin=3/m", rbac.Roles())
+	require.NoError(t, err)
+
+	limitedPath := "/limited"
+	rateLimit := rateLimitMiddleware(ratelimit.NewMemory(), limits, map[string]string{
+		http.MethodGet + " " + limitedPath: ratelimit.GroupAuth,
+	})
+	server.router.GET(limitedPath, func(ctx *gin.Context) {
+		// authenticated when there is a token, like the routes behind authMiddleware
+		if ctx.GetHeader(authorizationHeaderKey) != "" {
+			authMiddleware(server.tokenMaker, server.apiKeys)(ctx)
+		}
+	}, rateLimit, func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	send := func(role string, forwardedFor string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, limitedPath, nil)
+		require.NoError(t, err)
+		request.RemoteAddr = "198.51.100.7:4321"
+		if forwardedFor != "" {
+			request.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		if role != "" {
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "user", role, time.Minute)
+		}
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		recorder := send("", "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "2", recorder.Header().Get("RateLimit-Limit"))
+		require.Equal(t, fmt.Sprint(remaining), recorder.Header().Get("RateLimit-Remaining"))
+	}
+	recorder := send("", "")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "30", recorder.Header().Get("Retry-After"))
+	require.Equal(t, "60", recorder.Header().Get("RateLimit-Reset"))
+
+	// anonymous callers are limited by the peer address, a forwarding header from an untrusted peer does not get
+	// them a fresh bucket
+	for i := 1; i <= 3; i++ {
+		recorder = send("", fmt.Sprintf("203.0.113.%d", i))
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	}
+
+	// users have buckets of their own, with the limits of their role
+	recorder = send(db.UserRoleAdmin, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "3", recorder.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "2", recorder.Header().Get("RateLimit-Remaining"))
+}
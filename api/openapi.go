@@ -0,0 +1,1179 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/ratelimit"
+	"github.com/ShubhKanodia/GoBank/rbac"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// apiVersion prefixes every public route, probes and metrics stay at the root
+const apiVersion = "/v1"
+
+// apiDescription tells clients of the unversioned API what broke when authentication came in
+const apiDescription = "Breaking changes from the unversioned API: every route except the /users routes that register, " +
+	"log in, verify an email or reset a password needs a bearer token from POST /users/login or an API key. " +
+	"Accounts belong to the authenticated user, POST /accounts opens one for the caller instead of the owner in the body, and GET /accounts and GET /accounts/{id} " +
+	"only answer with the caller's accounts unless their role can read any."
+
+// operation documents one route of the versioned API. the OpenAPI document is generated from these
+// and the request/response structs they point at; TestOpenAPIMatchesRoutes fails when this list and the router drift apart
+type operation struct {
+	Method  string
+	Path    string // gin syntax, relative to apiVersion
+	Summary string
+	// Description is for what the summary has no room for, like how the route changed from the unversioned API
+	Description string
+	Tag         string
+	// Auth marks routes behind authMiddleware, they document the bearer and API key schemes and 401/403 answers
+	Auth bool
+	// Permission is what RequirePermission checks for the route, TestRoutePermissions holds the router to it
+	Permission rbac.Permission
+	// RateLimitGroup is the route group whose limits apply to the route, ratelimit.GroupDefault when empty
+	RateLimitGroup string
+	// Params is a struct with uri and form tags describing path and query parameters
+	Params any
+	// Body is the JSON request body
+	Body any
+	// Response is the JSON body returned with Status
+	Response any
+	// ContentType of the success response, application/json when empty. for text/event-stream
+	// Response describes the data of each event
+	ContentType string
+	Status      int
+	// OtherResponses are JSON bodies answered with other success statuses, by status
+	OtherResponses map[int]any
+	// Errors lists the error statuses the route can answer with on top of 400 and 500
+	Errors []int
+}
+
+var operations = []operation{
+	{
+		Method:         http.MethodPost,
+		Path:           "/users",
+		RateLimitGroup: ratelimit.GroupAuth,
+		Summary:        "Register a user",
+		Tag:            "users",
+		Body:           CreateUserRequest{},
+		Response:       UserResponse{},
+		Status:         http.StatusOK,
+		Errors:         []int{http.StatusConflict},
+	},
+	{
+		Method:         http.MethodPost,
+		Path:           "/users/login",
+		RateLimitGroup: ratelimit.GroupAuth,
+		Summary:        "Log in and get an access token, users with two-factor authentication get a login challenge instead (202)",
+		Tag:            "users",
+		Body:           LoginUserRequest{},
+		Response:       LoginUserResponse{},
+		Status:         http.StatusOK,
+		OtherResponses: map[int]any{
+			http.StatusAccepted: LoginChallengeResponse{},
+		},
+		// 429 with Retry-After while the username or the IP waits after failed logins, or is locked out, and while
+		// too many wrong two-factor codes lock the second factor of the user
+		Errors: []int{http.StatusUnauthorized, http.StatusTooManyRequests},
+	},
+	{
+		Method:         http.MethodPost,
+		Path:           "/users/login/2fa",
+		RateLimitGroup: ratelimit.GroupAuth,
+		Summary:        "Complete a login challenge with a code of the authenticator app or a recovery code and get an access token",
+		Tag:            "users",
+		Body:           completeLoginRequest{},
+		Response:       LoginUserResponse{},
+		Status:         http.StatusOK,
+		// wrong codes count against the user across challenges, 429 with Retry-After while they lock the second factor
+		Errors: []int{http.StatusUnauthorized, http.StatusTooManyRequests},
+	},
+	{
+		Method:         http.MethodPost,
+		Path:           "/users/verify_email",
+		RateLimitGroup: ratelimit.GroupAuth,
+		Summary:        "Verify the email of a user with the token of the link mailed to it",
+		Tag:            "users",
+		Body:           verifyEmailRequest{},
+		Response:       UserResponse{},
+		Status:         http.StatusOK,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/users/verify_email/resend",
+		Summary:    "Mail the caller a new link that verifies their email",
+		Tag:        "users",
+		Auth:       true,
+		Permission: rbac.EmailVerify,
+		Status:     http.StatusAccepted,
+		Errors:     []int{http.StatusConflict},
+	},
+	{
+		Method:         http.MethodPost,
+		Path:           "/users/password_reset/request",
+		RateLimitGroup: ratelimit.GroupAuth,
+		Summary:        "Mail a link that resets the password to the user with the email, the answer does not tell whether there is one",
+		Tag:            "users",
+		Body:           requestPasswordResetRequest{},
+		Status:         http.StatusAccepted,
+	},
+	{
+		Method:         http.MethodPost,
+		Path:           "/users/password_reset/confirm",
+		RateLimitGroup: ratelimit.GroupAuth,
+		Summary:        "Set a new password with the token of the link mailed to the user",
+		Tag:            "users",
+		Body:           confirmPasswordResetRequest{},
+		Response:       UserResponse{},
+		Status:         http.StatusOK,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/users/2fa/enroll",
+		Summary:    "Get a new TOTP secret and its otpauth URI for an authenticator app",
+		Tag:        "users",
+		Auth:       true,
+		Permission: rbac.TwoFactorManage,
+		Response:   enrollTOTPResponse{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusConflict},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/users/2fa/confirm",
+		Summary:    "Turn two-factor authentication on with a first code of the app, the recovery codes are shown once",
+		Tag:        "users",
+		Auth:       true,
+		Permission: rbac.TwoFactorManage,
+		Body:       confirmTOTPRequest{},
+		Response:   confirmTOTPResponse{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusConflict},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/users/2fa/disable",
+		Summary:    "Turn two-factor authentication off with a code of the app or a recovery code",
+		Tag:        "users",
+		Auth:       true,
+		Permission: rbac.TwoFactorManage,
+		Body:       secondFactorRequest{},
+		Response:   UserResponse{},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/api-keys",
+		Summary:    "Issue an API key for a service to call the API as the authenticated user, the key is only returned here",
+		Tag:        "api-keys",
+		Auth:       true,
+		Permission: rbac.APIKeysManage,
+		Body:       createAPIKeyRequest{},
+		Response:   createAPIKeyResponse{},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/api-keys",
+		Summary:    "List the API keys of the authenticated user, with when and from where each was last used",
+		Tag:        "api-keys",
+		Auth:       true,
+		Permission: rbac.APIKeysManage,
+		Response:   []any{apiKeyResponse{}},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/api-keys/:id/rotate",
+		Summary:    "Issue a new key in place of an API key, the old key keeps working for the rotation grace period",
+		Tag:        "api-keys",
+		Auth:       true,
+		Permission: rbac.APIKeysManage,
+		Params:     apiKeyRequest{},
+		Response:   rotateAPIKeyResponse{},
+		Status:     http.StatusOK,
+		// 409 for a key that is revoked or expired
+		Errors: []int{http.StatusNotFound, http.StatusConflict},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/api-keys/:id/revoke",
+		Summary:    "Revoke an API key for good",
+		Tag:        "api-keys",
+		Auth:       true,
+		Permission: rbac.APIKeysManage,
+		Params:     apiKeyRequest{},
+		Response:   apiKeyResponse{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound, http.StatusConflict},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/users/kyc",
+		Summary:    "Get the KYC status, tier, details and documents of the caller",
+		Tag:        "users",
+		Auth:       true,
+		Permission: rbac.KYCSubmit,
+		Response:   KYCResponse{},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/users/kyc",
+		Summary:    "Submit details and document references for identity verification, an unverified or rejected user becomes pending",
+		Tag:        "users",
+		Auth:       true,
+		Permission: rbac.KYCSubmit,
+		Body:       submitKYCRequest{},
+		Response:   KYCResponse{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusConflict},
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/accounts",
+		Summary: "Create an account, tellers can open one for a customer. a user without verified KYC can hold one",
+		Description: "owner is no longer required and no longer trusted. a body that names the caller, as clients of " +
+			"the unversioned API send it, still opens the account for them. naming another owner needs the role of a teller or an admin.",
+		Tag:        "accounts",
+		Auth:       true,
+		Permission: rbac.AccountsCreate,
+		Body:       CreateAccountRequest{},
+		Response:   db.Account{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusConflict},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/accounts/:id",
+		Summary:    "Get an account, tellers and admins can get any",
+		Tag:        "accounts",
+		Auth:       true,
+		Permission: rbac.AccountsRead,
+		Params:     GetAccountRequest{},
+		Response:   db.Account{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/accounts",
+		Summary:    "List the accounts of the authenticated user, or of owner for tellers and admins",
+		Tag:        "accounts",
+		Auth:       true,
+		Permission: rbac.AccountsRead,
+		Params:     listAccountsRequest{},
+		Response:   []any{db.Account{}},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/accounts/:id/events",
+		Summary:     "Stream balance changes and new entries of an account as Server-Sent Events, resumable with Last-Event-ID",
+		Tag:         "accounts",
+		Auth:        true,
+		Permission:  rbac.AccountsRead,
+		Params:      streamAccountEventsRequest{},
+		Response:    db.AccountEvent{},
+		ContentType: "text/event-stream",
+		Status:      http.StatusOK,
+		Errors:      []int{http.StatusNotFound},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/accounts/:id/limits",
+		Summary:    "Get the daily and monthly transfer limits of an account and the allowance its owner shares across accounts in the currency, how much of them is used and when they reset",
+		Tag:        "accounts",
+		Auth:       true,
+		Permission: rbac.AccountsRead,
+		Params:     GetAccountRequest{},
+		Response:   db.TransferLimitUsage{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:         http.MethodPost,
+		Path:           "/transfers",
+		RateLimitGroup: ratelimit.GroupTransfers,
+		Summary:        "Transfer money between two accounts, amounts above the step-up threshold of the currency need a second factor and amounts above the approval threshold are held for approval (202)",
+		Description: "users without two-factor authentication get 403 above the step-up threshold until they enable it. " +
+			"an accepted code is used up even when the transfer then fails, the next attempt needs a new one.",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersCreate,
+		Body:       CreateTransferRequest{},
+		Response:   db.TransferTxResult{},
+		Status:     http.StatusOK,
+		OtherResponses: map[int]any{
+			http.StatusAccepted: db.TransferApproval{},
+		},
+		Errors: []int{http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/transfer-approvals",
+		Summary:    "List the transfers of the authenticated user that were held for approval, newest first",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersRead,
+		Params:     pageQuery{},
+		Response:   []any{db.TransferApproval{}},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/transfer-approvals/pending",
+		Summary:    "List the transfers waiting for approval, oldest first. approvers only",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersApprove,
+		Params:     pageQuery{},
+		Response:   []any{db.TransferApproval{}},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/transfer-approvals/:id",
+		Summary:    "Get a transfer held for approval, for its initiator and approvers",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersRead,
+		Params:     transferApprovalRequest{},
+		Response:   db.TransferApproval{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:         http.MethodPost,
+		Path:           "/transfer-approvals/:id/approve",
+		RateLimitGroup: ratelimit.GroupTransfers,
+		Summary:        "Approve a held transfer and execute it. approvers only, not the initiator",
+		Tag:            "transfers",
+		Auth:           true,
+		Permission:     rbac.TransfersApprove,
+		Params:         transferApprovalRequest{},
+		Response:       db.ApproveTransferTxResult{},
+		Status:         http.StatusOK,
+		Errors:         []int{http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/transfer-approvals/:id/reject",
+		Summary:    "Reject a held transfer. approvers only, not the initiator",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersApprove,
+		Params:     transferApprovalRequest{},
+		Body:       rejectTransferRequest{},
+		Response:   db.TransferApproval{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound, http.StatusConflict},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/scheduled-transfers",
+		Summary:    "Schedule a transfer to run at execute_at, funds are checked when it runs. amounts above the step-up threshold of the currency need a second factor when scheduling",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersCreate,
+		Body:       CreateScheduledTransferRequest{},
+		Response:   db.ScheduledTransfer{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/scheduled-transfers",
+		Summary:    "List the scheduled transfers of the authenticated user, newest first",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersRead,
+		Params:     pageQuery{},
+		Response:   []any{db.ScheduledTransfer{}},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/scheduled-transfers/:id",
+		Summary:    "Get a scheduled transfer with its outcome",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersRead,
+		Params:     scheduledTransferRequest{},
+		Response:   db.ScheduledTransfer{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/scheduled-transfers/:id/cancel",
+		Summary:    "Cancel a scheduled transfer that has not run yet",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersCreate,
+		Params:     scheduledTransferRequest{},
+		Response:   db.ScheduledTransfer{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound, http.StatusConflict},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/standing-orders",
+		Summary:    "Create a standing order repeating a transfer weekly, monthly or yearly. amounts above the step-up threshold of the currency need a second factor when creating it",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersCreate,
+		Body:       CreateStandingOrderRequest{},
+		Response:   db.StandingOrder{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/standing-orders",
+		Summary:    "List the standing orders of the authenticated user, newest first",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersRead,
+		Params:     pageQuery{},
+		Response:   []any{db.StandingOrder{}},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/standing-orders/:id",
+		Summary:    "Get a standing order",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersRead,
+		Params:     standingOrderRequest{},
+		Response:   db.StandingOrder{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/standing-orders/:id/cancel",
+		Summary:    "Cancel an active standing order",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersCreate,
+		Params:     standingOrderRequest{},
+		Response:   db.StandingOrder{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound, http.StatusConflict},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/standing-orders/:id/occurrences",
+		Summary:    "List the occurrences of a standing order with the transfers they produced, latest first",
+		Tag:        "transfers",
+		Auth:       true,
+		Permission: rbac.TransfersRead,
+		Params:     listStandingOrderOccurrencesRequest{},
+		Response:   []any{db.StandingOrderOccurrence{}},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/webhooks",
+		Summary:    "Register a webhook endpoint, an https URL outside our network. the signing secret is only returned here",
+		Tag:        "webhooks",
+		Auth:       true,
+		Permission: rbac.WebhooksManage,
+		Body:       createWebhookEndpointRequest{},
+		Response:   createWebhookEndpointResponse{},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/webhooks",
+		Summary:    "List the webhook endpoints of the authenticated user",
+		Tag:        "webhooks",
+		Auth:       true,
+		Permission: rbac.WebhooksManage,
+		Response:   []any{webhookEndpointResponse{}},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:     http.MethodDelete,
+		Path:       "/webhooks/:id",
+		Summary:    "Delete a webhook endpoint and its delivery log",
+		Tag:        "webhooks",
+		Auth:       true,
+		Permission: rbac.WebhooksManage,
+		Params:     webhookEndpointRequest{},
+		Status:     http.StatusNoContent,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/webhooks/:id/deliveries",
+		Summary:    "List the deliveries of a webhook endpoint, newest first",
+		Tag:        "webhooks",
+		Auth:       true,
+		Permission: rbac.WebhooksManage,
+		Params:     listWebhookDeliveriesRequest{},
+		Response:   []any{webhookDeliveryResponse{}},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/webhooks/:id/deliveries/:delivery_id/redeliver",
+		Summary:    "Queue a delivery again with a fresh set of retries",
+		Tag:        "webhooks",
+		Auth:       true,
+		Permission: rbac.WebhooksManage,
+		Params:     redeliverWebhookRequest{},
+		Response:   webhookDeliveryResponse{},
+		Status:     http.StatusAccepted,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/admin/accounts/:id/freeze",
+		Summary:    "Freeze an account, it can neither send nor receive transfers until unfrozen. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.AccountsFreeze,
+		Params:     GetAccountRequest{},
+		Response:   db.Account{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound, http.StatusConflict},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/admin/accounts/:id/unfreeze",
+		Summary:    "Unfreeze a frozen account. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.AccountsFreeze,
+		Params:     GetAccountRequest{},
+		Response:   db.Account{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound, http.StatusConflict},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/admin/accounts/:id/adjustments",
+		Summary:    "Correct the balance of an account, a positive amount credits and a negative one debits. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.AccountsAdjust,
+		Params:     GetAccountRequest{},
+		Body:       adjustBalanceRequest{},
+		Response:   db.AdjustBalanceTxResult{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/admin/accounts/:id/adjustments",
+		Summary:    "List the balance adjustments of an account, oldest first. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.AccountsAdjust,
+		Params: struct {
+			GetAccountRequest
+			pageQuery
+		}{},
+		Response: []any{db.BalanceAdjustment{}},
+		Status:   http.StatusOK,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/admin/transfers/:id/reverse",
+		Summary:    "Move the amount of a transfer back to the account it came from, once per transfer. frozen accounts and sanctions stop it, limits and risk rules do not apply. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.TransfersReverse,
+		Params:     transferRequest{},
+		Response:   db.TransferTxResult{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/admin/risk-alerts",
+		Summary:    "The risk alert queue, oldest first, open alerts unless status says otherwise. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.RiskAlertsReview,
+		Params:     listRiskAlertsRequest{},
+		Response:   []any{db.RiskAlert{}},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/admin/risk-alerts/:id",
+		Summary:    "Get a risk alert and the findings of the rules that raised it. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.RiskAlertsReview,
+		Params:     riskAlertRequest{},
+		Response:   db.RiskAlert{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/admin/risk-alerts/:id/resolve",
+		Summary:    "Resolve an open risk alert as cleared (a false positive) or confirmed. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.RiskAlertsReview,
+		Params:     riskAlertRequest{},
+		Body:       resolveRiskAlertRequest{},
+		Response:   db.RiskAlert{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound, http.StatusConflict},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/admin/screening-cases",
+		Summary:    "The sanctions screening case queue, oldest first, open cases unless status says otherwise. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.ScreeningCasesReview,
+		Params:     listScreeningCasesRequest{},
+		Response:   []any{db.ScreeningCase{}},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/admin/screening-cases/:id",
+		Summary:    "Get a sanctions screening case, the name and the list entry it matched. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.ScreeningCasesReview,
+		Params:     screeningCaseRequest{},
+		Response:   db.ScreeningCase{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/admin/screening-cases/:id/resolve",
+		Summary:    "Resolve an open screening case as cleared (not the listed party) or confirmed, which blocks the user. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.ScreeningCasesReview,
+		Params:     screeningCaseRequest{},
+		Body:       resolveScreeningCaseRequest{},
+		Response:   db.ScreeningCase{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound, http.StatusConflict},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/admin/kyc",
+		Summary:    "The KYC queue, pending users unless status says otherwise, longest waiting first. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.KYCReview,
+		Params:     listKYCRequest{},
+		Response:   []any{KYCResponse{}},
+		Status:     http.StatusOK,
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/admin/users/:username/kyc",
+		Summary:    "Get the KYC of a user with its audit trail. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.KYCReview,
+		Params:     userKYCRequest{},
+		Response:   KYCResponse{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/admin/users/:username/kyc",
+		Summary:    "Verify a user in a tier, change the tier of a verified user, or reject them. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.KYCReview,
+		Params:     userKYCRequest{},
+		Body:       transitionKYCRequest{},
+		Response:   KYCResponse{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound, http.StatusConflict},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/admin/users/:username/unlock",
+		Summary:    "Lift the lockout of a user after too many failed logins and forget the failures. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.UsersUnlock,
+		Params:     unlockUserRequest{},
+		Response:   UserResponse{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusNotFound, http.StatusConflict},
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/admin/audit-events",
+		Summary:    "Search the audit log of privileged and state-changing actions by actor, action, target and time, newest first. admins only",
+		Tag:        "admin",
+		Auth:       true,
+		Permission: rbac.AuditEventsRead,
+		Params:     listAuditEventsRequest{},
+		Response:   []any{auditEventResponse{}},
+		Status:     http.StatusOK,
+	},
+}
+
+// openAPIDocument is the subset of OpenAPI 3 we generate
+type openAPIDocument struct {
+	OpenAPI    string                         `json:"openapi"`
+	Info       openAPIInfo                    `json:"info"`
+	Servers    []map[string]string            `json:"servers"`
+	Paths      map[string]map[string]*apiPath `json:"paths"`
+	Components openAPIComponents              `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*schema        `json:"schemas"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// bearerAuth and apiKeyAuth are the names of the security schemes authenticated operations refer to, either
+// one will do
+const (
+	bearerAuth = "bearerAuth"
+	apiKeyAuth = "apiKeyAuth"
+)
+
+type apiPath struct {
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	// Permission is the rbac permission the role of the caller must hold
+	Permission rbac.Permission `json:"x-permission,omitempty"`
+	// RateLimitGroup is the route group the caller's requests are counted in
+	RateLimitGroup string               `json:"x-rate-limit-group"`
+	RequestBody    *requestBody         `json:"requestBody,omitempty"`
+	Responses      map[string]*response `json:"responses"`
+}
+
+type parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaBuilder turns go types into schemas, named structs become shared components
+type schemaBuilder struct {
+	components map[string]*schema
+}
+
+// ref returns the schema for t, registering named structs as components
+func (b *schemaBuilder) ref(t reflect.Type) *schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &schema{Type: "string", Format: "date-time"}
+	}
+	if s, ok := nullableSchema(t); ok {
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &schema{Type: "integer", Format: "int32"}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte and json.RawMessage carry arbitrary JSON
+			return &schema{Type: "object"}
+		}
+		return &schema{Type: "array", Items: b.ref(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: b.ref(t.Elem())}
+	case reflect.Interface:
+		return &schema{}
+	case reflect.Struct:
+		name := t.Name()
+		if name == "" {
+			return b.object(t)
+		}
+		if _, ok := b.components[name]; !ok {
+			// placeholder first so self referencing types terminate
+			b.components[name] = &schema{}
+			*b.components[name] = *b.object(t)
+		}
+		return &schema{Ref: "#/components/schemas/" + name}
+	}
+	return &schema{}
+}
+
+// nullableSchema handles the sql.Null* wrappers sqlc generates for nullable columns
+func nullableSchema(t reflect.Type) (*schema, bool) {
+	if t.Kind() != reflect.Struct || t.PkgPath() != "database/sql" || !strings.HasPrefix(t.Name(), "Null") {
+		return nil, false
+	}
+	var s *schema
+	switch t.Name() {
+	case "NullString":
+		s = &schema{Type: "string"}
+	case "NullInt64":
+		s = &schema{Type: "integer", Format: "int64"}
+	case "NullInt32", "NullInt16":
+		s = &schema{Type: "integer", Format: "int32"}
+	case "NullBool":
+		s = &schema{Type: "boolean"}
+	case "NullFloat64":
+		s = &schema{Type: "number"}
+	case "NullTime":
+		s = &schema{Type: "string", Format: "date-time"}
+	default:
+		s = &schema{}
+	}
+	s.Nullable = true
+	return s, true
+}
+
+// object builds an object schema from the json and binding tags of a struct
+func (b *schemaBuilder) object(t reflect.Type) *schema {
+	s := &schema{Type: "object", Properties: map[string]*schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := b.object(field.Type)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := b.ref(field.Type)
+		if required := applyBinding(property, field); required {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = property
+	}
+	return s
+}
+
+// applyBinding copies the validator rules of field onto s and reports whether the field is required.
+// rules after dive describe the items of a slice
+func applyBinding(s *schema, field reflect.StructField) (required bool) {
+	target := s
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = required || target == s
+		case "dive":
+			if target.Items == nil {
+				return required
+			}
+			target = target.Items
+		case "min", "max":
+			value, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			n := int(value)
+			switch {
+			case target.Type == "string" && name == "min":
+				target.MinLength = &n
+			case target.Type == "string":
+				target.MaxLength = &n
+			case target.Type == "array" && name == "min":
+				target.MinItems = &n
+			case target.Type == "array":
+				target.MaxItems = &n
+			case name == "min":
+				target.Minimum = &value
+			default:
+				target.Maximum = &value
+			}
+		case "oneof":
+			target.Enum = strings.Fields(param)
+		case "currency":
+			target.Enum = util.SupportedCurrencies()
+		case "webhook_event":
+			target.Enum = db.WebhookEventTypes()
+		case "email":
+			target.Format = "email"
+		case "url":
+			target.Format = "uri"
+		case "datetime":
+			if param == dateLayout {
+				target.Format = "date"
+			}
+		}
+	}
+	return required
+}
+
+// parameters lists the path and query parameters described by the uri and form tags of params,
+// embedded structs included
+func (b *schemaBuilder) parameters(params any) []parameter {
+	if params == nil {
+		return nil
+	}
+	t := reflect.TypeOf(params)
+	var result []parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			result = append(result, b.parameters(reflect.New(field.Type).Elem().Interface())...)
+			continue
+		}
+		in, name := "path", field.Tag.Get("uri")
+		if name == "" {
+			in, name = "query", field.Tag.Get("form")
+		}
+		name, _, _ = strings.Cut(name, ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		s := b.ref(field.Type)
+		required := applyBinding(s, field)
+		result = append(result, parameter{
+			Name:     name,
+			In:       in,
+			Required: required || in == "path",
+			Schema:   s,
+		})
+	}
+	return result
+}
+
+// openAPIPath converts a gin path (/accounts/:id) into an OpenAPI one (/accounts/{id})
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func jsonContent(s *schema) map[string]mediaType {
+	return map[string]mediaType{"application/json": {Schema: s}}
+}
+
+// buildOpenAPI generates the document for operations
+func buildOpenAPI(operations []operation) openAPIDocument {
+	b := &schemaBuilder{components: map[string]*schema{}}
+	errorSchema := b.ref(reflect.TypeOf(errorBody{}))
+
+	doc := openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:       "GoBank API",
+			Description: apiDescription,
+			Version:     strings.TrimPrefix(apiVersion, "/"),
+		},
+		Servers: []map[string]string{{"url": apiVersion}},
+		Paths:   map[string]map[string]*apiPath{},
+	}
+
+	for _, op := range operations {
+		item := &apiPath{
+			Summary:     op.Summary,
+			Description: op.Description,
+			OperationID: operationID(op),
+			Parameters:  b.parameters(op.Params),
+			Responses:   map[string]*response{},
+		}
+		item.RateLimitGroup = rateLimitGroup(op)
+		if op.Tag != "" {
+			item.Tags = []string{op.Tag}
+		}
+		// every route is behind the rate limiter
+		errorStatuses := append([]int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusInternalServerError}, op.Errors...)
+		if op.Auth {
+			item.Security = []map[string][]string{{bearerAuth: {}}, {apiKeyAuth: {}}}
+			item.Permission = op.Permission
+			errorStatuses = append(errorStatuses, http.StatusUnauthorized, http.StatusForbidden)
+		}
+		if op.Body != nil {
+			item.RequestBody = &requestBody{Required: true, Content: jsonContent(b.ref(reflect.TypeOf(op.Body)))}
+		}
+
+		success := &response{Description: http.StatusText(op.Status)}
+		if op.Response != nil {
+			contentType := op.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			success.Content = map[string]mediaType{contentType: {Schema: b.responseSchema(op.Response)}}
+		}
+		item.Responses[strconv.Itoa(op.Status)] = success
+		for status, body := range op.OtherResponses {
+			item.Responses[strconv.Itoa(status)] = &response{
+				Description: http.StatusText(status),
+				Content:     jsonContent(b.responseSchema(body)),
+			}
+		}
+		for _, status := range errorStatuses {
+			item.Responses[strconv.Itoa(status)] = &response{
+				Description: http.StatusText(status),
+				Content:     jsonContent(errorSchema),
+			}
+		}
+
+		path := openAPIPath(op.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*apiPath{}
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = item
+	}
+
+	doc.Components.Schemas = b.components
+	doc.Components.SecuritySchemes = map[string]securityScheme{
+		bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		// the authorization header reads "ApiKey gbk_..."
+		apiKeyAuth: {Type: "http", Scheme: "apikey"},
+	}
+	return doc
+}
+
+// responseSchema accepts a value or a one element []any to describe a JSON array of that value
+func (b *schemaBuilder) responseSchema(value any) *schema {
+	if list, ok := value.([]any); ok && len(list) == 1 {
+		return &schema{Type: "array", Items: b.ref(reflect.TypeOf(list[0]))}
+	}
+	return b.ref(reflect.TypeOf(value))
+}
+
+// rateLimitGroup is the route group of op
+func rateLimitGroup(op operation) string {
+	if op.RateLimitGroup == "" {
+		return ratelimit.GroupDefault
+	}
+	return op.RateLimitGroup
+}
+
+// operationID derives a stable id such as getAccountsId from the method and path
+func operationID(op operation) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(op.Method))
+	for _, segment := range strings.FieldsFunc(op.Path, func(r rune) bool { return r == '/' || r == '_' || r == '-' }) {
+		segment = strings.TrimLeft(segment, ":*")
+		sb.WriteString(strings.ToUpper(segment[:1]) + segment[1:])
+	}
+	return sb.String()
+}
+
+// openAPI serves the generated document
+func (server *Server) openAPI(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", server.openAPIJSON)
+}
+
+// swaggerUI serves a Swagger UI page pointed at the generated document
+func (server *Server) swaggerUI(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>GoBank API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "` + apiVersion + `/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>`
+
+// mustMarshalOpenAPI renders the document once at startup
+func mustMarshalOpenAPI(operations []operation) []byte {
+	data, err := json.MarshalIndent(buildOpenAPI(operations), "", "  ")
+	if err != nil {
+		panic("cannot marshal openapi document: " + err.Error())
+	}
+	return data
+}
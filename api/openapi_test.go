@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// undocumentedRoutes are the /v1 routes serving the documentation itself
+var undocumentedRoutes = map[string]bool{
+	"GET " + apiVersion + "/openapi.json": true,
+	"GET " + apiVersion + "/docs":         true,
+}
+
+func fetchOpenAPI(t *testing.T, server *Server) openAPIDocument {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, apiVersion+"/openapi.json", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var doc openAPIDocument
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &doc))
+	return doc
+}
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	doc := fetchOpenAPI(t, server)
+
+	var registered []string
+	for _, route := range server.router.Routes() {
+		key := route.Method + " " + route.Path
+		if !strings.HasPrefix(route.Path, apiVersion+"/") || undocumentedRoutes[key] {
+			continue
+		}
+		registered = append(registered, route.Method+" "+openAPIPath(strings.TrimPrefix(route.Path, apiVersion)))
+	}
+
+	var documented []string
+	for path, methods := range doc.Paths {
+		for method := range methods {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	sort.Strings(registered)
+	sort.Strings(documented)
+	require.Equal(t, registered, documented, "routes registered in NewServer and operations in openapi.go drifted apart")
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	doc := fetchOpenAPI(t, newTestServer(t, mockdb.NewMockStore(ctrl)))
+
+	transfer := doc.Components.Schemas["CreateTransferRequest"]
+	require.NotNil(t, transfer)
+	require.ElementsMatch(t, []string{"from_account_id", "to_account_id", "amount", "currency"}, transfer.Required)
+	require.Equal(t, util.SupportedCurrencies(), transfer.Properties["currency"].Enum)
+	require.Equal(t, float64(1), *transfer.Properties["amount"].Minimum)
+
+	result := doc.Components.Schemas["TransferTxResult"]
+	require.NotNil(t, result)
+	require.Equal(t, "#/components/schemas/Transfer", result.Properties["transfer"].Ref)
+	require.Equal(t, "date-time", doc.Components.Schemas["Account"].Properties["created_at"].Format)
+
+	getAccount := doc.Paths["/accounts/{id}"]["get"]
+	require.NotNil(t, getAccount)
+	require.Len(t, getAccount.Parameters, 1)
+	require.Equal(t, "path", getAccount.Parameters[0].In)
+	require.Contains(t, getAccount.Responses, "404")
+
+	// clients of the unversioned API are told what broke
+	require.Contains(t, doc.Info.Description, "Breaking changes")
+	require.Contains(t, doc.Paths["/accounts"]["post"].Description, "still opens the account")
+
+	// rules after dive describe the items of a slice
+	eventTypes := doc.Components.Schemas["createWebhookEndpointRequest"].Properties["event_types"]
+	require.Equal(t, 1, *eventTypes.MinItems)
+	require.Equal(t, db.WebhookEventTypes(), eventTypes.Items.Enum)
+}
+
+func TestSwaggerUI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, apiVersion+"/docs", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), apiVersion+"/openapi.json")
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+type riskAlertRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listRiskAlertsRequest struct {
+	pageQuery
+	// Status defaults to open, the alerts still waiting for someone
+	Status string `form:"status" binding:"omitempty,oneof=open cleared confirmed"`
+}
+
+type resolveRiskAlertRequest struct {
+	// Resolution is cleared for a false positive, confirmed for fraud
+	Resolution string `json:"resolution" binding:"required,oneof=cleared confirmed"`
+	Note       string `json:"note" binding:"max=500"`
+}
+
+// listRiskAlerts is the alert queue of admins, oldest first
+func (server *Server) listRiskAlerts(ctx *gin.Context) {
+	var req listRiskAlertsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if req.Status == "" {
+		req.Status = db.RiskAlertOpen
+	}
+
+	alerts, err := server.store.ListRiskAlerts(ctx.Request.Context(), db.ListRiskAlertsParams{
+		Status: req.Status,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, alerts)
+}
+
+func (server *Server) getRiskAlert(ctx *gin.Context) {
+	var req riskAlertRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	alert, err := server.store.GetRiskAlert(ctx.Request.Context(), req.ID)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, alert)
+}
+
+// resolveRiskAlert closes an open alert as cleared or confirmed, an alert is resolved once
+func (server *Server) resolveRiskAlert(ctx *gin.Context) {
+	var uri riskAlertRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	var req resolveRiskAlertRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	admin := authPayload(ctx)
+
+	alert, err := server.store.ResolveRiskAlertTx(ctx.Request.Context(), db.ResolveRiskAlertParams{
+		ID:             uri.ID,
+		Status:         req.Resolution,
+		ResolvedBy:     sql.NullString{String: admin.Username, Valid: true},
+		ResolutionNote: sql.NullString{String: req.Note, Valid: req.Note != ""},
+	})
+	if err != nil {
+		// an alert that is no longer open is a conflict
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, alert)
+}
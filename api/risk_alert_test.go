@@ -0,0 +1,211 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/risk"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestListRiskAlertsAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = db.UserRoleAdmin
+	customer, _ := randomUser(t)
+	alerts := []db.RiskAlert{randomRiskAlert(), randomRiskAlert()}
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OpenByDefault",
+			user:  admin,
+			query: "page_id=1&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListRiskAlerts(gomock.Any(), gomock.Eq(db.ListRiskAlertsParams{
+					Status: db.RiskAlertOpen,
+					Limit:  5,
+					Offset: 0,
+				})).Times(1).Return(alerts, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got []db.RiskAlert
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got, len(alerts))
+			},
+		},
+		{
+			name:  "Resolved",
+			user:  admin,
+			query: "page_id=2&page_size=5&status=confirmed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListRiskAlerts(gomock.Any(), gomock.Eq(db.ListRiskAlertsParams{
+					Status: db.RiskAlertConfirmed,
+					Limit:  5,
+					Offset: 5,
+				})).Times(1).Return([]db.RiskAlert{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidStatus",
+			user:  admin,
+			query: "page_id=1&page_size=5&status=blocked",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListRiskAlerts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "NotAdmin",
+			user:  customer,
+			query: "page_id=1&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListRiskAlerts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/v1/admin/risk-alerts?"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user.Username, tc.user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestResolveRiskAlertAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = db.UserRoleAdmin
+	alert := randomRiskAlert()
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"resolution": "cleared", "note": "salary split with a partner"},
+			buildStubs: func(store *mockdb.MockStore) {
+				resolved := alert
+				resolved.Status = db.RiskAlertCleared
+				store.EXPECT().ResolveRiskAlertTx(gomock.Any(), gomock.Eq(db.ResolveRiskAlertParams{
+					ID:             alert.ID,
+					Status:         db.RiskAlertCleared,
+					ResolvedBy:     sql.NullString{String: admin.Username, Valid: true},
+					ResolutionNote: sql.NullString{String: "salary split with a partner", Valid: true},
+				})).Times(1).Return(resolved, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyResolved",
+			body: gin.H{"resolution": "confirmed"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRiskAlertTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.RiskAlert{}, apperror.Conflict("risk alert is already resolved"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			body: gin.H{"resolution": "confirmed"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRiskAlertTx(gomock.Any(), gomock.Any()).Times(1).Return(db.RiskAlert{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidResolution",
+			body: gin.H{"resolution": "open"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRiskAlertTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeValidation)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/v1/admin/risk-alerts/%d/resolve", alert.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.Username, admin.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomRiskAlert() db.RiskAlert {
+	return db.RiskAlert{
+		ID:            util.RandomInt(1, 1000),
+		Decision:      string(risk.Review),
+		Status:        db.RiskAlertOpen,
+		FromAccountID: util.RandomInt(1, 1000),
+		ToAccountID:   util.RandomInt(1, 1000),
+		Amount:        util.RandomInt(1000, 2000),
+		Currency:      util.USD,
+		Findings:      json.RawMessage(`[{"rule":"fan_out","decision":"review","reason":"6 recipients within 1h0m0s"}]`),
+		CreatedAt:     time.Now(),
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateScheduledTransferRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,min=1"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	// ExecuteAt is when the scheduler runs the transfer, funds are only checked then
+	ExecuteAt time.Time `json:"execute_at" binding:"required,future"`
+	// the second factor is asked for now, above the step-up threshold, the scheduler runs the transfer unattended
+	stepUpRequest
+}
+
+type scheduledTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req CreateScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if err := server.checkUnattendedAmount(req.Currency, req.Amount); err != nil {
+		renderError(ctx, err)
+		return
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	authPayload := authPayload(ctx)
+	if fromAccount.Owner != authPayload.Username {
+		renderError(ctx, apperror.Forbidden("from account doesn't belong to the authenticated user"))
+		return
+	}
+	if _, err := server.validAccount(ctx, req.ToAccountID, req.Currency); err != nil {
+		renderError(ctx, err)
+		return
+	}
+	if err := server.stepUp(ctx, req.Currency, req.Amount, req.stepUpRequest); err != nil {
+		renderError(ctx, err)
+		return
+	}
+
+	scheduled, err := server.store.CreateScheduledTransfer(ctx.Request.Context(), db.CreateScheduledTransferParams{
+		Owner:         authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		ExecuteAt:     req.ExecuteAt,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req pageQuery
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	scheduled, err := server.store.ListScheduledTransfers(ctx.Request.Context(), db.ListScheduledTransfersParams{
+		Owner:  authPayload(ctx).Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req scheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	scheduled, ok := server.ownScheduledTransfer(ctx, req.ID)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+// cancelScheduledTransfer cancels a transfer that has not run yet, one being executed right now is waited for
+func (server *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	var req scheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	scheduled, ok := server.ownScheduledTransfer(ctx, req.ID)
+	if !ok {
+		return
+	}
+	if scheduled.Status != db.ScheduledTransferScheduled {
+		renderError(ctx, scheduledTransferDone(scheduled.Status))
+		return
+	}
+
+	cancelled, err := server.store.CancelScheduledTransfer(ctx.Request.Context(), req.ID)
+	if errors.Is(db.TranslateError(err), apperror.ErrNotFound) {
+		// executed between the read above and the cancel
+		renderError(ctx, scheduledTransferDone("executed"))
+		return
+	}
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, cancelled)
+}
+
+func scheduledTransferDone(status string) error {
+	return apperror.Conflict(fmt.Sprintf("scheduled transfer was already %s", status)).WithDetails(gin.H{"status": status})
+}
+
+// ownScheduledTransfer loads the scheduled transfer and checks it belongs to the caller, it renders the error itself when not
+func (server *Server) ownScheduledTransfer(ctx *gin.Context, id int64) (db.ScheduledTransfer, bool) {
+	scheduled, err := server.store.GetScheduledTransfer(ctx.Request.Context(), id)
+	if err != nil {
+		renderError(ctx, err)
+		return scheduled, false
+	}
+	if scheduled.Owner != authPayload(ctx).Username {
+		renderError(ctx, apperror.Forbidden("scheduled transfer doesn't belong to the authenticated user"))
+		return scheduled, false
+	}
+	return scheduled, true
+}
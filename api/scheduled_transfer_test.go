@@ -0,0 +1,319 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateScheduledTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+	// funds are checked when the transfer runs, not when it is scheduled
+	account1.Balance = 0
+	executeAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	enrolled := user1
+	enrolled.TotpEnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"execute_at":      executeAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.CreateScheduledTransferParams{
+					Owner:         user1.Username,
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        10,
+					Currency:      util.USD,
+					ExecuteAt:     executeAt,
+				}
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.ScheduledTransfer{ID: 1, Status: db.ScheduledTransferScheduled}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "ExecuteAtInThePast",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"execute_at":      time.Now().Add(-time.Minute),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				body := requireErrorCode(t, recorder, apperror.CodeValidation)
+				require.Contains(t, string(body.Details), `"field":"execute_at"`)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: user2.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"execute_at":      executeAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeForbidden)
+			},
+		},
+		{
+			name:     "CurrencyMismatch",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.EUR,
+				"execute_at":      executeAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeCurrencyMismatch)
+			},
+		},
+		{
+			name:     "AmountNeedsApproval",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          101,
+				"currency":        util.USD,
+				"execute_at":      executeAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				body := requireErrorCode(t, recorder, apperror.CodeValidation)
+				require.Contains(t, string(body.Details), `"field":"amount"`)
+			},
+		},
+		{
+			name:     "StepUp",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          60,
+				"currency":        util.USD,
+				"execute_at":      executeAt,
+				"two_factor_code": "123456",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(enrolled, nil)
+				store.EXPECT().VerifySecondFactorTx(gomock.Any(), gomock.Eq(db.VerifySecondFactorTxParams{
+					Username:     user1.Username,
+					SecondFactor: db.SecondFactor{Code: "123456"},
+				})).Times(1).Return(nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ScheduledTransfer{ID: 1, Status: db.ScheduledTransferScheduled}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "StepUpCodeRequired",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          60,
+				"currency":        util.USD,
+				"execute_at":      executeAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(enrolled, nil)
+				store.EXPECT().VerifySecondFactorTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeUnauthenticated)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			// nobody approves a transfer when the scheduler runs it
+			server.approvalThresholds = map[string]int64{util.USD: 100}
+			// but the second factor is asked for when it is scheduled
+			server.stepUpThresholds = map[string]int64{util.USD: 50}
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/scheduled-transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, db.UserRoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCancelScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	scheduled := db.ScheduledTransfer{
+		ID:        util.RandomInt(1, 1000),
+		Owner:     user.Username,
+		Amount:    10,
+		Currency:  util.USD,
+		ExecuteAt: time.Now().Add(time.Hour),
+		Status:    db.ScheduledTransferScheduled,
+	}
+	succeeded := scheduled
+	succeeded.Status = db.ScheduledTransferSucceeded
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				cancelled := scheduled
+				cancelled.Status = db.ScheduledTransferCancelled
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().CancelScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(cancelled, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var rsp db.ScheduledTransfer
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.ScheduledTransferCancelled, rsp.Status)
+			},
+		},
+		{
+			name:     "AlreadyExecuted",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(succeeded, nil)
+				store.EXPECT().CancelScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeConflict)
+			},
+		},
+		{
+			name:     "ExecutedConcurrently",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().CancelScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeConflict)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: "unauthorized_user",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().CancelScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/v1/scheduled-transfers/%d/cancel", scheduled.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, db.UserRoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
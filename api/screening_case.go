@@ -0,0 +1,90 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+type screeningCaseRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listScreeningCasesRequest struct {
+	pageQuery
+	// Status defaults to open, the cases still waiting for someone
+	Status string `form:"status" binding:"omitempty,oneof=open cleared confirmed"`
+}
+
+type resolveScreeningCaseRequest struct {
+	// Resolution is cleared when the user is not the listed party, confirmed when they are
+	Resolution string `json:"resolution" binding:"required,oneof=cleared confirmed"`
+	Note       string `json:"note" binding:"max=500"`
+}
+
+// listScreeningCases is the sanctions case queue of admins, oldest first
+func (server *Server) listScreeningCases(ctx *gin.Context) {
+	var req listScreeningCasesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if req.Status == "" {
+		req.Status = db.ScreeningCaseOpen
+	}
+
+	cases, err := server.store.ListScreeningCases(ctx.Request.Context(), db.ListScreeningCasesParams{
+		Status: req.Status,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, cases)
+}
+
+func (server *Server) getScreeningCase(ctx *gin.Context) {
+	var req screeningCaseRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	screeningCase, err := server.store.GetScreeningCase(ctx.Request.Context(), req.ID)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, screeningCase)
+}
+
+// resolveScreeningCase closes an open case, the sanctions status of the user follows in the same transaction
+func (server *Server) resolveScreeningCase(ctx *gin.Context) {
+	var uri screeningCaseRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	var req resolveScreeningCaseRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	admin := authPayload(ctx)
+
+	screeningCase, err := server.store.ResolveScreeningCaseTx(ctx.Request.Context(), db.ResolveScreeningCaseParams{
+		ID:             uri.ID,
+		Status:         req.Resolution,
+		ResolvedBy:     sql.NullString{String: admin.Username, Valid: true},
+		ResolutionNote: sql.NullString{String: req.Note, Valid: req.Note != ""},
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, screeningCase)
+}
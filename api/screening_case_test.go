@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestResolveScreeningCaseAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = db.UserRoleAdmin
+	customer, _ := randomUser(t)
+	screeningCase := db.ScreeningCase{
+		ID:        7,
+		Username:  sql.NullString{String: customer.Username, Valid: true},
+		FullName:  customer.FullName,
+		EntryID:   "6365",
+		EntryName: "PETROV, Ivan Sergeyevich",
+		Decision:  "review",
+		Status:    db.ScreeningCaseOpen,
+	}
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: admin,
+			body: gin.H{"resolution": "cleared", "note": "different date of birth"},
+			buildStubs: func(store *mockdb.MockStore) {
+				cleared := screeningCase
+				cleared.Status = db.ScreeningCaseCleared
+				store.EXPECT().ResolveScreeningCaseTx(gomock.Any(), gomock.Eq(db.ResolveScreeningCaseParams{
+					ID:             screeningCase.ID,
+					Status:         db.ScreeningCaseCleared,
+					ResolvedBy:     sql.NullString{String: admin.Username, Valid: true},
+					ResolutionNote: sql.NullString{String: "different date of birth", Valid: true},
+				})).Times(1).Return(cleared, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyResolved",
+			user: admin,
+			body: gin.H{"resolution": "confirmed"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveScreeningCaseTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ScreeningCase{}, apperror.Conflict("screening case [7] is already resolved"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			user: customer,
+			body: gin.H{"resolution": "cleared"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveScreeningCaseTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/v1/admin/screening-cases/%d/resolve", screeningCase.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user.Username, tc.user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
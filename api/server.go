@@ -1,48 +1,257 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apikey"
 	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/loginguard"
+	"github.com/ShubhKanodia/GoBank/mail"
+	"github.com/ShubhKanodia/GoBank/ratelimit"
+	"github.com/ShubhKanodia/GoBank/rbac"
+	"github.com/ShubhKanodia/GoBank/sanctions"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/ShubhKanodia/GoBank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("github.com/ShubhKanodia/GoBank/api")
+
 type Server struct {
+	config util.Config
 	// Store is the database store that provides access to the database
 	store db.Store
+	// tokenMaker issues and verifies access tokens, it is shared with the gRPC server
+	tokenMaker token.Maker
 	// Store is the database store that provides access to the database
 
 	router *gin.Engine
 	// Router is the HTTP router that handles incoming requests
+
+	// httpServer is created by Start and kept so Shutdown can stop it gracefully
+	httpServer atomic.Pointer[http.Server]
+	// draining is set once shutdown starts so readiness probes take the instance out of rotation
+	draining atomic.Bool
+	// openAPIJSON is the OpenAPI document generated from operations at startup
+	openAPIJSON []byte
+	// events wakes up account event streams when new events are committed
+	events EventSubscriber
+	// streamsDone is cancelled when shutdown starts, long lived streams end so the server can drain
+	streamsDone context.Context
+	stopStreams context.CancelFunc
+	// approvalThresholds maps a currency to the largest transfer that runs without a second person's approval
+	approvalThresholds map[string]int64
+	// stepUpThresholds maps a currency to the largest transfer that runs without a second factor
+	stepUpThresholds map[string]int64
+	// screener screens the names of new users against the sanctions list
+	screener *sanctions.Screener
+	// tokenSender mails the links that verify an email or reset a password
+	tokenSender *mail.TokenSender
+	// loginGuard checks passwords and locks out usernames and IPs that keep getting them wrong
+	loginGuard *loginguard.Guard
+	// limiter keeps the token buckets of the callers, rateLimits are the limits of the route groups
+	limiter    ratelimit.Limiter
+	rateLimits ratelimit.Limits
+	// apiKeys authenticates the callers with an API key instead of an access token
+	apiKeys *apikey.Authenticator
 }
 
-func NewServer(store db.Store) *Server {
+func NewServer(config util.Config, store db.Store, events EventSubscriber, limiter ratelimit.Limiter) (*Server, error) {
+	tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	approvalThresholds, err := util.ParseCurrencyAmounts(config.TransferApprovalThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRANSFER_APPROVAL_THRESHOLDS: %w", err)
+	}
+
+	stepUpThresholds, err := util.ParseCurrencyAmounts(config.TransferStepUpThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRANSFER_STEP_UP_THRESHOLDS: %w", err)
+	}
+
+	rateLimits, err := ratelimit.ParseLimits(config.RateLimits, rbac.Roles())
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMITS: %w", err)
+	}
+
+	sanctionsList, err := sanctions.LoadFile(config.SanctionsListPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load sanctions list: %w", err)
+	}
+
 	server := &Server{
-		store: store,
+		config:             config,
+		store:              store,
+		tokenMaker:         tokenMaker,
+		openAPIJSON:        mustMarshalOpenAPI(operations),
+		events:             events,
+		approvalThresholds: approvalThresholds,
+		stepUpThresholds:   stepUpThresholds,
+		screener:           sanctions.NewScreener(sanctionsList, config.SanctionsBlockScore, config.SanctionsReviewScore),
+		tokenSender:        mail.NewTokenSender(store, config),
+		loginGuard:         loginguard.NewGuard(store, config),
+		limiter:            limiter,
+		rateLimits:         rateLimits,
+		apiKeys:            apikey.NewAuthenticator(store),
 	}
-	router := gin.Default()
+	server.streamsDone, server.stopStreams = context.WithCancel(context.Background())
+	// gin.Default() would add gin's plain text logger, we log structured JSON ourselves
+	router := gin.New()
+	// the client IP feeds API key allowlists, rate limits, login lockouts and the audit log, so forwarding
+	// headers are only believed from the proxies in front of us. gin trusts every peer by default
+	if err := router.SetTrustedProxies(parseTrustedProxies(config.TrustedProxies)); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	router.Use(gin.CustomRecoveryWithWriter(io.Discard, recoveryHandler))
+	router.Use(requestIDMiddleware())
+	router.Use(loggerMiddleware())
+	// otelgin extracts the W3C traceparent header and puts the request span into ctx.Request.Context()
+	router.Use(otelgin.Middleware("gobank"))
+	router.Use(metricsMiddleware())
 
 	//register custom validator
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validateCurrency)
+		v.RegisterValidation("webhook_event", validateWebhookEvent)
+		v.RegisterValidation("future", validateFuture)
+		v.RegisterValidation("api_key_scope", validateAPIKeyScope)
+		v.RegisterTagNameFunc(fieldName)
 	}
 
-	router.POST("/accounts", server.createAccount)   // last one shoukd be the handler func like (middleware1, middleware2..., handler)
-	router.GET("/accounts/:id", server.getAccount)   // this is the endpoint for getting an account by id
-	router.GET("/accounts", server.ListAccounts)     // this is the endpoint for listing accounts with pagination
-	router.POST("/transfers", server.createTransfer) // this is the endpoint for creating a transfer
+	// probes are registered on their own so they never sit behind auth or rate limits
+	router.GET("/healthz", server.healthz)
+	router.GET("/readyz", server.readyz)
+	router.GET("/version", server.version)
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// every public route lives under /v1, document new ones in operations (openapi.go)
+	v1 := router.Group(apiVersion)
+	v1.GET("/openapi.json", server.openAPI)
+	v1.GET("/docs", server.swaggerUI)
+
+	// the routes of operations are rate limited, by route group (see ratelimit)
+	rateLimit := rateLimitMiddleware(server.limiter, server.rateLimits, rateLimitGroups(operations))
+
+	publicRoutes := v1.Group("/").Use(rateLimit)
+	publicRoutes.POST("/users", server.createUser)
+	publicRoutes.POST("/users/login", server.loginUser)
+	publicRoutes.POST("/users/login/2fa", server.completeLogin) // the second step of logging in with two-factor authentication
+	publicRoutes.POST("/users/verify_email", server.verifyEmail)
+	publicRoutes.POST("/users/password_reset/request", server.requestPasswordReset) // mails a link with a reset token
+	publicRoutes.POST("/users/password_reset/confirm", server.confirmPasswordReset)
+
+	// everything below needs a bearer token or an API key, and a permission of the caller's role (see rbac)
+	authRoutes := v1.Group("/").Use(authMiddleware(server.tokenMaker, server.apiKeys), rateLimit)
+	authRoutes.POST("/users/verify_email/resend", RequirePermission(rbac.EmailVerify), server.resendVerification)
+	authRoutes.POST("/users/2fa/enroll", RequirePermission(rbac.TwoFactorManage), server.enrollTOTP)
+	authRoutes.POST("/users/2fa/confirm", RequirePermission(rbac.TwoFactorManage), server.confirmTOTP)
+	authRoutes.POST("/users/2fa/disable", RequirePermission(rbac.TwoFactorManage), server.disableTOTP)
+	authRoutes.POST("/api-keys", RequirePermission(rbac.APIKeysManage), server.createAPIKey) // keys for services acting as the caller
+	authRoutes.GET("/api-keys", RequirePermission(rbac.APIKeysManage), server.listAPIKeys)
+	authRoutes.POST("/api-keys/:id/rotate", RequirePermission(rbac.APIKeysManage), server.rotateAPIKey)
+	authRoutes.POST("/api-keys/:id/revoke", RequirePermission(rbac.APIKeysManage), server.revokeAPIKey)
+	authRoutes.GET("/users/kyc", RequirePermission(rbac.KYCSubmit), server.getKYC)
+	authRoutes.POST("/users/kyc", RequirePermission(rbac.KYCSubmit), server.submitKYC)                       // details and documents for identity verification
+	authRoutes.POST("/accounts", RequirePermission(rbac.AccountsCreate), server.createAccount)               // last one shoukd be the handler func like (middleware1, middleware2..., handler)
+	authRoutes.GET("/accounts/:id", RequirePermission(rbac.AccountsRead), server.getAccount)                 // this is the endpoint for getting an account by id
+	authRoutes.GET("/accounts", RequirePermission(rbac.AccountsRead), server.ListAccounts)                   // this is the endpoint for listing accounts with pagination
+	authRoutes.GET("/accounts/:id/events", RequirePermission(rbac.AccountsRead), server.streamAccountEvents) // server-sent events stream of balance changes
+	authRoutes.GET("/accounts/:id/limits", RequirePermission(rbac.AccountsRead), server.getAccountLimits)    // daily and monthly transfer limits and their usage
+	authRoutes.POST("/transfers", RequirePermission(rbac.TransfersCreate), server.createTransfer)            // this is the endpoint for creating a transfer
+	authRoutes.GET("/transfer-approvals", RequirePermission(rbac.TransfersRead), server.listTransferApprovals)
+	authRoutes.GET("/transfer-approvals/pending", RequirePermission(rbac.TransfersApprove), server.listPendingTransferApprovals) // the review queue of approvers
+	authRoutes.GET("/transfer-approvals/:id", RequirePermission(rbac.TransfersRead), server.getTransferApproval)
+	authRoutes.POST("/transfer-approvals/:id/approve", RequirePermission(rbac.TransfersApprove), server.approveTransfer)
+	authRoutes.POST("/transfer-approvals/:id/reject", RequirePermission(rbac.TransfersApprove), server.rejectTransfer)
+	authRoutes.POST("/scheduled-transfers", RequirePermission(rbac.TransfersCreate), server.createScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers", RequirePermission(rbac.TransfersRead), server.listScheduledTransfers)
+	authRoutes.GET("/scheduled-transfers/:id", RequirePermission(rbac.TransfersRead), server.getScheduledTransfer)
+	authRoutes.POST("/scheduled-transfers/:id/cancel", RequirePermission(rbac.TransfersCreate), server.cancelScheduledTransfer)
+	authRoutes.POST("/standing-orders", RequirePermission(rbac.TransfersCreate), server.createStandingOrder)
+	authRoutes.GET("/standing-orders", RequirePermission(rbac.TransfersRead), server.listStandingOrders)
+	authRoutes.GET("/standing-orders/:id", RequirePermission(rbac.TransfersRead), server.getStandingOrder)
+	authRoutes.POST("/standing-orders/:id/cancel", RequirePermission(rbac.TransfersCreate), server.cancelStandingOrder)
+	authRoutes.GET("/standing-orders/:id/occurrences", RequirePermission(rbac.TransfersRead), server.listStandingOrderOccurrences)
+	authRoutes.POST("/webhooks", RequirePermission(rbac.WebhooksManage), server.createWebhookEndpoint)
+	authRoutes.GET("/webhooks", RequirePermission(rbac.WebhooksManage), server.listWebhookEndpoints)
+	authRoutes.DELETE("/webhooks/:id", RequirePermission(rbac.WebhooksManage), server.deleteWebhookEndpoint)
+	authRoutes.GET("/webhooks/:id/deliveries", RequirePermission(rbac.WebhooksManage), server.listWebhookDeliveries)
+	authRoutes.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", RequirePermission(rbac.WebhooksManage), server.redeliverWebhook)
+	authRoutes.POST("/admin/accounts/:id/freeze", RequirePermission(rbac.AccountsFreeze), server.freezeAccount)
+	authRoutes.POST("/admin/accounts/:id/unfreeze", RequirePermission(rbac.AccountsFreeze), server.unfreezeAccount)
+	authRoutes.POST("/admin/accounts/:id/adjustments", RequirePermission(rbac.AccountsAdjust), server.adjustBalance) // corrections of a balance
+	authRoutes.GET("/admin/accounts/:id/adjustments", RequirePermission(rbac.AccountsAdjust), server.listBalanceAdjustments)
+	authRoutes.POST("/admin/transfers/:id/reverse", RequirePermission(rbac.TransfersReverse), server.reverseTransfer)
+	authRoutes.GET("/admin/risk-alerts", RequirePermission(rbac.RiskAlertsReview), server.listRiskAlerts) // the alert queue of admins
+	authRoutes.GET("/admin/risk-alerts/:id", RequirePermission(rbac.RiskAlertsReview), server.getRiskAlert)
+	authRoutes.POST("/admin/risk-alerts/:id/resolve", RequirePermission(rbac.RiskAlertsReview), server.resolveRiskAlert)
+	authRoutes.GET("/admin/screening-cases", RequirePermission(rbac.ScreeningCasesReview), server.listScreeningCases) // sanctions matches waiting for review
+	authRoutes.GET("/admin/screening-cases/:id", RequirePermission(rbac.ScreeningCasesReview), server.getScreeningCase)
+	authRoutes.POST("/admin/screening-cases/:id/resolve", RequirePermission(rbac.ScreeningCasesReview), server.resolveScreeningCase)
+	authRoutes.GET("/admin/kyc", RequirePermission(rbac.KYCReview), server.listKYC) // users waiting for a KYC decision
+	authRoutes.GET("/admin/users/:username/kyc", RequirePermission(rbac.KYCReview), server.getUserKYC)
+	authRoutes.POST("/admin/users/:username/kyc", RequirePermission(rbac.KYCReview), server.transitionKYC)
+	authRoutes.POST("/admin/users/:username/unlock", RequirePermission(rbac.UsersUnlock), server.unlockUser) // lifts a lockout after failed logins
+	authRoutes.GET("/admin/audit-events", RequirePermission(rbac.AuditEventsRead), server.listAuditEvents)   // who changed what, newest first
 	server.router = router
-	return server
+	return server, nil
+}
+
+// parseTrustedProxies splits the comma separated IPs and CIDRs of TRUSTED_PROXIES, none when empty
+func parseTrustedProxies(s string) []string {
+	var proxies []string
+	for _, proxy := range strings.Split(s, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
 }
 func (s *Server) Start(address string) error {
 	// Start the HTTP server on the given address
-	return s.router.Run(address)
+	httpServer := &http.Server{
+		Addr:    address,
+		Handler: s.router,
+	}
+	s.httpServer.Store(httpServer)
+	err := httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		// returned after a graceful Shutdown, not a failure
+		return nil
+	}
+	return err
 }
 
-// errorResponse creates a JSON response for errors
-// gin .H
-func errorResponse(err error) gin.H {
-	return gin.H{
-		"error": err.Error(),
+// Shutdown drains the server: readiness starts failing straight away, then after drainPeriod
+// (long enough for the load balancer to notice) in-flight requests are finished and the listener is closed
+func (s *Server) Shutdown(ctx context.Context, drainPeriod time.Duration) error {
+	s.draining.Store(true)
+	// streams never finish on their own, end them now so clients reconnect elsewhere while we drain
+	s.stopStreams()
+
+	select {
+	case <-time.After(drainPeriod):
+	case <-ctx.Done():
+	}
+
+	httpServer := s.httpServer.Load()
+	if httpServer == nil {
+		return nil
 	}
+	return httpServer.Shutdown(ctx)
 }
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// dateLayout is how calendar dates are sent, without a time of day
+const dateLayout = "2006-01-02"
+
+// defaultMaxRetries applies to orders that retry without saying how often
+const defaultMaxRetries = 3
+
+type CreateStandingOrderRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,min=1"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	Frequency     string `json:"frequency" binding:"required,oneof=weekly monthly yearly"`
+	// StartDate is the first occurrence, later ones keep its weekday, day of month or date
+	StartDate string `json:"start_date" binding:"required,datetime=2006-01-02"`
+	// EndDate and MaxOccurrences end the order, whichever comes first. without either it runs until cancelled
+	EndDate               string `json:"end_date" binding:"omitempty,datetime=2006-01-02"`
+	MaxOccurrences        int32  `json:"max_occurrences" binding:"omitempty,min=1"`
+	BusinessDayAdjustment string `json:"business_day_adjustment" binding:"omitempty,oneof=none following preceding modified_following"`
+	OnInsufficientFunds   string `json:"on_insufficient_funds" binding:"omitempty,oneof=skip retry"`
+	// MaxRetries is how many times an occurrence short of funds is tried again, only with on_insufficient_funds retry
+	MaxRetries int32 `json:"max_retries" binding:"omitempty,min=1,max=10"`
+	// the second factor is asked for now, above the step-up threshold, the occurrences run unattended
+	stepUpRequest
+}
+
+type standingOrderRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listStandingOrderOccurrencesRequest struct {
+	standingOrderRequest
+	pageQuery
+}
+
+func (server *Server) createStandingOrder(ctx *gin.Context) {
+	var req CreateStandingOrderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	arg, err := newStandingOrderParams(req, time.Now())
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	if err := server.checkUnattendedAmount(req.Currency, req.Amount); err != nil {
+		renderError(ctx, err)
+		return
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	authPayload := authPayload(ctx)
+	if fromAccount.Owner != authPayload.Username {
+		renderError(ctx, apperror.Forbidden("from account doesn't belong to the authenticated user"))
+		return
+	}
+	if _, err := server.validAccount(ctx, req.ToAccountID, req.Currency); err != nil {
+		renderError(ctx, err)
+		return
+	}
+	if err := server.stepUp(ctx, req.Currency, req.Amount, req.stepUpRequest); err != nil {
+		renderError(ctx, err)
+		return
+	}
+
+	arg.Owner = authPayload.Username
+	order, err := server.store.CreateStandingOrder(ctx.Request.Context(), arg)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, order)
+}
+
+// newStandingOrderParams checks the calendar rule of req and works out when the first occurrence runs
+func newStandingOrderParams(req CreateStandingOrderRequest, now time.Time) (db.CreateStandingOrderParams, error) {
+	arg := db.CreateStandingOrderParams{
+		FromAccountID:         req.FromAccountID,
+		ToAccountID:           req.ToAccountID,
+		Amount:                req.Amount,
+		Currency:              req.Currency,
+		Frequency:             req.Frequency,
+		MaxOccurrences:        sql.NullInt32{Int32: req.MaxOccurrences, Valid: req.MaxOccurrences > 0},
+		BusinessDayAdjustment: req.BusinessDayAdjustment,
+		OnInsufficientFunds:   req.OnInsufficientFunds,
+		MaxRetries:            req.MaxRetries,
+	}
+	if arg.BusinessDayAdjustment == "" {
+		arg.BusinessDayAdjustment = util.BusinessDayNone
+	}
+	if arg.OnInsufficientFunds == "" {
+		arg.OnInsufficientFunds = db.StandingOrderSkip
+	}
+	switch {
+	case arg.OnInsufficientFunds == db.StandingOrderSkip && arg.MaxRetries > 0:
+		return arg, invalidField("max_retries", "excluded_unless", "on_insufficient_funds retry")
+	case arg.OnInsufficientFunds == db.StandingOrderRetry && arg.MaxRetries == 0:
+		arg.MaxRetries = defaultMaxRetries
+	}
+
+	// the binding tags already checked the layout
+	arg.StartDate, _ = time.Parse(dateLayout, req.StartDate)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if arg.StartDate.Before(today) {
+		return arg, invalidField("start_date", "gte", today.Format(dateLayout))
+	}
+	if req.EndDate != "" {
+		endDate, _ := time.Parse(dateLayout, req.EndDate)
+		if endDate.Before(arg.StartDate) {
+			return arg, invalidField("end_date", "gtefield", "start_date")
+		}
+		arg.EndDate = sql.NullTime{Time: endDate, Valid: true}
+	}
+
+	_, firstRun := db.StandingOrder{
+		StartDate:             arg.StartDate,
+		Frequency:             arg.Frequency,
+		BusinessDayAdjustment: arg.BusinessDayAdjustment,
+	}.OccurrenceDates(0)
+	arg.NextRunAt = sql.NullTime{Time: firstRun, Valid: true}
+	return arg, nil
+}
+
+func (server *Server) listStandingOrders(ctx *gin.Context) {
+	var req pageQuery
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	orders, err := server.store.ListStandingOrders(ctx.Request.Context(), db.ListStandingOrdersParams{
+		Owner:  authPayload(ctx).Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, orders)
+}
+
+func (server *Server) getStandingOrder(ctx *gin.Context) {
+	var req standingOrderRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	order, ok := server.ownStandingOrder(ctx, req.ID)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, order)
+}
+
+// cancelStandingOrder stops an active order, the occurrences already run stay in its history
+func (server *Server) cancelStandingOrder(ctx *gin.Context) {
+	var req standingOrderRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	order, ok := server.ownStandingOrder(ctx, req.ID)
+	if !ok {
+		return
+	}
+	if order.Status != db.StandingOrderActive {
+		renderError(ctx, standingOrderInactive(order.Status))
+		return
+	}
+
+	cancelled, err := server.store.CancelStandingOrder(ctx.Request.Context(), req.ID)
+	if errors.Is(db.TranslateError(err), apperror.ErrNotFound) {
+		// its last occurrence completed it between the read above and the cancel
+		renderError(ctx, standingOrderInactive(db.StandingOrderCompleted))
+		return
+	}
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, cancelled)
+}
+
+func standingOrderInactive(status string) error {
+	return apperror.Conflict("standing order was already " + status).WithDetails(gin.H{"status": status})
+}
+
+func (server *Server) listStandingOrderOccurrences(ctx *gin.Context) {
+	var req listStandingOrderOccurrencesRequest
+	if err := ctx.ShouldBindUri(&req.standingOrderRequest); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req.pageQuery); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if _, ok := server.ownStandingOrder(ctx, req.ID); !ok {
+		return
+	}
+
+	occurrences, err := server.store.ListStandingOrderOccurrences(ctx.Request.Context(), db.ListStandingOrderOccurrencesParams{
+		StandingOrderID: req.ID,
+		Limit:           req.PageSize,
+		Offset:          (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, occurrences)
+}
+
+// ownStandingOrder loads the standing order and checks it belongs to the caller, it renders the error itself when not
+func (server *Server) ownStandingOrder(ctx *gin.Context, id int64) (db.StandingOrder, bool) {
+	order, err := server.store.GetStandingOrder(ctx.Request.Context(), id)
+	if err != nil {
+		renderError(ctx, err)
+		return order, false
+	}
+	if order.Owner != authPayload(ctx).Username {
+		renderError(ctx, apperror.Forbidden("standing order doesn't belong to the authenticated user"))
+		return order, false
+	}
+	return order, true
+}
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateStandingOrderAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	// a saturday at least a week ahead, so following moves the first run to monday
+	saturday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 7)
+	for saturday.Weekday() != time.Saturday {
+		saturday = saturday.AddDate(0, 0, 1)
+	}
+	monday := saturday.AddDate(0, 0, 2)
+	enrolled := user1
+	enrolled.TotpEnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	body := func(overrides gin.H) gin.H {
+		b := gin.H{
+			"from_account_id":         account1.ID,
+			"to_account_id":           account2.ID,
+			"amount":                  10,
+			"currency":                util.USD,
+			"frequency":               util.Monthly,
+			"start_date":              saturday.Format(dateLayout),
+			"business_day_adjustment": util.BusinessDayFollowing,
+		}
+		for k, v := range overrides {
+			b[k] = v
+		}
+		return b
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user1.Username,
+			body:     body(gin.H{"on_insufficient_funds": db.StandingOrderRetry, "max_occurrences": 12}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.CreateStandingOrderParams{
+					Owner:                 user1.Username,
+					FromAccountID:         account1.ID,
+					ToAccountID:           account2.ID,
+					Amount:                10,
+					Currency:              util.USD,
+					Frequency:             util.Monthly,
+					StartDate:             saturday,
+					MaxOccurrences:        sql.NullInt32{Int32: 12, Valid: true},
+					BusinessDayAdjustment: util.BusinessDayFollowing,
+					OnInsufficientFunds:   db.StandingOrderRetry,
+					MaxRetries:            defaultMaxRetries,
+					NextRunAt:             sql.NullTime{Time: monday, Valid: true},
+				}
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.StandingOrder{ID: 1, Status: db.StandingOrderActive}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "StartDateInThePast",
+			username: user1.Username,
+			body:     body(gin.H{"start_date": time.Now().AddDate(0, 0, -2).Format(dateLayout)}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				body := requireErrorCode(t, recorder, apperror.CodeValidation)
+				require.Contains(t, string(body.Details), `"field":"start_date"`)
+			},
+		},
+		{
+			name:     "EndDateBeforeStartDate",
+			username: user1.Username,
+			body:     body(gin.H{"end_date": saturday.AddDate(0, 0, -1).Format(dateLayout)}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				body := requireErrorCode(t, recorder, apperror.CodeValidation)
+				require.Contains(t, string(body.Details), `"field":"end_date"`)
+			},
+		},
+		{
+			name:     "RetriesWithSkip",
+			username: user1.Username,
+			body:     body(gin.H{"on_insufficient_funds": db.StandingOrderSkip, "max_retries": 2}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeValidation)
+			},
+		},
+		{
+			name:     "InvalidFrequency",
+			username: user1.Username,
+			body:     body(gin.H{"frequency": "daily"}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: user2.Username,
+			body:     body(nil),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "StepUp",
+			username: user1.Username,
+			body:     body(gin.H{"amount": 60, "recovery_code": "abcd-efgh"}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(enrolled, nil)
+				store.EXPECT().VerifySecondFactorTx(gomock.Any(), gomock.Eq(db.VerifySecondFactorTxParams{
+					Username:     user1.Username,
+					SecondFactor: db.SecondFactor{RecoveryCode: "abcd-efgh"},
+				})).Times(1).Return(nil)
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.StandingOrder{ID: 1, Status: db.StandingOrderActive}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "StepUpInvalidCode",
+			username: user1.Username,
+			body:     body(gin.H{"amount": 60, "two_factor_code": "123456"}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(enrolled, nil)
+				store.EXPECT().VerifySecondFactorTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(apperror.Unauthenticated("invalid two-factor code"))
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.stepUpThresholds = map[string]int64{util.USD: 50}
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/standing-orders", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, db.UserRoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCancelStandingOrderAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	order := db.StandingOrder{
+		ID:     util.RandomInt(1, 1000),
+		Owner:  user.Username,
+		Status: db.StandingOrderActive,
+	}
+	completed := order
+	completed.Status = db.StandingOrderCompleted
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				cancelled := order
+				cancelled.Status = db.StandingOrderCancelled
+				store.EXPECT().GetStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(order, nil)
+				store.EXPECT().CancelStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(cancelled, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyCompleted",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(completed, nil)
+				store.EXPECT().CancelStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeConflict)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/v1/standing-orders/%d/cancel", order.ID), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTransferTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	account1 := randomAccount(util.RandomOwner())
+	account2 := randomAccount(util.RandomOwner())
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+	account1.Balance = 100
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+	store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, nil)
+
+	server := newTestServer(t, store)
+
+	body, err := json.Marshal(gin.H{
+		"from_account_id": account1.ID,
+		"to_account_id":   account2.ID,
+		"amount":          10,
+		"currency":        util.USD,
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/v1/transfers", bytes.NewReader(body))
+	require.NoError(t, err)
+	// the caller's trace must be continued, not replaced
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	request.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, account1.Owner, db.UserRoleCustomer, time.Minute)
+	server.router.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		require.Equal(t, traceID, span.SpanContext().TraceID().String())
+		names = append(names, span.Name())
+	}
+	require.Contains(t, names, "/v1/transfers")
+	require.Equal(t, 2, countOf(names, "validAccount"))
+}
+
+func countOf(values []string, value string) int {
+	n := 0
+	for _, v := range values {
+		if v == value {
+			n++
+		}
+	}
+	return n
+}
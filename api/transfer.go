@@ -1,12 +1,16 @@
 package api
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/ShubhKanodia/GoBank/apperror"
 	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/metrics"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type CreateTransferRequest struct {
@@ -14,6 +18,7 @@ type CreateTransferRequest struct {
 	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
 	Amount        int64  `json:"amount" binding:"required,min=1"`
 	Currency      string `json:"currency" binding:"required,currency"` //currency is a custom validator
+	stepUpRequest
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
@@ -21,15 +26,40 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 	//Bind validation rules to the request body
 	// If the request body is not valid, it will return a 400 Bad Request error
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		transferFailed(ctx, bindingError(err))
 		// ShouldBindJSON binds the request body to the struct and validates it
 		return
 	}
 
-	if !server.validAccount(ctx, req.FromAccountID, req.Currency) {
+	fromAccount, err := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if err != nil {
+		transferFailed(ctx, err)
+		return
+	}
+	authPayload := authPayload(ctx)
+	if fromAccount.Owner != authPayload.Username {
+		transferFailed(ctx, apperror.Forbidden("from account doesn't belong to the authenticated user"))
+		return
+	}
+	if _, err := server.validAccount(ctx, req.ToAccountID, req.Currency); err != nil {
+		transferFailed(ctx, err)
+		return
+	}
+	// early check so a short account is refused before step-up or approval, the transfer checks the locked row again
+	if fromAccount.Balance < req.Amount {
+		err := apperror.InsufficientFunds(fmt.Sprintf("account [%d] has insufficient funds", req.FromAccountID)).
+			WithDetails(gin.H{"account_id": req.FromAccountID})
+		transferFailed(ctx, err)
 		return
 	}
-	if !server.validAccount(ctx, req.ToAccountID, req.Currency) {
+
+	if err := server.stepUp(ctx, req.Currency, req.Amount, req.stepUpRequest); err != nil {
+		transferFailed(ctx, err)
+		return
+	}
+
+	if server.needsApproval(req.Currency, req.Amount) {
+		server.holdTransfer(ctx, req)
 		return
 	}
 
@@ -42,26 +72,35 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 
 	result, err := server.store.TransferTx(ctx.Request.Context(), arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		transferFailed(ctx, err)
 		return
 	}
+	metrics.TransfersCreated.WithLabelValues(req.Currency).Inc()
+	metrics.TransferAmount.WithLabelValues(req.Currency).Add(float64(req.Amount))
 	ctx.JSON(http.StatusOK, result)
 }
 
-func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) bool {
-	account, err := server.store.GetAccount(ctx, accountID)
+// validAccount checks the account exists and holds the transfer currency
+func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, error) {
+	// *gin.Context does not carry the request span, the request context does
+	c, span := tracer.Start(ctx.Request.Context(), "validAccount", trace.WithAttributes(attribute.Int64("account.id", accountID)))
+	defer span.End()
+
+	account, err := server.store.GetAccount(c, accountID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return false
+		if errors.Is(db.TranslateError(err), apperror.ErrNotFound) {
+			err = apperror.Wrap(apperror.CodeNotFound, err, fmt.Sprintf("account [%d] not found", accountID))
 		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return false
+		return account, err
 	}
 	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return false
+		return account, apperror.CurrencyMismatch(fmt.Sprintf("account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency))
 	}
-	return true
+	return account, nil
+}
+
+// transferFailed counts the failure by error code and renders it
+func transferFailed(ctx *gin.Context, err error) {
+	metrics.TransfersFailed.WithLabelValues(string(apperror.CodeOf(db.TranslateError(err)))).Inc()
+	renderError(ctx, err)
 }
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/metrics"
+	"github.com/ShubhKanodia/GoBank/rbac"
+	"github.com/gin-gonic/gin"
+)
+
+type transferApprovalRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type rejectTransferRequest struct {
+	// Reason is shown to the initiator
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// needsApproval reports whether a transfer of amount in currency needs a second person's approval
+func (server *Server) needsApproval(currency string, amount int64) bool {
+	threshold, ok := server.approvalThresholds[currency]
+	return ok && amount > threshold
+}
+
+// checkUnattendedAmount refuses scheduled and repeating transfers that would need approval when they run,
+// nobody is around to approve them then
+func (server *Server) checkUnattendedAmount(currency string, amount int64) error {
+	if !server.needsApproval(currency, amount) {
+		return nil
+	}
+	return invalidField("amount", "lte", strconv.FormatInt(server.approvalThresholds[currency], 10))
+}
+
+// holdTransfer records a transfer above the approval threshold as a pending request instead of executing it,
+// the money moves once an approver approves it
+func (server *Server) holdTransfer(ctx *gin.Context, req CreateTransferRequest) {
+	approval, err := server.store.CreateTransferApproval(ctx.Request.Context(), db.CreateTransferApprovalParams{
+		Initiator:     authPayload(ctx).Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		ExpiresAt:     time.Now().Add(server.config.TransferApprovalTTL),
+	})
+	if err != nil {
+		transferFailed(ctx, err)
+		return
+	}
+	metrics.TransferApprovals.WithLabelValues(db.TransferApprovalPending).Inc()
+	ctx.JSON(http.StatusAccepted, approval)
+}
+
+// listTransferApprovals lists the transfers the caller requested that were held for approval
+func (server *Server) listTransferApprovals(ctx *gin.Context) {
+	var req pageQuery
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	approvals, err := server.store.ListTransferApprovals(ctx.Request.Context(), db.ListTransferApprovalsParams{
+		Initiator: authPayload(ctx).Username,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, approvals)
+}
+
+// listPendingTransferApprovals is the review queue, oldest first, for approvers only
+func (server *Server) listPendingTransferApprovals(ctx *gin.Context) {
+	var req pageQuery
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	approvals, err := server.store.ListPendingTransferApprovals(ctx.Request.Context(), db.ListPendingTransferApprovalsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, approvals)
+}
+
+// getTransferApproval shows a held transfer to its initiator and to approvers
+func (server *Server) getTransferApproval(ctx *gin.Context) {
+	var req transferApprovalRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	approval, err := server.store.GetTransferApproval(ctx.Request.Context(), req.ID)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	if !ownerOrPermitted(ctx, approval.Initiator, rbac.TransfersApprove) {
+		renderError(ctx, apperror.Forbidden("only the initiator and approvers can see a held transfer"))
+		return
+	}
+	ctx.JSON(http.StatusOK, approval)
+}
+
+// approveTransfer approves a held transfer and executes it, the approver must not be the initiator
+func (server *Server) approveTransfer(ctx *gin.Context) {
+	var req transferApprovalRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	reviewer := authPayload(ctx)
+
+	result, err := server.store.ApproveTransferTx(ctx.Request.Context(), db.ApproveTransferTxParams{
+		ID:       req.ID,
+		Reviewer: reviewer.Username,
+	})
+	if err != nil {
+		transferFailed(ctx, err)
+		return
+	}
+	metrics.TransferApprovals.WithLabelValues(db.TransferApprovalApproved).Inc()
+	metrics.TransfersCreated.WithLabelValues(result.Approval.Currency).Inc()
+	metrics.TransferAmount.WithLabelValues(result.Approval.Currency).Add(float64(result.Approval.Amount))
+	ctx.JSON(http.StatusOK, result)
+}
+
+// rejectTransfer turns down a held transfer, the approver must not be the initiator
+func (server *Server) rejectTransfer(ctx *gin.Context) {
+	var uri transferApprovalRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	var req rejectTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	reviewer := authPayload(ctx)
+
+	rejected, err := server.store.RejectTransfer(ctx.Request.Context(), db.RejectTransferParams{
+		ID:       uri.ID,
+		Reviewer: reviewer.Username,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	metrics.TransferApprovals.WithLabelValues(db.TransferApprovalRejected).Inc()
+	ctx.JSON(http.StatusOK, rejected)
+}
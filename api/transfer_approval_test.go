@@ -0,0 +1,340 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateTransferHeldForApproval(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account1.Balance = 1000
+	account2.Currency = util.USD
+
+	testCases := []struct {
+		name          string
+		amount        int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "AboveThreshold",
+			amount: 101,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferApproval(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, arg db.CreateTransferApprovalParams) (db.TransferApproval, error) {
+						require.Equal(t, user1.Username, arg.Initiator)
+						require.Equal(t, account1.ID, arg.FromAccountID)
+						require.Equal(t, account2.ID, arg.ToAccountID)
+						require.Equal(t, int64(101), arg.Amount)
+						require.Equal(t, util.USD, arg.Currency)
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiresAt, time.Minute)
+						return db.TransferApproval{ID: 1, Initiator: arg.Initiator, Amount: arg.Amount, Status: db.TransferApprovalPending}, nil
+					})
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				var approval db.TransferApproval
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &approval))
+				require.Equal(t, db.TransferApprovalPending, approval.Status)
+			},
+		},
+		{
+			name:   "AtThreshold",
+			amount: 100,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferApproval(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.approvalThresholds = map[string]int64{util.USD: 100}
+			server.config.TransferApprovalTTL = time.Hour
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          tc.amount,
+				"currency":        util.USD,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, db.UserRoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestApproveTransferAPI(t *testing.T) {
+	initiator, _ := randomUser(t)
+	approver, _ := randomUser(t)
+	approver.Role = db.UserRoleApprover
+	approval := randomTransferApproval(initiator.Username)
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: approver,
+			buildStubs: func(store *mockdb.MockStore) {
+				approved := approval
+				approved.Status = db.TransferApprovalApproved
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Eq(db.ApproveTransferTxParams{
+					ID:       approval.ID,
+					Reviewer: approver.Username,
+				})).Times(1).Return(db.ApproveTransferTxResult{Approval: approved}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var result db.ApproveTransferTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+				require.Equal(t, db.TransferApprovalApproved, result.Approval.Status)
+			},
+		},
+		{
+			name: "NotApprover",
+			user: initiator,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeForbidden)
+			},
+		},
+		{
+			name: "AlreadyReviewed",
+			user: approver,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ApproveTransferTxResult{}, apperror.Conflict("transfer approval was already rejected"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeConflict)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			user: approver,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ApproveTransferTxResult{}, apperror.InsufficientFunds("account [1] has insufficient funds"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeInsufficientFunds)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/v1/transfer-approvals/%d/approve", approval.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user.Username, tc.user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRejectTransferAPI(t *testing.T) {
+	initiator, _ := randomUser(t)
+	approver, _ := randomUser(t)
+	approver.Role = db.UserRoleApprover
+	approval := randomTransferApproval(initiator.Username)
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: approver,
+			body: gin.H{"reason": "beneficiary not verified"},
+			buildStubs: func(store *mockdb.MockStore) {
+				rejected := approval
+				rejected.Status = db.TransferApprovalRejected
+				store.EXPECT().RejectTransfer(gomock.Any(), gomock.Eq(db.RejectTransferParams{
+					ID:       approval.ID,
+					Reviewer: approver.Username,
+					Reason:   "beneficiary not verified",
+				})).Times(1).Return(rejected, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "MissingReason",
+			user: approver,
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RejectTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "NotApprover",
+			user: initiator,
+			body: gin.H{"reason": "changed my mind"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RejectTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/v1/transfer-approvals/%d/reject", approval.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user.Username, tc.user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetTransferApprovalAPI(t *testing.T) {
+	initiator, _ := randomUser(t)
+	approver, _ := randomUser(t)
+	approver.Role = db.UserRoleApprover
+	other, _ := randomUser(t)
+	approval := randomTransferApproval(initiator.Username)
+
+	testCases := []struct {
+		name   string
+		user   db.User
+		status int
+	}{
+		{
+			name:   "Initiator",
+			user:   initiator,
+			status: http.StatusOK,
+		},
+		{
+			name:   "Approver",
+			user:   approver,
+			status: http.StatusOK,
+		},
+		{
+			name:   "OtherCustomer",
+			user:   other,
+			status: http.StatusForbidden,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetTransferApproval(gomock.Any(), gomock.Eq(approval.ID)).Times(1).Return(approval, nil)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/v1/transfer-approvals/%d", approval.ID), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user.Username, tc.user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
+
+func randomTransferApproval(initiator string) db.TransferApproval {
+	return db.TransferApproval{
+		ID:            util.RandomInt(1, 1000),
+		Initiator:     initiator,
+		FromAccountID: util.RandomInt(1, 1000),
+		ToAccountID:   util.RandomInt(1, 1000),
+		Amount:        util.RandomInt(1000, 2000),
+		Currency:      util.USD,
+		Status:        db.TransferApprovalPending,
+		ExpiresAt:     time.Now().Add(time.Hour),
+		CreatedAt:     time.Now(),
+	}
+}
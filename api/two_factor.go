@@ -0,0 +1,229 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/totp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// loginChallengeTTL is how long a user has to enter the code once their password was accepted
+const loginChallengeTTL = 5 * time.Minute
+
+// secondFactorRequest is a code of the authenticator app or, when the device is lost, a recovery code. exactly
+// one of them is sent
+type secondFactorRequest struct {
+	Code         string `json:"code" binding:"omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" binding:"omitempty,max=32"`
+}
+
+// secondFactor checks exactly one factor was sent
+func (req secondFactorRequest) secondFactor() (db.SecondFactor, error) {
+	if (req.Code == "") == (req.RecoveryCode == "") {
+		return db.SecondFactor{}, invalidField("code", "required_without", "recovery_code")
+	}
+	return db.SecondFactor{Code: req.Code, RecoveryCode: req.RecoveryCode}, nil
+}
+
+type enrollTOTPResponse struct {
+	// Secret is for typing into the app, OtpauthURI is the content of the QR code to scan instead
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// enrollTOTP gives the caller a new secret for their authenticator app, two-factor authentication is on once
+// they confirm a first code. enrolling again before confirming replaces the secret
+func (server *Server) enrollTOTP(ctx *gin.Context) {
+	username := authPayload(ctx).Username
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+
+	_, err = server.store.SetTOTPSecret(ctx.Request.Context(), db.SetTOTPSecretParams{
+		Username:   username,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(db.TranslateError(err), apperror.ErrNotFound) {
+			// the caller exists, nothing was updated because two-factor authentication is already on
+			renderError(ctx, apperror.Conflict("two-factor authentication is already enabled"))
+			return
+		}
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, enrollTOTPResponse{
+		Secret:     secret,
+		OtpauthURI: totp.URI(server.config.TOTPIssuer, username, secret),
+	})
+}
+
+type confirmTOTPRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type confirmTOTPResponse struct {
+	User UserResponse `json:"user"`
+	// RecoveryCodes are shown this once, only their hashes are kept
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// confirmTOTP turns two-factor authentication on with a first code of the app and hands out recovery codes
+func (server *Server) confirmTOTP(ctx *gin.Context) {
+	var req confirmTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	codes, err := totp.GenerateRecoveryCodes()
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = totp.HashRecoveryCode(code)
+	}
+
+	user, err := server.store.ConfirmTOTPTx(ctx.Request.Context(), db.ConfirmTOTPTxParams{
+		Username:           authPayload(ctx).Username,
+		Code:               req.Code,
+		RecoveryCodeHashes: hashes,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, confirmTOTPResponse{User: newUserResponse(user), RecoveryCodes: codes})
+}
+
+// disableTOTP turns two-factor authentication off, the caller proves the second factor one last time
+func (server *Server) disableTOTP(ctx *gin.Context) {
+	var req secondFactorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	factor, err := req.secondFactor()
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+
+	user, err := server.store.DisableTOTPTx(ctx.Request.Context(), db.VerifySecondFactorTxParams{
+		Username:     authPayload(ctx).Username,
+		SecondFactor: factor,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// LoginChallengeResponse answers a correct password of a user with two-factor authentication on, the access
+// token comes from completing the challenge with a code
+type LoginChallengeResponse struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	ChallengeID       string    `json:"challenge_id"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// startLoginChallenge opens the second step of logging in
+func (server *Server) startLoginChallenge(ctx *gin.Context, user db.User) {
+	// while too many wrong codes lock the second factor no challenge is opened, each would be more guesses
+	challenge, err := server.store.StartLoginChallengeTx(ctx.Request.Context(), db.CreateLoginChallengeParams{
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(loginChallengeTTL),
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, LoginChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeID:       challenge.ID.String(),
+		ExpiresAt:         challenge.ExpiresAt,
+	})
+}
+
+type completeLoginRequest struct {
+	ChallengeID string `json:"challenge_id" binding:"required,uuid"`
+	secondFactorRequest
+}
+
+// completeLogin is the second step of logging in, a code of the app completes the challenge the password
+// opened and gets the access token
+func (server *Server) completeLogin(ctx *gin.Context) {
+	var req completeLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	factor, err := req.secondFactor()
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+
+	auditCtx := db.WithAuditor(ctx.Request.Context(), db.Auditor{IP: ctx.ClientIP()})
+	user, err := server.store.CompleteLoginChallengeTx(auditCtx, db.CompleteLoginChallengeTxParams{
+		ChallengeID:  uuid.MustParse(req.ChallengeID),
+		SecondFactor: factor,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	server.issueAccessToken(ctx, user)
+}
+
+// stepUpRequest carries the second factor of a transfer, scheduled transfer or standing order above the step-up
+// threshold of its currency
+type stepUpRequest struct {
+	// TwoFactorCode or RecoveryCode are required above the step-up threshold of the currency
+	TwoFactorCode string `json:"two_factor_code" binding:"omitempty,len=6,numeric"`
+	RecoveryCode  string `json:"recovery_code" binding:"omitempty,max=32"`
+}
+
+// stepUp asks for a second factor again before moving an amount above the step-up threshold of its currency, a
+// stolen access token alone cannot move large amounts, now or later through the scheduler. users without
+// two-factor authentication cannot move such amounts until they enable it.
+// the code is verified, and used up, in its own transaction: a transfer that fails after it, over a limit or
+// short of funds once the accounts are locked, needs a new code. callers run their own checks first so that
+// this is rare
+func (server *Server) stepUp(ctx *gin.Context, currency string, amount int64, req stepUpRequest) error {
+	threshold, ok := server.stepUpThresholds[currency]
+	if !ok || amount <= threshold {
+		return nil
+	}
+	user, err := server.store.GetUser(ctx.Request.Context(), authPayload(ctx).Username)
+	if err != nil {
+		return err
+	}
+	if !user.TotpEnabledAt.Valid {
+		return apperror.Forbidden(fmt.Sprintf("transfers above %d %s need two-factor authentication, enable it with POST /users/2fa/enroll first", threshold, currency)).
+			WithDetails(gin.H{"two_factor_enrollment_required": true})
+	}
+	if req.TwoFactorCode == "" && req.RecoveryCode == "" {
+		return apperror.Unauthenticated(fmt.Sprintf("transfers above %d %s need a two-factor code", threshold, currency)).
+			WithDetails(gin.H{"two_factor_required": true})
+	}
+	if req.TwoFactorCode != "" && req.RecoveryCode != "" {
+		return invalidField("recovery_code", "excluded_with", "two_factor_code")
+	}
+	return server.store.VerifySecondFactorTx(ctx.Request.Context(), db.VerifySecondFactorTxParams{
+		Username:     user.Username,
+		SecondFactor: db.SecondFactor{Code: req.TwoFactorCode, RecoveryCode: req.RecoveryCode},
+	})
+}
@@ -0,0 +1,385 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/totp"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestEnrollTOTPAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetTOTPSecret(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.SetTOTPSecretParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.True(t, arg.TotpSecret.Valid)
+						enrolled := user
+						enrolled.TotpSecret = arg.TotpSecret
+						return enrolled, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got enrollTOTPResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.NotEmpty(t, got.Secret)
+
+				uri, err := url.Parse(got.OtpauthURI)
+				require.NoError(t, err)
+				require.Equal(t, got.Secret, uri.Query().Get("secret"))
+				require.Equal(t, "GoBank", uri.Query().Get("issuer"))
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetTOTPSecret(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.TOTPIssuer = "GoBank"
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/users/2fa/enroll", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestConfirmTOTPAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"code": "123456"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ConfirmTOTPTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ConfirmTOTPTxParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, "123456", arg.Code)
+						require.Len(t, arg.RecoveryCodeHashes, totp.RecoveryCodeCount)
+						enabled := user
+						enabled.TotpEnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+						return enabled, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got confirmTOTPResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.True(t, got.User.TwoFactorEnabled)
+				require.Len(t, got.RecoveryCodes, totp.RecoveryCodeCount)
+			},
+		},
+		{
+			name: "InvalidCode",
+			body: gin.H{"code": "123456"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ConfirmTOTPTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.User{}, apperror.Unauthenticated("invalid two-factor code"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MalformedCode",
+			body: gin.H{"code": "12ab56"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ConfirmTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeValidation)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/users/2fa/confirm", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCompleteLoginAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	challengeID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"challenge_id": challengeID, "code": "123456"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CompleteLoginChallengeTx(gomock.Any(), gomock.Eq(db.CompleteLoginChallengeTxParams{
+					ChallengeID:  challengeID,
+					SecondFactor: db.SecondFactor{Code: "123456"},
+				})).Times(1).Return(user, nil)
+				store.EXPECT().DeleteLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got LoginUserResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.NotEmpty(t, got.AccessToken)
+				require.Equal(t, user.Username, got.User.Username)
+			},
+		},
+		{
+			name: "RecoveryCode",
+			body: gin.H{"challenge_id": challengeID, "recovery_code": "abcde-fghjk"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CompleteLoginChallengeTx(gomock.Any(), gomock.Eq(db.CompleteLoginChallengeTxParams{
+					ChallengeID:  challengeID,
+					SecondFactor: db.SecondFactor{RecoveryCode: "abcde-fghjk"},
+				})).Times(1).Return(user, nil)
+				store.EXPECT().DeleteLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCode",
+			body: gin.H{"challenge_id": challengeID, "code": "123456"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CompleteLoginChallengeTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.User{}, apperror.Unauthenticated("invalid two-factor code"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "access_token")
+			},
+		},
+		{
+			name: "BothFactors",
+			body: gin.H{"challenge_id": challengeID, "code": "123456", "recovery_code": "abcde-fghjk"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CompleteLoginChallengeTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidChallengeID",
+			body: gin.H{"challenge_id": "42", "code": "123456"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CompleteLoginChallengeTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/users/login/2fa", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCreateTransferStepUp(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account1.Balance = 1000
+	account2.Currency = util.USD
+	enrolled := user1
+	enrolled.TotpEnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"amount": 500, "two_factor_code": "123456"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(enrolled, nil)
+				store.EXPECT().VerifySecondFactorTx(gomock.Any(), gomock.Eq(db.VerifySecondFactorTxParams{
+					Username:     user1.Username,
+					SecondFactor: db.SecondFactor{Code: "123456"},
+				})).Times(1).Return(nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "CodeRequired",
+			body: gin.H{"amount": 500},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(enrolled, nil)
+				store.EXPECT().VerifySecondFactorTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeUnauthenticated)
+			},
+		},
+		{
+			name: "InvalidCode",
+			body: gin.H{"amount": 500, "two_factor_code": "123456"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(enrolled, nil)
+				store.EXPECT().VerifySecondFactorTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(apperror.Unauthenticated("invalid two-factor code"))
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotEnabled",
+			body: gin.H{"amount": 500},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(user1, nil)
+				store.EXPECT().VerifySecondFactorTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// told to enable two-factor authentication rather than asked for a code they cannot have
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				body := requireErrorCode(t, recorder, apperror.CodeForbidden)
+				require.Contains(t, body.Message, "/users/2fa/enroll")
+				require.Contains(t, string(body.Details), `"two_factor_enrollment_required":true`)
+			},
+		},
+		{
+			name: "AtThreshold",
+			body: gin.H{"amount": 100},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().VerifySecondFactorTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.stepUpThresholds = map[string]int64{util.USD: 100}
+			recorder := httptest.NewRecorder()
+
+			body := gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"currency":        util.USD,
+			}
+			for key, value := range tc.body {
+				body[key] = value
+			}
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, db.UserRoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
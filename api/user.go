@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	Password string `json:"password" binding:"required,min=6"`
+	FullName string `json:"full_name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+}
+
+// UserResponse is a user without its hashed password
+type UserResponse struct {
+	Username          string    `json:"username"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	EmailVerified     bool      `json:"email_verified"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	KYCStatus         string    `json:"kyc_status"`
+	KYCTier           int32     `json:"kyc_tier"`
+	TwoFactorEnabled  bool      `json:"two_factor_enabled"`
+}
+
+func newUserResponse(user db.User) UserResponse {
+	return UserResponse{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		EmailVerified:     user.EmailVerifiedAt.Valid,
+		PasswordChangedAt: user.PasswordChangedAt,
+		CreatedAt:         user.CreatedAt,
+		KYCStatus:         user.KycStatus,
+		KYCTier:           user.KycTier,
+		TwoFactorEnabled:  user.TotpEnabledAt.Valid,
+	}
+}
+
+func (server *Server) createUser(ctx *gin.Context) {
+	var req CreateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.Password)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+
+	arg := db.CreateUserParams{
+		Username:       req.Username,
+		HashedPassword: hashedPassword,
+		FullName:       req.FullName,
+		Email:          req.Email,
+	}
+
+	// a strong match against the sanctions list refuses the user, a weak one puts it in review
+	auditCtx := db.WithAuditor(ctx.Request.Context(), db.Auditor{IP: ctx.ClientIP()})
+	user, err := server.screener.Onboard(auditCtx, server.store, arg)
+	if err != nil {
+		// duplicate username or email come back as conflicts
+		renderError(ctx, err)
+		return
+	}
+	server.sendVerification(ctx, user)
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type LoginUserRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type LoginUserResponse struct {
+	AccessToken          string       `json:"access_token"`
+	AccessTokenExpiresAt time.Time    `json:"access_token_expires_at"`
+	User                 UserResponse `json:"user"`
+}
+
+func (server *Server) loginUser(ctx *gin.Context) {
+	var req LoginUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	// the same answers for a wrong password and a username nobody has, so usernames cannot be enumerated
+	// the IP locked out is the peer unless it is one of the TRUSTED_PROXIES, clients cannot pick it with X-Forwarded-For
+	auditCtx := db.WithAuditor(ctx.Request.Context(), db.Auditor{IP: ctx.ClientIP()})
+	user, err := server.loginGuard.Authenticate(auditCtx, req.Username, req.Password, ctx.ClientIP())
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		server.startLoginChallenge(ctx, user)
+		return
+	}
+	server.issueAccessToken(ctx, user)
+}
+
+// issueAccessToken answers a login with an access token for user, their failed logins are forgotten
+func (server *Server) issueAccessToken(ctx *gin.Context, user db.User) {
+	if err := server.loginGuard.Succeed(ctx.Request.Context(), user.Username); err != nil {
+		renderError(ctx, err)
+		return
+	}
+	accessToken, payload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, LoginUserResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: payload.ExpiredAt,
+		User:                 newUserResponse(user),
+	})
+}
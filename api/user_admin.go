@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type unlockUserRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// unlockUser lifts the lockout of a user, for someone who proved who they are another way. the admin is the
+// actor of the audit event
+func (server *Server) unlockUser(ctx *gin.Context) {
+	var req unlockUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+
+	user, err := server.store.UnlockUserTx(ctx.Request.Context(), req.Username)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestUnlockUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		role       string
+		buildStubs func(store *mockdb.MockStore)
+		status     int
+	}{
+		{
+			name: "OK",
+			role: db.UserRoleAdmin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UnlockUserTx(gomock.Any(), gomock.Eq(user.Username)).Times(1).
+					DoAndReturn(func(ctx context.Context, _ string) (db.User, error) {
+						// the admin is the actor of the audit event
+						require.Equal(t, "admin", db.AuditorFrom(ctx).Actor)
+						return user, nil
+					})
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "NotLockedOut",
+			role: db.UserRoleAdmin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UnlockUserTx(gomock.Any(), gomock.Eq(user.Username)).Times(1).
+					Return(db.User{}, apperror.Conflict("user is not locked out"))
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name: "NotFound",
+			role: db.UserRoleAdmin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UnlockUserTx(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "Teller",
+			role: db.UserRoleTeller,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UnlockUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusForbidden,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/v1/admin/users/%s/unlock", user.Username)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "admin", tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
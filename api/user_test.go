@@ -0,0 +1,568 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/mail"
+	"github.com/ShubhKanodia/GoBank/sanctions"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// eqCreateUserTxParamsMatcher matches the CreateUserTxParams of a user without screening cases,
+// whose hashed password belongs to password
+type eqCreateUserTxParamsMatcher struct {
+	arg      db.CreateUserParams
+	password string
+}
+
+func (e eqCreateUserTxParamsMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreateUserTxParams)
+	if !ok || len(arg.Cases) > 0 {
+		return false
+	}
+
+	if err := util.CheckPassword(e.password, arg.HashedPassword); err != nil {
+		return false
+	}
+
+	e.arg.HashedPassword = arg.HashedPassword
+	return e.arg == arg.CreateUserParams
+}
+
+func (e eqCreateUserTxParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
+}
+
+func EqCreateUserTxParams(arg db.CreateUserParams, password string) gomock.Matcher {
+	return eqCreateUserTxParamsMatcher{arg, password}
+}
+
+func TestCreateUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateUserParams{
+					Username: user.Username,
+					FullName: user.FullName,
+					Email:    user.Email,
+				}
+				store.EXPECT().CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, password)).Times(1).Return(user, nil)
+				store.EXPECT().CreateEmailToken(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateEmailTokenParams) (db.EmailToken, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, db.EmailTokenVerifyEmail, arg.Purpose)
+						require.Equal(t, user.Email, arg.Email)
+						return db.EmailToken{ID: 1}, nil
+					})
+				// the email is sent by the job worker
+				store.EXPECT().CreateJob(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateJobParams) (db.Job, error) {
+						require.Equal(t, mail.SendJob.Name, arg.Kind)
+						require.Contains(t, string(arg.Payload), user.Email)
+						return db.Job{ID: 1}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "hashed_password")
+
+				var got UserResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, newUserResponse(user), got)
+			},
+		},
+		{
+			name: "VerificationEmailFails",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().CreateEmailToken(gomock.Any(), gomock.Any()).Times(1).Return(db.EmailToken{}, sql.ErrConnDone)
+				store.EXPECT().CreateJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// the user exists, they can ask for the email again
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "DuplicateUsername",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.User{}, &pq.Error{Code: "23505", Constraint: "users_pkey"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeConflict)
+			},
+		},
+		{
+			name: "InvalidUsername",
+			body: gin.H{
+				"username":  "invalid-user#1",
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "TooShortPassword",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  "123",
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/users", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCreateUserSanctionsAPI(t *testing.T) {
+	testCases := []struct {
+		name          string
+		fullName      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "StrongMatch",
+			fullName: "Ivan Sergeyevich Petrov",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateScreeningCase(gomock.Any(), gomock.Any()).Times(1).Return(db.ScreeningCase{ID: 1}, nil)
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "PETROV")
+			},
+		},
+		{
+			name:     "WeakMatch",
+			fullName: "Ivan Petrov",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, arg db.CreateUserTxParams) (db.User, error) {
+						require.Len(t, arg.Cases, 1)
+						return db.User{Username: arg.Username, FullName: arg.FullName, SanctionsStatus: db.SanctionsReview}, nil
+					})
+				store.EXPECT().CreateEmailToken(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CreateJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// the user is created, nothing in the response says it is in review
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), db.SanctionsReview)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			list := sanctions.NewList([]sanctions.Entry{{ID: "6365", Name: "PETROV, Ivan Sergeyevich", Program: "UKRAINE-EO13660"}})
+			server.screener = sanctions.NewScreener(list, 0.97, 0.88)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"username":  util.RandomOwner(),
+				"password":  util.RandomString(6),
+				"full_name": tc.fullName,
+				"email":     util.RandomEmail(),
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/users", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestLoginUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		forwardedFor  string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				// the failed logins of the user are forgotten
+				store.EXPECT().DeleteLoginFailures(gomock.Any(), gomock.Eq(db.DeleteLoginFailuresParams{
+					Scope:   db.LoginScopeUsername,
+					Subject: user.Username,
+				})).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got LoginUserResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.NotEmpty(t, got.AccessToken)
+				require.Equal(t, user.Username, got.User.Username)
+			},
+		},
+		{
+			name: "TwoFactorEnabled",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				enrolled := user
+				enrolled.TotpSecret = sql.NullString{String: "JBSWY3DPEHPK3PXP", Valid: true}
+				enrolled.TotpEnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(enrolled, nil)
+				// the failures are only forgotten once the second factor is in
+				store.EXPECT().DeleteLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().StartLoginChallengeTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateLoginChallengeParams) (db.LoginChallenge, error) {
+						require.Equal(t, user.Username, arg.Username)
+						return db.LoginChallenge{ID: uuid.New(), Username: arg.Username, ExpiresAt: arg.ExpiresAt}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// no token until the challenge is completed
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				var got LoginChallengeResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.True(t, got.TwoFactorRequired)
+				require.NotEmpty(t, got.ChallengeID)
+				require.NotContains(t, recorder.Body.String(), "access_token")
+			},
+		},
+		{
+			name: "SecondFactorLocked",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				enrolled := user
+				enrolled.TotpSecret = sql.NullString{String: "JBSWY3DPEHPK3PXP", Valid: true}
+				enrolled.TotpEnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(enrolled, nil)
+				store.EXPECT().StartLoginChallengeTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.LoginChallenge{}, apperror.TooManyRequests("too many wrong two-factor codes, try again later", time.Minute))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "challenge_id")
+			},
+		},
+		{
+			name: "UserNotFound",
+			body: gin.H{
+				"username": "notfound",
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				// counted as any other username
+				store.EXPECT().RecordLoginFailureTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RecordLoginFailureTxParams) (db.RecordLoginFailureTxResult, error) {
+						require.Equal(t, "notfound", arg.Username)
+						require.NotEmpty(t, arg.IP)
+						return db.RecordLoginFailureTxResult{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				body := requireErrorCode(t, recorder, apperror.CodeUnauthenticated)
+				require.Equal(t, "invalid username or password", body.Message)
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			body: gin.H{
+				"username": user.Username,
+				"password": "incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().RecordLoginFailureTx(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CreateJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				body := requireErrorCode(t, recorder, apperror.CodeUnauthenticated)
+				require.Equal(t, "invalid username or password", body.Message)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "LockingFailure",
+			body: gin.H{
+				"username": user.Username,
+				"password": "incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().RecordLoginFailureTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.RecordLoginFailureTxResult{
+						Username: db.LoginFailure{
+							Scope:       db.LoginScopeUsername,
+							Subject:     user.Username,
+							Failures:    5,
+							LockedUntil: sql.NullTime{Time: time.Now().Add(15 * time.Minute), Valid: true},
+						},
+						Locked: true,
+						User:   user,
+					}, nil)
+				// the user is mailed about the lockout
+				store.EXPECT().CreateJob(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateJobParams) (db.Job, error) {
+						require.Equal(t, mail.SendJob.Name, arg.Kind)
+						require.Contains(t, string(arg.Payload), user.Email)
+						return db.Job{ID: 1}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "LockedOut",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Eq(db.ListLoginFailuresParams{
+					Username: user.Username,
+					Ip:       "192.0.2.1",
+				})).Times(1).Return([]db.LoginFailure{{
+					Scope:        db.LoginScopeUsername,
+					Subject:      user.Username,
+					Failures:     5,
+					LastFailedAt: time.Now(),
+					LockedUntil:  sql.NullTime{Time: time.Now().Add(10 * time.Minute), Valid: true},
+				}}, nil)
+				// not even the right password gets in
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeTooManyRequests)
+				require.Equal(t, "600", recorder.Header().Get("Retry-After"))
+			},
+		},
+		{
+			// the lockout of an IP goes by the peer, a forwarding header from an untrusted peer does not get around it
+			name: "IPLockedOutSpoofedForwardedFor",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			forwardedFor: "203.0.113.9",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Eq(db.ListLoginFailuresParams{
+					Username: user.Username,
+					Ip:       "192.0.2.1",
+				})).Times(1).Return([]db.LoginFailure{{
+					Scope:        db.LoginScopeIP,
+					Subject:      "192.0.2.1",
+					Failures:     20,
+					LastFailedAt: time.Now(),
+					LockedUntil:  sql.NullTime{Time: time.Now().Add(10 * time.Minute), Valid: true},
+				}}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeTooManyRequests)
+			},
+		},
+		{
+			name: "WaitingAfterFailure",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// three failures wait four seconds
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).Times(1).Return([]db.LoginFailure{{
+					Scope:        db.LoginScopeUsername,
+					Subject:      user.Username,
+					Failures:     3,
+					LastFailedAt: time.Now(),
+				}}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "4", recorder.Header().Get("Retry-After"))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.RemoteAddr = "192.0.2.1:1234"
+			if tc.forwardedFor != "" {
+				request.Header.Set("X-Forwarded-For", tc.forwardedFor)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomUser(t *testing.T) (user db.User, password string) {
+	password = util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user = db.User{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+		Role:           db.UserRoleCustomer,
+	}
+	return
+}
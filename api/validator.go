@@ -1,6 +1,11 @@
 package api
 
 import (
+	"slices"
+	"time"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/rbac"
 	"github.com/ShubhKanodia/GoBank/util"
 	"github.com/go-playground/validator/v10"
 )
@@ -14,3 +19,27 @@ func validateCurrency(field validator.FieldLevel) bool {
 	}
 	return false
 }
+
+// validateWebhookEvent accepts the event types a webhook endpoint can subscribe to
+func validateWebhookEvent(field validator.FieldLevel) bool {
+	if eventType, ok := field.Field().Interface().(string); ok {
+		return slices.Contains(db.WebhookEventTypes(), eventType)
+	}
+	return false
+}
+
+// validateAPIKeyScope accepts the scopes an API key can be issued with
+func validateAPIKeyScope(field validator.FieldLevel) bool {
+	if scope, ok := field.Field().Interface().(string); ok {
+		return rbac.IsScope(scope)
+	}
+	return false
+}
+
+// validateFuture accepts times strictly after now
+func validateFuture(field validator.FieldLevel) bool {
+	if t, ok := field.Field().Interface().(time.Time); ok {
+		return t.After(time.Now())
+	}
+	return false
+}
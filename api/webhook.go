@@ -0,0 +1,229 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+type createWebhookEndpointRequest struct {
+	URL        string   `json:"url" binding:"required,url,startswith=http"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,webhook_event"`
+	// Secret signs the deliveries, one is generated when it is left out
+	Secret string `json:"secret" binding:"omitempty,min=16,max=128"`
+}
+
+// webhookEndpointResponse never includes the secret, it is only returned once on creation
+type webhookEndpointResponse struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type createWebhookEndpointResponse struct {
+	webhookEndpointResponse
+	Secret string `json:"secret"`
+}
+
+func newWebhookEndpointResponse(endpoint db.WebhookEndpoint) webhookEndpointResponse {
+	return webhookEndpointResponse{
+		ID:         endpoint.ID,
+		URL:        endpoint.Url,
+		EventTypes: endpoint.EventTypes,
+		CreatedAt:  endpoint.CreatedAt,
+	}
+}
+
+type webhookEndpointRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type pageQuery struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=50"`
+}
+
+// listWebhookDeliveriesRequest is bound in two steps, gin validates the whole struct on each binding
+type listWebhookDeliveriesRequest struct {
+	webhookEndpointRequest
+	pageQuery
+}
+
+type redeliverWebhookRequest struct {
+	ID         int64 `uri:"id" binding:"required,min=1"`
+	DeliveryID int64 `uri:"delivery_id" binding:"required,min=1"`
+}
+
+// webhookDeliveryResponse is one entry of the delivery log
+type webhookDeliveryResponse struct {
+	ID                 int64           `json:"id"`
+	EventType          string          `json:"event_type"`
+	Status             string          `json:"status"`
+	Attempts           int32           `json:"attempts"`
+	NextAttemptAt      time.Time       `json:"next_attempt_at"`
+	LastResponseStatus sql.NullInt32   `json:"last_response_status"`
+	LastError          sql.NullString  `json:"last_error"`
+	DeliveredAt        sql.NullTime    `json:"delivered_at"`
+	CreatedAt          time.Time       `json:"created_at"`
+	Payload            json.RawMessage `json:"payload"`
+}
+
+func newWebhookDeliveryResponse(delivery db.WebhookDelivery) webhookDeliveryResponse {
+	return webhookDeliveryResponse{
+		ID:                 delivery.ID,
+		EventType:          delivery.EventType,
+		Status:             delivery.Status,
+		Attempts:           delivery.Attempts,
+		NextAttemptAt:      delivery.NextAttemptAt,
+		LastResponseStatus: delivery.LastResponseStatus,
+		LastError:          delivery.LastError,
+		DeliveredAt:        delivery.DeliveredAt,
+		CreatedAt:          delivery.CreatedAt,
+		Payload:            delivery.Payload,
+	}
+}
+
+func (server *Server) createWebhookEndpoint(ctx *gin.Context) {
+	var req createWebhookEndpointRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if err := webhook.CheckURL(req.URL, server.config.WebhookAllowInsecure); err != nil {
+		renderError(ctx, apperror.Validation(err.Error()))
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = webhook.NewSecret(); err != nil {
+			renderError(ctx, err)
+			return
+		}
+	}
+
+	endpoint, err := server.store.CreateWebhookEndpoint(ctx.Request.Context(), db.CreateWebhookEndpointParams{
+		Owner:      authPayload(ctx).Username,
+		Url:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, createWebhookEndpointResponse{
+		webhookEndpointResponse: newWebhookEndpointResponse(endpoint),
+		Secret:                  endpoint.Secret,
+	})
+}
+
+func (server *Server) listWebhookEndpoints(ctx *gin.Context) {
+	endpoints, err := server.store.ListWebhookEndpoints(ctx.Request.Context(), authPayload(ctx).Username)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	rsp := make([]webhookEndpointResponse, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		rsp = append(rsp, newWebhookEndpointResponse(endpoint))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) deleteWebhookEndpoint(ctx *gin.Context) {
+	var req webhookEndpointRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if _, ok := server.ownWebhookEndpoint(ctx, req.ID); !ok {
+		return
+	}
+	if err := server.store.DeleteWebhookEndpoint(ctx.Request.Context(), req.ID); err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (server *Server) listWebhookDeliveries(ctx *gin.Context) {
+	var req listWebhookDeliveriesRequest
+	if err := ctx.ShouldBindUri(&req.webhookEndpointRequest); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req.pageQuery); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if _, ok := server.ownWebhookEndpoint(ctx, req.ID); !ok {
+		return
+	}
+
+	deliveries, err := server.store.ListWebhookDeliveries(ctx.Request.Context(), db.ListWebhookDeliveriesParams{
+		EndpointID: req.ID,
+		Limit:      req.PageSize,
+		Offset:     (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	rsp := make([]webhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		rsp = append(rsp, newWebhookDeliveryResponse(delivery))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// redeliverWebhook queues a delivery again with a fresh set of retries, dead deliveries included
+func (server *Server) redeliverWebhook(ctx *gin.Context) {
+	var req redeliverWebhookRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		renderError(ctx, bindingError(err))
+		return
+	}
+	if _, ok := server.ownWebhookEndpoint(ctx, req.ID); !ok {
+		return
+	}
+
+	delivery, err := server.store.GetWebhookDelivery(ctx.Request.Context(), req.DeliveryID)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	if delivery.EndpointID != req.ID {
+		renderError(ctx, apperror.NotFound("delivery not found"))
+		return
+	}
+
+	delivery, err = server.store.RedeliverWebhookDelivery(ctx.Request.Context(), delivery.ID)
+	if err != nil {
+		renderError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, newWebhookDeliveryResponse(delivery))
+}
+
+// ownWebhookEndpoint loads the endpoint and checks it belongs to the caller, it renders the error itself when not
+func (server *Server) ownWebhookEndpoint(ctx *gin.Context, id int64) (db.WebhookEndpoint, bool) {
+	endpoint, err := server.store.GetWebhookEndpoint(ctx.Request.Context(), id)
+	if err != nil {
+		renderError(ctx, err)
+		return endpoint, false
+	}
+	if endpoint.Owner != authPayload(ctx).Username {
+		renderError(ctx, apperror.Forbidden("webhook endpoint doesn't belong to the authenticated user"))
+		return endpoint, false
+	}
+	return endpoint, true
+}
@@ -0,0 +1,419 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateWebhookEndpointAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	endpoint := randomWebhookEndpoint(user.Username)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"url":         endpoint.Url,
+				"event_types": endpoint.EventTypes,
+				"secret":      endpoint.Secret,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateWebhookEndpointParams{
+					Owner:      user.Username,
+					Url:        endpoint.Url,
+					Secret:     endpoint.Secret,
+					EventTypes: endpoint.EventTypes,
+				}
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Eq(arg)).Times(1).Return(endpoint, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var rsp createWebhookEndpointResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, endpoint.ID, rsp.ID)
+				require.Equal(t, endpoint.Secret, rsp.Secret)
+			},
+		},
+		{
+			name: "GeneratedSecret",
+			body: gin.H{
+				"url":         endpoint.Url,
+				"event_types": endpoint.EventTypes,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, arg db.CreateWebhookEndpointParams) (db.WebhookEndpoint, error) {
+						require.True(t, strings.HasPrefix(arg.Secret, "whsec_"))
+						return endpoint, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownEventType",
+			body: gin.H{
+				"url":         endpoint.Url,
+				"event_types": []string{db.WebhookEventTransferSent, "account.deleted"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "NoEventTypes",
+			body: gin.H{
+				"url":         endpoint.Url,
+				"event_types": []string{},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidURL",
+			body: gin.H{
+				"url":         "ftp://example.com/hook",
+				"event_types": endpoint.EventTypes,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "PlainHTTP",
+			body: gin.H{
+				"url":         "http://example.com/hook",
+				"event_types": endpoint.EventTypes,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "PrivateTarget",
+			body: gin.H{
+				"url":         "https://169.254.169.254/latest/meta-data",
+				"event_types": endpoint.EventTypes,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: gin.H{
+				"url":         endpoint.Url,
+				"event_types": endpoint.EventTypes,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/v1/webhooks", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListWebhookEndpointsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	endpoints := []db.WebhookEndpoint{randomWebhookEndpoint(user.Username), randomWebhookEndpoint(user.Username)}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListWebhookEndpoints(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(endpoints, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/v1/webhooks", nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	// secrets are only shown once, on creation
+	require.NotContains(t, recorder.Body.String(), endpoints[0].Secret)
+	var rsp []webhookEndpointResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp, 2)
+	require.Equal(t, endpoints[1].ID, rsp[1].ID)
+}
+
+func TestDeleteWebhookEndpointAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	endpoint := randomWebhookEndpoint(user.Username)
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+				store.EXPECT().DeleteWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: "unauthorized_user",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+				store.EXPECT().DeleteWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeForbidden)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(db.WebhookEndpoint{}, sql.ErrNoRows)
+				store.EXPECT().DeleteWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeNotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/webhooks/%d", endpoint.ID), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, db.UserRoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListWebhookDeliveriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	endpoint := randomWebhookEndpoint(user.Username)
+	deliveries := []db.WebhookDelivery{randomWebhookDelivery(endpoint.ID), randomWebhookDelivery(endpoint.ID)}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+	store.EXPECT().ListWebhookDeliveries(gomock.Any(), gomock.Eq(db.ListWebhookDeliveriesParams{
+		EndpointID: endpoint.ID,
+		Limit:      5,
+		Offset:     5,
+	})).Times(1).Return(deliveries, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	url := fmt.Sprintf("/v1/webhooks/%d/deliveries?page_id=2&page_size=5", endpoint.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var rsp []webhookDeliveryResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp, 2)
+	require.Equal(t, deliveries[0].ID, rsp[0].ID)
+	require.JSONEq(t, string(deliveries[0].Payload), string(rsp[0].Payload))
+}
+
+func TestRedeliverWebhookAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	endpoint := randomWebhookEndpoint(user.Username)
+	delivery := randomWebhookDelivery(endpoint.ID)
+	delivery.Status = db.WebhookDeliveryDead
+	otherDelivery := randomWebhookDelivery(endpoint.ID + 1)
+
+	testCases := []struct {
+		name          string
+		deliveryID    int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "OK",
+			deliveryID: delivery.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				queued := delivery
+				queued.Status = db.WebhookDeliveryPending
+				queued.Attempts = 0
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).Times(1).Return(delivery, nil)
+				store.EXPECT().RedeliverWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).Times(1).Return(queued, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				var rsp webhookDeliveryResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.WebhookDeliveryPending, rsp.Status)
+			},
+		},
+		{
+			name:       "OtherEndpoint",
+			deliveryID: otherDelivery.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(otherDelivery.ID)).Times(1).Return(otherDelivery, nil)
+				store.EXPECT().RedeliverWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorCode(t, recorder, apperror.CodeNotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/v1/webhooks/%d/deliveries/%d/redeliver", endpoint.ID, tc.deliveryID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, db.UserRoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomWebhookEndpoint(owner string) db.WebhookEndpoint {
+	return db.WebhookEndpoint{
+		ID:         util.RandomInt(1, 1000),
+		Owner:      owner,
+		Url:        "https://example.com/hooks/" + util.RandomString(6),
+		Secret:     "whsec_" + util.RandomString(32),
+		EventTypes: []string{db.WebhookEventTransferReceived},
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func randomWebhookDelivery(endpointID int64) db.WebhookDelivery {
+	return db.WebhookDelivery{
+		ID:         util.RandomInt(1, 1000),
+		EndpointID: endpointID,
+		EventType:  db.WebhookEventTransferReceived,
+		Payload:    json.RawMessage(`{"account_id":42}`),
+		Status:     db.WebhookDeliveryPending,
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+}
@@ -0,0 +1,160 @@
+// Package apikey issues the API keys services call the APIs with instead of logging in, and authenticates the
+// requests carrying one. a key reads gbk_<prefix>_<secret>: the prefix finds the key in the store and only a
+// SHA-256 of the secret is kept there, so the full key is shown once when it is issued. a request with a key
+// acts as the user who issued it, with the scopes of the key narrowing the permissions of their role
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/token"
+)
+
+// keyTag starts every key, it tells a key from other secrets in logs and secret scanners
+const keyTag = "gbk"
+
+// prefixSize is the length of the prefix of a key in random bytes
+const prefixSize = 8
+
+// touchInterval is how often the last use of a busy key is recorded
+const touchInterval = time.Minute
+
+// NewKey is a freshly issued key. Key goes to the user, Prefix and SecretHash to the store
+type NewKey struct {
+	Key        string
+	Prefix     string
+	SecretHash string
+}
+
+// Generate returns a new random key
+func Generate() (NewKey, error) {
+	raw := make([]byte, prefixSize)
+	if _, err := rand.Read(raw); err != nil {
+		return NewKey{}, err
+	}
+	prefix := hex.EncodeToString(raw)
+	secret, err := token.NewOpaque()
+	if err != nil {
+		return NewKey{}, err
+	}
+	return NewKey{
+		Key:        keyTag + "_" + prefix + "_" + secret,
+		Prefix:     prefix,
+		SecretHash: token.HashOpaque(secret),
+	}, nil
+}
+
+// parse splits a key into its prefix and secret, the secret may hold underscores of its own
+func parse(key string) (prefix string, secret string, ok bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != keyTag || len(parts[1]) != 2*prefixSize || parts[2] == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// ParseAllowedIPs checks the addresses a key is accepted from, single IPs or CIDRs, and returns them as CIDRs
+func ParseAllowedIPs(values []string) ([]string, error) {
+	allowed := make([]string, 0, len(values))
+	for _, value := range values {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(value)
+			if addrErr != nil {
+				return nil, fmt.Errorf("%q is not an IP address or a CIDR", value)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		allowed = append(allowed, prefix.Masked().String())
+	}
+	return allowed, nil
+}
+
+// allowedFrom reports whether ip is within one of the CIDRs of allowed, any ip is when allowed is empty
+func allowedFrom(allowed []string, ip string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	for _, value := range allowed {
+		prefix, err := netip.ParsePrefix(value)
+		if err == nil && prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// errInvalidKey is the answer to a key nobody has and to a wrong secret alike
+var errInvalidKey = apperror.Unauthenticated("invalid API key")
+
+// Authenticator checks the keys of requests against the store
+type Authenticator struct {
+	store db.Store
+	// now is time.Now, tests move it by hand
+	now func() time.Time
+}
+
+func NewAuthenticator(store db.Store) *Authenticator {
+	return &Authenticator{store: store, now: time.Now}
+}
+
+// Authenticate returns the payload a request with key from ip acts with. a key that is unknown, revoked or
+// expired returns apperror.ErrUnauthenticated, one not allowed from ip apperror.ErrForbidden
+func (authenticator *Authenticator) Authenticate(ctx context.Context, key string, ip string) (*token.Payload, error) {
+	prefix, secret, ok := parse(key)
+	if !ok {
+		return nil, errInvalidKey
+	}
+	caller, err := authenticator.store.GetAPIKeyCaller(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil, errInvalidKey
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(token.HashOpaque(secret)), []byte(caller.SecretHash)) != 1 {
+		return nil, errInvalidKey
+	}
+
+	now := authenticator.now()
+	if caller.RevokedAt.Valid || (caller.ExpiresAt.Valid && !caller.ExpiresAt.Time.After(now)) {
+		return nil, apperror.Unauthenticated("API key is revoked or expired")
+	}
+	if !allowedFrom(caller.AllowedIps, ip) {
+		return nil, apperror.Forbidden("API key is not allowed from this address")
+	}
+
+	// a lost record of use is not worth failing the request over
+	err = authenticator.store.TouchAPIKey(ctx, db.TouchAPIKeyParams{
+		ID:         caller.ID,
+		Ip:         ip,
+		UsedBefore: now.Add(-touchInterval),
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "recording the use of an API key failed", slog.Int64("api_key_id", caller.ID), slog.String("error", err.Error()))
+	}
+
+	return &token.Payload{
+		Username:  caller.Username,
+		Role:      caller.Role,
+		IssuedAt:  caller.CreatedAt,
+		ExpiredAt: caller.ExpiresAt.Time,
+		APIKeyID:  caller.ID,
+		Scopes:    caller.Scopes,
+	}, nil
+}
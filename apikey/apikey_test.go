@@ -0,0 +1,183 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGenerate(t *testing.T) {
+	key, err := Generate()
+	require.NoError(t, err)
+
+	prefix, secret, ok := parse(key.Key)
+	require.True(t, ok)
+	require.Equal(t, key.Prefix, prefix)
+	require.NotEqual(t, key.SecretHash, secret)
+
+	other, err := Generate()
+	require.NoError(t, err)
+	require.NotEqual(t, key.Prefix, other.Prefix)
+
+	for _, s := range []string{"", "gbk", "gbk_" + key.Prefix, "gbk_" + key.Prefix + "_", "abc_" + key.Prefix + "_x", "gbk_short_x"} {
+		_, _, ok := parse(s)
+		require.False(t, ok, s)
+	}
+}
+
+func TestParseAllowedIPs(t *testing.T) {
+	allowed, err := ParseAllowedIPs([]string{"192.0.2.7", "198.51.100.9/24", "2001:db8::1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"192.0.2.7/32", "198.51.100.0/24", "2001:db8::1/128"}, allowed)
+
+	_, err = ParseAllowedIPs([]string{"example.com"})
+	require.Error(t, err)
+
+	require.True(t, allowedFrom(allowed, "198.51.100.200"))
+	require.True(t, allowedFrom(allowed, "::ffff:192.0.2.7"))
+	require.False(t, allowedFrom(allowed, "192.0.2.8"))
+	require.False(t, allowedFrom(allowed, ""))
+	require.True(t, allowedFrom(nil, "203.0.113.1"))
+}
+
+func TestAuthenticate(t *testing.T) {
+	key, err := Generate()
+	require.NoError(t, err)
+	now := time.Now()
+
+	testCases := []struct {
+		name   string
+		key    string
+		caller func(caller *db.GetAPIKeyCallerRow)
+		// callerErr is what the store answers the lookup with
+		callerErr error
+		check     func(t *testing.T, err error)
+	}{
+		{
+			name:   "OK",
+			key:    key.Key,
+			caller: func(caller *db.GetAPIKeyCallerRow) {},
+			check: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Malformed",
+			key:  "gbk_" + key.Prefix,
+			check: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, apperror.ErrUnauthenticated)
+			},
+		},
+		{
+			name:      "UnknownPrefix",
+			key:       key.Key,
+			callerErr: apperror.NotFound("record not found"),
+			check: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, apperror.ErrUnauthenticated)
+			},
+		},
+		{
+			name:   "WrongSecret",
+			key:    key.Key + "x",
+			caller: func(caller *db.GetAPIKeyCallerRow) {},
+			check: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, apperror.ErrUnauthenticated)
+			},
+		},
+		{
+			name: "Revoked",
+			key:  key.Key,
+			caller: func(caller *db.GetAPIKeyCallerRow) {
+				caller.RevokedAt = sql.NullTime{Time: now.Add(-time.Hour), Valid: true}
+			},
+			check: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, apperror.ErrUnauthenticated)
+			},
+		},
+		{
+			name: "Expired",
+			key:  key.Key,
+			caller: func(caller *db.GetAPIKeyCallerRow) {
+				caller.ExpiresAt = sql.NullTime{Time: now, Valid: true}
+			},
+			check: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, apperror.ErrUnauthenticated)
+			},
+		},
+		{
+			name: "AddressNotAllowed",
+			key:  key.Key,
+			caller: func(caller *db.GetAPIKeyCallerRow) {
+				caller.AllowedIps = []string{"198.51.100.0/24"}
+			},
+			check: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, apperror.ErrForbidden)
+			},
+		},
+		{
+			name:      "StoreError",
+			key:       key.Key,
+			callerErr: sql.ErrConnDone,
+			check: func(t *testing.T, err error) {
+				require.True(t, errors.Is(err, sql.ErrConnDone))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			caller := db.GetAPIKeyCallerRow{
+				ID:         7,
+				Username:   "payroll",
+				Prefix:     key.Prefix,
+				SecretHash: key.SecretHash,
+				Scopes:     []string{"transfers:write"},
+				AllowedIps: []string{"192.0.2.0/24"},
+				Role:       db.UserRoleCustomer,
+			}
+			switch {
+			case tc.caller != nil:
+				tc.caller(&caller)
+				store.EXPECT().GetAPIKeyCaller(gomock.Any(), key.Prefix).Times(1).Return(caller, nil)
+			case tc.callerErr != nil:
+				store.EXPECT().GetAPIKeyCaller(gomock.Any(), key.Prefix).Times(1).Return(db.GetAPIKeyCallerRow{}, tc.callerErr)
+			default:
+				store.EXPECT().GetAPIKeyCaller(gomock.Any(), gomock.Any()).Times(0)
+			}
+			authenticator := NewAuthenticator(store)
+			authenticator.now = func() time.Time { return now }
+
+			if tc.name == "OK" {
+				store.EXPECT().TouchAPIKey(gomock.Any(), db.TouchAPIKeyParams{
+					ID:         caller.ID,
+					Ip:         "192.0.2.10",
+					UsedBefore: now.Add(-touchInterval),
+				}).Times(1).Return(nil)
+			} else {
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			payload, err := authenticator.Authenticate(context.Background(), tc.key, "192.0.2.10")
+			tc.check(t, err)
+			if err == nil {
+				require.Equal(t, "payroll", payload.Username)
+				require.Equal(t, db.UserRoleCustomer, payload.Role)
+				require.Equal(t, caller.ID, payload.APIKeyID)
+				require.Equal(t, caller.Scopes, payload.Scopes)
+			}
+		})
+	}
+}
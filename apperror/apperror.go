@@ -0,0 +1,139 @@
+// Package apperror defines the domain errors shared by the store and the API.
+// the store translates database errors into these, the API renders them with a stable code
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Code is the stable, machine readable identifier clients can switch on
+type Code string
+
+const (
+	CodeNotFound          Code = "not_found"
+	CodeConflict          Code = "conflict"
+	CodeInsufficientFunds Code = "insufficient_funds"
+	CodeCurrencyMismatch  Code = "currency_mismatch"
+	CodeLimitExceeded     Code = "limit_exceeded"
+	CodeTransferBlocked   Code = "transfer_blocked"
+	CodeForbidden         Code = "forbidden"
+	CodeUnauthenticated   Code = "unauthenticated"
+	CodeValidation        Code = "validation_failed"
+	CodeTooManyRequests   Code = "too_many_requests"
+	CodeInternal          Code = "internal"
+)
+
+// Error is a domain error. Message is safe to show to clients, Err keeps the underlying cause for logs
+type Error struct {
+	Code    Code
+	Message string
+	Details any
+	// RetryAfter is how long the client should wait before trying again, set on CodeTooManyRequests errors
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, apperror.ErrNotFound) match any error of the same code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Message == "" && t.Code == e.Code
+}
+
+// sentinels for errors.Is, they only carry a code
+var (
+	ErrNotFound          = &Error{Code: CodeNotFound}
+	ErrConflict          = &Error{Code: CodeConflict}
+	ErrInsufficientFunds = &Error{Code: CodeInsufficientFunds}
+	ErrCurrencyMismatch  = &Error{Code: CodeCurrencyMismatch}
+	ErrLimitExceeded     = &Error{Code: CodeLimitExceeded}
+	ErrTransferBlocked   = &Error{Code: CodeTransferBlocked}
+	ErrForbidden         = &Error{Code: CodeForbidden}
+	ErrUnauthenticated   = &Error{Code: CodeUnauthenticated}
+	ErrValidation        = &Error{Code: CodeValidation}
+	ErrTooManyRequests   = &Error{Code: CodeTooManyRequests}
+)
+
+// New returns a domain error with a client facing message
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap returns a domain error that keeps err as its cause
+func Wrap(code Code, err error, message string) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// WithDetails returns a copy of e carrying structured details for the client
+func (e *Error) WithDetails(details any) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+func InsufficientFunds(message string) *Error {
+	return New(CodeInsufficientFunds, message)
+}
+
+func CurrencyMismatch(message string) *Error {
+	return New(CodeCurrencyMismatch, message)
+}
+
+func LimitExceeded(message string) *Error {
+	return New(CodeLimitExceeded, message)
+}
+
+func TransferBlocked(message string) *Error {
+	return New(CodeTransferBlocked, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+func Unauthenticated(message string) *Error {
+	return New(CodeUnauthenticated, message)
+}
+
+func Validation(message string) *Error {
+	return New(CodeValidation, message)
+}
+
+// TooManyRequests is an error telling the client to slow down and try again after retryAfter
+func TooManyRequests(message string, retryAfter time.Duration) *Error {
+	return &Error{Code: CodeTooManyRequests, Message: message, RetryAfter: retryAfter}
+}
+
+// As returns err as a domain error, anything else is reported as an internal error wrapping it
+func As(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Wrap(CodeInternal, err, "internal server error")
+}
+
+// CodeOf returns the code of err, CodeInternal for errors that are not domain errors
+func CodeOf(err error) Code {
+	return As(err).Code
+}
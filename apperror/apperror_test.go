@@ -0,0 +1,31 @@
+package apperror
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorsIs(t *testing.T) {
+	err := fmt.Errorf("get account: %w", Wrap(CodeNotFound, sql.ErrNoRows, "account not found"))
+
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.False(t, errors.Is(err, ErrConflict))
+	require.Equal(t, CodeNotFound, CodeOf(err))
+}
+
+func TestAs(t *testing.T) {
+	appErr := As(sql.ErrConnDone)
+	require.Equal(t, CodeInternal, appErr.Code)
+	require.Equal(t, "internal server error", appErr.Message)
+	require.ErrorIs(t, appErr, sql.ErrConnDone)
+
+	details := map[string]int64{"account_id": 1}
+	appErr = As(InsufficientFunds("balance too low").WithDetails(details))
+	require.Equal(t, CodeInsufficientFunds, appErr.Code)
+	require.Equal(t, details, appErr.Details)
+}
@@ -0,0 +1,58 @@
+// Command rescreen screens every user against the sanctions list again, run it whenever the list changes.
+// it reads the same app.env as the server, run it from the repository root: go run ./cmd/rescreen
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/logging"
+	"github.com/ShubhKanodia/GoBank/sanctions"
+	"github.com/ShubhKanodia/GoBank/util"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	config, err := util.LoadConfig(".")
+	if err != nil {
+		panic("Cannot load config: " + err.Error())
+	}
+	slog.SetDefault(logging.New(os.Stdout, logging.ParseLevel(config.LogLevel)))
+
+	list, err := sanctions.LoadFile(config.SanctionsListPath)
+	if err != nil {
+		panic("Cannot load sanctions list: " + err.Error())
+	}
+	if list.Len() == 0 {
+		slog.Warn("the sanctions list is empty, set SANCTIONS_LIST_PATH")
+	}
+
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	if err != nil {
+		panic("Cannot connect to db: " + err.Error())
+	}
+	defer conn.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	screener := sanctions.NewScreener(list, config.SanctionsBlockScore, config.SanctionsReviewScore)
+	result, err := screener.Rescreen(ctx, db.NewStore(conn))
+	attrs := []any{
+		slog.Int("list_entries", list.Len()),
+		slog.Int("screened", result.Screened),
+		slog.Int("matched", result.Matched),
+		slog.Int("new_cases", result.NewCases),
+		slog.Int("blocked", result.Blocked),
+	}
+	if err != nil {
+		slog.Error("rescreen failed", append(attrs, slog.String("error", err.Error()))...)
+		os.Exit(1)
+	}
+	slog.Info("rescreen finished", attrs...)
+}
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS "account_events_notify" ON "account_events";
+DROP FUNCTION IF EXISTS notify_account_event();
+DROP TABLE IF EXISTS "account_events";
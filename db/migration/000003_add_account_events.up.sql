@@ -0,0 +1,25 @@
+-- account_events is the replayable history behind GET /v1/accounts/:id/events,
+-- rows are written by TransferTx in the same transaction as the balance change
+CREATE TABLE "account_events" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "type" varchar NOT NULL,
+  "data" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "account_events" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX ON "account_events" ("account_id", "id");
+
+-- NOTIFY is only delivered once the inserting transaction commits, so listeners never see rolled back events.
+-- the payload is just the account id, streams read the rows themselves
+CREATE FUNCTION notify_account_event() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('account_events', NEW.account_id::text);
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "account_events_notify" AFTER INSERT ON "account_events"
+  FOR EACH ROW EXECUTE FUNCTION notify_account_event();
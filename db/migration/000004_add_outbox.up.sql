@@ -0,0 +1,17 @@
+-- outbox holds domain events for downstream systems. rows are inserted in the same transaction as the change
+-- they describe, so an event exists exactly when its transaction committed. the dispatcher delivers them at least once
+CREATE TABLE "outbox" (
+  "id" bigserial PRIMARY KEY,
+  "aggregate_type" varchar NOT NULL,
+  "aggregate_id" varchar NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "attempts" int NOT NULL DEFAULT 0,
+  -- the dispatcher pushes this forward to lease a row while delivering it, and to back off after a failure
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_error" varchar,
+  "sent_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX "outbox_pending_idx" ON "outbox" ("next_attempt_at") WHERE "sent_at" IS NULL;
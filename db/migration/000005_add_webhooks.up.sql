@@ -0,0 +1,37 @@
+-- webhook_endpoints are registered by users to be called back about their accounts
+CREATE TABLE "webhook_endpoints" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "url" varchar NOT NULL,
+  -- HMAC-SHA256 key for the signature header, it has to stay readable to sign with it
+  "secret" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhook_endpoints" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+CREATE INDEX ON "webhook_endpoints" ("owner");
+
+-- webhook_deliveries is the delivery log, one row per event and endpoint.
+-- status moves from pending to succeeded, or to dead once the retries are used up
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "endpoint_id" bigint NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_response_status" int,
+  "last_error" varchar,
+  "delivered_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "webhook_deliveries_status_check" CHECK ("status" IN ('pending', 'succeeded', 'dead'))
+);
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("endpoint_id") REFERENCES "webhook_endpoints" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "webhook_deliveries" ("endpoint_id", "id");
+
+CREATE INDEX "webhook_deliveries_pending_idx" ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
@@ -0,0 +1,31 @@
+-- scheduled_transfers are transfers a user asked to run at execute_at. the scheduler executes a row in the
+-- same transaction that moves the money, so a transfer runs at most once whatever happens to the scheduler.
+-- status moves from scheduled to succeeded, failed or cancelled
+CREATE TABLE "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "execute_at" timestamptz NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'scheduled',
+  "transfer_id" bigint,
+  "failure_reason" varchar,
+  "executed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "scheduled_transfers_amount_check" CHECK ("amount" > 0),
+  CONSTRAINT "scheduled_transfers_status_check" CHECK ("status" IN ('scheduled', 'succeeded', 'failed', 'cancelled'))
+);
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "scheduled_transfers" ("owner", "id");
+
+CREATE INDEX "scheduled_transfers_due_idx" ON "scheduled_transfers" ("execute_at") WHERE "status" = 'scheduled';
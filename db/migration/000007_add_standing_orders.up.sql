@@ -0,0 +1,65 @@
+-- standing_orders repeat a transfer on a calendar rule. occurrence n is due on start_date plus n periods (month
+-- and year ends are clamped), moved off weekends by business_day_adjustment. the order ends after end_date or
+-- max_occurrences, whichever comes first
+CREATE TABLE "standing_orders" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "frequency" varchar NOT NULL,
+  "start_date" date NOT NULL,
+  "end_date" date,
+  "max_occurrences" int,
+  "business_day_adjustment" varchar NOT NULL DEFAULT 'none',
+  -- skip moves on to the next occurrence when funds are short, retry tries again up to max_retries times first
+  "on_insufficient_funds" varchar NOT NULL DEFAULT 'skip',
+  "max_retries" int NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'active',
+  -- occurrences already settled, the next one to run is number occurrences (counting from 0)
+  "occurrences" int NOT NULL DEFAULT 0,
+  -- attempts made at the next occurrence, only retries make it go above 0
+  "attempts" int NOT NULL DEFAULT 0,
+  -- null once the order is completed or cancelled
+  "next_run_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "standing_orders_amount_check" CHECK ("amount" > 0),
+  CONSTRAINT "standing_orders_frequency_check" CHECK ("frequency" IN ('weekly', 'monthly', 'yearly')),
+  CONSTRAINT "standing_orders_business_day_adjustment_check" CHECK ("business_day_adjustment" IN ('none', 'following', 'preceding', 'modified_following')),
+  CONSTRAINT "standing_orders_on_insufficient_funds_check" CHECK ("on_insufficient_funds" IN ('skip', 'retry')),
+  CONSTRAINT "standing_orders_status_check" CHECK ("status" IN ('active', 'completed', 'cancelled'))
+);
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX ON "standing_orders" ("owner", "id");
+
+CREATE INDEX "standing_orders_due_idx" ON "standing_orders" ("next_run_at") WHERE "status" = 'active';
+
+-- standing_order_occurrences is the history of an order, one row per occurrence with the transfer it produced.
+-- an occurrence waiting for a retry is retrying, it ends up succeeded, skipped or failed
+CREATE TABLE "standing_order_occurrences" (
+  "id" bigserial PRIMARY KEY,
+  "standing_order_id" bigint NOT NULL,
+  "sequence" int NOT NULL,
+  "scheduled_date" date NOT NULL,
+  "run_date" date NOT NULL,
+  "status" varchar NOT NULL,
+  "attempts" int NOT NULL,
+  "transfer_id" bigint,
+  "failure_reason" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "standing_order_occurrences_status_check" CHECK ("status" IN ('succeeded', 'skipped', 'retrying', 'failed'))
+);
+
+ALTER TABLE "standing_order_occurrences" ADD FOREIGN KEY ("standing_order_id") REFERENCES "standing_orders" ("id");
+
+ALTER TABLE "standing_order_occurrences" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE UNIQUE INDEX ON "standing_order_occurrences" ("standing_order_id", "sequence");
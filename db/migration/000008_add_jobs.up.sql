@@ -0,0 +1,23 @@
+-- jobs is a queue of background work. a job inserted in the same transaction as the change that needs it
+-- runs exactly when that transaction committed. workers lease due rows with SKIP LOCKED and run them at least once
+CREATE TABLE "jobs" (
+  "id" bigserial PRIMARY KEY,
+  "kind" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  -- at most one pending job per key, enqueueing a duplicate is a no-op
+  "unique_key" varchar,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "max_attempts" int NOT NULL,
+  -- workers push this forward to lease a job while running it (the visibility timeout), and to back off after a failure
+  "run_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_error" varchar,
+  "finished_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "jobs_status_check" CHECK ("status" IN ('pending', 'succeeded', 'dead')),
+  CONSTRAINT "jobs_max_attempts_check" CHECK ("max_attempts" > 0)
+);
+
+CREATE INDEX "jobs_due_idx" ON "jobs" ("run_at") WHERE "status" = 'pending';
+
+CREATE UNIQUE INDEX "jobs_unique_key_idx" ON "jobs" ("kind", "unique_key") WHERE "status" = 'pending';
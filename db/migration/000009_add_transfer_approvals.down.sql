@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "transfer_approvals";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "role";
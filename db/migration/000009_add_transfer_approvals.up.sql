@@ -0,0 +1,42 @@
+-- role decides what a user may do besides moving their own money. approvers review transfers held for approval,
+-- the role is granted directly in the database for now
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'customer';
+
+ALTER TABLE "users" ADD CONSTRAINT "users_role_check" CHECK ("role" IN ('customer', 'approver'));
+
+-- transfer_approvals hold transfers above the approval threshold of their currency until a second person reviews
+-- them. an approved transfer is executed in the same transaction that approves it. status moves from pending to
+-- approved, rejected or expired
+CREATE TABLE "transfer_approvals" (
+  "id" bigserial PRIMARY KEY,
+  "initiator" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "reviewer" varchar,
+  "rejection_reason" varchar,
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "reviewed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "transfer_approvals_amount_check" CHECK ("amount" > 0),
+  CONSTRAINT "transfer_approvals_status_check" CHECK ("status" IN ('pending', 'approved', 'rejected', 'expired')),
+  -- the four eyes principle, whatever the application does
+  CONSTRAINT "transfer_approvals_reviewer_check" CHECK ("reviewer" <> "initiator")
+);
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("initiator") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("reviewer") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfer_approvals" ("initiator", "id");
+
+CREATE INDEX "transfer_approvals_pending_idx" ON "transfer_approvals" ("expires_at") WHERE "status" = 'pending';
@@ -0,0 +1,34 @@
+-- kyc_tier places a user in a band of transfer limits, 0 until KYC says otherwise
+ALTER TABLE "users" ADD COLUMN "kyc_tier" int NOT NULL DEFAULT 0;
+
+-- transfer_limits cap how much can leave an account per UTC day and per UTC month. a row applies to one account
+-- (an override), to the accounts of users in one KYC tier, or to every account of its currency (the default).
+-- each period is resolved on its own, the most specific row with a value wins, no value anywhere means no limit
+CREATE TABLE "transfer_limits" (
+  "id" bigserial PRIMARY KEY,
+  "currency" varchar NOT NULL,
+  "account_id" bigint,
+  "kyc_tier" int,
+  "daily_limit" bigint,
+  "monthly_limit" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "transfer_limits_scope_check" CHECK ("account_id" IS NULL OR "kyc_tier" IS NULL),
+  CONSTRAINT "transfer_limits_daily_limit_check" CHECK ("daily_limit" >= 0),
+  CONSTRAINT "transfer_limits_monthly_limit_check" CHECK ("monthly_limit" >= 0)
+);
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX "transfer_limits_account_key" ON "transfer_limits" ("account_id") WHERE "account_id" IS NOT NULL;
+
+CREATE UNIQUE INDEX "transfer_limits_tier_key" ON "transfer_limits" ("kyc_tier", "currency") WHERE "kyc_tier" IS NOT NULL;
+
+CREATE UNIQUE INDEX "transfer_limits_default_key" ON "transfer_limits" ("currency") WHERE "account_id" IS NULL AND "kyc_tier" IS NULL;
+
+-- the sums behind the limits read an account's outgoing transfers of the current month
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+INSERT INTO "transfer_limits" ("currency", "daily_limit", "monthly_limit") VALUES
+  ('USD', 2500000, 10000000),
+  ('EUR', 2500000, 10000000),
+  ('CAD', 2500000, 10000000);
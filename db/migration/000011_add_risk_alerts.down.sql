@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "risk_alerts";
+
+UPDATE "users" SET "role" = 'customer' WHERE "role" = 'admin';
+
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_role_check";
+
+ALTER TABLE "users" ADD CONSTRAINT "users_role_check" CHECK ("role" IN ('customer', 'approver'));
@@ -0,0 +1,35 @@
+-- admins work the risk alert queue
+ALTER TABLE "users" DROP CONSTRAINT "users_role_check";
+
+ALTER TABLE "users" ADD CONSTRAINT "users_role_check" CHECK ("role" IN ('customer', 'approver', 'admin'));
+
+-- risk_alerts record the transfers the risk rules wanted a person to look at. a reviewed transfer went through and
+-- transfer_id points at it, a blocked one was rolled back and has none. findings lists what each rule that fired
+-- said. status moves from open to cleared (a false positive) or confirmed
+CREATE TABLE "risk_alerts" (
+  "id" bigserial PRIMARY KEY,
+  "decision" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'open',
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "transfer_id" bigint,
+  "findings" jsonb NOT NULL,
+  "resolved_by" varchar,
+  "resolution_note" varchar,
+  "resolved_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "risk_alerts_decision_check" CHECK ("decision" IN ('review', 'block')),
+  CONSTRAINT "risk_alerts_status_check" CHECK ("status" IN ('open', 'cleared', 'confirmed'))
+);
+
+ALTER TABLE "risk_alerts" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "risk_alerts" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "risk_alerts" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "risk_alerts" ADD FOREIGN KEY ("resolved_by") REFERENCES "users" ("username");
+
+CREATE INDEX "risk_alerts_status_idx" ON "risk_alerts" ("status", "id");
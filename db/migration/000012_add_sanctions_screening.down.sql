@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "screening_cases";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "sanctions_status";
@@ -0,0 +1,36 @@
+-- sanctions_status follows the screening cases of a user: blocked while a strong match is open or any match was
+-- confirmed, review while a weak match is open, clear otherwise. transfers to or from a blocked user are refused
+ALTER TABLE "users" ADD COLUMN "sanctions_status" varchar NOT NULL DEFAULT 'clear';
+
+ALTER TABLE "users" ADD CONSTRAINT "users_sanctions_status_check" CHECK ("sanctions_status" IN ('clear', 'review', 'blocked'));
+
+-- screening_cases record the matches of a name against the sanctions list. a strong match at onboarding refuses
+-- the user, so its case has no username. status moves from open to cleared (a false positive) or confirmed
+CREATE TABLE "screening_cases" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar,
+  "full_name" varchar NOT NULL,
+  "entry_id" varchar NOT NULL,
+  "entry_name" varchar NOT NULL,
+  "program" varchar NOT NULL,
+  "score" double precision NOT NULL,
+  "decision" varchar NOT NULL,
+  "source" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'open',
+  "resolved_by" varchar,
+  "resolution_note" varchar,
+  "resolved_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "screening_cases_decision_check" CHECK ("decision" IN ('review', 'block')),
+  CONSTRAINT "screening_cases_source_check" CHECK ("source" IN ('onboarding', 'rescreen')),
+  CONSTRAINT "screening_cases_status_check" CHECK ("status" IN ('open', 'cleared', 'confirmed'))
+);
+
+ALTER TABLE "screening_cases" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "screening_cases" ADD FOREIGN KEY ("resolved_by") REFERENCES "users" ("username");
+
+-- a user matches an entry once, screening again does not reopen a case someone already cleared
+CREATE UNIQUE INDEX "screening_cases_username_entry_id_key" ON "screening_cases" ("username", "entry_id");
+
+CREATE INDEX "screening_cases_status_idx" ON "screening_cases" ("status", "id");
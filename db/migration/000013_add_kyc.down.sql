@@ -0,0 +1,19 @@
+DELETE FROM "transfer_limits" WHERE "kyc_tier" IN (0, 2) AND "account_id" IS NULL;
+
+DROP TABLE IF EXISTS "kyc_events";
+
+DROP TABLE IF EXISTS "kyc_documents";
+
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_kyc_tier_check";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "country";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "postal_code";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "city";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "address_line";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "date_of_birth";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "kyc_status";
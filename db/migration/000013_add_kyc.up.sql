@@ -0,0 +1,64 @@
+-- kyc_status is where a user stands with identity verification: unverified until they submit their details,
+-- pending until an admin verifies or rejects them. kyc_tier, which picks the band of transfer limits, stays 0
+-- unless the user is verified. users already placed in a tier are taken as verified
+ALTER TABLE "users" ADD COLUMN "kyc_status" varchar NOT NULL DEFAULT 'unverified';
+
+ALTER TABLE "users" ADD COLUMN "date_of_birth" date;
+
+ALTER TABLE "users" ADD COLUMN "address_line" varchar;
+
+ALTER TABLE "users" ADD COLUMN "city" varchar;
+
+ALTER TABLE "users" ADD COLUMN "postal_code" varchar;
+
+-- country is an ISO 3166-1 alpha-2 code
+ALTER TABLE "users" ADD COLUMN "country" varchar(2);
+
+UPDATE "users" SET "kyc_status" = 'verified' WHERE "kyc_tier" > 0;
+
+ALTER TABLE "users" ADD CONSTRAINT "users_kyc_status_check" CHECK ("kyc_status" IN ('unverified', 'pending', 'verified', 'rejected'));
+
+ALTER TABLE "users" ADD CONSTRAINT "users_kyc_tier_check" CHECK ("kyc_tier" >= 0 AND ("kyc_tier" = 0 OR "kyc_status" = 'verified'));
+
+-- kyc_documents point at identity documents kept in document storage, reference is the key there
+CREATE TABLE "kyc_documents" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "document_type" varchar NOT NULL,
+  "reference" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "kyc_documents_document_type_check" CHECK ("document_type" IN ('passport', 'national_id', 'driving_licence', 'proof_of_address'))
+);
+
+ALTER TABLE "kyc_documents" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "kyc_documents" ("username");
+
+-- kyc_events are the audit trail of KYC, one row per change of status or tier. actor is the user themselves for
+-- a submission and the admin for a decision
+CREATE TABLE "kyc_events" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "from_status" varchar NOT NULL,
+  "to_status" varchar NOT NULL,
+  "from_tier" int NOT NULL,
+  "to_tier" int NOT NULL,
+  "actor" varchar NOT NULL,
+  "note" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "kyc_events" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "kyc_events" ADD FOREIGN KEY ("actor") REFERENCES "users" ("username");
+
+CREATE INDEX ON "kyc_events" ("username", "id");
+
+-- tier 0 (anyone not verified) gets low limits, tier 1 keeps the currency defaults, tier 2 gets high ones
+INSERT INTO "transfer_limits" ("currency", "kyc_tier", "daily_limit", "monthly_limit") VALUES
+  ('USD', 0, 50000, 200000),
+  ('EUR', 0, 50000, 200000),
+  ('CAD', 0, 50000, 200000),
+  ('USD', 2, 10000000, 50000000),
+  ('EUR', 2, 10000000, 50000000),
+  ('CAD', 2, 10000000, 50000000);
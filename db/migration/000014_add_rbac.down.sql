@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS "balance_adjustments";
+
+DROP INDEX IF EXISTS "transfers_reversal_of_key";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "reversal_of";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "frozen_at";
+
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_role_check";
+
+UPDATE "users" SET "role" = 'customer' WHERE "role" = 'teller';
+
+ALTER TABLE "users" ADD CONSTRAINT "users_role_check" CHECK ("role" IN ('customer', 'approver', 'admin'));
@@ -0,0 +1,34 @@
+-- tellers serve customers at the counter: they see any account and open accounts for customers
+ALTER TABLE "users" DROP CONSTRAINT "users_role_check";
+
+ALTER TABLE "users" ADD CONSTRAINT "users_role_check" CHECK ("role" IN ('customer', 'teller', 'approver', 'admin'));
+
+-- an admin can freeze an account, it neither sends nor receives transfers until unfrozen
+ALTER TABLE "accounts" ADD COLUMN "frozen_at" timestamptz;
+
+-- reversal_of links a reversal, the same amount moved back, to the transfer it undoes. a transfer is reversed once
+ALTER TABLE "transfers" ADD COLUMN "reversal_of" bigint;
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reversal_of") REFERENCES "transfers" ("id");
+
+CREATE UNIQUE INDEX "transfers_reversal_of_key" ON "transfers" ("reversal_of") WHERE "reversal_of" IS NOT NULL;
+
+-- balance_adjustments are corrections an admin books on one account, with the entry they created and why
+CREATE TABLE "balance_adjustments" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "entry_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "reason" varchar NOT NULL,
+  "created_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "balance_adjustments_amount_check" CHECK ("amount" <> 0)
+);
+
+ALTER TABLE "balance_adjustments" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "balance_adjustments" ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "balance_adjustments" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
+
+CREATE INDEX ON "balance_adjustments" ("account_id", "id");
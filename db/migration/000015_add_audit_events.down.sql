@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS "audit_events_no_truncate" ON "audit_events";
+DROP TRIGGER IF EXISTS "audit_events_immutable" ON "audit_events";
+DROP FUNCTION IF EXISTS reject_audit_event_change();
+DROP TABLE IF EXISTS "audit_events";
@@ -0,0 +1,37 @@
+-- audit_events is the append-only record of privileged and state-changing actions, who did what to which row,
+-- written in the transaction of the change. actor is null for changes the system made on its own
+CREATE TABLE "audit_events" (
+  "id" bigserial PRIMARY KEY,
+  "actor" varchar,
+  "action" varchar NOT NULL,
+  "target_type" varchar NOT NULL,
+  "target_id" varchar NOT NULL,
+  -- before and after are the target as it was and became, json null when there is no such side
+  "before" jsonb NOT NULL,
+  "after" jsonb NOT NULL,
+  "request_id" varchar,
+  "ip" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "audit_events" ("target_type", "target_id", "id");
+
+CREATE INDEX ON "audit_events" ("actor", "id");
+
+CREATE INDEX ON "audit_events" ("created_at");
+
+-- rows are never changed once written. the trigger holds for the owner of the table too, who is not bound by
+-- the revoke below
+CREATE FUNCTION reject_audit_event_change() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only, % is not allowed', TG_OP USING ERRCODE = 'insufficient_privilege';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_events_immutable" BEFORE UPDATE OR DELETE ON "audit_events"
+  FOR EACH ROW EXECUTE FUNCTION reject_audit_event_change();
+
+CREATE TRIGGER "audit_events_no_truncate" BEFORE TRUNCATE ON "audit_events"
+  FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_event_change();
+
+REVOKE UPDATE, DELETE, TRUNCATE ON "audit_events" FROM PUBLIC;
@@ -0,0 +1,37 @@
+-- totp_secret is the base32 secret of the user's authenticator app, set when they enroll. two-factor
+-- authentication is on from totp_enabled_at, once they confirmed a first code of the app
+ALTER TABLE "users" ADD COLUMN "totp_secret" varchar;
+
+ALTER TABLE "users" ADD COLUMN "totp_enabled_at" timestamptz;
+
+-- totp_last_step is the time step of the last code accepted, so that a code is never accepted twice
+ALTER TABLE "users" ADD COLUMN "totp_last_step" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "users" ADD CONSTRAINT "users_totp_enabled_check" CHECK ("totp_enabled_at" IS NULL OR "totp_secret" IS NOT NULL);
+
+-- recovery_codes stand in for a code of the app when the device is lost, each is used once. only a SHA-256 of
+-- the code is kept, the user is shown the codes when they turn two-factor authentication on
+CREATE TABLE "recovery_codes" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE UNIQUE INDEX ON "recovery_codes" ("username", "code_hash");
+
+-- login_challenges are the second step of logging in with two-factor authentication. the password opens one,
+-- a valid code completes it and gets the access token. attempts counts the wrong codes
+CREATE TABLE "login_challenges" (
+  "id" uuid PRIMARY KEY DEFAULT (gen_random_uuid()),
+  "username" varchar NOT NULL,
+  "attempts" int NOT NULL DEFAULT 0,
+  "expires_at" timestamptz NOT NULL,
+  "completed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "login_challenges" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
@@ -0,0 +1,21 @@
+-- email_verified_at is when the user proved the email is theirs, changing the email would reset it
+ALTER TABLE "users" ADD COLUMN "email_verified_at" timestamptz;
+
+-- email_tokens are the single-use tokens mailed to users, to verify their email or reset their password.
+-- only a SHA-256 of the token is kept. email is the address the token was sent to, a token is only good
+-- while the user still has that address
+CREATE TABLE "email_tokens" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "purpose" varchar NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "email" varchar NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "email_tokens_purpose_check" CHECK ("purpose" IN ('verify_email', 'reset_password'))
+);
+
+ALTER TABLE "email_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "email_tokens" ("username", "purpose");
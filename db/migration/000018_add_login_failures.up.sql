@@ -0,0 +1,12 @@
+-- login_failures counts the failed logins of a username or of an IP, whether or not the username exists, so
+-- that the answers never tell the two apart. failures older than the window are forgotten on the next one,
+-- locked_until is when a lockout ends
+CREATE TABLE "login_failures" (
+  "scope" varchar NOT NULL,
+  "subject" varchar NOT NULL,
+  "failures" integer NOT NULL DEFAULT 0,
+  "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  PRIMARY KEY ("scope", "subject"),
+  CONSTRAINT "login_failures_scope_check" CHECK ("scope" IN ('username', 'ip'))
+);
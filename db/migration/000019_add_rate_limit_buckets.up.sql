@@ -0,0 +1,12 @@
+-- rate_limit_buckets are the token buckets of the rate limiter when the replicas share them. tokens is what the
+-- bucket held at updated_at, it refills from there. allowed is whether the last request found a token.
+-- buckets unused for long are deleted, they would be full by then. the table is unlogged, a crash losing
+-- buckets only refills them
+CREATE UNLOGGED TABLE "rate_limit_buckets" (
+  "key" varchar PRIMARY KEY,
+  "tokens" double precision NOT NULL,
+  "allowed" boolean NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "rate_limit_buckets" ("updated_at");
@@ -0,0 +1,22 @@
+-- api_keys let services call the APIs as the user who issued them, without logging in. a key reads
+-- gbk_<prefix>_<secret>, prefix finds the row and only a SHA-256 of the secret is kept. scopes narrow what the
+-- key may do within the permissions of the user's role. allowed_ips are the CIDRs the key is accepted from,
+-- any address when empty. a key without expires_at works until it is revoked
+CREATE TABLE "api_keys" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "name" varchar NOT NULL,
+  "prefix" varchar UNIQUE NOT NULL,
+  "secret_hash" varchar NOT NULL,
+  "scopes" varchar[] NOT NULL,
+  "allowed_ips" varchar[] NOT NULL,
+  "expires_at" timestamptz,
+  "last_used_at" timestamptz,
+  "last_used_ip" varchar,
+  "revoked_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "api_keys" ("username");
@@ -0,0 +1,5 @@
+DELETE FROM "login_failures" WHERE "scope" = 'second_factor';
+
+ALTER TABLE "login_failures" DROP CONSTRAINT "login_failures_scope_check";
+
+ALTER TABLE "login_failures" ADD CONSTRAINT "login_failures_scope_check" CHECK ("scope" IN ('username', 'ip'));
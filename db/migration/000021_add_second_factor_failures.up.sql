@@ -0,0 +1,5 @@
+-- wrong codes of a step-up or of turning two-factor authentication off are counted per user as well, a stolen
+-- access token must not be able to guess codes for long
+ALTER TABLE "login_failures" DROP CONSTRAINT "login_failures_scope_check";
+
+ALTER TABLE "login_failures" ADD CONSTRAINT "login_failures_scope_check" CHECK ("scope" IN ('username', 'ip', 'second_factor'));
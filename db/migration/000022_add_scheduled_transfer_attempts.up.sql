@@ -0,0 +1,5 @@
+-- a scheduled transfer that fails for another reason than a rejection (a lost connection, a timeout) is tried
+-- again after retry_at, and failed once it ran out of attempts, so one bad row cannot hold up the others
+ALTER TABLE "scheduled_transfers" ADD COLUMN "attempts" int NOT NULL DEFAULT 0;
+
+ALTER TABLE "scheduled_transfers" ADD COLUMN "retry_at" timestamptz;
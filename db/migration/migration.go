@@ -0,0 +1,39 @@
+package migration
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// FS holds every migration file so the binary knows which schema version it was built against.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// LatestVersion returns the highest migration version shipped with the binary.
+// migration files are named <version>_<title>.<up|down>.sql, so the version is the numeric prefix
+func LatestVersion() (uint, error) {
+	files, err := fs.Glob(FS, "*.up.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, name := range files {
+		prefix, _, found := strings.Cut(name, "_")
+		if !found {
+			return 0, fmt.Errorf("invalid migration file name: %s", name)
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration version in %s: %w", name, err)
+		}
+		if uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+	return latest, nil
+}
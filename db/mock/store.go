@@ -12,8 +12,10 @@ package mockdb
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	uuid "github.com/google/uuid"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -56,198 +58,2095 @@ func (mr *MockStoreMockRecorder) AddAccountBalance(ctx, arg any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAccountBalance), ctx, arg)
 }
 
+// AddLoginChallengeAttempt mocks base method.
+func (m *MockStore) AddLoginChallengeAttempt(ctx context.Context, id uuid.UUID) (db.LoginChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLoginChallengeAttempt", ctx, id)
+	ret0, _ := ret[0].(db.LoginChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLoginChallengeAttempt indicates an expected call of AddLoginChallengeAttempt.
+func (mr *MockStoreMockRecorder) AddLoginChallengeAttempt(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoginChallengeAttempt", reflect.TypeOf((*MockStore)(nil).AddLoginChallengeAttempt), ctx, id)
+}
+
+// AddLoginFailure mocks base method.
+func (m *MockStore) AddLoginFailure(ctx context.Context, arg db.AddLoginFailureParams) (db.LoginFailure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLoginFailure", ctx, arg)
+	ret0, _ := ret[0].(db.LoginFailure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLoginFailure indicates an expected call of AddLoginFailure.
+func (mr *MockStoreMockRecorder) AddLoginFailure(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoginFailure", reflect.TypeOf((*MockStore)(nil).AddLoginFailure), ctx, arg)
+}
+
+// AdjustBalanceTx mocks base method.
+func (m *MockStore) AdjustBalanceTx(ctx context.Context, arg db.AdjustBalanceTxParams) (db.AdjustBalanceTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustBalanceTx", ctx, arg)
+	ret0, _ := ret[0].(db.AdjustBalanceTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustBalanceTx indicates an expected call of AdjustBalanceTx.
+func (mr *MockStoreMockRecorder) AdjustBalanceTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustBalanceTx", reflect.TypeOf((*MockStore)(nil).AdjustBalanceTx), ctx, arg)
+}
+
+// ApproveTransferTx mocks base method.
+func (m *MockStore) ApproveTransferTx(ctx context.Context, arg db.ApproveTransferTxParams) (db.ApproveTransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveTransferTx", ctx, arg)
+	ret0, _ := ret[0].(db.ApproveTransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApproveTransferTx indicates an expected call of ApproveTransferTx.
+func (mr *MockStoreMockRecorder) ApproveTransferTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveTransferTx", reflect.TypeOf((*MockStore)(nil).ApproveTransferTx), ctx, arg)
+}
+
+// CancelScheduledTransfer mocks base method.
+func (m *MockStore) CancelScheduledTransfer(ctx context.Context, id int64) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduledTransfer", ctx, id)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelScheduledTransfer indicates an expected call of CancelScheduledTransfer.
+func (mr *MockStoreMockRecorder) CancelScheduledTransfer(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduledTransfer", reflect.TypeOf((*MockStore)(nil).CancelScheduledTransfer), ctx, id)
+}
+
+// CancelStandingOrder mocks base method.
+func (m *MockStore) CancelStandingOrder(ctx context.Context, id int64) (db.StandingOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelStandingOrder", ctx, id)
+	ret0, _ := ret[0].(db.StandingOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelStandingOrder indicates an expected call of CancelStandingOrder.
+func (mr *MockStoreMockRecorder) CancelStandingOrder(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelStandingOrder", reflect.TypeOf((*MockStore)(nil).CancelStandingOrder), ctx, id)
+}
+
+// ClaimDueScheduledTransfer mocks base method.
+func (m *MockStore) ClaimDueScheduledTransfer(ctx context.Context) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueScheduledTransfer", ctx)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueScheduledTransfer indicates an expected call of ClaimDueScheduledTransfer.
+func (mr *MockStoreMockRecorder) ClaimDueScheduledTransfer(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueScheduledTransfer", reflect.TypeOf((*MockStore)(nil).ClaimDueScheduledTransfer), ctx)
+}
+
+// ClaimDueStandingOrder mocks base method.
+func (m *MockStore) ClaimDueStandingOrder(ctx context.Context) (db.StandingOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueStandingOrder", ctx)
+	ret0, _ := ret[0].(db.StandingOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueStandingOrder indicates an expected call of ClaimDueStandingOrder.
+func (mr *MockStoreMockRecorder) ClaimDueStandingOrder(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueStandingOrder", reflect.TypeOf((*MockStore)(nil).ClaimDueStandingOrder), ctx)
+}
+
+// ClaimJobs mocks base method.
+func (m *MockStore) ClaimJobs(ctx context.Context, arg db.ClaimJobsParams) ([]db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimJobs", ctx, arg)
+	ret0, _ := ret[0].([]db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimJobs indicates an expected call of ClaimJobs.
+func (mr *MockStoreMockRecorder) ClaimJobs(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimJobs", reflect.TypeOf((*MockStore)(nil).ClaimJobs), ctx, arg)
+}
+
+// ClaimOutboxEvents mocks base method.
+func (m *MockStore) ClaimOutboxEvents(ctx context.Context, arg db.ClaimOutboxEventsParams) ([]db.Outbox, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimOutboxEvents", ctx, arg)
+	ret0, _ := ret[0].([]db.Outbox)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimOutboxEvents indicates an expected call of ClaimOutboxEvents.
+func (mr *MockStoreMockRecorder) ClaimOutboxEvents(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimOutboxEvents", reflect.TypeOf((*MockStore)(nil).ClaimOutboxEvents), ctx, arg)
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockStore) ClaimWebhookDeliveries(ctx context.Context, arg db.ClaimWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, arg)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ClaimWebhookDeliveries(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ClaimWebhookDeliveries), ctx, arg)
+}
+
+// CompleteLoginChallenge mocks base method.
+func (m *MockStore) CompleteLoginChallenge(ctx context.Context, id uuid.UUID) (db.LoginChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteLoginChallenge", ctx, id)
+	ret0, _ := ret[0].(db.LoginChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteLoginChallenge indicates an expected call of CompleteLoginChallenge.
+func (mr *MockStoreMockRecorder) CompleteLoginChallenge(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteLoginChallenge", reflect.TypeOf((*MockStore)(nil).CompleteLoginChallenge), ctx, id)
+}
+
+// CompleteLoginChallengeTx mocks base method.
+func (m *MockStore) CompleteLoginChallengeTx(ctx context.Context, arg db.CompleteLoginChallengeTxParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteLoginChallengeTx", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteLoginChallengeTx indicates an expected call of CompleteLoginChallengeTx.
+func (mr *MockStoreMockRecorder) CompleteLoginChallengeTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteLoginChallengeTx", reflect.TypeOf((*MockStore)(nil).CompleteLoginChallengeTx), ctx, arg)
+}
+
+// ConfirmTOTPTx mocks base method.
+func (m *MockStore) ConfirmTOTPTx(ctx context.Context, arg db.ConfirmTOTPTxParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTPTx", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTPTx indicates an expected call of ConfirmTOTPTx.
+func (mr *MockStoreMockRecorder) ConfirmTOTPTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTPTx", reflect.TypeOf((*MockStore)(nil).ConfirmTOTPTx), ctx, arg)
+}
+
+// CountAccountsByOwner mocks base method.
+func (m *MockStore) CountAccountsByOwner(ctx context.Context, owner string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountAccountsByOwner", ctx, owner)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountAccountsByOwner indicates an expected call of CountAccountsByOwner.
+func (mr *MockStoreMockRecorder) CountAccountsByOwner(ctx, owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAccountsByOwner", reflect.TypeOf((*MockStore)(nil).CountAccountsByOwner), ctx, owner)
+}
+
+// CountUnusedRecoveryCodes mocks base method.
+func (m *MockStore) CountUnusedRecoveryCodes(ctx context.Context, username string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnusedRecoveryCodes", ctx, username)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnusedRecoveryCodes indicates an expected call of CountUnusedRecoveryCodes.
+func (mr *MockStoreMockRecorder) CountUnusedRecoveryCodes(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnusedRecoveryCodes", reflect.TypeOf((*MockStore)(nil).CountUnusedRecoveryCodes), ctx, username)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockStore) CreateAPIKey(ctx context.Context, arg db.CreateAPIKeyParams) (db.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, arg)
+	ret0, _ := ret[0].(db.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockStoreMockRecorder) CreateAPIKey(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockStore)(nil).CreateAPIKey), ctx, arg)
+}
+
+// CreateAPIKeyTx mocks base method.
+func (m *MockStore) CreateAPIKeyTx(ctx context.Context, arg db.CreateAPIKeyParams) (db.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKeyTx", ctx, arg)
+	ret0, _ := ret[0].(db.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKeyTx indicates an expected call of CreateAPIKeyTx.
+func (mr *MockStoreMockRecorder) CreateAPIKeyTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKeyTx", reflect.TypeOf((*MockStore)(nil).CreateAPIKeyTx), ctx, arg)
+}
+
 // CreateAccount mocks base method.
 func (m *MockStore) CreateAccount(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAccount", ctx, arg)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "CreateAccount", ctx, arg)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccount indicates an expected call of CreateAccount.
+func (mr *MockStoreMockRecorder) CreateAccount(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), ctx, arg)
+}
+
+// CreateAccountEvent mocks base method.
+func (m *MockStore) CreateAccountEvent(ctx context.Context, arg db.CreateAccountEventParams) (db.AccountEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccountEvent", ctx, arg)
+	ret0, _ := ret[0].(db.AccountEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccountEvent indicates an expected call of CreateAccountEvent.
+func (mr *MockStoreMockRecorder) CreateAccountEvent(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountEvent", reflect.TypeOf((*MockStore)(nil).CreateAccountEvent), ctx, arg)
+}
+
+// CreateAccountTx mocks base method.
+func (m *MockStore) CreateAccountTx(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccountTx", ctx, arg)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccountTx indicates an expected call of CreateAccountTx.
+func (mr *MockStoreMockRecorder) CreateAccountTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountTx", reflect.TypeOf((*MockStore)(nil).CreateAccountTx), ctx, arg)
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockStore) CreateAuditEvent(ctx context.Context, arg db.CreateAuditEventParams) (db.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", ctx, arg)
+	ret0, _ := ret[0].(db.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockStoreMockRecorder) CreateAuditEvent(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockStore)(nil).CreateAuditEvent), ctx, arg)
+}
+
+// CreateBalanceAdjustment mocks base method.
+func (m *MockStore) CreateBalanceAdjustment(ctx context.Context, arg db.CreateBalanceAdjustmentParams) (db.BalanceAdjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBalanceAdjustment", ctx, arg)
+	ret0, _ := ret[0].(db.BalanceAdjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBalanceAdjustment indicates an expected call of CreateBalanceAdjustment.
+func (mr *MockStoreMockRecorder) CreateBalanceAdjustment(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBalanceAdjustment", reflect.TypeOf((*MockStore)(nil).CreateBalanceAdjustment), ctx, arg)
+}
+
+// CreateEmailToken mocks base method.
+func (m *MockStore) CreateEmailToken(ctx context.Context, arg db.CreateEmailTokenParams) (db.EmailToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailToken", ctx, arg)
+	ret0, _ := ret[0].(db.EmailToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEmailToken indicates an expected call of CreateEmailToken.
+func (mr *MockStoreMockRecorder) CreateEmailToken(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailToken", reflect.TypeOf((*MockStore)(nil).CreateEmailToken), ctx, arg)
+}
+
+// CreateEntry mocks base method.
+func (m *MockStore) CreateEntry(ctx context.Context, arg db.CreateEntryParams) (db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEntry", ctx, arg)
+	ret0, _ := ret[0].(db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEntry indicates an expected call of CreateEntry.
+func (mr *MockStoreMockRecorder) CreateEntry(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), ctx, arg)
+}
+
+// CreateJob mocks base method.
+func (m *MockStore) CreateJob(ctx context.Context, arg db.CreateJobParams) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJob", ctx, arg)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateJob indicates an expected call of CreateJob.
+func (mr *MockStoreMockRecorder) CreateJob(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJob", reflect.TypeOf((*MockStore)(nil).CreateJob), ctx, arg)
+}
+
+// CreateKYCDocument mocks base method.
+func (m *MockStore) CreateKYCDocument(ctx context.Context, arg db.CreateKYCDocumentParams) (db.KycDocument, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateKYCDocument", ctx, arg)
+	ret0, _ := ret[0].(db.KycDocument)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateKYCDocument indicates an expected call of CreateKYCDocument.
+func (mr *MockStoreMockRecorder) CreateKYCDocument(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKYCDocument", reflect.TypeOf((*MockStore)(nil).CreateKYCDocument), ctx, arg)
+}
+
+// CreateKYCEvent mocks base method.
+func (m *MockStore) CreateKYCEvent(ctx context.Context, arg db.CreateKYCEventParams) (db.KycEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateKYCEvent", ctx, arg)
+	ret0, _ := ret[0].(db.KycEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateKYCEvent indicates an expected call of CreateKYCEvent.
+func (mr *MockStoreMockRecorder) CreateKYCEvent(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKYCEvent", reflect.TypeOf((*MockStore)(nil).CreateKYCEvent), ctx, arg)
+}
+
+// CreateLoginChallenge mocks base method.
+func (m *MockStore) CreateLoginChallenge(ctx context.Context, arg db.CreateLoginChallengeParams) (db.LoginChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLoginChallenge", ctx, arg)
+	ret0, _ := ret[0].(db.LoginChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLoginChallenge indicates an expected call of CreateLoginChallenge.
+func (mr *MockStoreMockRecorder) CreateLoginChallenge(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoginChallenge", reflect.TypeOf((*MockStore)(nil).CreateLoginChallenge), ctx, arg)
+}
+
+// CreateOutboxEvent mocks base method.
+func (m *MockStore) CreateOutboxEvent(ctx context.Context, arg db.CreateOutboxEventParams) (db.Outbox, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOutboxEvent", ctx, arg)
+	ret0, _ := ret[0].(db.Outbox)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOutboxEvent indicates an expected call of CreateOutboxEvent.
+func (mr *MockStoreMockRecorder) CreateOutboxEvent(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOutboxEvent", reflect.TypeOf((*MockStore)(nil).CreateOutboxEvent), ctx, arg)
+}
+
+// CreateRecoveryCode mocks base method.
+func (m *MockStore) CreateRecoveryCode(ctx context.Context, arg db.CreateRecoveryCodeParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRecoveryCode", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRecoveryCode indicates an expected call of CreateRecoveryCode.
+func (mr *MockStoreMockRecorder) CreateRecoveryCode(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecoveryCode", reflect.TypeOf((*MockStore)(nil).CreateRecoveryCode), ctx, arg)
+}
+
+// CreateReversal mocks base method.
+func (m *MockStore) CreateReversal(ctx context.Context, arg db.CreateReversalParams) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReversal", ctx, arg)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReversal indicates an expected call of CreateReversal.
+func (mr *MockStoreMockRecorder) CreateReversal(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReversal", reflect.TypeOf((*MockStore)(nil).CreateReversal), ctx, arg)
+}
+
+// CreateRiskAlert mocks base method.
+func (m *MockStore) CreateRiskAlert(ctx context.Context, arg db.CreateRiskAlertParams) (db.RiskAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRiskAlert", ctx, arg)
+	ret0, _ := ret[0].(db.RiskAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRiskAlert indicates an expected call of CreateRiskAlert.
+func (mr *MockStoreMockRecorder) CreateRiskAlert(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRiskAlert", reflect.TypeOf((*MockStore)(nil).CreateRiskAlert), ctx, arg)
+}
+
+// CreateScheduledTransfer mocks base method.
+func (m *MockStore) CreateScheduledTransfer(ctx context.Context, arg db.CreateScheduledTransferParams) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledTransfer", ctx, arg)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScheduledTransfer indicates an expected call of CreateScheduledTransfer.
+func (mr *MockStoreMockRecorder) CreateScheduledTransfer(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledTransfer", reflect.TypeOf((*MockStore)(nil).CreateScheduledTransfer), ctx, arg)
+}
+
+// CreateScreeningCase mocks base method.
+func (m *MockStore) CreateScreeningCase(ctx context.Context, arg db.CreateScreeningCaseParams) (db.ScreeningCase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScreeningCase", ctx, arg)
+	ret0, _ := ret[0].(db.ScreeningCase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScreeningCase indicates an expected call of CreateScreeningCase.
+func (mr *MockStoreMockRecorder) CreateScreeningCase(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScreeningCase", reflect.TypeOf((*MockStore)(nil).CreateScreeningCase), ctx, arg)
+}
+
+// CreateStandingOrder mocks base method.
+func (m *MockStore) CreateStandingOrder(ctx context.Context, arg db.CreateStandingOrderParams) (db.StandingOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateStandingOrder", ctx, arg)
+	ret0, _ := ret[0].(db.StandingOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateStandingOrder indicates an expected call of CreateStandingOrder.
+func (mr *MockStoreMockRecorder) CreateStandingOrder(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStandingOrder", reflect.TypeOf((*MockStore)(nil).CreateStandingOrder), ctx, arg)
+}
+
+// CreateTransfer mocks base method.
+func (m *MockStore) CreateTransfer(ctx context.Context, arg db.CreateTransferParams) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransfer", ctx, arg)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransfer indicates an expected call of CreateTransfer.
+func (mr *MockStoreMockRecorder) CreateTransfer(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), ctx, arg)
+}
+
+// CreateTransferApproval mocks base method.
+func (m *MockStore) CreateTransferApproval(ctx context.Context, arg db.CreateTransferApprovalParams) (db.TransferApproval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransferApproval", ctx, arg)
+	ret0, _ := ret[0].(db.TransferApproval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransferApproval indicates an expected call of CreateTransferApproval.
+func (mr *MockStoreMockRecorder) CreateTransferApproval(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransferApproval", reflect.TypeOf((*MockStore)(nil).CreateTransferApproval), ctx, arg)
+}
+
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), ctx, arg)
+}
+
+// CreateUserTx mocks base method.
+func (m *MockStore) CreateUserTx(ctx context.Context, arg db.CreateUserTxParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserTx", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserTx indicates an expected call of CreateUserTx.
+func (mr *MockStoreMockRecorder) CreateUserTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserTx", reflect.TypeOf((*MockStore)(nil).CreateUserTx), ctx, arg)
+}
+
+// CreateWebhookDeliveries mocks base method.
+func (m *MockStore) CreateWebhookDeliveries(ctx context.Context, arg db.CreateWebhookDeliveriesParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDeliveries", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookDeliveries indicates an expected call of CreateWebhookDeliveries.
+func (mr *MockStoreMockRecorder) CreateWebhookDeliveries(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).CreateWebhookDeliveries), ctx, arg)
+}
+
+// CreateWebhookEndpoint mocks base method.
+func (m *MockStore) CreateWebhookEndpoint(ctx context.Context, arg db.CreateWebhookEndpointParams) (db.WebhookEndpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookEndpoint", ctx, arg)
+	ret0, _ := ret[0].(db.WebhookEndpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookEndpoint indicates an expected call of CreateWebhookEndpoint.
+func (mr *MockStoreMockRecorder) CreateWebhookEndpoint(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookEndpoint", reflect.TypeOf((*MockStore)(nil).CreateWebhookEndpoint), ctx, arg)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockStore) DeleteAccount(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockStoreMockRecorder) DeleteAccount(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), ctx, id)
+}
+
+// DeleteIdleRateLimitBuckets mocks base method.
+func (m *MockStore) DeleteIdleRateLimitBuckets(ctx context.Context, idleSince time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdleRateLimitBuckets", ctx, idleSince)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteIdleRateLimitBuckets indicates an expected call of DeleteIdleRateLimitBuckets.
+func (mr *MockStoreMockRecorder) DeleteIdleRateLimitBuckets(ctx, idleSince any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdleRateLimitBuckets", reflect.TypeOf((*MockStore)(nil).DeleteIdleRateLimitBuckets), ctx, idleSince)
+}
+
+// DeleteLoginFailures mocks base method.
+func (m *MockStore) DeleteLoginFailures(ctx context.Context, arg db.DeleteLoginFailuresParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginFailures", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteLoginFailures indicates an expected call of DeleteLoginFailures.
+func (mr *MockStoreMockRecorder) DeleteLoginFailures(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginFailures", reflect.TypeOf((*MockStore)(nil).DeleteLoginFailures), ctx, arg)
+}
+
+// DeleteRecoveryCodes mocks base method.
+func (m *MockStore) DeleteRecoveryCodes(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecoveryCodes", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecoveryCodes indicates an expected call of DeleteRecoveryCodes.
+func (mr *MockStoreMockRecorder) DeleteRecoveryCodes(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecoveryCodes", reflect.TypeOf((*MockStore)(nil).DeleteRecoveryCodes), ctx, username)
+}
+
+// DeleteWebhookEndpoint mocks base method.
+func (m *MockStore) DeleteWebhookEndpoint(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookEndpoint", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhookEndpoint indicates an expected call of DeleteWebhookEndpoint.
+func (mr *MockStoreMockRecorder) DeleteWebhookEndpoint(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookEndpoint", reflect.TypeOf((*MockStore)(nil).DeleteWebhookEndpoint), ctx, id)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockStore) DisableTOTP(ctx context.Context, username string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", ctx, username)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockStoreMockRecorder) DisableTOTP(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockStore)(nil).DisableTOTP), ctx, username)
+}
+
+// DisableTOTPTx mocks base method.
+func (m *MockStore) DisableTOTPTx(ctx context.Context, arg db.VerifySecondFactorTxParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTPTx", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableTOTPTx indicates an expected call of DisableTOTPTx.
+func (mr *MockStoreMockRecorder) DisableTOTPTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTPTx", reflect.TypeOf((*MockStore)(nil).DisableTOTPTx), ctx, arg)
+}
+
+// EnableTOTP mocks base method.
+func (m *MockStore) EnableTOTP(ctx context.Context, arg db.EnableTOTPParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTOTP", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableTOTP indicates an expected call of EnableTOTP.
+func (mr *MockStoreMockRecorder) EnableTOTP(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTOTP", reflect.TypeOf((*MockStore)(nil).EnableTOTP), ctx, arg)
+}
+
+// ExecuteScheduledTransferTx mocks base method.
+func (m *MockStore) ExecuteScheduledTransferTx(ctx context.Context) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteScheduledTransferTx", ctx)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteScheduledTransferTx indicates an expected call of ExecuteScheduledTransferTx.
+func (mr *MockStoreMockRecorder) ExecuteScheduledTransferTx(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteScheduledTransferTx", reflect.TypeOf((*MockStore)(nil).ExecuteScheduledTransferTx), ctx)
+}
+
+// ExecuteStandingOrderTx mocks base method.
+func (m *MockStore) ExecuteStandingOrderTx(ctx context.Context) (db.ExecuteStandingOrderTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteStandingOrderTx", ctx)
+	ret0, _ := ret[0].(db.ExecuteStandingOrderTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteStandingOrderTx indicates an expected call of ExecuteStandingOrderTx.
+func (mr *MockStoreMockRecorder) ExecuteStandingOrderTx(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteStandingOrderTx", reflect.TypeOf((*MockStore)(nil).ExecuteStandingOrderTx), ctx)
+}
+
+// ExpireTransferApprovals mocks base method.
+func (m *MockStore) ExpireTransferApprovals(ctx context.Context) ([]db.TransferApproval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireTransferApprovals", ctx)
+	ret0, _ := ret[0].([]db.TransferApproval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireTransferApprovals indicates an expected call of ExpireTransferApprovals.
+func (mr *MockStoreMockRecorder) ExpireTransferApprovals(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireTransferApprovals", reflect.TypeOf((*MockStore)(nil).ExpireTransferApprovals), ctx)
+}
+
+// FreezeAccount mocks base method.
+func (m *MockStore) FreezeAccount(ctx context.Context, id int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FreezeAccount", ctx, id)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FreezeAccount indicates an expected call of FreezeAccount.
+func (mr *MockStoreMockRecorder) FreezeAccount(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreezeAccount", reflect.TypeOf((*MockStore)(nil).FreezeAccount), ctx, id)
+}
+
+// FreezeAccountTx mocks base method.
+func (m *MockStore) FreezeAccountTx(ctx context.Context, id int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FreezeAccountTx", ctx, id)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FreezeAccountTx indicates an expected call of FreezeAccountTx.
+func (mr *MockStoreMockRecorder) FreezeAccountTx(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreezeAccountTx", reflect.TypeOf((*MockStore)(nil).FreezeAccountTx), ctx, id)
+}
+
+// GetAPIKey mocks base method.
+func (m *MockStore) GetAPIKey(ctx context.Context, id int64) (db.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKey", ctx, id)
+	ret0, _ := ret[0].(db.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey.
+func (mr *MockStoreMockRecorder) GetAPIKey(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockStore)(nil).GetAPIKey), ctx, id)
+}
+
+// GetAPIKeyCaller mocks base method.
+func (m *MockStore) GetAPIKeyCaller(ctx context.Context, prefix string) (db.GetAPIKeyCallerRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyCaller", ctx, prefix)
+	ret0, _ := ret[0].(db.GetAPIKeyCallerRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyCaller indicates an expected call of GetAPIKeyCaller.
+func (mr *MockStoreMockRecorder) GetAPIKeyCaller(ctx, prefix any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyCaller", reflect.TypeOf((*MockStore)(nil).GetAPIKeyCaller), ctx, prefix)
+}
+
+// GetAPIKeyForUpdate mocks base method.
+func (m *MockStore) GetAPIKeyForUpdate(ctx context.Context, id int64) (db.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyForUpdate", ctx, id)
+	ret0, _ := ret[0].(db.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyForUpdate indicates an expected call of GetAPIKeyForUpdate.
+func (mr *MockStoreMockRecorder) GetAPIKeyForUpdate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyForUpdate", reflect.TypeOf((*MockStore)(nil).GetAPIKeyForUpdate), ctx, id)
+}
+
+// GetAccount mocks base method.
+func (m *MockStore) GetAccount(ctx context.Context, id int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", ctx, id)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockStoreMockRecorder) GetAccount(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), ctx, id)
+}
+
+// GetAccountsForUpdate mocks base method.
+func (m *MockStore) GetAccountsForUpdate(ctx context.Context, id int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountsForUpdate", ctx, id)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountsForUpdate indicates an expected call of GetAccountsForUpdate.
+func (mr *MockStoreMockRecorder) GetAccountsForUpdate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsForUpdate", reflect.TypeOf((*MockStore)(nil).GetAccountsForUpdate), ctx, id)
+}
+
+// GetEmailTokenForUpdate mocks base method.
+func (m *MockStore) GetEmailTokenForUpdate(ctx context.Context, tokenHash string) (db.EmailToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmailTokenForUpdate", ctx, tokenHash)
+	ret0, _ := ret[0].(db.EmailToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmailTokenForUpdate indicates an expected call of GetEmailTokenForUpdate.
+func (mr *MockStoreMockRecorder) GetEmailTokenForUpdate(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailTokenForUpdate", reflect.TypeOf((*MockStore)(nil).GetEmailTokenForUpdate), ctx, tokenHash)
+}
+
+// GetEntry mocks base method.
+func (m *MockStore) GetEntry(ctx context.Context, id int64) (db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntry", ctx, id)
+	ret0, _ := ret[0].(db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEntry indicates an expected call of GetEntry.
+func (mr *MockStoreMockRecorder) GetEntry(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), ctx, id)
+}
+
+// GetJob mocks base method.
+func (m *MockStore) GetJob(ctx context.Context, id int64) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", ctx, id)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockStoreMockRecorder) GetJob(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockStore)(nil).GetJob), ctx, id)
+}
+
+// GetLoginChallengeForUpdate mocks base method.
+func (m *MockStore) GetLoginChallengeForUpdate(ctx context.Context, id uuid.UUID) (db.LoginChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginChallengeForUpdate", ctx, id)
+	ret0, _ := ret[0].(db.LoginChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginChallengeForUpdate indicates an expected call of GetLoginChallengeForUpdate.
+func (mr *MockStoreMockRecorder) GetLoginChallengeForUpdate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginChallengeForUpdate", reflect.TypeOf((*MockStore)(nil).GetLoginChallengeForUpdate), ctx, id)
+}
+
+// GetLoginFailureForUpdate mocks base method.
+func (m *MockStore) GetLoginFailureForUpdate(ctx context.Context, arg db.GetLoginFailureForUpdateParams) (db.LoginFailure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginFailureForUpdate", ctx, arg)
+	ret0, _ := ret[0].(db.LoginFailure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginFailureForUpdate indicates an expected call of GetLoginFailureForUpdate.
+func (mr *MockStoreMockRecorder) GetLoginFailureForUpdate(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginFailureForUpdate", reflect.TypeOf((*MockStore)(nil).GetLoginFailureForUpdate), ctx, arg)
+}
+
+// GetOwnerTransferLimits mocks base method.
+func (m *MockStore) GetOwnerTransferLimits(ctx context.Context, arg db.GetOwnerTransferLimitsParams) (db.GetOwnerTransferLimitsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnerTransferLimits", ctx, arg)
+	ret0, _ := ret[0].(db.GetOwnerTransferLimitsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOwnerTransferLimits indicates an expected call of GetOwnerTransferLimits.
+func (mr *MockStoreMockRecorder) GetOwnerTransferLimits(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnerTransferLimits", reflect.TypeOf((*MockStore)(nil).GetOwnerTransferLimits), ctx, arg)
+}
+
+// GetRiskAlert mocks base method.
+func (m *MockStore) GetRiskAlert(ctx context.Context, id int64) (db.RiskAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRiskAlert", ctx, id)
+	ret0, _ := ret[0].(db.RiskAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRiskAlert indicates an expected call of GetRiskAlert.
+func (mr *MockStoreMockRecorder) GetRiskAlert(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRiskAlert", reflect.TypeOf((*MockStore)(nil).GetRiskAlert), ctx, id)
+}
+
+// GetScheduledTransfer mocks base method.
+func (m *MockStore) GetScheduledTransfer(ctx context.Context, id int64) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTransfer", ctx, id)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledTransfer indicates an expected call of GetScheduledTransfer.
+func (mr *MockStoreMockRecorder) GetScheduledTransfer(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTransfer", reflect.TypeOf((*MockStore)(nil).GetScheduledTransfer), ctx, id)
+}
+
+// GetScreeningCase mocks base method.
+func (m *MockStore) GetScreeningCase(ctx context.Context, id int64) (db.ScreeningCase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScreeningCase", ctx, id)
+	ret0, _ := ret[0].(db.ScreeningCase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScreeningCase indicates an expected call of GetScreeningCase.
+func (mr *MockStoreMockRecorder) GetScreeningCase(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScreeningCase", reflect.TypeOf((*MockStore)(nil).GetScreeningCase), ctx, id)
+}
+
+// GetStandingOrder mocks base method.
+func (m *MockStore) GetStandingOrder(ctx context.Context, id int64) (db.StandingOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStandingOrder", ctx, id)
+	ret0, _ := ret[0].(db.StandingOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStandingOrder indicates an expected call of GetStandingOrder.
+func (mr *MockStoreMockRecorder) GetStandingOrder(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStandingOrder", reflect.TypeOf((*MockStore)(nil).GetStandingOrder), ctx, id)
+}
+
+// GetTransfer mocks base method.
+func (m *MockStore) GetTransfer(ctx context.Context, id int64) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfer", ctx, id)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransfer indicates an expected call of GetTransfer.
+func (mr *MockStoreMockRecorder) GetTransfer(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), ctx, id)
+}
+
+// GetTransferApproval mocks base method.
+func (m *MockStore) GetTransferApproval(ctx context.Context, id int64) (db.TransferApproval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferApproval", ctx, id)
+	ret0, _ := ret[0].(db.TransferApproval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferApproval indicates an expected call of GetTransferApproval.
+func (mr *MockStoreMockRecorder) GetTransferApproval(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferApproval", reflect.TypeOf((*MockStore)(nil).GetTransferApproval), ctx, id)
+}
+
+// GetTransferApprovalForUpdate mocks base method.
+func (m *MockStore) GetTransferApprovalForUpdate(ctx context.Context, id int64) (db.TransferApproval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferApprovalForUpdate", ctx, id)
+	ret0, _ := ret[0].(db.TransferApproval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferApprovalForUpdate indicates an expected call of GetTransferApprovalForUpdate.
+func (mr *MockStoreMockRecorder) GetTransferApprovalForUpdate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferApprovalForUpdate", reflect.TypeOf((*MockStore)(nil).GetTransferApprovalForUpdate), ctx, id)
+}
+
+// GetTransferLimitUsage mocks base method.
+func (m *MockStore) GetTransferLimitUsage(ctx context.Context, accountID int64) (db.TransferLimitUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferLimitUsage", ctx, accountID)
+	ret0, _ := ret[0].(db.TransferLimitUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferLimitUsage indicates an expected call of GetTransferLimitUsage.
+func (mr *MockStoreMockRecorder) GetTransferLimitUsage(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferLimitUsage", reflect.TypeOf((*MockStore)(nil).GetTransferLimitUsage), ctx, accountID)
+}
+
+// GetTransferLimits mocks base method.
+func (m *MockStore) GetTransferLimits(ctx context.Context, id int64) (db.GetTransferLimitsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferLimits", ctx, id)
+	ret0, _ := ret[0].(db.GetTransferLimitsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferLimits indicates an expected call of GetTransferLimits.
+func (mr *MockStoreMockRecorder) GetTransferLimits(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferLimits", reflect.TypeOf((*MockStore)(nil).GetTransferLimits), ctx, id)
+}
+
+// GetUser mocks base method.
+func (m *MockStore) GetUser(ctx context.Context, username string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStoreMockRecorder) GetUser(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), ctx, username)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockStore) GetUserByEmail(ctx context.Context, email string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, email)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockStoreMockRecorder) GetUserByEmail(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockStore)(nil).GetUserByEmail), ctx, email)
+}
+
+// GetUserForUpdate mocks base method.
+func (m *MockStore) GetUserForUpdate(ctx context.Context, username string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserForUpdate", ctx, username)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserForUpdate indicates an expected call of GetUserForUpdate.
+func (mr *MockStoreMockRecorder) GetUserForUpdate(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserForUpdate", reflect.TypeOf((*MockStore)(nil).GetUserForUpdate), ctx, username)
+}
+
+// GetWebhookDelivery mocks base method.
+func (m *MockStore) GetWebhookDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDelivery", ctx, id)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDelivery indicates an expected call of GetWebhookDelivery.
+func (mr *MockStoreMockRecorder) GetWebhookDelivery(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDelivery", reflect.TypeOf((*MockStore)(nil).GetWebhookDelivery), ctx, id)
+}
+
+// GetWebhookEndpoint mocks base method.
+func (m *MockStore) GetWebhookEndpoint(ctx context.Context, id int64) (db.WebhookEndpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookEndpoint", ctx, id)
+	ret0, _ := ret[0].(db.WebhookEndpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookEndpoint indicates an expected call of GetWebhookEndpoint.
+func (mr *MockStoreMockRecorder) GetWebhookEndpoint(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookEndpoint", reflect.TypeOf((*MockStore)(nil).GetWebhookEndpoint), ctx, id)
+}
+
+// ListAPIKeys mocks base method.
+func (m *MockStore) ListAPIKeys(ctx context.Context, username string) ([]db.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeys", ctx, username)
+	ret0, _ := ret[0].([]db.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockStoreMockRecorder) ListAPIKeys(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockStore)(nil).ListAPIKeys), ctx, username)
+}
+
+// ListAccountEvents mocks base method.
+func (m *MockStore) ListAccountEvents(ctx context.Context, arg db.ListAccountEventsParams) ([]db.AccountEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountEvents", ctx, arg)
+	ret0, _ := ret[0].([]db.AccountEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountEvents indicates an expected call of ListAccountEvents.
+func (mr *MockStoreMockRecorder) ListAccountEvents(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEvents", reflect.TypeOf((*MockStore)(nil).ListAccountEvents), ctx, arg)
+}
+
+// ListAccounts mocks base method.
+func (m *MockStore) ListAccounts(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccounts", ctx, arg)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccounts indicates an expected call of ListAccounts.
+func (mr *MockStoreMockRecorder) ListAccounts(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), ctx, arg)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockStore) ListAuditEvents(ctx context.Context, arg db.ListAuditEventsParams) ([]db.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, arg)
+	ret0, _ := ret[0].([]db.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockStoreMockRecorder) ListAuditEvents(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockStore)(nil).ListAuditEvents), ctx, arg)
+}
+
+// ListBalanceAdjustments mocks base method.
+func (m *MockStore) ListBalanceAdjustments(ctx context.Context, arg db.ListBalanceAdjustmentsParams) ([]db.BalanceAdjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBalanceAdjustments", ctx, arg)
+	ret0, _ := ret[0].([]db.BalanceAdjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBalanceAdjustments indicates an expected call of ListBalanceAdjustments.
+func (mr *MockStoreMockRecorder) ListBalanceAdjustments(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBalanceAdjustments", reflect.TypeOf((*MockStore)(nil).ListBalanceAdjustments), ctx, arg)
+}
+
+// ListBlockedOwners mocks base method.
+func (m *MockStore) ListBlockedOwners(ctx context.Context, arg db.ListBlockedOwnersParams) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBlockedOwners", ctx, arg)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBlockedOwners indicates an expected call of ListBlockedOwners.
+func (mr *MockStoreMockRecorder) ListBlockedOwners(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBlockedOwners", reflect.TypeOf((*MockStore)(nil).ListBlockedOwners), ctx, arg)
+}
+
+// ListEntries mocks base method.
+func (m *MockStore) ListEntries(ctx context.Context, arg db.ListEntriesParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEntries", ctx, arg)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEntries indicates an expected call of ListEntries.
+func (mr *MockStoreMockRecorder) ListEntries(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockStore)(nil).ListEntries), ctx, arg)
+}
+
+// ListKYCDocuments mocks base method.
+func (m *MockStore) ListKYCDocuments(ctx context.Context, username string) ([]db.KycDocument, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKYCDocuments", ctx, username)
+	ret0, _ := ret[0].([]db.KycDocument)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKYCDocuments indicates an expected call of ListKYCDocuments.
+func (mr *MockStoreMockRecorder) ListKYCDocuments(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKYCDocuments", reflect.TypeOf((*MockStore)(nil).ListKYCDocuments), ctx, username)
+}
+
+// ListKYCEvents mocks base method.
+func (m *MockStore) ListKYCEvents(ctx context.Context, username string) ([]db.KycEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKYCEvents", ctx, username)
+	ret0, _ := ret[0].([]db.KycEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKYCEvents indicates an expected call of ListKYCEvents.
+func (mr *MockStoreMockRecorder) ListKYCEvents(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKYCEvents", reflect.TypeOf((*MockStore)(nil).ListKYCEvents), ctx, username)
+}
+
+// ListLoginFailures mocks base method.
+func (m *MockStore) ListLoginFailures(ctx context.Context, arg db.ListLoginFailuresParams) ([]db.LoginFailure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoginFailures", ctx, arg)
+	ret0, _ := ret[0].([]db.LoginFailure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoginFailures indicates an expected call of ListLoginFailures.
+func (mr *MockStoreMockRecorder) ListLoginFailures(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginFailures", reflect.TypeOf((*MockStore)(nil).ListLoginFailures), ctx, arg)
+}
+
+// ListPendingTransferApprovals mocks base method.
+func (m *MockStore) ListPendingTransferApprovals(ctx context.Context, arg db.ListPendingTransferApprovalsParams) ([]db.TransferApproval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingTransferApprovals", ctx, arg)
+	ret0, _ := ret[0].([]db.TransferApproval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingTransferApprovals indicates an expected call of ListPendingTransferApprovals.
+func (mr *MockStoreMockRecorder) ListPendingTransferApprovals(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingTransferApprovals", reflect.TypeOf((*MockStore)(nil).ListPendingTransferApprovals), ctx, arg)
+}
+
+// ListRiskAlerts mocks base method.
+func (m *MockStore) ListRiskAlerts(ctx context.Context, arg db.ListRiskAlertsParams) ([]db.RiskAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRiskAlerts", ctx, arg)
+	ret0, _ := ret[0].([]db.RiskAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRiskAlerts indicates an expected call of ListRiskAlerts.
+func (mr *MockStoreMockRecorder) ListRiskAlerts(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRiskAlerts", reflect.TypeOf((*MockStore)(nil).ListRiskAlerts), ctx, arg)
+}
+
+// ListRiskTransfers mocks base method.
+func (m *MockStore) ListRiskTransfers(ctx context.Context, arg db.ListRiskTransfersParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRiskTransfers", ctx, arg)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRiskTransfers indicates an expected call of ListRiskTransfers.
+func (mr *MockStoreMockRecorder) ListRiskTransfers(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRiskTransfers", reflect.TypeOf((*MockStore)(nil).ListRiskTransfers), ctx, arg)
+}
+
+// ListScheduledTransfers mocks base method.
+func (m *MockStore) ListScheduledTransfers(ctx context.Context, arg db.ListScheduledTransfersParams) ([]db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTransfers", ctx, arg)
+	ret0, _ := ret[0].([]db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledTransfers indicates an expected call of ListScheduledTransfers.
+func (mr *MockStoreMockRecorder) ListScheduledTransfers(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransfers", reflect.TypeOf((*MockStore)(nil).ListScheduledTransfers), ctx, arg)
+}
+
+// ListScreeningCases mocks base method.
+func (m *MockStore) ListScreeningCases(ctx context.Context, arg db.ListScreeningCasesParams) ([]db.ScreeningCase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScreeningCases", ctx, arg)
+	ret0, _ := ret[0].([]db.ScreeningCase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScreeningCases indicates an expected call of ListScreeningCases.
+func (mr *MockStoreMockRecorder) ListScreeningCases(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScreeningCases", reflect.TypeOf((*MockStore)(nil).ListScreeningCases), ctx, arg)
+}
+
+// ListStandingOrderOccurrences mocks base method.
+func (m *MockStore) ListStandingOrderOccurrences(ctx context.Context, arg db.ListStandingOrderOccurrencesParams) ([]db.StandingOrderOccurrence, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStandingOrderOccurrences", ctx, arg)
+	ret0, _ := ret[0].([]db.StandingOrderOccurrence)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStandingOrderOccurrences indicates an expected call of ListStandingOrderOccurrences.
+func (mr *MockStoreMockRecorder) ListStandingOrderOccurrences(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStandingOrderOccurrences", reflect.TypeOf((*MockStore)(nil).ListStandingOrderOccurrences), ctx, arg)
+}
+
+// ListStandingOrders mocks base method.
+func (m *MockStore) ListStandingOrders(ctx context.Context, arg db.ListStandingOrdersParams) ([]db.StandingOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStandingOrders", ctx, arg)
+	ret0, _ := ret[0].([]db.StandingOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStandingOrders indicates an expected call of ListStandingOrders.
+func (mr *MockStoreMockRecorder) ListStandingOrders(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStandingOrders", reflect.TypeOf((*MockStore)(nil).ListStandingOrders), ctx, arg)
+}
+
+// ListTransferApprovals mocks base method.
+func (m *MockStore) ListTransferApprovals(ctx context.Context, arg db.ListTransferApprovalsParams) ([]db.TransferApproval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransferApprovals", ctx, arg)
+	ret0, _ := ret[0].([]db.TransferApproval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransferApprovals indicates an expected call of ListTransferApprovals.
+func (mr *MockStoreMockRecorder) ListTransferApprovals(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransferApprovals", reflect.TypeOf((*MockStore)(nil).ListTransferApprovals), ctx, arg)
+}
+
+// ListTransfers mocks base method.
+func (m *MockStore) ListTransfers(ctx context.Context, arg db.ListTransfersParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfers", ctx, arg)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransfers indicates an expected call of ListTransfers.
+func (mr *MockStoreMockRecorder) ListTransfers(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), ctx, arg)
+}
+
+// ListUsersByKYCStatus mocks base method.
+func (m *MockStore) ListUsersByKYCStatus(ctx context.Context, arg db.ListUsersByKYCStatusParams) ([]db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsersByKYCStatus", ctx, arg)
+	ret0, _ := ret[0].([]db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsersByKYCStatus indicates an expected call of ListUsersByKYCStatus.
+func (mr *MockStoreMockRecorder) ListUsersByKYCStatus(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsersByKYCStatus", reflect.TypeOf((*MockStore)(nil).ListUsersByKYCStatus), ctx, arg)
+}
+
+// ListUsersForScreening mocks base method.
+func (m *MockStore) ListUsersForScreening(ctx context.Context, arg db.ListUsersForScreeningParams) ([]db.ListUsersForScreeningRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsersForScreening", ctx, arg)
+	ret0, _ := ret[0].([]db.ListUsersForScreeningRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsersForScreening indicates an expected call of ListUsersForScreening.
+func (mr *MockStoreMockRecorder) ListUsersForScreening(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsersForScreening", reflect.TypeOf((*MockStore)(nil).ListUsersForScreening), ctx, arg)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockStore) ListWebhookDeliveries(ctx context.Context, arg db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, arg)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ListWebhookDeliveries(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ListWebhookDeliveries), ctx, arg)
+}
+
+// ListWebhookEndpoints mocks base method.
+func (m *MockStore) ListWebhookEndpoints(ctx context.Context, owner string) ([]db.WebhookEndpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookEndpoints", ctx, owner)
+	ret0, _ := ret[0].([]db.WebhookEndpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookEndpoints indicates an expected call of ListWebhookEndpoints.
+func (mr *MockStoreMockRecorder) ListWebhookEndpoints(ctx, owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookEndpoints", reflect.TypeOf((*MockStore)(nil).ListWebhookEndpoints), ctx, owner)
+}
+
+// LockLoginSubject mocks base method.
+func (m *MockStore) LockLoginSubject(ctx context.Context, arg db.LockLoginSubjectParams) (db.LoginFailure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLoginSubject", ctx, arg)
+	ret0, _ := ret[0].(db.LoginFailure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockLoginSubject indicates an expected call of LockLoginSubject.
+func (mr *MockStoreMockRecorder) LockLoginSubject(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLoginSubject", reflect.TypeOf((*MockStore)(nil).LockLoginSubject), ctx, arg)
+}
+
+// MarkJobFailed mocks base method.
+func (m *MockStore) MarkJobFailed(ctx context.Context, arg db.MarkJobFailedParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkJobFailed", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkJobFailed indicates an expected call of MarkJobFailed.
+func (mr *MockStoreMockRecorder) MarkJobFailed(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkJobFailed", reflect.TypeOf((*MockStore)(nil).MarkJobFailed), ctx, arg)
+}
+
+// MarkJobSucceeded mocks base method.
+func (m *MockStore) MarkJobSucceeded(ctx context.Context, arg db.MarkJobSucceededParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkJobSucceeded", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkJobSucceeded indicates an expected call of MarkJobSucceeded.
+func (mr *MockStoreMockRecorder) MarkJobSucceeded(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkJobSucceeded", reflect.TypeOf((*MockStore)(nil).MarkJobSucceeded), ctx, arg)
+}
+
+// MarkOutboxEventFailed mocks base method.
+func (m *MockStore) MarkOutboxEventFailed(ctx context.Context, arg db.MarkOutboxEventFailedParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxEventFailed", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxEventFailed indicates an expected call of MarkOutboxEventFailed.
+func (mr *MockStoreMockRecorder) MarkOutboxEventFailed(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxEventFailed", reflect.TypeOf((*MockStore)(nil).MarkOutboxEventFailed), ctx, arg)
+}
+
+// MarkOutboxEventSent mocks base method.
+func (m *MockStore) MarkOutboxEventSent(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxEventSent", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxEventSent indicates an expected call of MarkOutboxEventSent.
+func (mr *MockStoreMockRecorder) MarkOutboxEventSent(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxEventSent", reflect.TypeOf((*MockStore)(nil).MarkOutboxEventSent), ctx, id)
+}
+
+// MarkScheduledTransferFailed mocks base method.
+func (m *MockStore) MarkScheduledTransferFailed(ctx context.Context, arg db.MarkScheduledTransferFailedParams) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkScheduledTransferFailed", ctx, arg)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkScheduledTransferFailed indicates an expected call of MarkScheduledTransferFailed.
+func (mr *MockStoreMockRecorder) MarkScheduledTransferFailed(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkScheduledTransferFailed", reflect.TypeOf((*MockStore)(nil).MarkScheduledTransferFailed), ctx, arg)
+}
+
+// MarkScheduledTransferSucceeded mocks base method.
+func (m *MockStore) MarkScheduledTransferSucceeded(ctx context.Context, arg db.MarkScheduledTransferSucceededParams) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkScheduledTransferSucceeded", ctx, arg)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkScheduledTransferSucceeded indicates an expected call of MarkScheduledTransferSucceeded.
+func (mr *MockStoreMockRecorder) MarkScheduledTransferSucceeded(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkScheduledTransferSucceeded", reflect.TypeOf((*MockStore)(nil).MarkScheduledTransferSucceeded), ctx, arg)
+}
+
+// MarkWebhookDeliveryFailed mocks base method.
+func (m *MockStore) MarkWebhookDeliveryFailed(ctx context.Context, arg db.MarkWebhookDeliveryFailedParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWebhookDeliveryFailed", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWebhookDeliveryFailed indicates an expected call of MarkWebhookDeliveryFailed.
+func (mr *MockStoreMockRecorder) MarkWebhookDeliveryFailed(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWebhookDeliveryFailed", reflect.TypeOf((*MockStore)(nil).MarkWebhookDeliveryFailed), ctx, arg)
+}
+
+// MarkWebhookDeliverySucceeded mocks base method.
+func (m *MockStore) MarkWebhookDeliverySucceeded(ctx context.Context, arg db.MarkWebhookDeliverySucceededParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWebhookDeliverySucceeded", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWebhookDeliverySucceeded indicates an expected call of MarkWebhookDeliverySucceeded.
+func (mr *MockStoreMockRecorder) MarkWebhookDeliverySucceeded(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWebhookDeliverySucceeded", reflect.TypeOf((*MockStore)(nil).MarkWebhookDeliverySucceeded), ctx, arg)
+}
+
+// MigrationVersion mocks base method.
+func (m *MockStore) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrationVersion", ctx)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// MigrationVersion indicates an expected call of MigrationVersion.
+func (mr *MockStoreMockRecorder) MigrationVersion(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrationVersion", reflect.TypeOf((*MockStore)(nil).MigrationVersion), ctx)
+}
+
+// Ping mocks base method.
+func (m *MockStore) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStoreMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStore)(nil).Ping), ctx)
+}
+
+// RecordLoginFailureTx mocks base method.
+func (m *MockStore) RecordLoginFailureTx(ctx context.Context, arg db.RecordLoginFailureTxParams) (db.RecordLoginFailureTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailureTx", ctx, arg)
+	ret0, _ := ret[0].(db.RecordLoginFailureTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailureTx indicates an expected call of RecordLoginFailureTx.
+func (mr *MockStoreMockRecorder) RecordLoginFailureTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailureTx", reflect.TypeOf((*MockStore)(nil).RecordLoginFailureTx), ctx, arg)
+}
+
+// RecordScreeningTx mocks base method.
+func (m *MockStore) RecordScreeningTx(ctx context.Context, username string, cases []db.CreateScreeningCaseParams) (db.User, []db.ScreeningCase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordScreeningTx", ctx, username, cases)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].([]db.ScreeningCase)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RecordScreeningTx indicates an expected call of RecordScreeningTx.
+func (mr *MockStoreMockRecorder) RecordScreeningTx(ctx, username, cases any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordScreeningTx", reflect.TypeOf((*MockStore)(nil).RecordScreeningTx), ctx, username, cases)
+}
+
+// RedeliverWebhookDelivery mocks base method.
+func (m *MockStore) RedeliverWebhookDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeliverWebhookDelivery", ctx, id)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeliverWebhookDelivery indicates an expected call of RedeliverWebhookDelivery.
+func (mr *MockStoreMockRecorder) RedeliverWebhookDelivery(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeliverWebhookDelivery", reflect.TypeOf((*MockStore)(nil).RedeliverWebhookDelivery), ctx, id)
+}
+
+// RefreshSanctionsStatus mocks base method.
+func (m *MockStore) RefreshSanctionsStatus(ctx context.Context, username string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshSanctionsStatus", ctx, username)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshSanctionsStatus indicates an expected call of RefreshSanctionsStatus.
+func (mr *MockStoreMockRecorder) RefreshSanctionsStatus(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshSanctionsStatus", reflect.TypeOf((*MockStore)(nil).RefreshSanctionsStatus), ctx, username)
+}
+
+// RejectTransfer mocks base method.
+func (m *MockStore) RejectTransfer(ctx context.Context, arg db.RejectTransferParams) (db.TransferApproval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectTransfer", ctx, arg)
+	ret0, _ := ret[0].(db.TransferApproval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectTransfer indicates an expected call of RejectTransfer.
+func (mr *MockStoreMockRecorder) RejectTransfer(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectTransfer", reflect.TypeOf((*MockStore)(nil).RejectTransfer), ctx, arg)
+}
+
+// ResetPasswordTx mocks base method.
+func (m *MockStore) ResetPasswordTx(ctx context.Context, arg db.ResetPasswordTxParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPasswordTx", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPasswordTx indicates an expected call of ResetPasswordTx.
+func (mr *MockStoreMockRecorder) ResetPasswordTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPasswordTx", reflect.TypeOf((*MockStore)(nil).ResetPasswordTx), ctx, arg)
+}
+
+// ResolveRiskAlert mocks base method.
+func (m *MockStore) ResolveRiskAlert(ctx context.Context, arg db.ResolveRiskAlertParams) (db.RiskAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveRiskAlert", ctx, arg)
+	ret0, _ := ret[0].(db.RiskAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveRiskAlert indicates an expected call of ResolveRiskAlert.
+func (mr *MockStoreMockRecorder) ResolveRiskAlert(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveRiskAlert", reflect.TypeOf((*MockStore)(nil).ResolveRiskAlert), ctx, arg)
+}
+
+// ResolveRiskAlertTx mocks base method.
+func (m *MockStore) ResolveRiskAlertTx(ctx context.Context, arg db.ResolveRiskAlertParams) (db.RiskAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveRiskAlertTx", ctx, arg)
+	ret0, _ := ret[0].(db.RiskAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveRiskAlertTx indicates an expected call of ResolveRiskAlertTx.
+func (mr *MockStoreMockRecorder) ResolveRiskAlertTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveRiskAlertTx", reflect.TypeOf((*MockStore)(nil).ResolveRiskAlertTx), ctx, arg)
+}
+
+// ResolveScreeningCase mocks base method.
+func (m *MockStore) ResolveScreeningCase(ctx context.Context, arg db.ResolveScreeningCaseParams) (db.ScreeningCase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveScreeningCase", ctx, arg)
+	ret0, _ := ret[0].(db.ScreeningCase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveScreeningCase indicates an expected call of ResolveScreeningCase.
+func (mr *MockStoreMockRecorder) ResolveScreeningCase(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveScreeningCase", reflect.TypeOf((*MockStore)(nil).ResolveScreeningCase), ctx, arg)
+}
+
+// ResolveScreeningCaseTx mocks base method.
+func (m *MockStore) ResolveScreeningCaseTx(ctx context.Context, arg db.ResolveScreeningCaseParams) (db.ScreeningCase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveScreeningCaseTx", ctx, arg)
+	ret0, _ := ret[0].(db.ScreeningCase)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateAccount indicates an expected call of CreateAccount.
-func (mr *MockStoreMockRecorder) CreateAccount(ctx, arg any) *gomock.Call {
+// ResolveScreeningCaseTx indicates an expected call of ResolveScreeningCaseTx.
+func (mr *MockStoreMockRecorder) ResolveScreeningCaseTx(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveScreeningCaseTx", reflect.TypeOf((*MockStore)(nil).ResolveScreeningCaseTx), ctx, arg)
 }
 
-// CreateEntry mocks base method.
-func (m *MockStore) CreateEntry(ctx context.Context, arg db.CreateEntryParams) (db.Entry, error) {
+// RetryScheduledTransfer mocks base method.
+func (m *MockStore) RetryScheduledTransfer(ctx context.Context, arg db.RetryScheduledTransferParams) (db.ScheduledTransfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateEntry", ctx, arg)
-	ret0, _ := ret[0].(db.Entry)
+	ret := m.ctrl.Call(m, "RetryScheduledTransfer", ctx, arg)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateEntry indicates an expected call of CreateEntry.
-func (mr *MockStoreMockRecorder) CreateEntry(ctx, arg any) *gomock.Call {
+// RetryScheduledTransfer indicates an expected call of RetryScheduledTransfer.
+func (mr *MockStoreMockRecorder) RetryScheduledTransfer(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryScheduledTransfer", reflect.TypeOf((*MockStore)(nil).RetryScheduledTransfer), ctx, arg)
 }
 
-// CreateTransfer mocks base method.
-func (m *MockStore) CreateTransfer(ctx context.Context, arg db.CreateTransferParams) (db.Transfer, error) {
+// ReverseTransferTx mocks base method.
+func (m *MockStore) ReverseTransferTx(ctx context.Context, transferID int64) (db.TransferTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTransfer", ctx, arg)
-	ret0, _ := ret[0].(db.Transfer)
+	ret := m.ctrl.Call(m, "ReverseTransferTx", ctx, transferID)
+	ret0, _ := ret[0].(db.TransferTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateTransfer indicates an expected call of CreateTransfer.
-func (mr *MockStoreMockRecorder) CreateTransfer(ctx, arg any) *gomock.Call {
+// ReverseTransferTx indicates an expected call of ReverseTransferTx.
+func (mr *MockStoreMockRecorder) ReverseTransferTx(ctx, transferID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransferTx", reflect.TypeOf((*MockStore)(nil).ReverseTransferTx), ctx, transferID)
 }
 
-// DeleteAccount mocks base method.
-func (m *MockStore) DeleteAccount(ctx context.Context, id int64) error {
+// ReviewTransferApproval mocks base method.
+func (m *MockStore) ReviewTransferApproval(ctx context.Context, arg db.ReviewTransferApprovalParams) (db.TransferApproval, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteAccount", ctx, id)
+	ret := m.ctrl.Call(m, "ReviewTransferApproval", ctx, arg)
+	ret0, _ := ret[0].(db.TransferApproval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReviewTransferApproval indicates an expected call of ReviewTransferApproval.
+func (mr *MockStoreMockRecorder) ReviewTransferApproval(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviewTransferApproval", reflect.TypeOf((*MockStore)(nil).ReviewTransferApproval), ctx, arg)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockStore) RevokeAPIKey(ctx context.Context, id int64) (db.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, id)
+	ret0, _ := ret[0].(db.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockStoreMockRecorder) RevokeAPIKey(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockStore)(nil).RevokeAPIKey), ctx, id)
+}
+
+// RevokeAPIKeyTx mocks base method.
+func (m *MockStore) RevokeAPIKeyTx(ctx context.Context, id int64) (db.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKeyTx", ctx, id)
+	ret0, _ := ret[0].(db.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKeyTx indicates an expected call of RevokeAPIKeyTx.
+func (mr *MockStoreMockRecorder) RevokeAPIKeyTx(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKeyTx", reflect.TypeOf((*MockStore)(nil).RevokeAPIKeyTx), ctx, id)
+}
+
+// RevokeEmailTokens mocks base method.
+func (m *MockStore) RevokeEmailTokens(ctx context.Context, arg db.RevokeEmailTokensParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeEmailTokens", ctx, arg)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteAccount indicates an expected call of DeleteAccount.
-func (mr *MockStoreMockRecorder) DeleteAccount(ctx, id any) *gomock.Call {
+// RevokeEmailTokens indicates an expected call of RevokeEmailTokens.
+func (mr *MockStoreMockRecorder) RevokeEmailTokens(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeEmailTokens", reflect.TypeOf((*MockStore)(nil).RevokeEmailTokens), ctx, arg)
 }
 
-// GetAccount mocks base method.
-func (m *MockStore) GetAccount(ctx context.Context, id int64) (db.Account, error) {
+// RotateAPIKeyTx mocks base method.
+func (m *MockStore) RotateAPIKeyTx(ctx context.Context, arg db.RotateAPIKeyTxParams) (db.RotateAPIKeyTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccount", ctx, id)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "RotateAPIKeyTx", ctx, arg)
+	ret0, _ := ret[0].(db.RotateAPIKeyTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccount indicates an expected call of GetAccount.
-func (mr *MockStoreMockRecorder) GetAccount(ctx, id any) *gomock.Call {
+// RotateAPIKeyTx indicates an expected call of RotateAPIKeyTx.
+func (mr *MockStoreMockRecorder) RotateAPIKeyTx(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateAPIKeyTx", reflect.TypeOf((*MockStore)(nil).RotateAPIKeyTx), ctx, arg)
 }
 
-// GetAccountsForUpdate mocks base method.
-func (m *MockStore) GetAccountsForUpdate(ctx context.Context, id int64) (db.Account, error) {
+// SetAPIKeyExpiry mocks base method.
+func (m *MockStore) SetAPIKeyExpiry(ctx context.Context, arg db.SetAPIKeyExpiryParams) (db.ApiKey, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountsForUpdate", ctx, id)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "SetAPIKeyExpiry", ctx, arg)
+	ret0, _ := ret[0].(db.ApiKey)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccountsForUpdate indicates an expected call of GetAccountsForUpdate.
-func (mr *MockStoreMockRecorder) GetAccountsForUpdate(ctx, id any) *gomock.Call {
+// SetAPIKeyExpiry indicates an expected call of SetAPIKeyExpiry.
+func (mr *MockStoreMockRecorder) SetAPIKeyExpiry(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsForUpdate", reflect.TypeOf((*MockStore)(nil).GetAccountsForUpdate), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAPIKeyExpiry", reflect.TypeOf((*MockStore)(nil).SetAPIKeyExpiry), ctx, arg)
 }
 
-// GetEntry mocks base method.
-func (m *MockStore) GetEntry(ctx context.Context, id int64) (db.Entry, error) {
+// SetAccountTransferLimit mocks base method.
+func (m *MockStore) SetAccountTransferLimit(ctx context.Context, arg db.SetAccountTransferLimitParams) (db.TransferLimit, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetEntry", ctx, id)
-	ret0, _ := ret[0].(db.Entry)
+	ret := m.ctrl.Call(m, "SetAccountTransferLimit", ctx, arg)
+	ret0, _ := ret[0].(db.TransferLimit)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetEntry indicates an expected call of GetEntry.
-func (mr *MockStoreMockRecorder) GetEntry(ctx, id any) *gomock.Call {
+// SetAccountTransferLimit indicates an expected call of SetAccountTransferLimit.
+func (mr *MockStoreMockRecorder) SetAccountTransferLimit(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountTransferLimit", reflect.TypeOf((*MockStore)(nil).SetAccountTransferLimit), ctx, arg)
 }
 
-// GetTransfer mocks base method.
-func (m *MockStore) GetTransfer(ctx context.Context, id int64) (db.Transfer, error) {
+// SetTOTPSecret mocks base method.
+func (m *MockStore) SetTOTPSecret(ctx context.Context, arg db.SetTOTPSecretParams) (db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransfer", ctx, id)
-	ret0, _ := ret[0].(db.Transfer)
+	ret := m.ctrl.Call(m, "SetTOTPSecret", ctx, arg)
+	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTransfer indicates an expected call of GetTransfer.
-func (mr *MockStoreMockRecorder) GetTransfer(ctx, id any) *gomock.Call {
+// SetTOTPSecret indicates an expected call of SetTOTPSecret.
+func (mr *MockStoreMockRecorder) SetTOTPSecret(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTOTPSecret", reflect.TypeOf((*MockStore)(nil).SetTOTPSecret), ctx, arg)
 }
 
-// ListAccounts mocks base method.
-func (m *MockStore) ListAccounts(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
+// StartLoginChallengeTx mocks base method.
+func (m *MockStore) StartLoginChallengeTx(ctx context.Context, arg db.CreateLoginChallengeParams) (db.LoginChallenge, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAccounts", ctx, arg)
-	ret0, _ := ret[0].([]db.Account)
+	ret := m.ctrl.Call(m, "StartLoginChallengeTx", ctx, arg)
+	ret0, _ := ret[0].(db.LoginChallenge)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListAccounts indicates an expected call of ListAccounts.
-func (mr *MockStoreMockRecorder) ListAccounts(ctx, arg any) *gomock.Call {
+// StartLoginChallengeTx indicates an expected call of StartLoginChallengeTx.
+func (mr *MockStoreMockRecorder) StartLoginChallengeTx(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartLoginChallengeTx", reflect.TypeOf((*MockStore)(nil).StartLoginChallengeTx), ctx, arg)
 }
 
-// ListEntries mocks base method.
-func (m *MockStore) ListEntries(ctx context.Context, arg db.ListEntriesParams) ([]db.Entry, error) {
+// SubmitKYCTx mocks base method.
+func (m *MockStore) SubmitKYCTx(ctx context.Context, arg db.SubmitKYCTxParams) (db.SubmitKYCTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListEntries", ctx, arg)
-	ret0, _ := ret[0].([]db.Entry)
+	ret := m.ctrl.Call(m, "SubmitKYCTx", ctx, arg)
+	ret0, _ := ret[0].(db.SubmitKYCTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListEntries indicates an expected call of ListEntries.
-func (mr *MockStoreMockRecorder) ListEntries(ctx, arg any) *gomock.Call {
+// SubmitKYCTx indicates an expected call of SubmitKYCTx.
+func (mr *MockStoreMockRecorder) SubmitKYCTx(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockStore)(nil).ListEntries), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitKYCTx", reflect.TypeOf((*MockStore)(nil).SubmitKYCTx), ctx, arg)
 }
 
-// ListTransfers mocks base method.
-func (m *MockStore) ListTransfers(ctx context.Context, arg db.ListTransfersParams) ([]db.Transfer, error) {
+// SumOutgoingTransfers mocks base method.
+func (m *MockStore) SumOutgoingTransfers(ctx context.Context, arg db.SumOutgoingTransfersParams) (db.SumOutgoingTransfersRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTransfers", ctx, arg)
-	ret0, _ := ret[0].([]db.Transfer)
+	ret := m.ctrl.Call(m, "SumOutgoingTransfers", ctx, arg)
+	ret0, _ := ret[0].(db.SumOutgoingTransfersRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListTransfers indicates an expected call of ListTransfers.
-func (mr *MockStoreMockRecorder) ListTransfers(ctx, arg any) *gomock.Call {
+// SumOutgoingTransfers indicates an expected call of SumOutgoingTransfers.
+func (mr *MockStoreMockRecorder) SumOutgoingTransfers(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumOutgoingTransfers", reflect.TypeOf((*MockStore)(nil).SumOutgoingTransfers), ctx, arg)
 }
 
-// CreateUser mocks base method.
-func (m *MockStore) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+// SumOwnerOutgoingTransfers mocks base method.
+func (m *MockStore) SumOwnerOutgoingTransfers(ctx context.Context, arg db.SumOwnerOutgoingTransfersParams) (db.SumOwnerOutgoingTransfersRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", ctx, arg)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "SumOwnerOutgoingTransfers", ctx, arg)
+	ret0, _ := ret[0].(db.SumOwnerOutgoingTransfersRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateUser indicates an expected call of CreateUser.
-func (mr *MockStoreMockRecorder) CreateUser(ctx, arg any) *gomock.Call {
+// SumOwnerOutgoingTransfers indicates an expected call of SumOwnerOutgoingTransfers.
+func (mr *MockStoreMockRecorder) SumOwnerOutgoingTransfers(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumOwnerOutgoingTransfers", reflect.TypeOf((*MockStore)(nil).SumOwnerOutgoingTransfers), ctx, arg)
 }
 
-// GetUser mocks base method.
-func (m *MockStore) GetUser(ctx context.Context, username string) (db.User, error) {
+// TakeRateLimitToken mocks base method.
+func (m *MockStore) TakeRateLimitToken(ctx context.Context, arg db.TakeRateLimitTokenParams) (db.TakeRateLimitTokenRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", ctx, username)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "TakeRateLimitToken", ctx, arg)
+	ret0, _ := ret[0].(db.TakeRateLimitTokenRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockStoreMockRecorder) GetUser(ctx, username any) *gomock.Call {
+// TakeRateLimitToken indicates an expected call of TakeRateLimitToken.
+func (mr *MockStoreMockRecorder) TakeRateLimitToken(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), ctx, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeRateLimitToken", reflect.TypeOf((*MockStore)(nil).TakeRateLimitToken), ctx, arg)
+}
+
+// TouchAPIKey mocks base method.
+func (m *MockStore) TouchAPIKey(ctx context.Context, arg db.TouchAPIKeyParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockStoreMockRecorder) TouchAPIKey(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockStore)(nil).TouchAPIKey), ctx, arg)
 }
 
 // TransferTx mocks base method.
@@ -265,6 +2164,67 @@ func (mr *MockStoreMockRecorder) TransferTx(ctx, arg any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferTx", reflect.TypeOf((*MockStore)(nil).TransferTx), ctx, arg)
 }
 
+// TransitionKYCTx mocks base method.
+func (m *MockStore) TransitionKYCTx(ctx context.Context, arg db.TransitionKYCTxParams) (db.User, db.KycEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransitionKYCTx", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(db.KycEvent)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// TransitionKYCTx indicates an expected call of TransitionKYCTx.
+func (mr *MockStoreMockRecorder) TransitionKYCTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionKYCTx", reflect.TypeOf((*MockStore)(nil).TransitionKYCTx), ctx, arg)
+}
+
+// UnfreezeAccount mocks base method.
+func (m *MockStore) UnfreezeAccount(ctx context.Context, id int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnfreezeAccount", ctx, id)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnfreezeAccount indicates an expected call of UnfreezeAccount.
+func (mr *MockStoreMockRecorder) UnfreezeAccount(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnfreezeAccount", reflect.TypeOf((*MockStore)(nil).UnfreezeAccount), ctx, id)
+}
+
+// UnfreezeAccountTx mocks base method.
+func (m *MockStore) UnfreezeAccountTx(ctx context.Context, id int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnfreezeAccountTx", ctx, id)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnfreezeAccountTx indicates an expected call of UnfreezeAccountTx.
+func (mr *MockStoreMockRecorder) UnfreezeAccountTx(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnfreezeAccountTx", reflect.TypeOf((*MockStore)(nil).UnfreezeAccountTx), ctx, id)
+}
+
+// UnlockUserTx mocks base method.
+func (m *MockStore) UnlockUserTx(ctx context.Context, username string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUserTx", ctx, username)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnlockUserTx indicates an expected call of UnlockUserTx.
+func (mr *MockStoreMockRecorder) UnlockUserTx(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUserTx", reflect.TypeOf((*MockStore)(nil).UnlockUserTx), ctx, username)
+}
+
 // UpdateAccount mocks base method.
 func (m *MockStore) UpdateAccount(ctx context.Context, arg db.UpdateAccountParams) (db.Account, error) {
 	m.ctrl.T.Helper()
@@ -279,3 +2239,166 @@ func (mr *MockStoreMockRecorder) UpdateAccount(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockStore)(nil).UpdateAccount), ctx, arg)
 }
+
+// UpdateKYCDetails mocks base method.
+func (m *MockStore) UpdateKYCDetails(ctx context.Context, arg db.UpdateKYCDetailsParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateKYCDetails", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateKYCDetails indicates an expected call of UpdateKYCDetails.
+func (mr *MockStoreMockRecorder) UpdateKYCDetails(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateKYCDetails", reflect.TypeOf((*MockStore)(nil).UpdateKYCDetails), ctx, arg)
+}
+
+// UpdateKYCStatus mocks base method.
+func (m *MockStore) UpdateKYCStatus(ctx context.Context, arg db.UpdateKYCStatusParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateKYCStatus", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateKYCStatus indicates an expected call of UpdateKYCStatus.
+func (mr *MockStoreMockRecorder) UpdateKYCStatus(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateKYCStatus", reflect.TypeOf((*MockStore)(nil).UpdateKYCStatus), ctx, arg)
+}
+
+// UpdateStandingOrderSchedule mocks base method.
+func (m *MockStore) UpdateStandingOrderSchedule(ctx context.Context, arg db.UpdateStandingOrderScheduleParams) (db.StandingOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStandingOrderSchedule", ctx, arg)
+	ret0, _ := ret[0].(db.StandingOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStandingOrderSchedule indicates an expected call of UpdateStandingOrderSchedule.
+func (mr *MockStoreMockRecorder) UpdateStandingOrderSchedule(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStandingOrderSchedule", reflect.TypeOf((*MockStore)(nil).UpdateStandingOrderSchedule), ctx, arg)
+}
+
+// UpdateTOTPLastStep mocks base method.
+func (m *MockStore) UpdateTOTPLastStep(ctx context.Context, arg db.UpdateTOTPLastStepParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTOTPLastStep", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTOTPLastStep indicates an expected call of UpdateTOTPLastStep.
+func (mr *MockStoreMockRecorder) UpdateTOTPLastStep(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTOTPLastStep", reflect.TypeOf((*MockStore)(nil).UpdateTOTPLastStep), ctx, arg)
+}
+
+// UpdateUserPassword mocks base method.
+func (m *MockStore) UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockStoreMockRecorder) UpdateUserPassword(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockStore)(nil).UpdateUserPassword), ctx, arg)
+}
+
+// UpsertStandingOrderOccurrence mocks base method.
+func (m *MockStore) UpsertStandingOrderOccurrence(ctx context.Context, arg db.UpsertStandingOrderOccurrenceParams) (db.StandingOrderOccurrence, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertStandingOrderOccurrence", ctx, arg)
+	ret0, _ := ret[0].(db.StandingOrderOccurrence)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertStandingOrderOccurrence indicates an expected call of UpsertStandingOrderOccurrence.
+func (mr *MockStoreMockRecorder) UpsertStandingOrderOccurrence(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertStandingOrderOccurrence", reflect.TypeOf((*MockStore)(nil).UpsertStandingOrderOccurrence), ctx, arg)
+}
+
+// UseEmailToken mocks base method.
+func (m *MockStore) UseEmailToken(ctx context.Context, id int64) (db.EmailToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseEmailToken", ctx, id)
+	ret0, _ := ret[0].(db.EmailToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseEmailToken indicates an expected call of UseEmailToken.
+func (mr *MockStoreMockRecorder) UseEmailToken(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseEmailToken", reflect.TypeOf((*MockStore)(nil).UseEmailToken), ctx, id)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockStore) UseRecoveryCode(ctx context.Context, arg db.UseRecoveryCodeParams) (db.RecoveryCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, arg)
+	ret0, _ := ret[0].(db.RecoveryCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockStoreMockRecorder) UseRecoveryCode(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockStore)(nil).UseRecoveryCode), ctx, arg)
+}
+
+// VerifyEmailTx mocks base method.
+func (m *MockStore) VerifyEmailTx(ctx context.Context, tokenHash string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmailTx", ctx, tokenHash)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmailTx indicates an expected call of VerifyEmailTx.
+func (mr *MockStoreMockRecorder) VerifyEmailTx(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmailTx", reflect.TypeOf((*MockStore)(nil).VerifyEmailTx), ctx, tokenHash)
+}
+
+// VerifySecondFactorTx mocks base method.
+func (m *MockStore) VerifySecondFactorTx(ctx context.Context, arg db.VerifySecondFactorTxParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifySecondFactorTx", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifySecondFactorTx indicates an expected call of VerifySecondFactorTx.
+func (mr *MockStoreMockRecorder) VerifySecondFactorTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySecondFactorTx", reflect.TypeOf((*MockStore)(nil).VerifySecondFactorTx), ctx, arg)
+}
+
+// VerifyUserEmail mocks base method.
+func (m *MockStore) VerifyUserEmail(ctx context.Context, username string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyUserEmail", ctx, username)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyUserEmail indicates an expected call of VerifyUserEmail.
+func (mr *MockStoreMockRecorder) VerifyUserEmail(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyUserEmail", reflect.TypeOf((*MockStore)(nil).VerifyUserEmail), ctx, username)
+}
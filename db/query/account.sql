@@ -18,9 +18,10 @@ FOR NO KEY UPDATE;
 
 -- name: ListAccounts :many
 SELECT * FROM accounts
+WHERE owner = $1
 ORDER BY id
-LIMIT $1
-OFFSET $2;
+LIMIT $2
+OFFSET $3;
 
 -- name: UpdateAccount :one
 UPDATE accounts
@@ -37,4 +38,21 @@ RETURNING *;
 
 -- name: DeleteAccount :exec
 DELETE from accounts
-where id = $1;
\ No newline at end of file
+where id = $1;
+-- name: CountAccountsByOwner :one
+SELECT count(*) FROM accounts
+WHERE owner = $1;
+
+-- name: FreezeAccount :one
+-- no row comes back for an account that is already frozen
+UPDATE accounts
+SET frozen_at = now()
+WHERE id = $1 AND frozen_at IS NULL
+RETURNING *;
+
+-- name: UnfreezeAccount :one
+-- no row comes back for an account that is not frozen
+UPDATE accounts
+SET frozen_at = NULL
+WHERE id = $1 AND frozen_at IS NOT NULL
+RETURNING *;
@@ -0,0 +1,15 @@
+-- name: CreateAccountEvent :one
+INSERT INTO account_events(
+    account_id,
+    type,
+    data
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: ListAccountEvents :many
+-- events of an account committed after after_id, oldest first
+SELECT * FROM account_events
+WHERE account_id = $1 AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT $2;
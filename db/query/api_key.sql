@@ -0,0 +1,52 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+    username,
+    name,
+    prefix,
+    secret_hash,
+    scopes,
+    allowed_ips,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetAPIKey :one
+SELECT * FROM api_keys
+WHERE id = $1 LIMIT 1;
+
+-- name: GetAPIKeyForUpdate :one
+SELECT * FROM api_keys
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetAPIKeyCaller :one
+-- the key of prefix with the current role of its user, what a request with the key acts as
+SELECT api_keys.*, users.role FROM api_keys
+JOIN users ON users.username = api_keys.username
+WHERE api_keys.prefix = $1 LIMIT 1;
+
+-- name: ListAPIKeys :many
+SELECT * FROM api_keys
+WHERE username = $1
+ORDER BY id;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetAPIKeyExpiry :one
+UPDATE api_keys
+SET expires_at = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: TouchAPIKey :exec
+-- records a use of the key, at most once per key before used_before so that busy keys do not write on
+-- every request
+UPDATE api_keys
+SET last_used_at = now(),
+    last_used_ip = sqlc.arg(ip)::varchar
+WHERE id = sqlc.arg(id) AND (last_used_at IS NULL OR last_used_at < sqlc.arg(used_before)::timestamptz);
@@ -0,0 +1,26 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+    actor,
+    action,
+    target_type,
+    target_id,
+    before,
+    after,
+    request_id,
+    ip
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: ListAuditEvents :many
+-- every filter is optional, newest first
+SELECT * FROM audit_events
+WHERE (sqlc.narg(actor)::varchar IS NULL OR actor = sqlc.narg(actor))
+  AND (sqlc.narg(action)::varchar IS NULL OR action = sqlc.narg(action))
+  AND (sqlc.narg(target_type)::varchar IS NULL OR target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::varchar IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size)
+OFFSET sqlc.arg(page_offset);
@@ -0,0 +1,27 @@
+-- name: CreateEmailToken :one
+INSERT INTO email_tokens (
+    username,
+    purpose,
+    token_hash,
+    email,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetEmailTokenForUpdate :one
+SELECT * FROM email_tokens
+WHERE token_hash = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: UseEmailToken :one
+UPDATE email_tokens
+SET used_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: RevokeEmailTokens :exec
+-- uses up the tokens of a user that are still good for purpose
+UPDATE email_tokens
+SET used_at = now()
+WHERE username = $1 AND purpose = $2 AND used_at IS NULL;
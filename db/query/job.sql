@@ -0,0 +1,50 @@
+-- name: CreateJob :one
+-- returns no row when a pending job of the same kind already holds unique_key
+INSERT INTO jobs(
+    kind,
+    payload,
+    unique_key,
+    max_attempts,
+    run_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (kind, unique_key) WHERE status = 'pending' DO NOTHING
+RETURNING *;
+
+-- name: GetJob :one
+SELECT * FROM jobs
+WHERE id = $1 LIMIT 1;
+
+-- name: ClaimJobs :many
+-- leases due jobs of the given kinds until lease_until and counts the attempt, so a job that
+-- keeps crashing its worker still runs out of attempts. SKIP LOCKED lets workers claim disjoint batches
+UPDATE jobs
+SET run_at = sqlc.arg(lease_until),
+    attempts = attempts + 1
+WHERE id IN (
+    SELECT id FROM jobs
+    WHERE status = 'pending' AND run_at <= now() AND kind = ANY(sqlc.arg(kinds)::varchar[])
+    ORDER BY run_at, id
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkJobSucceeded :execrows
+-- attempts is that of the claim, a worker whose lease ran out and whose job was claimed again updates no row
+UPDATE jobs
+SET status = 'succeeded',
+    finished_at = now(),
+    last_error = NULL
+WHERE id = $1 AND attempts = $2 AND status = 'pending';
+
+-- name: MarkJobFailed :execrows
+-- status stays pending for a retry at run_at, or becomes dead once the job is out of attempts.
+-- fenced by the attempts of the claim like MarkJobSucceeded
+UPDATE jobs
+SET status = sqlc.arg(status),
+    run_at = sqlc.arg(run_at),
+    last_error = sqlc.arg(last_error),
+    finished_at = CASE WHEN sqlc.arg(status)::varchar = 'dead' THEN now() END
+WHERE id = sqlc.arg(id) AND attempts = sqlc.arg(attempts) AND status = 'pending';
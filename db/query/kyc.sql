@@ -0,0 +1,56 @@
+-- name: UpdateKYCDetails :one
+UPDATE users
+SET date_of_birth = sqlc.arg(date_of_birth)::date,
+    address_line = sqlc.arg(address_line)::varchar,
+    city = sqlc.arg(city)::varchar,
+    postal_code = sqlc.arg(postal_code)::varchar,
+    country = sqlc.arg(country)::varchar
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: UpdateKYCStatus :one
+UPDATE users
+SET kyc_status = $2,
+    kyc_tier = $3
+WHERE username = $1
+RETURNING *;
+
+-- name: ListUsersByKYCStatus :many
+-- the KYC queue of admins, the users who have waited longest first
+SELECT * FROM users
+WHERE kyc_status = $1
+ORDER BY created_at, username
+LIMIT $2
+OFFSET $3;
+
+-- name: CreateKYCDocument :one
+INSERT INTO kyc_documents (
+    username,
+    document_type,
+    reference
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: ListKYCDocuments :many
+SELECT * FROM kyc_documents
+WHERE username = $1
+ORDER BY id;
+
+-- name: CreateKYCEvent :one
+INSERT INTO kyc_events (
+    username,
+    from_status,
+    to_status,
+    from_tier,
+    to_tier,
+    actor,
+    note
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: ListKYCEvents :many
+SELECT * FROM kyc_events
+WHERE username = $1
+ORDER BY id;
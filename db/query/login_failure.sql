@@ -0,0 +1,34 @@
+-- name: ListLoginFailures :many
+-- the counters of a username and of the IP it logs in from
+SELECT * FROM login_failures
+WHERE (scope = 'username' AND subject = sqlc.arg(username))
+   OR (scope = 'ip' AND subject = sqlc.arg(ip));
+
+-- name: GetLoginFailureForUpdate :one
+SELECT * FROM login_failures
+WHERE scope = $1 AND subject = $2 LIMIT 1
+FOR UPDATE;
+
+-- name: AddLoginFailure :one
+-- counts one more failure, starting over when the last one is older than window_start
+INSERT INTO login_failures (
+    scope,
+    subject,
+    failures
+) VALUES (
+    sqlc.arg(scope), sqlc.arg(subject), 1
+)
+ON CONFLICT (scope, subject) DO UPDATE
+SET failures = CASE WHEN login_failures.last_failed_at < sqlc.arg(window_start) THEN 1 ELSE login_failures.failures + 1 END,
+    last_failed_at = now()
+RETURNING *;
+
+-- name: LockLoginSubject :one
+UPDATE login_failures
+SET locked_until = sqlc.arg(locked_until)::timestamptz
+WHERE scope = sqlc.arg(scope) AND subject = sqlc.arg(subject)
+RETURNING *;
+
+-- name: DeleteLoginFailures :execrows
+DELETE FROM login_failures
+WHERE scope = $1 AND subject = $2;
@@ -0,0 +1,36 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox(
+    aggregate_type,
+    aggregate_id,
+    event_type,
+    payload
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ClaimOutboxEvents :many
+-- leases due events until lease_until, SKIP LOCKED lets several dispatchers claim disjoint batches
+UPDATE outbox
+SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+    SELECT id FROM outbox
+    WHERE sent_at IS NULL AND next_attempt_at <= now()
+    ORDER BY id
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxEventSent :exec
+UPDATE outbox
+SET sent_at = now(),
+    attempts = attempts + 1,
+    last_error = NULL
+WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox
+SET attempts = attempts + 1,
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id);
@@ -0,0 +1,23 @@
+-- name: TakeRateLimitToken :one
+-- refills the bucket of key for the time since its last use and takes a token from it. when less than a
+-- token is left nothing is taken and allowed is false. a new bucket starts full
+INSERT INTO rate_limit_buckets AS b (
+    key,
+    tokens,
+    allowed
+) VALUES (
+    sqlc.arg(key), sqlc.arg(burst)::float8 - 1, true
+)
+ON CONFLICT (key) DO UPDATE
+SET tokens = CASE
+        WHEN LEAST(sqlc.arg(burst)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg(rate)::float8) >= 1
+        THEN LEAST(sqlc.arg(burst)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg(rate)::float8) - 1
+        ELSE LEAST(sqlc.arg(burst)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg(rate)::float8)
+    END,
+    allowed = LEAST(sqlc.arg(burst)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg(rate)::float8) >= 1,
+    updated_at = now()
+RETURNING tokens, allowed;
+
+-- name: DeleteIdleRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE updated_at < sqlc.arg(idle_since);
@@ -0,0 +1,43 @@
+-- name: CreateRiskAlert :one
+INSERT INTO risk_alerts (
+    decision,
+    from_account_id,
+    to_account_id,
+    amount,
+    currency,
+    transfer_id,
+    findings
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetRiskAlert :one
+SELECT * FROM risk_alerts
+WHERE id = $1 LIMIT 1;
+
+-- name: ListRiskAlerts :many
+-- the alert queue, oldest first
+SELECT * FROM risk_alerts
+WHERE status = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ResolveRiskAlert :one
+-- only an open alert is resolved, one resolved in the meantime no longer matches
+UPDATE risk_alerts
+SET status = sqlc.arg(status),
+    resolved_by = sqlc.arg(resolved_by),
+    resolution_note = sqlc.arg(resolution_note),
+    resolved_at = now()
+WHERE id = sqlc.arg(id) AND status = 'open'
+RETURNING *;
+
+-- name: ListRiskTransfers :many
+-- the history the risk rules judge a transfer by: what the account sent since, and what the counterparty sent it
+SELECT * FROM transfers
+WHERE created_at >= sqlc.arg(since)
+  AND (from_account_id = sqlc.arg(account_id)
+    OR (from_account_id = sqlc.arg(counterparty_id) AND to_account_id = sqlc.arg(account_id)))
+ORDER BY id DESC
+LIMIT 1000;
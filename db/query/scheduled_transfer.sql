@@ -0,0 +1,63 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+    owner,
+    from_account_id,
+    to_account_id,
+    amount,
+    currency,
+    execute_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: ListScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE owner = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: CancelScheduledTransfer :one
+-- waits for a scheduler executing the row, which then no longer matches
+UPDATE scheduled_transfers
+SET status = 'cancelled'
+WHERE id = $1 AND status = 'scheduled'
+RETURNING *;
+
+-- name: ClaimDueScheduledTransfer :one
+-- locks the oldest due row until the claiming transaction ends, other schedulers skip it. a row that failed
+-- for another reason than a rejection waits for its retry_at
+SELECT * FROM scheduled_transfers
+WHERE status = 'scheduled' AND execute_at <= now() AND (retry_at IS NULL OR retry_at <= now())
+ORDER BY execute_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkScheduledTransferSucceeded :one
+UPDATE scheduled_transfers
+SET status = 'succeeded',
+    transfer_id = $2,
+    executed_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: MarkScheduledTransferFailed :one
+-- only a row still scheduled is failed, a concurrent scheduler may have executed it in the meantime
+UPDATE scheduled_transfers
+SET status = 'failed',
+    failure_reason = $2,
+    executed_at = now()
+WHERE id = $1 AND status = 'scheduled'
+RETURNING *;
+
+-- name: RetryScheduledTransfer :one
+-- counts a try that failed for another reason than a rejection, the row is not claimed again before retry_at
+UPDATE scheduled_transfers
+SET attempts = attempts + 1,
+    retry_at = $2
+WHERE id = $1 AND status = 'scheduled'
+RETURNING *;
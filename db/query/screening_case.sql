@@ -0,0 +1,68 @@
+-- name: CreateScreeningCase :one
+-- a match already on record for the user is left alone and no row comes back
+INSERT INTO screening_cases (
+    username,
+    full_name,
+    entry_id,
+    entry_name,
+    program,
+    score,
+    decision,
+    source
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (username, entry_id) DO NOTHING
+RETURNING *;
+
+-- name: GetScreeningCase :one
+SELECT * FROM screening_cases
+WHERE id = $1 LIMIT 1;
+
+-- name: ListScreeningCases :many
+-- the case queue, oldest first
+SELECT * FROM screening_cases
+WHERE status = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ResolveScreeningCase :one
+-- only an open case is resolved, one resolved in the meantime no longer matches
+UPDATE screening_cases
+SET status = sqlc.arg(status),
+    resolved_by = sqlc.arg(resolved_by),
+    resolution_note = sqlc.arg(resolution_note),
+    resolved_at = now()
+WHERE id = sqlc.arg(id) AND status = 'open'
+RETURNING *;
+
+-- name: RefreshSanctionsStatus :one
+-- derives the sanctions status of a user from their cases
+UPDATE users u
+SET sanctions_status = CASE
+    WHEN EXISTS (
+        SELECT 1 FROM screening_cases c
+        WHERE c.username = u.username
+          AND (c.status = 'confirmed' OR (c.status = 'open' AND c.decision = 'block'))
+    ) THEN 'blocked'
+    WHEN EXISTS (
+        SELECT 1 FROM screening_cases c
+        WHERE c.username = u.username AND c.status = 'open'
+    ) THEN 'review'
+    ELSE 'clear'
+END
+WHERE u.username = sqlc.arg(username)
+RETURNING *;
+
+-- name: ListUsersForScreening :many
+-- pages through every user by username, after is the last username of the previous page
+SELECT username, full_name FROM users
+WHERE username > sqlc.arg(after)
+ORDER BY username
+LIMIT sqlc.arg(page_size);
+
+-- name: ListBlockedOwners :many
+SELECT u.username FROM accounts a
+JOIN users u ON u.username = a.owner
+WHERE a.id IN (sqlc.arg(from_account_id), sqlc.arg(to_account_id)) AND u.sanctions_status = 'blocked';
@@ -0,0 +1,83 @@
+-- name: CreateStandingOrder :one
+INSERT INTO standing_orders (
+    owner,
+    from_account_id,
+    to_account_id,
+    amount,
+    currency,
+    frequency,
+    start_date,
+    end_date,
+    max_occurrences,
+    business_day_adjustment,
+    on_insufficient_funds,
+    max_retries,
+    next_run_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+) RETURNING *;
+
+-- name: GetStandingOrder :one
+SELECT * FROM standing_orders
+WHERE id = $1 LIMIT 1;
+
+-- name: ListStandingOrders :many
+SELECT * FROM standing_orders
+WHERE owner = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: CancelStandingOrder :one
+-- waits for a worker running the order, the occurrence it settles is kept
+UPDATE standing_orders
+SET status = 'cancelled',
+    next_run_at = NULL
+WHERE id = $1 AND status = 'active'
+RETURNING *;
+
+-- name: ClaimDueStandingOrder :one
+-- locks the most overdue order until the claiming transaction ends, other workers skip it
+SELECT * FROM standing_orders
+WHERE status = 'active' AND next_run_at <= now()
+ORDER BY next_run_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: UpdateStandingOrderSchedule :one
+UPDATE standing_orders
+SET status = $2,
+    occurrences = $3,
+    attempts = $4,
+    next_run_at = $5
+WHERE id = $1
+RETURNING *;
+
+-- name: UpsertStandingOrderOccurrence :one
+-- a retried occurrence keeps its row, each attempt updates it
+INSERT INTO standing_order_occurrences (
+    standing_order_id,
+    sequence,
+    scheduled_date,
+    run_date,
+    status,
+    attempts,
+    transfer_id,
+    failure_reason
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (standing_order_id, sequence) DO UPDATE
+SET status = EXCLUDED.status,
+    attempts = EXCLUDED.attempts,
+    transfer_id = EXCLUDED.transfer_id,
+    failure_reason = EXCLUDED.failure_reason,
+    updated_at = now()
+RETURNING *;
+
+-- name: ListStandingOrderOccurrences :many
+SELECT * FROM standing_order_occurrences
+WHERE standing_order_id = $1
+ORDER BY sequence DESC
+LIMIT $2
+OFFSET $3;
@@ -19,3 +19,16 @@ WHERE
 ORDER BY id
 LIMIT $3
 OFFSET $4;
+
+-- name: CreateReversal :one
+-- no row comes back when the transfer was reversed already
+INSERT INTO transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    reversal_of
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (reversal_of) WHERE reversal_of IS NOT NULL DO NOTHING
+RETURNING *;
@@ -0,0 +1,53 @@
+-- name: CreateTransferApproval :one
+INSERT INTO transfer_approvals (
+    initiator,
+    from_account_id,
+    to_account_id,
+    amount,
+    currency,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetTransferApproval :one
+SELECT * FROM transfer_approvals
+WHERE id = $1 LIMIT 1;
+
+-- name: GetTransferApprovalForUpdate :one
+-- holds the row until the reviewing transaction ends, so a request is approved or rejected once
+SELECT * FROM transfer_approvals
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListTransferApprovals :many
+SELECT * FROM transfer_approvals
+WHERE initiator = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: ListPendingTransferApprovals :many
+-- the review queue, oldest first
+SELECT * FROM transfer_approvals
+WHERE status = 'pending' AND expires_at > now()
+ORDER BY id
+LIMIT $1
+OFFSET $2;
+
+-- name: ReviewTransferApproval :one
+-- only a pending request is reviewed, one reviewed or expired in the meantime no longer matches
+UPDATE transfer_approvals
+SET status = sqlc.arg(status),
+    reviewer = sqlc.arg(reviewer),
+    rejection_reason = sqlc.arg(rejection_reason),
+    transfer_id = sqlc.arg(transfer_id),
+    reviewed_at = now()
+WHERE id = sqlc.arg(id) AND status = 'pending'
+RETURNING *;
+
+-- name: ExpireTransferApprovals :many
+UPDATE transfer_approvals
+SET status = 'expired'
+WHERE status = 'pending' AND expires_at <= now()
+RETURNING *;
@@ -0,0 +1,60 @@
+-- name: GetTransferLimits :one
+-- resolves each period on its own: the account override, then the tier of the owner, then the currency default
+SELECT
+    a.id AS account_id,
+    a.owner,
+    a.currency,
+    COALESCE(o.daily_limit, t.daily_limit, d.daily_limit) AS daily_limit,
+    COALESCE(o.monthly_limit, t.monthly_limit, d.monthly_limit) AS monthly_limit
+FROM accounts a
+JOIN users u ON u.username = a.owner
+LEFT JOIN transfer_limits o ON o.account_id = a.id
+LEFT JOIN transfer_limits t ON t.kyc_tier = u.kyc_tier AND t.currency = a.currency
+LEFT JOIN transfer_limits d ON d.account_id IS NULL AND d.kyc_tier IS NULL AND d.currency = a.currency
+WHERE a.id = $1;
+
+-- name: SumOutgoingTransfers :one
+-- reversals are corrections booked by staff, they use up no limit
+SELECT
+    COALESCE(SUM(amount) FILTER (WHERE created_at >= sqlc.arg(day_start)), 0)::bigint AS daily,
+    COALESCE(SUM(amount), 0)::bigint AS monthly
+FROM transfers
+WHERE from_account_id = sqlc.arg(account_id) AND created_at >= sqlc.arg(month_start) AND reversal_of IS NULL;
+
+-- name: GetOwnerTransferLimits :one
+-- the allowance a user shares across their accounts in a currency: the tier of the user, then the currency default
+SELECT
+    COALESCE(t.daily_limit, d.daily_limit) AS daily_limit,
+    COALESCE(t.monthly_limit, d.monthly_limit) AS monthly_limit
+FROM users u
+LEFT JOIN transfer_limits t ON t.kyc_tier = u.kyc_tier AND t.currency = sqlc.arg(currency)
+LEFT JOIN transfer_limits d ON d.account_id IS NULL AND d.kyc_tier IS NULL AND d.currency = sqlc.arg(currency)
+WHERE u.username = sqlc.arg(owner);
+
+-- name: SumOwnerOutgoingTransfers :one
+-- money the user moves between their own accounts never leaves them and reversals are corrections booked by
+-- staff, neither uses up the allowance
+SELECT
+    COALESCE(SUM(t.amount) FILTER (WHERE t.created_at >= sqlc.arg(day_start)), 0)::bigint AS daily,
+    COALESCE(SUM(t.amount), 0)::bigint AS monthly
+FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+JOIN accounts d ON d.id = t.to_account_id
+WHERE a.owner = sqlc.arg(owner) AND a.currency = sqlc.arg(currency) AND t.created_at >= sqlc.arg(month_start)
+    AND t.reversal_of IS NULL AND d.owner <> a.owner;
+
+-- name: SetAccountTransferLimit :one
+-- sets or replaces the override of one account, a NULL period falls back to the tier or the default
+INSERT INTO transfer_limits (
+    currency,
+    account_id,
+    daily_limit,
+    monthly_limit
+)
+SELECT a.currency, a.id, sqlc.narg(daily_limit), sqlc.narg(monthly_limit)
+FROM accounts a
+WHERE a.id = sqlc.arg(account_id)
+ON CONFLICT (account_id) WHERE account_id IS NOT NULL DO UPDATE
+SET daily_limit = EXCLUDED.daily_limit,
+    monthly_limit = EXCLUDED.monthly_limit
+RETURNING *;
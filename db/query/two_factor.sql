@@ -0,0 +1,74 @@
+-- name: SetTOTPSecret :one
+-- enrolling again before confirming replaces the secret, once two-factor authentication is on nothing is updated
+UPDATE users
+SET totp_secret = $2
+WHERE username = $1 AND totp_enabled_at IS NULL
+RETURNING *;
+
+-- name: EnableTOTP :one
+UPDATE users
+SET totp_enabled_at = now(),
+    totp_last_step = $2
+WHERE username = $1
+RETURNING *;
+
+-- name: DisableTOTP :one
+UPDATE users
+SET totp_secret = NULL,
+    totp_enabled_at = NULL,
+    totp_last_step = 0
+WHERE username = $1
+RETURNING *;
+
+-- name: UpdateTOTPLastStep :exec
+UPDATE users
+SET totp_last_step = $2
+WHERE username = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (
+    username,
+    code_hash
+) VALUES (
+    $1, $2
+);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE username = $1;
+
+-- name: UseRecoveryCode :one
+-- marks an unused code as used, no rows when the code is unknown or was already used
+UPDATE recovery_codes
+SET used_at = now()
+WHERE username = $1 AND code_hash = $2 AND used_at IS NULL
+RETURNING *;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT count(*) FROM recovery_codes
+WHERE username = $1 AND used_at IS NULL;
+
+-- name: CreateLoginChallenge :one
+INSERT INTO login_challenges (
+    username,
+    expires_at
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: GetLoginChallengeForUpdate :one
+SELECT * FROM login_challenges
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: AddLoginChallengeAttempt :one
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: CompleteLoginChallenge :one
+UPDATE login_challenges
+SET completed_at = now()
+WHERE id = $1
+RETURNING *;
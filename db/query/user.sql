@@ -12,3 +12,26 @@ INSERT INTO users
 -- name: GetUser :one
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;    
+
+
+-- name: GetUserForUpdate :one
+SELECT * FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2,
+    password_changed_at = now()
+WHERE username = $1
+RETURNING *;
+
+-- name: VerifyUserEmail :one
+UPDATE users
+SET email_verified_at = now()
+WHERE username = $1
+RETURNING *;
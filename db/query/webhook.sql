@@ -0,0 +1,81 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints(
+    owner,
+    url,
+    secret,
+    event_types
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE owner = $1
+ORDER BY id;
+
+-- name: DeleteWebhookEndpoint :exec
+DELETE FROM webhook_endpoints
+WHERE id = $1;
+
+-- name: CreateWebhookDeliveries :execrows
+-- queues the event for every endpoint of owner subscribed to event_type
+INSERT INTO webhook_deliveries(endpoint_id, event_type, payload)
+SELECT id, sqlc.arg(event_type)::varchar, sqlc.arg(payload)::jsonb
+FROM webhook_endpoints
+WHERE owner = sqlc.arg(owner) AND sqlc.arg(event_type)::varchar = ANY(event_types);
+
+-- name: ClaimWebhookDeliveries :many
+-- leases due deliveries until lease_until, SKIP LOCKED lets several deliverers claim disjoint batches
+UPDATE webhook_deliveries
+SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= now()
+    ORDER BY id
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+SET status = 'succeeded',
+    attempts = attempts + 1,
+    last_response_status = $2,
+    last_error = NULL,
+    delivered_at = now()
+WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+-- status stays pending for another try at next_attempt_at, or becomes dead
+UPDATE webhook_deliveries
+SET status = sqlc.arg(status),
+    attempts = attempts + 1,
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    last_response_status = sqlc.arg(last_response_status),
+    last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id);
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: RedeliverWebhookDelivery :one
+-- queues a delivery again with a fresh set of retries, whatever its status
+UPDATE webhook_deliveries
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = now()
+WHERE id = $1
+RETURNING *;
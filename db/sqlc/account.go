@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FreezeAccountTx freezes an account, it neither sends nor receives transfers until unfrozen. freezing a frozen
+// account returns apperror.ErrConflict
+func (store *SQLStore) FreezeAccountTx(ctx context.Context, id int64) (Account, error) {
+	return store.setFrozenTx(ctx, id, true)
+}
+
+// UnfreezeAccountTx lifts the freeze of an account, an account that is not frozen returns apperror.ErrConflict
+func (store *SQLStore) UnfreezeAccountTx(ctx context.Context, id int64) (Account, error) {
+	return store.setFrozenTx(ctx, id, false)
+}
+
+func (store *SQLStore) setFrozenTx(ctx context.Context, id int64, frozen bool) (account Account, err error) {
+	ctx, span := startTxSpan(ctx, "SetFrozenTx", attribute.Int64("account.id", id), attribute.Bool("account.frozen", frozen))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetAccountsForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if before.FrozenAt.Valid == frozen {
+			return apperror.Conflict(fmt.Sprintf("account [%d] is already %s", id, frozenState(frozen)))
+		}
+
+		action := AuditAccountUnfrozen
+		if frozen {
+			action = AuditAccountFrozen
+			account, err = q.FreezeAccount(ctx, id)
+		} else {
+			account, err = q.UnfreezeAccount(ctx, id)
+		}
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, action, AuditTargetAccount, auditID(id), before, account)
+	})
+	return account, err
+}
+
+func frozenState(frozen bool) string {
+	if frozen {
+		return "frozen"
+	}
+	return "unfrozen"
+}
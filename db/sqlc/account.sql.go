@@ -13,7 +13,7 @@ const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance+$1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, frozen_at
 `
 
 type AddAccountBalanceParams struct {
@@ -30,10 +30,23 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.FrozenAt,
 	)
 	return i, err
 }
 
+const countAccountsByOwner = `-- name: CountAccountsByOwner :one
+SELECT count(*) FROM accounts
+WHERE owner = $1
+`
+
+func (q *Queries) CountAccountsByOwner(ctx context.Context, owner string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAccountsByOwner, owner)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts(
     owner, 
@@ -41,7 +54,7 @@ INSERT INTO accounts(
     currency
 ) VALUES (
     $1, $2, $3
-) RETURNING id, owner, balance, currency, created_at
+) RETURNING id, owner, balance, currency, created_at, frozen_at
 `
 
 type CreateAccountParams struct {
@@ -59,6 +72,7 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.FrozenAt,
 	)
 	return i, err
 }
@@ -73,8 +87,30 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 	return err
 }
 
+const freezeAccount = `-- name: FreezeAccount :one
+UPDATE accounts
+SET frozen_at = now()
+WHERE id = $1 AND frozen_at IS NULL
+RETURNING id, owner, balance, currency, created_at, frozen_at
+`
+
+// no row comes back for an account that is already frozen
+func (q *Queries) FreezeAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRowContext(ctx, freezeAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.FrozenAt,
+	)
+	return i, err
+}
+
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, frozen_at FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -87,12 +123,13 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.FrozenAt,
 	)
 	return i, err
 }
 
 const getAccountsForUpdate = `-- name: GetAccountsForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, frozen_at FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -106,24 +143,27 @@ func (q *Queries) GetAccountsForUpdate(ctx context.Context, id int64) (Account,
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.FrozenAt,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, frozen_at FROM accounts
+WHERE owner = $1
 ORDER BY id
-LIMIT $1
-OFFSET $2
+LIMIT $2
+OFFSET $3
 `
 
 type ListAccountsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	Owner  string `json:"owner"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
 }
 
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Owner, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -137,6 +177,7 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.FrozenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -151,11 +192,33 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
+const unfreezeAccount = `-- name: UnfreezeAccount :one
+UPDATE accounts
+SET frozen_at = NULL
+WHERE id = $1 AND frozen_at IS NOT NULL
+RETURNING id, owner, balance, currency, created_at, frozen_at
+`
+
+// no row comes back for an account that is not frozen
+func (q *Queries) UnfreezeAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRowContext(ctx, unfreezeAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.FrozenAt,
+	)
+	return i, err
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, frozen_at
 `
 
 type UpdateAccountParams struct {
@@ -172,6 +235,7 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.FrozenAt,
 	)
 	return i, err
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ShubhKanodia/GoBank/apperror"
 	"github.com/ShubhKanodia/GoBank/util"
 	"github.com/stretchr/testify/require"
 )
@@ -85,13 +86,15 @@ func TestDeleteAccount(t *testing.T) {
 }
 
 func TestListAccounts(t *testing.T) {
+	var lastAccount Account
 	for i := 0; i < 10; i++ {
-		createRandomAccount(t)
+		lastAccount = createRandomAccount(t)
 	}
 
 	arg := ListAccountsParams{
+		Owner:  lastAccount.Owner,
 		Limit:  5,
-		Offset: 5,
+		Offset: 0,
 	}
 
 	accounts, err := testQueries.ListAccounts(context.Background(), arg)
@@ -100,5 +103,27 @@ func TestListAccounts(t *testing.T) {
 
 	for _, account := range accounts {
 		require.NotEmpty(t, account)
+		require.Equal(t, lastAccount.Owner, account.Owner) //only the owner's accounts are listed
 	}
 }
+
+func TestFreezeAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	frozen, err := store.FreezeAccountTx(ctx, account.ID)
+	require.NoError(t, err)
+	require.True(t, frozen.FrozenAt.Valid)
+	_, err = store.FreezeAccountTx(ctx, account.ID)
+	require.ErrorIs(t, err, apperror.ErrConflict)
+
+	unfrozen, err := store.UnfreezeAccountTx(ctx, account.ID)
+	require.NoError(t, err)
+	require.False(t, unfrozen.FrozenAt.Valid)
+	_, err = store.UnfreezeAccountTx(ctx, account.ID)
+	require.ErrorIs(t, err, apperror.ErrConflict)
+
+	_, err = store.FreezeAccountTx(ctx, 0)
+	require.ErrorIs(t, err, apperror.ErrNotFound)
+}
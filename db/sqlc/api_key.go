@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// auditedAPIKey is an API key as the audit log records it, without the hash of the secret
+type auditedAPIKey struct {
+	ID         int64        `json:"id"`
+	Username   string       `json:"username"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	Scopes     []string     `json:"scopes"`
+	AllowedIps []string     `json:"allowed_ips"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+}
+
+func newAuditedAPIKey(key ApiKey) auditedAPIKey {
+	return auditedAPIKey{
+		ID:         key.ID,
+		Username:   key.Username,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		AllowedIps: key.AllowedIps,
+		ExpiresAt:  key.ExpiresAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+// Usable reports whether requests can still be made with the key at now
+func (key ApiKey) Usable(now time.Time) bool {
+	return !key.RevokedAt.Valid && (!key.ExpiresAt.Valid || key.ExpiresAt.Time.After(now))
+}
+
+// CreateAPIKeyTx issues an API key and records it in the audit log
+func (store *SQLStore) CreateAPIKeyTx(ctx context.Context, arg CreateAPIKeyParams) (key ApiKey, err error) {
+	ctx, span := startTxSpan(ctx, "CreateAPIKeyTx", attribute.String("user.username", arg.Username))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		key, err = q.CreateAPIKey(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditAPIKeyCreated, AuditTargetAPIKey, auditID(key.ID), nil, newAuditedAPIKey(key))
+	})
+	return key, err
+}
+
+// RevokeAPIKeyTx stops the key from working for good. a key revoked already returns apperror.ErrConflict
+func (store *SQLStore) RevokeAPIKeyTx(ctx context.Context, id int64) (key ApiKey, err error) {
+	ctx, span := startTxSpan(ctx, "RevokeAPIKeyTx", attribute.Int64("api_key.id", id))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetAPIKeyForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if before.RevokedAt.Valid {
+			return apperror.Conflict(fmt.Sprintf("api key [%d] is already revoked", id))
+		}
+		key, err = q.RevokeAPIKey(ctx, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditAPIKeyRevoked, AuditTargetAPIKey, auditID(key.ID), newAuditedAPIKey(before), newAuditedAPIKey(key))
+	})
+	return key, err
+}
+
+// RotateAPIKeyTxParams replaces an API key with a new secret
+type RotateAPIKeyTxParams struct {
+	ID         int64
+	Prefix     string
+	SecretHash string
+	// OldExpiresAt is when the replaced key stops working, it keeps an earlier expiry of its own
+	OldExpiresAt time.Time
+}
+
+// RotateAPIKeyTxResult is the new key and the key it replaces
+type RotateAPIKeyTxResult struct {
+	Key    ApiKey
+	OldKey ApiKey
+}
+
+// RotateAPIKeyTx issues a key with the name, scopes, allowed IPs and expiry of the key of arg.ID, which keeps
+// working until arg.OldExpiresAt so that clients can switch over. a revoked or expired key returns
+// apperror.ErrConflict
+func (store *SQLStore) RotateAPIKeyTx(ctx context.Context, arg RotateAPIKeyTxParams) (result RotateAPIKeyTxResult, err error) {
+	ctx, span := startTxSpan(ctx, "RotateAPIKeyTx", attribute.Int64("api_key.id", arg.ID))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetAPIKeyForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if !before.Usable(time.Now()) {
+			return apperror.Conflict(fmt.Sprintf("api key [%d] is revoked or expired", arg.ID))
+		}
+
+		result.Key, err = q.CreateAPIKey(ctx, CreateAPIKeyParams{
+			Username:   before.Username,
+			Name:       before.Name,
+			Prefix:     arg.Prefix,
+			SecretHash: arg.SecretHash,
+			Scopes:     before.Scopes,
+			AllowedIps: before.AllowedIps,
+			ExpiresAt:  before.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+		result.OldKey = before
+		if !before.ExpiresAt.Valid || arg.OldExpiresAt.Before(before.ExpiresAt.Time) {
+			result.OldKey, err = q.SetAPIKeyExpiry(ctx, SetAPIKeyExpiryParams{
+				ID:        before.ID,
+				ExpiresAt: sql.NullTime{Time: arg.OldExpiresAt, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return recordAudit(ctx, q, AuditAPIKeyRotated, AuditTargetAPIKey, auditID(before.ID), newAuditedAPIKey(before), newAuditedAPIKey(result.Key))
+	})
+	return result, err
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAPIKey(t *testing.T, store Store, user User) ApiKey {
+	key, err := store.CreateAPIKeyTx(context.Background(), CreateAPIKeyParams{
+		Username:   user.Username,
+		Name:       "payroll",
+		Prefix:     util.RandomString(16),
+		SecretHash: util.RandomString(64),
+		Scopes:     []string{"accounts:read", "transfers:write"},
+		AllowedIps: []string{"192.0.2.0/24"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, key.Username)
+	require.Equal(t, []string{"accounts:read", "transfers:write"}, key.Scopes)
+	require.False(t, key.ExpiresAt.Valid)
+	return key
+}
+
+func TestCreateAPIKeyTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	key := createRandomAPIKey(t, store, user)
+
+	caller, err := store.GetAPIKeyCaller(context.Background(), key.Prefix)
+	require.NoError(t, err)
+	require.Equal(t, key.ID, caller.ID)
+	require.Equal(t, user.Role, caller.Role)
+
+	events, err := store.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		TargetType: sql.NullString{String: AuditTargetAPIKey, Valid: true},
+		TargetID:   sql.NullString{String: auditID(key.ID), Valid: true},
+		PageSize:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, AuditAPIKeyCreated, events[0].Action)
+	require.NotContains(t, string(events[0].After), key.SecretHash)
+}
+
+func TestTouchAPIKey(t *testing.T) {
+	store := NewStore(testDB)
+	key := createRandomAPIKey(t, store, createRandomUser(t))
+
+	err := store.TouchAPIKey(context.Background(), TouchAPIKeyParams{ID: key.ID, Ip: "192.0.2.1", UsedBefore: time.Now()})
+	require.NoError(t, err)
+	touched, err := store.GetAPIKey(context.Background(), key.ID)
+	require.NoError(t, err)
+	require.True(t, touched.LastUsedAt.Valid)
+	require.Equal(t, "192.0.2.1", touched.LastUsedIp.String)
+
+	// a use recorded after used_before is kept
+	err = store.TouchAPIKey(context.Background(), TouchAPIKeyParams{ID: key.ID, Ip: "192.0.2.2", UsedBefore: time.Now().Add(-time.Minute)})
+	require.NoError(t, err)
+	touched, err = store.GetAPIKey(context.Background(), key.ID)
+	require.NoError(t, err)
+	require.Equal(t, "192.0.2.1", touched.LastUsedIp.String)
+}
+
+func TestRotateAPIKeyTx(t *testing.T) {
+	store := NewStore(testDB)
+	key := createRandomAPIKey(t, store, createRandomUser(t))
+	oldExpiresAt := time.Now().Add(time.Hour)
+
+	result, err := store.RotateAPIKeyTx(context.Background(), RotateAPIKeyTxParams{
+		ID:           key.ID,
+		Prefix:       util.RandomString(16),
+		SecretHash:   util.RandomString(64),
+		OldExpiresAt: oldExpiresAt,
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, key.ID, result.Key.ID)
+	require.Equal(t, key.Name, result.Key.Name)
+	require.Equal(t, key.Scopes, result.Key.Scopes)
+	require.Equal(t, key.AllowedIps, result.Key.AllowedIps)
+	require.False(t, result.Key.ExpiresAt.Valid)
+	require.WithinDuration(t, oldExpiresAt, result.OldKey.ExpiresAt.Time, time.Second)
+	require.True(t, result.OldKey.Usable(time.Now()))
+
+	_, err = store.RevokeAPIKeyTx(context.Background(), result.OldKey.ID)
+	require.NoError(t, err)
+	_, err = store.RotateAPIKeyTx(context.Background(), RotateAPIKeyTxParams{
+		ID:           result.OldKey.ID,
+		Prefix:       util.RandomString(16),
+		SecretHash:   util.RandomString(64),
+		OldExpiresAt: oldExpiresAt,
+	})
+	require.ErrorIs(t, err, apperror.ErrConflict)
+}
+
+func TestRevokeAPIKeyTx(t *testing.T) {
+	store := NewStore(testDB)
+	key := createRandomAPIKey(t, store, createRandomUser(t))
+
+	revoked, err := store.RevokeAPIKeyTx(context.Background(), key.ID)
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+	require.False(t, revoked.Usable(time.Now()))
+
+	_, err = store.RevokeAPIKeyTx(context.Background(), key.ID)
+	require.ErrorIs(t, err, apperror.ErrConflict)
+
+	_, err = store.RevokeAPIKeyTx(context.Background(), 0)
+	require.ErrorIs(t, err, apperror.ErrNotFound)
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+
+	"github.com/ShubhKanodia/GoBank/logging"
+)
+
+// actions recorded in the audit log
+const (
+	AuditUserCreated           = "user.created"
+	AuditAccountCreated        = "account.created"
+	AuditAccountFrozen         = "account.frozen"
+	AuditAccountUnfrozen       = "account.unfrozen"
+	AuditAccountAdjusted       = "account.adjusted"
+	AuditTransferReversed      = "transfer.reversed"
+	AuditTransferApproved      = "transfer_approval.approved"
+	AuditTransferRejected      = "transfer_approval.rejected"
+	AuditKYCSubmitted          = "kyc.submitted"
+	AuditKYCTransitioned       = "kyc.transitioned"
+	AuditRiskAlertResolved     = "risk_alert.resolved"
+	AuditScreeningCaseResolved = "screening_case.resolved"
+	AuditTwoFactorEnabled      = "user.two_factor_enabled"
+	AuditTwoFactorDisabled     = "user.two_factor_disabled"
+	AuditRecoveryCodeUsed      = "user.recovery_code_used"
+	AuditEmailVerified         = "user.email_verified"
+	AuditPasswordReset         = "user.password_reset"
+	AuditUserLocked            = "user.locked"
+	AuditUserUnlocked          = "user.unlocked"
+	AuditSecondFactorLocked    = "user.second_factor_locked"
+	AuditAPIKeyCreated         = "api_key.created"
+	AuditAPIKeyRevoked         = "api_key.revoked"
+	AuditAPIKeyRotated         = "api_key.rotated"
+)
+
+// target types of audit events, the tables the targets live in
+const (
+	AuditTargetUser             = "user"
+	AuditTargetAccount          = "account"
+	AuditTargetTransfer         = "transfer"
+	AuditTargetTransferApproval = "transfer_approval"
+	AuditTargetRiskAlert        = "risk_alert"
+	AuditTargetScreeningCase    = "screening_case"
+	AuditTargetAPIKey           = "api_key"
+)
+
+// Auditor is who is behind a request. the APIs put it in the context of the request, and every audit event
+// written while serving it carries the actor and IP, with the request ID logging keeps in the context
+type Auditor struct {
+	// Actor is the username of the caller, empty before they are authenticated
+	Actor string
+	IP    string
+}
+
+type auditorKey struct{}
+
+// WithAuditor returns a copy of ctx carrying auditor
+func WithAuditor(ctx context.Context, auditor Auditor) context.Context {
+	return context.WithValue(ctx, auditorKey{}, auditor)
+}
+
+// AuditorFrom returns the auditor of ctx, the zero value for work the system does on its own
+func AuditorFrom(ctx context.Context) Auditor {
+	auditor, _ := ctx.Value(auditorKey{}).(Auditor)
+	return auditor
+}
+
+// actingUser makes username the actor of ctx unless the caller is authenticated, for the changes users make to
+// themselves before they have an access token
+func actingUser(ctx context.Context, username string) context.Context {
+	auditor := AuditorFrom(ctx)
+	if auditor.Actor != "" {
+		return ctx
+	}
+	return WithAuditor(ctx, Auditor{Actor: username, IP: auditor.IP})
+}
+
+// auditedUser is a user as the audit log records it, without the password hash
+type auditedUser struct {
+	Username        string `json:"username"`
+	FullName        string `json:"full_name"`
+	Email           string `json:"email"`
+	Role            string `json:"role"`
+	KycStatus       string `json:"kyc_status"`
+	KycTier         int32  `json:"kyc_tier"`
+	SanctionsStatus string `json:"sanctions_status"`
+	EmailVerified   bool   `json:"email_verified"`
+	// TwoFactorEnabled stands in for the TOTP secret, which is never recorded
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+}
+
+func newAuditedUser(user User) auditedUser {
+	return auditedUser{
+		Username:         user.Username,
+		FullName:         user.FullName,
+		Email:            user.Email,
+		Role:             user.Role,
+		KycStatus:        user.KycStatus,
+		KycTier:          user.KycTier,
+		SanctionsStatus:  user.SanctionsStatus,
+		EmailVerified:    user.EmailVerifiedAt.Valid,
+		TwoFactorEnabled: user.TotpEnabledAt.Valid,
+	}
+}
+
+// recordAudit writes an audit event with q, which must be bound to the transaction making the change, so the
+// event commits or rolls back with it. a nil before or after is recorded as json null
+func recordAudit(ctx context.Context, q *Queries, action string, targetType string, targetID string, before any, after any) error {
+	beforeData, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	afterData, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	auditor := AuditorFrom(ctx)
+	requestID := logging.RequestID(ctx)
+	_, err = q.CreateAuditEvent(ctx, CreateAuditEventParams{
+		Actor:      sql.NullString{String: auditor.Actor, Valid: auditor.Actor != ""},
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     beforeData,
+		After:      afterData,
+		RequestID:  sql.NullString{String: requestID, Valid: requestID != ""},
+		Ip:         sql.NullString{String: auditor.IP, Valid: auditor.IP != ""},
+	})
+	return err
+}
+
+// auditID formats the id of a target
+func auditID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditEvents(t *testing.T) {
+	store := NewStore(testDB)
+	admin := createRandomUser(t)
+	account := createRandomAccount(t)
+	ctx := WithAuditor(logging.WithRequestID(context.Background(), "audit-test"), Auditor{Actor: admin.Username, IP: "203.0.113.7"})
+
+	_, err := store.FreezeAccountTx(ctx, account.ID)
+	require.NoError(t, err)
+	// a change that fails leaves no event behind, it rolled back with the transaction
+	_, err = store.FreezeAccountTx(ctx, account.ID)
+	require.ErrorIs(t, err, apperror.ErrConflict)
+
+	events, err := store.ListAuditEvents(ctx, ListAuditEventsParams{
+		TargetType: sql.NullString{String: AuditTargetAccount, Valid: true},
+		TargetID:   sql.NullString{String: auditID(account.ID), Valid: true},
+		PageSize:   5,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	event := events[0]
+	require.Equal(t, AuditAccountFrozen, event.Action)
+	require.Equal(t, admin.Username, event.Actor.String)
+	require.Equal(t, "audit-test", event.RequestID.String)
+	require.Equal(t, "203.0.113.7", event.Ip.String)
+	require.Contains(t, string(event.Before), `"frozen_at":null`)
+
+	// the log is append-only
+	_, err = testDB.Exec("UPDATE audit_events SET actor = 'someone' WHERE id = $1", event.ID)
+	require.ErrorContains(t, err, "append-only")
+	_, err = testDB.Exec("DELETE FROM audit_events WHERE id = $1", event.ID)
+	require.ErrorContains(t, err, "append-only")
+}
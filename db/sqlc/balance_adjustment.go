@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// AdjustBalanceTxParams is a correction of the balance of one account, positive credits and negative debits
+type AdjustBalanceTxParams struct {
+	AccountID int64
+	Amount    int64
+	Reason    string
+	CreatedBy string
+}
+
+// AdjustBalanceTxResult is the adjustment, the entry it booked and the account after it
+type AdjustBalanceTxResult struct {
+	Adjustment BalanceAdjustment `json:"adjustment"`
+	Entry      Entry             `json:"entry"`
+	Account    Account           `json:"account"`
+}
+
+// AccountAdjustedPayload is the payload of EventAccountAdjusted
+type AccountAdjustedPayload struct {
+	Adjustment BalanceAdjustment `json:"adjustment"`
+	Currency   string            `json:"currency"`
+	Balance    int64             `json:"balance"`
+}
+
+// AdjustBalanceTx books an adjustment on an account in one transaction: the entry, the balance, the account event,
+// the audit event and the outbox event. a debit larger than the balance returns apperror.ErrInsufficientFunds
+func (store *SQLStore) AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (result AdjustBalanceTxResult, err error) {
+	ctx, span := startTxSpan(ctx, "AdjustBalanceTx",
+		attribute.Int64("account.id", arg.AccountID),
+		attribute.Int64("adjustment.amount", arg.Amount),
+	)
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountsForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if account.Balance+arg.Amount < 0 {
+			return apperror.InsufficientFunds(fmt.Sprintf("account [%d] has insufficient funds", arg.AccountID))
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{AccountID: arg.AccountID, Amount: arg.Amount})
+		if err != nil {
+			return err
+		}
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{ID: arg.AccountID, Amount: arg.Amount})
+		if err != nil {
+			return err
+		}
+		result.Adjustment, err = q.CreateBalanceAdjustment(ctx, CreateBalanceAdjustmentParams{
+			AccountID: arg.AccountID,
+			EntryID:   result.Entry.ID,
+			Amount:    arg.Amount,
+			Reason:    arg.Reason,
+			CreatedBy: arg.CreatedBy,
+		})
+		if err != nil {
+			return err
+		}
+		// an adjustment has no transfer, its event carries transfer id 0
+		if err = createEntryEvent(ctx, q, result.Entry, 0, result.Account.Balance); err != nil {
+			return err
+		}
+		if err = recordAudit(ctx, q, AuditAccountAdjusted, AuditTargetAccount, auditID(arg.AccountID), account, result); err != nil {
+			return err
+		}
+		return enqueueEvent(ctx, q, AggregateAccount, arg.AccountID, EventAccountAdjusted, AccountAdjustedPayload{
+			Adjustment: result.Adjustment,
+			Currency:   result.Account.Currency,
+			Balance:    result.Account.Balance,
+		})
+	})
+	return result, err
+}
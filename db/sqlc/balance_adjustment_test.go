@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdjustBalanceTx(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	admin := createRandomUser(t)
+	account := createRandomAccount(t)
+
+	result, err := store.AdjustBalanceTx(ctx, AdjustBalanceTxParams{
+		AccountID: account.ID,
+		Amount:    5,
+		Reason:    "card fee refund",
+		CreatedBy: admin.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+5, result.Account.Balance)
+	require.Equal(t, int64(5), result.Entry.Amount)
+	require.Equal(t, result.Entry.ID, result.Adjustment.EntryID)
+
+	// an adjustment cannot leave a negative balance
+	_, err = store.AdjustBalanceTx(ctx, AdjustBalanceTxParams{
+		AccountID: account.ID,
+		Amount:    -result.Account.Balance - 1,
+		Reason:    "chargeback",
+		CreatedBy: admin.Username,
+	})
+	require.ErrorIs(t, err, apperror.ErrInsufficientFunds)
+
+	adjustments, err := store.ListBalanceAdjustments(ctx, ListBalanceAdjustmentsParams{AccountID: account.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, adjustments, 1)
+	require.Equal(t, admin.Username, adjustments[0].CreatedBy)
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+)
+
+// purposes of an email token
+const (
+	EmailTokenVerifyEmail   = "verify_email"
+	EmailTokenResetPassword = "reset_password"
+)
+
+// VerifyEmailTx marks the email of a user verified with the token mailed to it. an unknown, expired or used
+// token, or one sent to an address the user no longer has, returns apperror.ErrValidation
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, tokenHash string) (user User, err error) {
+	ctx, span := startTxSpan(ctx, "VerifyEmailTx")
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		before, err := redeemEmailToken(ctx, q, tokenHash, EmailTokenVerifyEmail)
+		if err != nil {
+			return err
+		}
+		user, err = q.VerifyUserEmail(ctx, before.Username)
+		if err != nil {
+			return err
+		}
+		return recordAudit(actingUser(ctx, user.Username), q, AuditEmailVerified, AuditTargetUser, user.Username, newAuditedUser(before), newAuditedUser(user))
+	})
+	return user, err
+}
+
+// ResetPasswordTxParams sets a new password with a password reset token
+type ResetPasswordTxParams struct {
+	TokenHash      string
+	HashedPassword string
+}
+
+// ResetPasswordTx sets the new password of a user with the token mailed to them, every other reset token of
+// the user is used up with it. bad tokens return apperror.ErrValidation as for VerifyEmailTx
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (user User, err error) {
+	ctx, span := startTxSpan(ctx, "ResetPasswordTx")
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		before, err := redeemEmailToken(ctx, q, arg.TokenHash, EmailTokenResetPassword)
+		if err != nil {
+			return err
+		}
+		user, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Username:       before.Username,
+			HashedPassword: arg.HashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+		err = q.RevokeEmailTokens(ctx, RevokeEmailTokensParams{Username: user.Username, Purpose: EmailTokenResetPassword})
+		if err != nil {
+			return err
+		}
+		return recordAudit(actingUser(ctx, user.Username), q, AuditPasswordReset, AuditTargetUser, user.Username, nil, nil)
+	})
+	return user, err
+}
+
+// redeemEmailToken uses up a token of purpose and returns its user, locked by the transaction of q
+func redeemEmailToken(ctx context.Context, q *Queries, tokenHash string, purpose string) (User, error) {
+	invalid := apperror.Validation("invalid or expired token")
+	token, err := q.GetEmailTokenForUpdate(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, invalid
+		}
+		return User{}, err
+	}
+	if token.Purpose != purpose || token.UsedAt.Valid || !token.ExpiresAt.After(time.Now()) {
+		return User{}, invalid
+	}
+
+	user, err := q.GetUserForUpdate(ctx, token.Username)
+	if err != nil {
+		return User{}, err
+	}
+	if user.Email != token.Email {
+		return User{}, invalid
+	}
+	if _, err := q.UseEmailToken(ctx, token.ID); err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// newEmailToken creates a token of purpose for the current email of user and returns the raw token
+func newEmailToken(t *testing.T, user User, purpose string, expiresAt time.Time) string {
+	raw, err := token.NewOpaque()
+	require.NoError(t, err)
+	_, err = testQueries.CreateEmailToken(context.Background(), CreateEmailTokenParams{
+		Username:  user.Username,
+		Purpose:   purpose,
+		TokenHash: token.HashOpaque(raw),
+		Email:     user.Email,
+		ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	return raw
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	require.False(t, user.EmailVerifiedAt.Valid)
+
+	raw := newEmailToken(t, user, EmailTokenVerifyEmail, time.Now().Add(time.Hour))
+	verified, err := store.VerifyEmailTx(context.Background(), token.HashOpaque(raw))
+	require.NoError(t, err)
+	require.True(t, verified.EmailVerifiedAt.Valid)
+
+	// tokens are single use
+	_, err = store.VerifyEmailTx(context.Background(), token.HashOpaque(raw))
+	require.ErrorIs(t, err, apperror.ErrValidation)
+
+	// nor does a token verify anything but an email
+	raw = newEmailToken(t, user, EmailTokenResetPassword, time.Now().Add(time.Hour))
+	_, err = store.VerifyEmailTx(context.Background(), token.HashOpaque(raw))
+	require.ErrorIs(t, err, apperror.ErrValidation)
+
+	_, err = store.VerifyEmailTx(context.Background(), token.HashOpaque("unknown"))
+	require.ErrorIs(t, err, apperror.ErrValidation)
+}
+
+func TestVerifyEmailTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	raw := newEmailToken(t, user, EmailTokenVerifyEmail, time.Now().Add(-time.Minute))
+	_, err := store.VerifyEmailTx(context.Background(), token.HashOpaque(raw))
+	require.ErrorIs(t, err, apperror.ErrValidation)
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	raw := newEmailToken(t, user, EmailTokenResetPassword, time.Now().Add(time.Hour))
+	other := newEmailToken(t, user, EmailTokenResetPassword, time.Now().Add(time.Hour))
+
+	hashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+	reset, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      token.HashOpaque(raw),
+		HashedPassword: hashedPassword,
+	})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, reset.HashedPassword)
+	require.True(t, reset.PasswordChangedAt.After(user.PasswordChangedAt))
+
+	// the other reset tokens of the user are used up with it
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      token.HashOpaque(other),
+		HashedPassword: hashedPassword,
+	})
+	require.ErrorIs(t, err, apperror.ErrValidation)
+}
@@ -0,0 +1,686 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// constraintMessages gives client facing messages for the constraints clients can trip over
+var constraintMessages = map[string]string{
+	"owner_currency_key":  "owner already has an account in this currency",
+	"accounts_owner_fkey": "owner does not exist",
+	"users_pkey":          "username already exists",
+	"users_email_key":     "email already exists",
+}
+
+// TranslateError maps database errors into domain errors so callers never see raw postgres messages.
+// domain errors and nil pass through unchanged, unknown errors are kept as they are and end up as internal errors
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return apperror.Wrap(apperror.CodeNotFound, err, "record not found")
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	message, known := constraintMessages[pqErr.Constraint]
+	switch pqErr.Code.Name() {
+	case "unique_violation":
+		if !known {
+			message = "record already exists"
+		}
+		return apperror.Wrap(apperror.CodeConflict, err, message)
+	case "foreign_key_violation":
+		if !known {
+			message = "referenced record does not exist"
+		}
+		return apperror.Wrap(apperror.CodeConflict, err, message)
+	case "check_violation", "not_null_violation", "invalid_text_representation", "numeric_value_out_of_range":
+		if !known {
+			message = "invalid value"
+		}
+		return apperror.Wrap(apperror.CodeValidation, err, message)
+	}
+	return err
+}
+
+// the methods below shadow the embedded *Queries so errors leaving SQLStore are already translated.
+// transactions get the same treatment in execTx
+
+func (store *SQLStore) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
+	account, err := store.Queries.AddAccountBalance(ctx, arg)
+	return account, TranslateError(err)
+}
+
+func (store *SQLStore) AddLoginChallengeAttempt(ctx context.Context, id uuid.UUID) (LoginChallenge, error) {
+	challenge, err := store.Queries.AddLoginChallengeAttempt(ctx, id)
+	return challenge, TranslateError(err)
+}
+
+func (store *SQLStore) AddLoginFailure(ctx context.Context, arg AddLoginFailureParams) (LoginFailure, error) {
+	failure, err := store.Queries.AddLoginFailure(ctx, arg)
+	return failure, TranslateError(err)
+}
+
+func (store *SQLStore) CancelScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error) {
+	scheduled, err := store.Queries.CancelScheduledTransfer(ctx, id)
+	return scheduled, TranslateError(err)
+}
+
+func (store *SQLStore) CancelStandingOrder(ctx context.Context, id int64) (StandingOrder, error) {
+	order, err := store.Queries.CancelStandingOrder(ctx, id)
+	return order, TranslateError(err)
+}
+
+func (store *SQLStore) ClaimDueScheduledTransfer(ctx context.Context) (ScheduledTransfer, error) {
+	scheduled, err := store.Queries.ClaimDueScheduledTransfer(ctx)
+	return scheduled, TranslateError(err)
+}
+
+func (store *SQLStore) ClaimDueStandingOrder(ctx context.Context) (StandingOrder, error) {
+	order, err := store.Queries.ClaimDueStandingOrder(ctx)
+	return order, TranslateError(err)
+}
+
+func (store *SQLStore) ClaimJobs(ctx context.Context, arg ClaimJobsParams) ([]Job, error) {
+	jobs, err := store.Queries.ClaimJobs(ctx, arg)
+	return jobs, TranslateError(err)
+}
+
+func (store *SQLStore) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]Outbox, error) {
+	events, err := store.Queries.ClaimOutboxEvents(ctx, arg)
+	return events, TranslateError(err)
+}
+
+func (store *SQLStore) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	deliveries, err := store.Queries.ClaimWebhookDeliveries(ctx, arg)
+	return deliveries, TranslateError(err)
+}
+
+func (store *SQLStore) CompleteLoginChallenge(ctx context.Context, id uuid.UUID) (LoginChallenge, error) {
+	challenge, err := store.Queries.CompleteLoginChallenge(ctx, id)
+	return challenge, TranslateError(err)
+}
+
+func (store *SQLStore) CountAccountsByOwner(ctx context.Context, owner string) (int64, error) {
+	count, err := store.Queries.CountAccountsByOwner(ctx, owner)
+	return count, TranslateError(err)
+}
+
+func (store *SQLStore) CountUnusedRecoveryCodes(ctx context.Context, username string) (int64, error) {
+	count, err := store.Queries.CountUnusedRecoveryCodes(ctx, username)
+	return count, TranslateError(err)
+}
+
+func (store *SQLStore) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
+	key, err := store.Queries.CreateAPIKey(ctx, arg)
+	return key, TranslateError(err)
+}
+
+func (store *SQLStore) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	account, err := store.Queries.CreateAccount(ctx, arg)
+	return account, TranslateError(err)
+}
+
+func (store *SQLStore) CreateAccountEvent(ctx context.Context, arg CreateAccountEventParams) (AccountEvent, error) {
+	event, err := store.Queries.CreateAccountEvent(ctx, arg)
+	return event, TranslateError(err)
+}
+
+func (store *SQLStore) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error) {
+	event, err := store.Queries.CreateAuditEvent(ctx, arg)
+	return event, TranslateError(err)
+}
+
+func (store *SQLStore) CreateBalanceAdjustment(ctx context.Context, arg CreateBalanceAdjustmentParams) (BalanceAdjustment, error) {
+	adjustment, err := store.Queries.CreateBalanceAdjustment(ctx, arg)
+	return adjustment, TranslateError(err)
+}
+
+func (store *SQLStore) CreateEmailToken(ctx context.Context, arg CreateEmailTokenParams) (EmailToken, error) {
+	emailToken, err := store.Queries.CreateEmailToken(ctx, arg)
+	return emailToken, TranslateError(err)
+}
+
+func (store *SQLStore) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
+	entry, err := store.Queries.CreateEntry(ctx, arg)
+	return entry, TranslateError(err)
+}
+
+func (store *SQLStore) CreateJob(ctx context.Context, arg CreateJobParams) (Job, error) {
+	job, err := store.Queries.CreateJob(ctx, arg)
+	return job, TranslateError(err)
+}
+
+func (store *SQLStore) CreateKYCDocument(ctx context.Context, arg CreateKYCDocumentParams) (KycDocument, error) {
+	document, err := store.Queries.CreateKYCDocument(ctx, arg)
+	return document, TranslateError(err)
+}
+
+func (store *SQLStore) CreateKYCEvent(ctx context.Context, arg CreateKYCEventParams) (KycEvent, error) {
+	event, err := store.Queries.CreateKYCEvent(ctx, arg)
+	return event, TranslateError(err)
+}
+
+func (store *SQLStore) CreateLoginChallenge(ctx context.Context, arg CreateLoginChallengeParams) (LoginChallenge, error) {
+	challenge, err := store.Queries.CreateLoginChallenge(ctx, arg)
+	return challenge, TranslateError(err)
+}
+
+func (store *SQLStore) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (Outbox, error) {
+	event, err := store.Queries.CreateOutboxEvent(ctx, arg)
+	return event, TranslateError(err)
+}
+
+func (store *SQLStore) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	return TranslateError(store.Queries.CreateRecoveryCode(ctx, arg))
+}
+
+func (store *SQLStore) CreateReversal(ctx context.Context, arg CreateReversalParams) (Transfer, error) {
+	transfer, err := store.Queries.CreateReversal(ctx, arg)
+	return transfer, TranslateError(err)
+}
+
+func (store *SQLStore) CreateRiskAlert(ctx context.Context, arg CreateRiskAlertParams) (RiskAlert, error) {
+	alert, err := store.Queries.CreateRiskAlert(ctx, arg)
+	return alert, TranslateError(err)
+}
+
+func (store *SQLStore) CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error) {
+	scheduled, err := store.Queries.CreateScheduledTransfer(ctx, arg)
+	return scheduled, TranslateError(err)
+}
+
+func (store *SQLStore) CreateScreeningCase(ctx context.Context, arg CreateScreeningCaseParams) (ScreeningCase, error) {
+	screeningCase, err := store.Queries.CreateScreeningCase(ctx, arg)
+	return screeningCase, TranslateError(err)
+}
+
+func (store *SQLStore) CreateStandingOrder(ctx context.Context, arg CreateStandingOrderParams) (StandingOrder, error) {
+	order, err := store.Queries.CreateStandingOrder(ctx, arg)
+	return order, TranslateError(err)
+}
+
+func (store *SQLStore) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
+	transfer, err := store.Queries.CreateTransfer(ctx, arg)
+	return transfer, TranslateError(err)
+}
+
+func (store *SQLStore) CreateTransferApproval(ctx context.Context, arg CreateTransferApprovalParams) (TransferApproval, error) {
+	approval, err := store.Queries.CreateTransferApproval(ctx, arg)
+	return approval, TranslateError(err)
+}
+
+func (store *SQLStore) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	user, err := store.Queries.CreateUser(ctx, arg)
+	return user, TranslateError(err)
+}
+
+func (store *SQLStore) CreateWebhookDeliveries(ctx context.Context, arg CreateWebhookDeliveriesParams) (int64, error) {
+	rows, err := store.Queries.CreateWebhookDeliveries(ctx, arg)
+	return rows, TranslateError(err)
+}
+
+func (store *SQLStore) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error) {
+	endpoint, err := store.Queries.CreateWebhookEndpoint(ctx, arg)
+	return endpoint, TranslateError(err)
+}
+
+func (store *SQLStore) DeleteAccount(ctx context.Context, id int64) error {
+	return TranslateError(store.Queries.DeleteAccount(ctx, id))
+}
+
+func (store *SQLStore) DeleteIdleRateLimitBuckets(ctx context.Context, idleSince time.Time) (int64, error) {
+	rows, err := store.Queries.DeleteIdleRateLimitBuckets(ctx, idleSince)
+	return rows, TranslateError(err)
+}
+
+func (store *SQLStore) DeleteLoginFailures(ctx context.Context, arg DeleteLoginFailuresParams) (int64, error) {
+	rows, err := store.Queries.DeleteLoginFailures(ctx, arg)
+	return rows, TranslateError(err)
+}
+
+func (store *SQLStore) DeleteRecoveryCodes(ctx context.Context, username string) error {
+	return TranslateError(store.Queries.DeleteRecoveryCodes(ctx, username))
+}
+
+func (store *SQLStore) DeleteWebhookEndpoint(ctx context.Context, id int64) error {
+	return TranslateError(store.Queries.DeleteWebhookEndpoint(ctx, id))
+}
+
+func (store *SQLStore) DisableTOTP(ctx context.Context, username string) (User, error) {
+	user, err := store.Queries.DisableTOTP(ctx, username)
+	return user, TranslateError(err)
+}
+
+func (store *SQLStore) EnableTOTP(ctx context.Context, arg EnableTOTPParams) (User, error) {
+	user, err := store.Queries.EnableTOTP(ctx, arg)
+	return user, TranslateError(err)
+}
+
+func (store *SQLStore) ExpireTransferApprovals(ctx context.Context) ([]TransferApproval, error) {
+	approvals, err := store.Queries.ExpireTransferApprovals(ctx)
+	return approvals, TranslateError(err)
+}
+
+func (store *SQLStore) FreezeAccount(ctx context.Context, id int64) (Account, error) {
+	account, err := store.Queries.FreezeAccount(ctx, id)
+	return account, TranslateError(err)
+}
+
+func (store *SQLStore) GetAPIKey(ctx context.Context, id int64) (ApiKey, error) {
+	key, err := store.Queries.GetAPIKey(ctx, id)
+	return key, TranslateError(err)
+}
+
+func (store *SQLStore) GetAPIKeyCaller(ctx context.Context, prefix string) (GetAPIKeyCallerRow, error) {
+	row, err := store.Queries.GetAPIKeyCaller(ctx, prefix)
+	return row, TranslateError(err)
+}
+
+func (store *SQLStore) GetAPIKeyForUpdate(ctx context.Context, id int64) (ApiKey, error) {
+	key, err := store.Queries.GetAPIKeyForUpdate(ctx, id)
+	return key, TranslateError(err)
+}
+
+func (store *SQLStore) GetAccount(ctx context.Context, id int64) (Account, error) {
+	account, err := store.Queries.GetAccount(ctx, id)
+	return account, TranslateError(err)
+}
+
+func (store *SQLStore) GetAccountsForUpdate(ctx context.Context, id int64) (Account, error) {
+	account, err := store.Queries.GetAccountsForUpdate(ctx, id)
+	return account, TranslateError(err)
+}
+
+func (store *SQLStore) GetEmailTokenForUpdate(ctx context.Context, tokenHash string) (EmailToken, error) {
+	emailToken, err := store.Queries.GetEmailTokenForUpdate(ctx, tokenHash)
+	return emailToken, TranslateError(err)
+}
+
+func (store *SQLStore) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	entry, err := store.Queries.GetEntry(ctx, id)
+	return entry, TranslateError(err)
+}
+
+func (store *SQLStore) GetJob(ctx context.Context, id int64) (Job, error) {
+	job, err := store.Queries.GetJob(ctx, id)
+	return job, TranslateError(err)
+}
+
+func (store *SQLStore) GetLoginChallengeForUpdate(ctx context.Context, id uuid.UUID) (LoginChallenge, error) {
+	challenge, err := store.Queries.GetLoginChallengeForUpdate(ctx, id)
+	return challenge, TranslateError(err)
+}
+
+func (store *SQLStore) GetLoginFailureForUpdate(ctx context.Context, arg GetLoginFailureForUpdateParams) (LoginFailure, error) {
+	failure, err := store.Queries.GetLoginFailureForUpdate(ctx, arg)
+	return failure, TranslateError(err)
+}
+
+func (store *SQLStore) GetOwnerTransferLimits(ctx context.Context, arg GetOwnerTransferLimitsParams) (GetOwnerTransferLimitsRow, error) {
+	limits, err := store.Queries.GetOwnerTransferLimits(ctx, arg)
+	return limits, TranslateError(err)
+}
+
+func (store *SQLStore) GetRiskAlert(ctx context.Context, id int64) (RiskAlert, error) {
+	alert, err := store.Queries.GetRiskAlert(ctx, id)
+	return alert, TranslateError(err)
+}
+
+func (store *SQLStore) GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error) {
+	scheduled, err := store.Queries.GetScheduledTransfer(ctx, id)
+	return scheduled, TranslateError(err)
+}
+
+func (store *SQLStore) GetScreeningCase(ctx context.Context, id int64) (ScreeningCase, error) {
+	screeningCase, err := store.Queries.GetScreeningCase(ctx, id)
+	return screeningCase, TranslateError(err)
+}
+
+func (store *SQLStore) GetStandingOrder(ctx context.Context, id int64) (StandingOrder, error) {
+	order, err := store.Queries.GetStandingOrder(ctx, id)
+	return order, TranslateError(err)
+}
+
+func (store *SQLStore) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
+	transfer, err := store.Queries.GetTransfer(ctx, id)
+	return transfer, TranslateError(err)
+}
+
+func (store *SQLStore) GetTransferApproval(ctx context.Context, id int64) (TransferApproval, error) {
+	approval, err := store.Queries.GetTransferApproval(ctx, id)
+	return approval, TranslateError(err)
+}
+
+func (store *SQLStore) GetTransferApprovalForUpdate(ctx context.Context, id int64) (TransferApproval, error) {
+	approval, err := store.Queries.GetTransferApprovalForUpdate(ctx, id)
+	return approval, TranslateError(err)
+}
+
+func (store *SQLStore) GetTransferLimits(ctx context.Context, id int64) (GetTransferLimitsRow, error) {
+	limits, err := store.Queries.GetTransferLimits(ctx, id)
+	return limits, TranslateError(err)
+}
+
+func (store *SQLStore) GetUser(ctx context.Context, username string) (User, error) {
+	user, err := store.Queries.GetUser(ctx, username)
+	return user, TranslateError(err)
+}
+
+func (store *SQLStore) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	user, err := store.Queries.GetUserByEmail(ctx, email)
+	return user, TranslateError(err)
+}
+
+func (store *SQLStore) GetUserForUpdate(ctx context.Context, username string) (User, error) {
+	user, err := store.Queries.GetUserForUpdate(ctx, username)
+	return user, TranslateError(err)
+}
+
+func (store *SQLStore) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	delivery, err := store.Queries.GetWebhookDelivery(ctx, id)
+	return delivery, TranslateError(err)
+}
+
+func (store *SQLStore) GetWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoint, error) {
+	endpoint, err := store.Queries.GetWebhookEndpoint(ctx, id)
+	return endpoint, TranslateError(err)
+}
+
+func (store *SQLStore) ListAPIKeys(ctx context.Context, username string) ([]ApiKey, error) {
+	keys, err := store.Queries.ListAPIKeys(ctx, username)
+	return keys, TranslateError(err)
+}
+
+func (store *SQLStore) ListAccountEvents(ctx context.Context, arg ListAccountEventsParams) ([]AccountEvent, error) {
+	events, err := store.Queries.ListAccountEvents(ctx, arg)
+	return events, TranslateError(err)
+}
+
+func (store *SQLStore) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	accounts, err := store.Queries.ListAccounts(ctx, arg)
+	return accounts, TranslateError(err)
+}
+
+func (store *SQLStore) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	events, err := store.Queries.ListAuditEvents(ctx, arg)
+	return events, TranslateError(err)
+}
+
+func (store *SQLStore) ListBalanceAdjustments(ctx context.Context, arg ListBalanceAdjustmentsParams) ([]BalanceAdjustment, error) {
+	adjustments, err := store.Queries.ListBalanceAdjustments(ctx, arg)
+	return adjustments, TranslateError(err)
+}
+
+func (store *SQLStore) ListBlockedOwners(ctx context.Context, arg ListBlockedOwnersParams) ([]string, error) {
+	owners, err := store.Queries.ListBlockedOwners(ctx, arg)
+	return owners, TranslateError(err)
+}
+
+func (store *SQLStore) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	entries, err := store.Queries.ListEntries(ctx, arg)
+	return entries, TranslateError(err)
+}
+
+func (store *SQLStore) ListKYCDocuments(ctx context.Context, username string) ([]KycDocument, error) {
+	documents, err := store.Queries.ListKYCDocuments(ctx, username)
+	return documents, TranslateError(err)
+}
+
+func (store *SQLStore) ListKYCEvents(ctx context.Context, username string) ([]KycEvent, error) {
+	events, err := store.Queries.ListKYCEvents(ctx, username)
+	return events, TranslateError(err)
+}
+
+func (store *SQLStore) ListLoginFailures(ctx context.Context, arg ListLoginFailuresParams) ([]LoginFailure, error) {
+	failures, err := store.Queries.ListLoginFailures(ctx, arg)
+	return failures, TranslateError(err)
+}
+
+func (store *SQLStore) ListPendingTransferApprovals(ctx context.Context, arg ListPendingTransferApprovalsParams) ([]TransferApproval, error) {
+	approvals, err := store.Queries.ListPendingTransferApprovals(ctx, arg)
+	return approvals, TranslateError(err)
+}
+
+func (store *SQLStore) ListRiskAlerts(ctx context.Context, arg ListRiskAlertsParams) ([]RiskAlert, error) {
+	alerts, err := store.Queries.ListRiskAlerts(ctx, arg)
+	return alerts, TranslateError(err)
+}
+
+func (store *SQLStore) ListRiskTransfers(ctx context.Context, arg ListRiskTransfersParams) ([]Transfer, error) {
+	transfers, err := store.Queries.ListRiskTransfers(ctx, arg)
+	return transfers, TranslateError(err)
+}
+
+func (store *SQLStore) ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error) {
+	scheduled, err := store.Queries.ListScheduledTransfers(ctx, arg)
+	return scheduled, TranslateError(err)
+}
+
+func (store *SQLStore) ListScreeningCases(ctx context.Context, arg ListScreeningCasesParams) ([]ScreeningCase, error) {
+	cases, err := store.Queries.ListScreeningCases(ctx, arg)
+	return cases, TranslateError(err)
+}
+
+func (store *SQLStore) ListStandingOrderOccurrences(ctx context.Context, arg ListStandingOrderOccurrencesParams) ([]StandingOrderOccurrence, error) {
+	occurrences, err := store.Queries.ListStandingOrderOccurrences(ctx, arg)
+	return occurrences, TranslateError(err)
+}
+
+func (store *SQLStore) ListStandingOrders(ctx context.Context, arg ListStandingOrdersParams) ([]StandingOrder, error) {
+	orders, err := store.Queries.ListStandingOrders(ctx, arg)
+	return orders, TranslateError(err)
+}
+
+func (store *SQLStore) ListTransferApprovals(ctx context.Context, arg ListTransferApprovalsParams) ([]TransferApproval, error) {
+	approvals, err := store.Queries.ListTransferApprovals(ctx, arg)
+	return approvals, TranslateError(err)
+}
+
+func (store *SQLStore) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	transfers, err := store.Queries.ListTransfers(ctx, arg)
+	return transfers, TranslateError(err)
+}
+
+func (store *SQLStore) ListUsersByKYCStatus(ctx context.Context, arg ListUsersByKYCStatusParams) ([]User, error) {
+	users, err := store.Queries.ListUsersByKYCStatus(ctx, arg)
+	return users, TranslateError(err)
+}
+
+func (store *SQLStore) ListUsersForScreening(ctx context.Context, arg ListUsersForScreeningParams) ([]ListUsersForScreeningRow, error) {
+	users, err := store.Queries.ListUsersForScreening(ctx, arg)
+	return users, TranslateError(err)
+}
+
+func (store *SQLStore) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	deliveries, err := store.Queries.ListWebhookDeliveries(ctx, arg)
+	return deliveries, TranslateError(err)
+}
+
+func (store *SQLStore) ListWebhookEndpoints(ctx context.Context, owner string) ([]WebhookEndpoint, error) {
+	endpoints, err := store.Queries.ListWebhookEndpoints(ctx, owner)
+	return endpoints, TranslateError(err)
+}
+
+func (store *SQLStore) LockLoginSubject(ctx context.Context, arg LockLoginSubjectParams) (LoginFailure, error) {
+	failure, err := store.Queries.LockLoginSubject(ctx, arg)
+	return failure, TranslateError(err)
+}
+
+func (store *SQLStore) MarkJobFailed(ctx context.Context, arg MarkJobFailedParams) (int64, error) {
+	rows, err := store.Queries.MarkJobFailed(ctx, arg)
+	return rows, TranslateError(err)
+}
+
+func (store *SQLStore) MarkJobSucceeded(ctx context.Context, arg MarkJobSucceededParams) (int64, error) {
+	rows, err := store.Queries.MarkJobSucceeded(ctx, arg)
+	return rows, TranslateError(err)
+}
+
+func (store *SQLStore) MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error {
+	return TranslateError(store.Queries.MarkOutboxEventFailed(ctx, arg))
+}
+
+func (store *SQLStore) MarkOutboxEventSent(ctx context.Context, id int64) error {
+	return TranslateError(store.Queries.MarkOutboxEventSent(ctx, id))
+}
+
+func (store *SQLStore) MarkScheduledTransferFailed(ctx context.Context, arg MarkScheduledTransferFailedParams) (ScheduledTransfer, error) {
+	scheduled, err := store.Queries.MarkScheduledTransferFailed(ctx, arg)
+	return scheduled, TranslateError(err)
+}
+
+func (store *SQLStore) MarkScheduledTransferSucceeded(ctx context.Context, arg MarkScheduledTransferSucceededParams) (ScheduledTransfer, error) {
+	scheduled, err := store.Queries.MarkScheduledTransferSucceeded(ctx, arg)
+	return scheduled, TranslateError(err)
+}
+
+func (store *SQLStore) MarkWebhookDeliveryFailed(ctx context.Context, arg MarkWebhookDeliveryFailedParams) error {
+	return TranslateError(store.Queries.MarkWebhookDeliveryFailed(ctx, arg))
+}
+
+func (store *SQLStore) MarkWebhookDeliverySucceeded(ctx context.Context, arg MarkWebhookDeliverySucceededParams) error {
+	return TranslateError(store.Queries.MarkWebhookDeliverySucceeded(ctx, arg))
+}
+
+func (store *SQLStore) RedeliverWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	delivery, err := store.Queries.RedeliverWebhookDelivery(ctx, id)
+	return delivery, TranslateError(err)
+}
+
+func (store *SQLStore) RefreshSanctionsStatus(ctx context.Context, username string) (User, error) {
+	user, err := store.Queries.RefreshSanctionsStatus(ctx, username)
+	return user, TranslateError(err)
+}
+
+func (store *SQLStore) ResolveRiskAlert(ctx context.Context, arg ResolveRiskAlertParams) (RiskAlert, error) {
+	alert, err := store.Queries.ResolveRiskAlert(ctx, arg)
+	return alert, TranslateError(err)
+}
+
+func (store *SQLStore) ResolveScreeningCase(ctx context.Context, arg ResolveScreeningCaseParams) (ScreeningCase, error) {
+	screeningCase, err := store.Queries.ResolveScreeningCase(ctx, arg)
+	return screeningCase, TranslateError(err)
+}
+
+func (store *SQLStore) RetryScheduledTransfer(ctx context.Context, arg RetryScheduledTransferParams) (ScheduledTransfer, error) {
+	scheduled, err := store.Queries.RetryScheduledTransfer(ctx, arg)
+	return scheduled, TranslateError(err)
+}
+
+func (store *SQLStore) ReviewTransferApproval(ctx context.Context, arg ReviewTransferApprovalParams) (TransferApproval, error) {
+	approval, err := store.Queries.ReviewTransferApproval(ctx, arg)
+	return approval, TranslateError(err)
+}
+
+func (store *SQLStore) RevokeAPIKey(ctx context.Context, id int64) (ApiKey, error) {
+	key, err := store.Queries.RevokeAPIKey(ctx, id)
+	return key, TranslateError(err)
+}
+
+func (store *SQLStore) RevokeEmailTokens(ctx context.Context, arg RevokeEmailTokensParams) error {
+	return TranslateError(store.Queries.RevokeEmailTokens(ctx, arg))
+}
+
+func (store *SQLStore) SetAPIKeyExpiry(ctx context.Context, arg SetAPIKeyExpiryParams) (ApiKey, error) {
+	key, err := store.Queries.SetAPIKeyExpiry(ctx, arg)
+	return key, TranslateError(err)
+}
+
+func (store *SQLStore) SetAccountTransferLimit(ctx context.Context, arg SetAccountTransferLimitParams) (TransferLimit, error) {
+	limit, err := store.Queries.SetAccountTransferLimit(ctx, arg)
+	return limit, TranslateError(err)
+}
+
+func (store *SQLStore) SetTOTPSecret(ctx context.Context, arg SetTOTPSecretParams) (User, error) {
+	user, err := store.Queries.SetTOTPSecret(ctx, arg)
+	return user, TranslateError(err)
+}
+
+func (store *SQLStore) SumOutgoingTransfers(ctx context.Context, arg SumOutgoingTransfersParams) (SumOutgoingTransfersRow, error) {
+	sums, err := store.Queries.SumOutgoingTransfers(ctx, arg)
+	return sums, TranslateError(err)
+}
+
+func (store *SQLStore) SumOwnerOutgoingTransfers(ctx context.Context, arg SumOwnerOutgoingTransfersParams) (SumOwnerOutgoingTransfersRow, error) {
+	sums, err := store.Queries.SumOwnerOutgoingTransfers(ctx, arg)
+	return sums, TranslateError(err)
+}
+
+func (store *SQLStore) TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (TakeRateLimitTokenRow, error) {
+	row, err := store.Queries.TakeRateLimitToken(ctx, arg)
+	return row, TranslateError(err)
+}
+
+func (store *SQLStore) TouchAPIKey(ctx context.Context, arg TouchAPIKeyParams) error {
+	return TranslateError(store.Queries.TouchAPIKey(ctx, arg))
+}
+
+func (store *SQLStore) UnfreezeAccount(ctx context.Context, id int64) (Account, error) {
+	account, err := store.Queries.UnfreezeAccount(ctx, id)
+	return account, TranslateError(err)
+}
+
+func (store *SQLStore) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	account, err := store.Queries.UpdateAccount(ctx, arg)
+	return account, TranslateError(err)
+}
+
+func (store *SQLStore) UpdateKYCDetails(ctx context.Context, arg UpdateKYCDetailsParams) (User, error) {
+	user, err := store.Queries.UpdateKYCDetails(ctx, arg)
+	return user, TranslateError(err)
+}
+
+func (store *SQLStore) UpdateKYCStatus(ctx context.Context, arg UpdateKYCStatusParams) (User, error) {
+	user, err := store.Queries.UpdateKYCStatus(ctx, arg)
+	return user, TranslateError(err)
+}
+
+func (store *SQLStore) UpdateStandingOrderSchedule(ctx context.Context, arg UpdateStandingOrderScheduleParams) (StandingOrder, error) {
+	order, err := store.Queries.UpdateStandingOrderSchedule(ctx, arg)
+	return order, TranslateError(err)
+}
+
+func (store *SQLStore) UpdateTOTPLastStep(ctx context.Context, arg UpdateTOTPLastStepParams) error {
+	return TranslateError(store.Queries.UpdateTOTPLastStep(ctx, arg))
+}
+
+func (store *SQLStore) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	user, err := store.Queries.UpdateUserPassword(ctx, arg)
+	return user, TranslateError(err)
+}
+
+func (store *SQLStore) UpsertStandingOrderOccurrence(ctx context.Context, arg UpsertStandingOrderOccurrenceParams) (StandingOrderOccurrence, error) {
+	occurrence, err := store.Queries.UpsertStandingOrderOccurrence(ctx, arg)
+	return occurrence, TranslateError(err)
+}
+
+func (store *SQLStore) UseEmailToken(ctx context.Context, id int64) (EmailToken, error) {
+	emailToken, err := store.Queries.UseEmailToken(ctx, id)
+	return emailToken, TranslateError(err)
+}
+
+func (store *SQLStore) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error) {
+	code, err := store.Queries.UseRecoveryCode(ctx, arg)
+	return code, TranslateError(err)
+}
+
+func (store *SQLStore) VerifyUserEmail(ctx context.Context, username string) (User, error) {
+	user, err := store.Queries.VerifyUserEmail(ctx, username)
+	return user, TranslateError(err)
+}
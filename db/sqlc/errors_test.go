@@ -0,0 +1,65 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		err     error
+		code    apperror.Code
+		message string
+	}{
+		{
+			name:    "NoRows",
+			err:     sql.ErrNoRows,
+			code:    apperror.CodeNotFound,
+			message: "record not found",
+		},
+		{
+			name:    "DuplicateAccountCurrency",
+			err:     &pq.Error{Code: "23505", Constraint: "owner_currency_key"},
+			code:    apperror.CodeConflict,
+			message: "owner already has an account in this currency",
+		},
+		{
+			name:    "UnknownOwner",
+			err:     &pq.Error{Code: "23503", Constraint: "accounts_owner_fkey"},
+			code:    apperror.CodeConflict,
+			message: "owner does not exist",
+		},
+		{
+			name:    "CheckViolation",
+			err:     &pq.Error{Code: "23514", Constraint: "some_check"},
+			code:    apperror.CodeValidation,
+			message: "invalid value",
+		},
+		{
+			name: "Unknown",
+			err:  sql.ErrConnDone,
+			code: apperror.CodeInternal,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			err := TranslateError(tc.err)
+			require.ErrorIs(t, err, tc.err)
+
+			appErr := apperror.As(err)
+			require.Equal(t, tc.code, appErr.Code)
+			if tc.message != "" {
+				require.Equal(t, tc.message, appErr.Message)
+			}
+		})
+	}
+
+	require.NoError(t, TranslateError(nil))
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// AccountEventEntryCreated is recorded for each side of a transfer and for balance adjustments, carrying the entry
+// and the resulting balance
+const AccountEventEntryCreated = "entry.created"
+
+// EntryCreatedEvent is the data of an AccountEventEntryCreated event
+type EntryCreatedEvent struct {
+	Entry Entry `json:"entry"`
+	// TransferID is 0 for a balance adjustment
+	TransferID int64 `json:"transfer_id"`
+	Balance    int64 `json:"balance"`
+}
+
+// createEntryEvent records the entry in the account's event history, inserting it fires the NOTIFY streams listen for
+func createEntryEvent(ctx context.Context, q *Queries, entry Entry, transferID int64, balance int64) error {
+	data, err := json.Marshal(EntryCreatedEvent{
+		Entry:      entry,
+		TransferID: transferID,
+		Balance:    balance,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = q.CreateAccountEvent(ctx, CreateAccountEventParams{
+		AccountID: entry.AccountID,
+		Type:      AccountEventEntryCreated,
+		Data:      data,
+	})
+	return err
+}
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Ping verifies that the database is reachable
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return store.db.PingContext(ctx)
+}
+
+// schema_migrations is maintained by golang-migrate and is not part of our schema,
+// so this query is written by hand instead of being generated by sqlc
+const migrationVersion = `SELECT version, dirty FROM schema_migrations LIMIT 1`
+
+// MigrationVersion returns the schema version applied to the database and whether the last migration failed half way.
+// a database that has never been migrated reports version 0
+func (store *SQLStore) MigrationVersion(ctx context.Context) (version uint, dirty bool, err error) {
+	var v int64
+	err = store.db.QueryRowContext(ctx, migrationVersion).Scan(&v, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint(v), dirty, nil
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/ShubhKanodia/GoBank/db/sqlc")
+
+// instrumentedDBTX wraps the *sql.DB or *sql.Tx handed to Queries, it records the duration of every query
+// and opens a span for it. every Querier method runs exactly one query, so this gives one span per method.
+// sqlc starts every generated query with "-- name: <Name> :<kind>", which is where the query name comes from
+type instrumentedDBTX struct {
+	DBTX
+}
+
+func instrument(db DBTX) DBTX {
+	return &instrumentedDBTX{DBTX: db}
+}
+
+// ExecContext records the rows the statement affected as db.exec.rows_affected. only Exec knows its row count when
+// it returns, queries hand back rows that are read later, so their spans carry no count
+func (db *instrumentedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span, start := startQuery(ctx, query)
+	result, err := db.DBTX.ExecContext(ctx, query, args...)
+	if err == nil {
+		if rows, rowsErr := result.RowsAffected(); rowsErr == nil {
+			span.SetAttributes(attribute.Int64("db.exec.rows_affected", rows))
+		}
+	}
+	endQuery(ctx, span, query, start, err)
+	return result, err
+}
+
+// QueryContext hands back the rows before they are read, so the span covers the round trip, not the scan
+func (db *instrumentedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span, start := startQuery(ctx, query)
+	rows, err := db.DBTX.QueryContext(ctx, query, args...)
+	endQuery(ctx, span, query, start, err)
+	return rows, err
+}
+
+// QueryRowContext defers its error to Scan, so only the round trip is measured here
+func (db *instrumentedDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span, start := startQuery(ctx, query)
+	row := db.DBTX.QueryRowContext(ctx, query, args...)
+	endQuery(ctx, span, query, start, row.Err())
+	return row
+}
+
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span, time.Time) {
+	name := queryName(query)
+	ctx, span := tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", name),
+		),
+	)
+	return ctx, span, time.Now()
+}
+
+func endQuery(ctx context.Context, span trace.Span, query string, start time.Time, err error) {
+	status := "ok"
+	if err != nil && err != sql.ErrNoRows {
+		status = "error"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		// ctx carries the request id, so this line can be joined with the access log of the request
+		slog.ErrorContext(ctx, "db query failed", slog.String("query", queryName(query)), slog.String("error", err.Error()))
+	}
+	span.End()
+	metrics.DBQueryDuration.WithLabelValues(queryName(query), status).Observe(time.Since(start).Seconds())
+}
+
+// queryName extracts the sqlc query name, hand written queries without the header are reported as "raw"
+func queryName(query string) string {
+	header, _, _ := strings.Cut(query, "\n")
+	fields := strings.Fields(header)
+	if len(fields) >= 3 && fields[0] == "--" && fields[1] == "name:" {
+		return fields[2]
+	}
+	return "raw"
+}
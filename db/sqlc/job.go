@@ -0,0 +1,9 @@
+package db
+
+// statuses of a job. a pending job is waiting for its run_at, or leased by a worker until then
+const (
+	JobPending   = "pending"
+	JobSucceeded = "succeeded"
+	// JobDead is a job that failed max_attempts times, LastError holds the last failure
+	JobDead = "dead"
+)
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomJob enqueues a due job of a kind no other test uses, so claims only see this test's jobs
+func createRandomJob(t *testing.T, uniqueKey string) Job {
+	job, err := testQueries.CreateJob(context.Background(), CreateJobParams{
+		Kind:        "test." + util.RandomString(8),
+		Payload:     json.RawMessage(`{}`),
+		UniqueKey:   sql.NullString{String: uniqueKey, Valid: uniqueKey != ""},
+		MaxAttempts: 3,
+		RunAt:       time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+	require.Equal(t, JobPending, job.Status)
+	require.Zero(t, job.Attempts)
+	return job
+}
+
+func TestCreateJobUniqueKey(t *testing.T) {
+	ctx := context.Background()
+	job := createRandomJob(t, "key")
+
+	arg := CreateJobParams{
+		Kind:        job.Kind,
+		Payload:     json.RawMessage(`{}`),
+		UniqueKey:   job.UniqueKey,
+		MaxAttempts: 3,
+		RunAt:       time.Now(),
+	}
+	// a pending job holds the key
+	_, err := testQueries.CreateJob(ctx, arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// a finished one does not
+	rows, err := testQueries.MarkJobSucceeded(ctx, MarkJobSucceededParams{ID: job.ID, Attempts: job.Attempts})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rows)
+	again, err := testQueries.CreateJob(ctx, arg)
+	require.NoError(t, err)
+	require.NotEqual(t, job.ID, again.ID)
+}
+
+func TestClaimJobs(t *testing.T) {
+	ctx := context.Background()
+	job := createRandomJob(t, "")
+	arg := ClaimJobsParams{
+		LeaseUntil: time.Now().Add(time.Minute),
+		Kinds:      []string{job.Kind},
+		BatchSize:  10,
+	}
+
+	claimed, err := testQueries.ClaimJobs(ctx, arg)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.Equal(t, job.ID, claimed[0].ID)
+	// the claim counts the attempt
+	require.EqualValues(t, 1, claimed[0].Attempts)
+	require.WithinDuration(t, arg.LeaseUntil, claimed[0].RunAt, time.Second)
+
+	// leased, so a second worker does not get it
+	claimed, err = testQueries.ClaimJobs(ctx, arg)
+	require.NoError(t, err)
+	require.Empty(t, claimed)
+
+	rows, err := testQueries.MarkJobFailed(ctx, MarkJobFailedParams{
+		ID:        job.ID,
+		Attempts:  claimed[0].Attempts,
+		Status:    JobPending,
+		RunAt:     time.Now().Add(-time.Second),
+		LastError: sql.NullString{String: "handler failed", Valid: true},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rows)
+
+	claimed, err = testQueries.ClaimJobs(ctx, arg)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.EqualValues(t, 2, claimed[0].Attempts)
+	require.Equal(t, "handler failed", claimed[0].LastError.String)
+
+	rows, err = testQueries.MarkJobFailed(ctx, MarkJobFailedParams{
+		ID:        job.ID,
+		Attempts:  claimed[0].Attempts,
+		Status:    JobDead,
+		RunAt:     time.Now().Add(-time.Second),
+		LastError: sql.NullString{String: "handler failed", Valid: true},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rows)
+
+	// dead jobs are never claimed again
+	claimed, err = testQueries.ClaimJobs(ctx, arg)
+	require.NoError(t, err)
+	require.Empty(t, claimed)
+
+	dead, err := testQueries.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, JobDead, dead.Status)
+	require.True(t, dead.FinishedAt.Valid)
+}
+
+func TestMarkJobAfterLeaseLost(t *testing.T) {
+	ctx := context.Background()
+	job := createRandomJob(t, "")
+
+	// the first lease has already run out when the second worker claims the job
+	first, err := testQueries.ClaimJobs(ctx, ClaimJobsParams{
+		LeaseUntil: time.Now().Add(-time.Second),
+		Kinds:      []string{job.Kind},
+		BatchSize:  1,
+	})
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	second, err := testQueries.ClaimJobs(ctx, ClaimJobsParams{
+		LeaseUntil: time.Now().Add(time.Minute),
+		Kinds:      []string{job.Kind},
+		BatchSize:  1,
+	})
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+
+	// the first worker finishing late does not touch the job of the second claim
+	rows, err := testQueries.MarkJobSucceeded(ctx, MarkJobSucceededParams{ID: job.ID, Attempts: first[0].Attempts})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+	rows, err = testQueries.MarkJobFailed(ctx, MarkJobFailedParams{
+		ID:        job.ID,
+		Attempts:  first[0].Attempts,
+		Status:    JobDead,
+		RunAt:     time.Now(),
+		LastError: sql.NullString{String: "handler failed", Valid: true},
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	pending, err := testQueries.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, JobPending, pending.Status)
+
+	rows, err = testQueries.MarkJobSucceeded(ctx, MarkJobSucceededParams{ID: job.ID, Attempts: second[0].Attempts})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rows)
+}
@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// KYC statuses of a user
+const (
+	KYCUnverified = "unverified"
+	// KYCPending is a user who submitted their details, waiting for an admin
+	KYCPending  = "pending"
+	KYCVerified = "verified"
+	KYCRejected = "rejected"
+)
+
+// types of KYC documents
+const (
+	DocumentPassport       = "passport"
+	DocumentNationalID     = "national_id"
+	DocumentDrivingLicence = "driving_licence"
+	DocumentProofOfAddress = "proof_of_address"
+)
+
+// UnverifiedAccountLimit is how many accounts a user who is not verified may hold
+const UnverifiedAccountLimit = 1
+
+// kycTransitions are the statuses each status can move to. a verified user moving to verified changes tier
+var kycTransitions = map[string][]string{
+	KYCUnverified: {KYCPending},
+	KYCPending:    {KYCVerified, KYCRejected},
+	KYCVerified:   {KYCVerified, KYCRejected},
+	KYCRejected:   {KYCPending},
+}
+
+// SubmitKYCTxParams are the details and documents a user submits for verification
+type SubmitKYCTxParams struct {
+	UpdateKYCDetailsParams
+	Documents []CreateKYCDocumentParams
+}
+
+// SubmitKYCTxResult is the user, now pending, and the documents they submitted
+type SubmitKYCTxResult struct {
+	User      User          `json:"user"`
+	Documents []KycDocument `json:"documents"`
+	Event     KycEvent      `json:"event"`
+}
+
+// SubmitKYCTx stores the details and documents of a user and puts them in the review queue. only an unverified
+// or rejected user can submit, anyone else gets apperror.ErrConflict
+func (store *SQLStore) SubmitKYCTx(ctx context.Context, arg SubmitKYCTxParams) (result SubmitKYCTxResult, err error) {
+	ctx, span := startTxSpan(ctx, "SubmitKYCTx", attribute.String("user.username", arg.Username))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		result.User, result.Event, err = transitionKYC(ctx, q, before, TransitionKYCTxParams{
+			Username: arg.Username,
+			Status:   KYCPending,
+			Actor:    arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+		result.User, err = q.UpdateKYCDetails(ctx, arg.UpdateKYCDetailsParams)
+		if err != nil {
+			return err
+		}
+		result.Documents = make([]KycDocument, 0, len(arg.Documents))
+		for _, document := range arg.Documents {
+			document.Username = arg.Username
+			created, err := q.CreateKYCDocument(ctx, document)
+			if err != nil {
+				return err
+			}
+			result.Documents = append(result.Documents, created)
+		}
+		return recordAudit(ctx, q, AuditKYCSubmitted, AuditTargetUser, arg.Username, newAuditedUser(before), newAuditedUser(result.User))
+	})
+	return result, err
+}
+
+// TransitionKYCTxParams move a user to a KYC status. Tier only counts for verified, every other status is tier 0
+type TransitionKYCTxParams struct {
+	Username string
+	Status   string
+	Tier     int32
+	// Actor is who made the change, it goes into the audit trail with Note
+	Actor string
+	Note  string
+}
+
+// TransitionKYCTx moves a user to another KYC status and records the change in the KYC trail and the audit log,
+// in one transaction. a move kycTransitions does not allow returns apperror.ErrConflict
+func (store *SQLStore) TransitionKYCTx(ctx context.Context, arg TransitionKYCTxParams) (user User, event KycEvent, err error) {
+	ctx, span := startTxSpan(ctx, "TransitionKYCTx", attribute.String("user.username", arg.Username))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		user, event, err = transitionKYC(ctx, q, before, arg)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditKYCTransitioned, AuditTargetUser, arg.Username, newAuditedUser(before), newAuditedUser(user))
+	})
+	return user, event, err
+}
+
+// transitionKYC moves user, locked by the caller, to the status of arg
+func transitionKYC(ctx context.Context, q *Queries, user User, arg TransitionKYCTxParams) (User, KycEvent, error) {
+	if !slices.Contains(kycTransitions[user.KycStatus], arg.Status) {
+		return User{}, KycEvent{}, apperror.Conflict(fmt.Sprintf("KYC of user [%s] cannot move from %s to %s", arg.Username, user.KycStatus, arg.Status))
+	}
+
+	tier := int32(0)
+	if arg.Status == KYCVerified {
+		if arg.Tier < 1 {
+			return User{}, KycEvent{}, apperror.Validation("a verified user needs a tier of at least 1")
+		}
+		tier = arg.Tier
+	}
+	if arg.Status == user.KycStatus && tier == user.KycTier {
+		return User{}, KycEvent{}, apperror.Conflict(fmt.Sprintf("user [%s] is already %s in tier %d", arg.Username, arg.Status, tier))
+	}
+
+	event, err := q.CreateKYCEvent(ctx, CreateKYCEventParams{
+		Username:   user.Username,
+		FromStatus: user.KycStatus,
+		ToStatus:   arg.Status,
+		FromTier:   user.KycTier,
+		ToTier:     tier,
+		Actor:      arg.Actor,
+		Note:       sql.NullString{String: arg.Note, Valid: arg.Note != ""},
+	})
+	if err != nil {
+		return User{}, KycEvent{}, err
+	}
+	user, err = q.UpdateKYCStatus(ctx, UpdateKYCStatusParams{
+		Username:  user.Username,
+		KycStatus: arg.Status,
+		KycTier:   tier,
+	})
+	return user, event, err
+}
+
+// checkAccountAllowance refuses a new account to a user who is not verified and already holds
+// UnverifiedAccountLimit accounts. it locks the user, so concurrent requests cannot both open the last one
+func checkAccountAllowance(ctx context.Context, q *Queries, owner string) error {
+	user, err := q.GetUserForUpdate(ctx, owner)
+	if err != nil || user.KycStatus == KYCVerified {
+		return err
+	}
+	count, err := q.CountAccountsByOwner(ctx, owner)
+	if err != nil {
+		return err
+	}
+	if count >= UnverifiedAccountLimit {
+		return apperror.Forbidden(fmt.Sprintf("users without verified KYC can hold at most %d account", UnverifiedAccountLimit))
+	}
+	return nil
+}
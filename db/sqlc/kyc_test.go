@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKYC(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	admin := createRandomUser(t)
+	user := createRandomUser(t)
+	require.Equal(t, KYCUnverified, user.KycStatus)
+
+	// an unverified user holds one account, on tier 0 limits
+	account, err := store.CreateAccountTx(ctx, CreateAccountParams{Owner: user.Username, Currency: util.USD})
+	require.NoError(t, err)
+	_, err = store.CreateAccountTx(ctx, CreateAccountParams{Owner: user.Username, Currency: util.EUR})
+	require.ErrorIs(t, err, apperror.ErrForbidden)
+	unverified, err := store.GetTransferLimitUsage(ctx, account.ID)
+	require.NoError(t, err)
+
+	// an admin cannot decide before the user submitted
+	_, _, err = store.TransitionKYCTx(ctx, TransitionKYCTxParams{Username: user.Username, Status: KYCVerified, Tier: 1, Actor: admin.Username})
+	require.ErrorIs(t, err, apperror.ErrConflict)
+
+	details := UpdateKYCDetailsParams{
+		Username:    user.Username,
+		DateOfBirth: time.Date(1990, 4, 12, 0, 0, 0, 0, time.UTC),
+		AddressLine: "221B Baker Street",
+		City:        "London",
+		PostalCode:  "NW1 6XE",
+		Country:     "GB",
+	}
+	submitted, err := store.SubmitKYCTx(ctx, SubmitKYCTxParams{
+		UpdateKYCDetailsParams: details,
+		Documents:              []CreateKYCDocumentParams{{DocumentType: DocumentPassport, Reference: util.RandomString(12)}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, KYCPending, submitted.User.KycStatus)
+	require.Equal(t, "GB", submitted.User.Country.String)
+	require.Len(t, submitted.Documents, 1)
+
+	// submitting again while pending is not allowed
+	_, err = store.SubmitKYCTx(ctx, SubmitKYCTxParams{UpdateKYCDetailsParams: details})
+	require.ErrorIs(t, err, apperror.ErrConflict)
+
+	verified, _, err := store.TransitionKYCTx(ctx, TransitionKYCTxParams{
+		Username: user.Username,
+		Status:   KYCVerified,
+		Tier:     2,
+		Actor:    admin.Username,
+		Note:     "passport checked",
+	})
+	require.NoError(t, err)
+	require.Equal(t, KYCVerified, verified.KycStatus)
+	require.Equal(t, int32(2), verified.KycTier)
+
+	// the tier drives the limits and the account allowance is gone
+	tier2, err := store.GetTransferLimitUsage(ctx, account.ID)
+	require.NoError(t, err)
+	require.Greater(t, *tier2.Daily.Limit, *unverified.Daily.Limit)
+	_, err = store.CreateAccountTx(ctx, CreateAccountParams{Owner: user.Username, Currency: util.EUR})
+	require.NoError(t, err)
+
+	rejected, _, err := store.TransitionKYCTx(ctx, TransitionKYCTxParams{Username: user.Username, Status: KYCRejected, Actor: admin.Username})
+	require.NoError(t, err)
+	require.Zero(t, rejected.KycTier)
+
+	events, err := store.ListKYCEvents(ctx, user.Username)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.Equal(t, KYCUnverified, events[0].FromStatus)
+	require.Equal(t, user.Username, events[0].Actor)
+	require.Equal(t, KYCVerified, events[1].ToStatus)
+	require.Equal(t, int32(2), events[1].ToTier)
+	require.Equal(t, admin.Username, events[1].Actor)
+	require.Equal(t, "passport checked", events[1].Note.String)
+	require.Equal(t, int32(2), events[2].FromTier)
+	require.Equal(t, KYCRejected, events[2].ToStatus)
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+)
+
+// scopes of the failed login counters
+const (
+	LoginScopeUsername = "username"
+	LoginScopeIP       = "ip"
+	// LoginScopeSecondFactor counts the wrong codes of a step-up or of turning two-factor authentication off
+	LoginScopeSecondFactor = "second_factor"
+)
+
+// RecordLoginFailureTxParams counts a failed login against its username and IP, and locks out those that
+// reach their limit
+type RecordLoginFailureTxParams struct {
+	Username string
+	// IP is left uncounted when empty
+	IP string
+	// WindowStart is the time before which failures are forgotten
+	WindowStart time.Time
+	// MaxFailures and MaxFailuresPerIP are the failures within the window that lock out a username or an IP
+	MaxFailures      int32
+	MaxFailuresPerIP int32
+	LockedUntil      time.Time
+}
+
+// RecordLoginFailureTxResult holds the counters after the failure
+type RecordLoginFailureTxResult struct {
+	Username LoginFailure
+	// IP is the zero value when no IP was given
+	IP LoginFailure
+	// Locked reports that this failure locked out the username
+	Locked bool
+	// User is who the username belongs to, the zero value when nobody does
+	User User
+}
+
+// RecordLoginFailureTx counts a failed login. usernames nobody has are counted and locked out as any other,
+// so the answers to a login never reveal whether a username exists. locking out the username of a user is
+// recorded in the audit log
+func (store *SQLStore) RecordLoginFailureTx(ctx context.Context, arg RecordLoginFailureTxParams) (result RecordLoginFailureTxResult, err error) {
+	ctx, span := startTxSpan(ctx, "RecordLoginFailureTx")
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Username, result.Locked, err = countLoginFailure(ctx, q, LoginScopeUsername, arg.Username, arg.WindowStart, arg.MaxFailures, arg.LockedUntil)
+		if err != nil {
+			return err
+		}
+		if arg.IP != "" {
+			result.IP, _, err = countLoginFailure(ctx, q, LoginScopeIP, arg.IP, arg.WindowStart, arg.MaxFailuresPerIP, arg.LockedUntil)
+			if err != nil {
+				return err
+			}
+		}
+		if !result.Locked {
+			return nil
+		}
+
+		result.User, err = q.GetUser(ctx, arg.Username)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditUserLocked, AuditTargetUser, arg.Username, nil, result.Username)
+	})
+	return result, err
+}
+
+// countLoginFailure counts one more failure of subject, and locks it until lockedUntil when it reaches
+// maxFailures and is not locked already
+func countLoginFailure(ctx context.Context, q *Queries, scope string, subject string, windowStart time.Time, maxFailures int32, lockedUntil time.Time) (LoginFailure, bool, error) {
+	failure, err := q.AddLoginFailure(ctx, AddLoginFailureParams{
+		Scope:       scope,
+		Subject:     subject,
+		WindowStart: windowStart,
+	})
+	if err != nil {
+		return LoginFailure{}, false, err
+	}
+	if failure.Failures < maxFailures || (failure.LockedUntil.Valid && failure.LockedUntil.Time.After(time.Now())) {
+		return failure, false, nil
+	}
+
+	failure, err = q.LockLoginSubject(ctx, LockLoginSubjectParams{
+		LockedUntil: lockedUntil,
+		Scope:       scope,
+		Subject:     subject,
+	})
+	return failure, err == nil, err
+}
+
+// UnlockUserTx lifts the lockout of a user and forgets their failed logins. a user who is not locked out
+// returns apperror.ErrConflict
+func (store *SQLStore) UnlockUserTx(ctx context.Context, username string) (user User, err error) {
+	ctx, span := startTxSpan(ctx, "UnlockUserTx")
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		user, err = q.GetUser(ctx, username)
+		if err != nil {
+			return err
+		}
+		before, err := q.GetLoginFailureForUpdate(ctx, GetLoginFailureForUpdateParams{Scope: LoginScopeUsername, Subject: username})
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && !before.LockedUntil.Time.After(time.Now())) {
+			return apperror.Conflict(fmt.Sprintf("user [%s] is not locked out", username))
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.DeleteLoginFailures(ctx, DeleteLoginFailuresParams{Scope: LoginScopeUsername, Subject: username}); err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditUserUnlocked, AuditTargetUser, username, before, nil)
+	})
+	return user, err
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// recordLoginFailure fails a login of username, locking it out after three failures
+func recordLoginFailure(t *testing.T, store Store, username string, ip string) RecordLoginFailureTxResult {
+	result, err := store.RecordLoginFailureTx(context.Background(), RecordLoginFailureTxParams{
+		Username:         username,
+		IP:               ip,
+		WindowStart:      time.Now().Add(-time.Hour),
+		MaxFailures:      3,
+		MaxFailuresPerIP: 10,
+		LockedUntil:      time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func TestRecordLoginFailureTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	ip := "192.0.2." + util.RandomString(3)
+
+	for i := 1; i < 3; i++ {
+		result := recordLoginFailure(t, store, user.Username, ip)
+		require.EqualValues(t, i, result.Username.Failures)
+		require.EqualValues(t, i, result.IP.Failures)
+		require.False(t, result.Locked)
+	}
+
+	result := recordLoginFailure(t, store, user.Username, ip)
+	require.True(t, result.Locked)
+	require.True(t, result.Username.LockedUntil.Valid)
+	require.False(t, result.IP.LockedUntil.Valid)
+	require.Equal(t, user.Username, result.User.Username)
+
+	// a username locked out already is not locked again
+	result = recordLoginFailure(t, store, user.Username, ip)
+	require.False(t, result.Locked)
+
+	failures, err := store.ListLoginFailures(context.Background(), ListLoginFailuresParams{Username: user.Username, Ip: ip})
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+}
+
+func TestRecordLoginFailureTxUnknownUsername(t *testing.T) {
+	store := NewStore(testDB)
+	username := util.RandomOwner()
+
+	var result RecordLoginFailureTxResult
+	for i := 0; i < 3; i++ {
+		result = recordLoginFailure(t, store, username, "")
+	}
+	// locked out as any other username, with nobody to tell
+	require.True(t, result.Locked)
+	require.Empty(t, result.User.Username)
+	require.Empty(t, result.IP.Subject)
+}
+
+func TestUnlockUserTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	_, err := store.UnlockUserTx(context.Background(), user.Username)
+	require.ErrorIs(t, err, apperror.ErrConflict)
+
+	for i := 0; i < 3; i++ {
+		recordLoginFailure(t, store, user.Username, "")
+	}
+	unlocked, err := store.UnlockUserTx(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, unlocked.Username)
+
+	failures, err := store.ListLoginFailures(context.Background(), ListLoginFailuresParams{Username: user.Username})
+	require.NoError(t, err)
+	require.Empty(t, failures)
+}
@@ -5,17 +5,79 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Account struct {
+	ID        int64        `json:"id"`
+	Owner     string       `json:"owner"`
+	Balance   int64        `json:"balance"`
+	Currency  string       `json:"currency"`
+	CreatedAt time.Time    `json:"created_at"`
+	FrozenAt  sql.NullTime `json:"frozen_at"`
+}
+
+type AccountEvent struct {
+	ID        int64           `json:"id"`
+	AccountID int64           `json:"account_id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type ApiKey struct {
+	ID         int64          `json:"id"`
+	Username   string         `json:"username"`
+	Name       string         `json:"name"`
+	Prefix     string         `json:"prefix"`
+	SecretHash string         `json:"secret_hash"`
+	Scopes     []string       `json:"scopes"`
+	AllowedIps []string       `json:"allowed_ips"`
+	ExpiresAt  sql.NullTime   `json:"expires_at"`
+	LastUsedAt sql.NullTime   `json:"last_used_at"`
+	LastUsedIp sql.NullString `json:"last_used_ip"`
+	RevokedAt  sql.NullTime   `json:"revoked_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type AuditEvent struct {
+	ID         int64           `json:"id"`
+	Actor      sql.NullString  `json:"actor"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  sql.NullString  `json:"request_id"`
+	Ip         sql.NullString  `json:"ip"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type BalanceAdjustment struct {
 	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
-	Balance   int64     `json:"balance"`
-	Currency  string    `json:"currency"`
+	AccountID int64     `json:"account_id"`
+	EntryID   int64     `json:"entry_id"`
+	Amount    int64     `json:"amount"`
+	Reason    string    `json:"reason"`
+	CreatedBy string    `json:"created_by"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type EmailToken struct {
+	ID        int64        `json:"id"`
+	Username  string       `json:"username"`
+	Purpose   string       `json:"purpose"`
+	TokenHash string       `json:"token_hash"`
+	Email     string       `json:"email"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
 type Entry struct {
 	ID        int64 `json:"id"`
 	AccountID int64 `json:"account_id"`
@@ -24,20 +86,247 @@ type Entry struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type Job struct {
+	ID          int64           `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	UniqueKey   sql.NullString  `json:"unique_key"`
+	Status      string          `json:"status"`
+	Attempts    int32           `json:"attempts"`
+	MaxAttempts int32           `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LastError   sql.NullString  `json:"last_error"`
+	FinishedAt  sql.NullTime    `json:"finished_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type KycDocument struct {
+	ID           int64     `json:"id"`
+	Username     string    `json:"username"`
+	DocumentType string    `json:"document_type"`
+	Reference    string    `json:"reference"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type KycEvent struct {
+	ID         int64          `json:"id"`
+	Username   string         `json:"username"`
+	FromStatus string         `json:"from_status"`
+	ToStatus   string         `json:"to_status"`
+	FromTier   int32          `json:"from_tier"`
+	ToTier     int32          `json:"to_tier"`
+	Actor      string         `json:"actor"`
+	Note       sql.NullString `json:"note"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type LoginChallenge struct {
+	ID          uuid.UUID    `json:"id"`
+	Username    string       `json:"username"`
+	Attempts    int32        `json:"attempts"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	CompletedAt sql.NullTime `json:"completed_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type LoginFailure struct {
+	Scope        string       `json:"scope"`
+	Subject      string       `json:"subject"`
+	Failures     int32        `json:"failures"`
+	LastFailedAt time.Time    `json:"last_failed_at"`
+	LockedUntil  sql.NullTime `json:"locked_until"`
+}
+
+type Outbox struct {
+	ID            int64           `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int32           `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LastError     sql.NullString  `json:"last_error"`
+	SentAt        sql.NullTime    `json:"sent_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type RateLimitBucket struct {
+	Key       string    `json:"key"`
+	Tokens    float64   `json:"tokens"`
+	Allowed   bool      `json:"allowed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type RecoveryCode struct {
+	ID        int64        `json:"id"`
+	Username  string       `json:"username"`
+	CodeHash  string       `json:"code_hash"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type RiskAlert struct {
+	ID             int64           `json:"id"`
+	Decision       string          `json:"decision"`
+	Status         string          `json:"status"`
+	FromAccountID  int64           `json:"from_account_id"`
+	ToAccountID    int64           `json:"to_account_id"`
+	Amount         int64           `json:"amount"`
+	Currency       string          `json:"currency"`
+	TransferID     sql.NullInt64   `json:"transfer_id"`
+	Findings       json.RawMessage `json:"findings"`
+	ResolvedBy     sql.NullString  `json:"resolved_by"`
+	ResolutionNote sql.NullString  `json:"resolution_note"`
+	ResolvedAt     sql.NullTime    `json:"resolved_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64          `json:"id"`
+	Owner         string         `json:"owner"`
+	FromAccountID int64          `json:"from_account_id"`
+	ToAccountID   int64          `json:"to_account_id"`
+	Amount        int64          `json:"amount"`
+	Currency      string         `json:"currency"`
+	ExecuteAt     time.Time      `json:"execute_at"`
+	Status        string         `json:"status"`
+	TransferID    sql.NullInt64  `json:"transfer_id"`
+	FailureReason sql.NullString `json:"failure_reason"`
+	ExecutedAt    sql.NullTime   `json:"executed_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Attempts      int32          `json:"attempts"`
+	RetryAt       sql.NullTime   `json:"retry_at"`
+}
+
+type ScreeningCase struct {
+	ID             int64          `json:"id"`
+	Username       sql.NullString `json:"username"`
+	FullName       string         `json:"full_name"`
+	EntryID        string         `json:"entry_id"`
+	EntryName      string         `json:"entry_name"`
+	Program        string         `json:"program"`
+	Score          float64        `json:"score"`
+	Decision       string         `json:"decision"`
+	Source         string         `json:"source"`
+	Status         string         `json:"status"`
+	ResolvedBy     sql.NullString `json:"resolved_by"`
+	ResolutionNote sql.NullString `json:"resolution_note"`
+	ResolvedAt     sql.NullTime   `json:"resolved_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+type StandingOrder struct {
+	ID                    int64         `json:"id"`
+	Owner                 string        `json:"owner"`
+	FromAccountID         int64         `json:"from_account_id"`
+	ToAccountID           int64         `json:"to_account_id"`
+	Amount                int64         `json:"amount"`
+	Currency              string        `json:"currency"`
+	Frequency             string        `json:"frequency"`
+	StartDate             time.Time     `json:"start_date"`
+	EndDate               sql.NullTime  `json:"end_date"`
+	MaxOccurrences        sql.NullInt32 `json:"max_occurrences"`
+	BusinessDayAdjustment string        `json:"business_day_adjustment"`
+	OnInsufficientFunds   string        `json:"on_insufficient_funds"`
+	MaxRetries            int32         `json:"max_retries"`
+	Status                string        `json:"status"`
+	Occurrences           int32         `json:"occurrences"`
+	Attempts              int32         `json:"attempts"`
+	NextRunAt             sql.NullTime  `json:"next_run_at"`
+	CreatedAt             time.Time     `json:"created_at"`
+}
+
+type StandingOrderOccurrence struct {
+	ID              int64          `json:"id"`
+	StandingOrderID int64          `json:"standing_order_id"`
+	Sequence        int32          `json:"sequence"`
+	ScheduledDate   time.Time      `json:"scheduled_date"`
+	RunDate         time.Time      `json:"run_date"`
+	Status          string         `json:"status"`
+	Attempts        int32          `json:"attempts"`
+	TransferID      sql.NullInt64  `json:"transfer_id"`
+	FailureReason   sql.NullString `json:"failure_reason"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	// must be positive
-	Amount    int64     `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	Amount     int64         `json:"amount"`
+	CreatedAt  time.Time     `json:"created_at"`
+	ReversalOf sql.NullInt64 `json:"reversal_of"`
+}
+
+type TransferApproval struct {
+	ID              int64          `json:"id"`
+	Initiator       string         `json:"initiator"`
+	FromAccountID   int64          `json:"from_account_id"`
+	ToAccountID     int64          `json:"to_account_id"`
+	Amount          int64          `json:"amount"`
+	Currency        string         `json:"currency"`
+	Status          string         `json:"status"`
+	Reviewer        sql.NullString `json:"reviewer"`
+	RejectionReason sql.NullString `json:"rejection_reason"`
+	TransferID      sql.NullInt64  `json:"transfer_id"`
+	ExpiresAt       time.Time      `json:"expires_at"`
+	ReviewedAt      sql.NullTime   `json:"reviewed_at"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+type TransferLimit struct {
+	ID           int64         `json:"id"`
+	Currency     string        `json:"currency"`
+	AccountID    sql.NullInt64 `json:"account_id"`
+	KycTier      sql.NullInt32 `json:"kyc_tier"`
+	DailyLimit   sql.NullInt64 `json:"daily_limit"`
+	MonthlyLimit sql.NullInt64 `json:"monthly_limit"`
+	CreatedAt    time.Time     `json:"created_at"`
 }
 
 type User struct {
-	Username          string    `json:"username"`
-	HashedPassword    string    `json:"hashed_password"`
-	FullName          string    `json:"full_name"`
-	Email             string    `json:"email"`
-	PasswordChangedAt time.Time `json:"password_changed_at"`
-	CreatedAt         time.Time `json:"created_at"`
+	Username          string         `json:"username"`
+	HashedPassword    string         `json:"hashed_password"`
+	FullName          string         `json:"full_name"`
+	Email             string         `json:"email"`
+	PasswordChangedAt time.Time      `json:"password_changed_at"`
+	CreatedAt         time.Time      `json:"created_at"`
+	Role              string         `json:"role"`
+	KycTier           int32          `json:"kyc_tier"`
+	SanctionsStatus   string         `json:"sanctions_status"`
+	KycStatus         string         `json:"kyc_status"`
+	DateOfBirth       sql.NullTime   `json:"date_of_birth"`
+	AddressLine       sql.NullString `json:"address_line"`
+	City              sql.NullString `json:"city"`
+	PostalCode        sql.NullString `json:"postal_code"`
+	Country           sql.NullString `json:"country"`
+	TotpSecret        sql.NullString `json:"totp_secret"`
+	TotpEnabledAt     sql.NullTime   `json:"totp_enabled_at"`
+	TotpLastStep      int64          `json:"totp_last_step"`
+	EmailVerifiedAt   sql.NullTime   `json:"email_verified_at"`
+}
+
+type WebhookDelivery struct {
+	ID                 int64           `json:"id"`
+	EndpointID         int64           `json:"endpoint_id"`
+	EventType          string          `json:"event_type"`
+	Payload            json.RawMessage `json:"payload"`
+	Status             string          `json:"status"`
+	Attempts           int32           `json:"attempts"`
+	NextAttemptAt      time.Time       `json:"next_attempt_at"`
+	LastResponseStatus sql.NullInt32   `json:"last_response_status"`
+	LastError          sql.NullString  `json:"last_error"`
+	DeliveredAt        sql.NullTime    `json:"delivered_at"`
+	CreatedAt          time.Time       `json:"created_at"`
+}
+
+type WebhookEndpoint struct {
+	ID         int64     `json:"id"`
+	Owner      string    `json:"owner"`
+	Url        string    `json:"url"`
+	Secret     string    `json:"secret"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
 }
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+)
+
+// aggregate and event types written to the outbox
+const (
+	AggregateAccount  = "account"
+	AggregateTransfer = "transfer"
+
+	EventAccountCreated  = "account.created"
+	EventAccountAdjusted = "account.adjusted"
+	EventTransferCreated = "transfer.created"
+	// EventTransferReversed carries the reversal, its reversal_of is the transfer it undid
+	EventTransferReversed = "transfer.reversed"
+)
+
+// TransferCreatedPayload is the payload of EventTransferCreated
+type TransferCreatedPayload struct {
+	Transfer  Transfer `json:"transfer"`
+	Currency  string   `json:"currency"`
+	FromEntry Entry    `json:"from_entry"`
+	ToEntry   Entry    `json:"to_entry"`
+}
+
+// enqueueEvent writes an outbox row with q, which must be bound to the transaction making the change
+func enqueueEvent(ctx context.Context, q *Queries, aggregateType string, aggregateID int64, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		AggregateType: aggregateType,
+		AggregateID:   strconv.FormatInt(aggregateID, 10),
+		EventType:     eventType,
+		Payload:       data,
+	})
+	return err
+}
+
+// CreateAccountTx creates an account and its account.created outbox event in one transaction. a user without
+// verified KYC who already holds an account gets apperror.ErrForbidden
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (account Account, err error) {
+	ctx, span := startTxSpan(ctx, "CreateAccountTx")
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		if err := checkAccountAllowance(ctx, q, arg.Owner); err != nil {
+			return err
+		}
+		var err error
+		account, err = q.CreateAccount(ctx, arg)
+		if err != nil {
+			return err
+		}
+		if err = recordAudit(ctx, q, AuditAccountCreated, AuditTargetAccount, auditID(account.ID), nil, account); err != nil {
+			return err
+		}
+		return enqueueEvent(ctx, q, AggregateAccount, account.ID, EventAccountCreated, account)
+	})
+	return account, err
+}
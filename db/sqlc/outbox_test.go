@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: util.RandomCurrency(),
+	})
+	require.NoError(t, err)
+	require.NotZero(t, account.ID)
+
+	// the outbox row was committed with the account
+	var payload json.RawMessage
+	err = testDB.QueryRow(
+		"SELECT payload FROM outbox WHERE aggregate_type = $1 AND aggregate_id = $2 AND event_type = $3",
+		AggregateAccount, strconv.FormatInt(account.ID, 10), EventAccountCreated,
+	).Scan(&payload)
+	require.NoError(t, err)
+
+	var got Account
+	require.NoError(t, json.Unmarshal(payload, &got))
+	require.Equal(t, account.ID, got.ID)
+}
+
+func TestCreateAccountTxRollback(t *testing.T) {
+	store := NewStore(testDB)
+
+	// no such owner, so nothing may reach the outbox
+	owner := util.RandomOwner()
+	_, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:    owner,
+		Currency: util.RandomCurrency(),
+	})
+	require.Error(t, err)
+
+	var count int
+	err = testDB.QueryRow("SELECT count(*) FROM outbox WHERE payload->>'owner' = $1", owner).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+func TestClaimOutboxEvents(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+
+	event, err := store.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		AggregateType: AggregateAccount,
+		AggregateID:   strconv.FormatInt(util.RandomInt(1, 1000), 10),
+		EventType:     EventAccountCreated,
+		Payload:       json.RawMessage(`{}`),
+	})
+	require.NoError(t, err)
+
+	claimed := claimUntil(t, store, event.ID, time.Now().Add(time.Minute))
+	require.Zero(t, claimed.Attempts)
+
+	// leased, so a second dispatcher does not get it
+	require.NotContains(t, claimIDs(t, store, time.Now().Add(time.Minute)), event.ID)
+
+	err = store.MarkOutboxEventFailed(ctx, MarkOutboxEventFailedParams{
+		ID:            event.ID,
+		NextAttemptAt: time.Now().Add(-time.Second),
+		LastError:     sql.NullString{String: "sink down", Valid: true},
+	})
+	require.NoError(t, err)
+
+	claimed = claimUntil(t, store, event.ID, time.Now().Add(time.Minute))
+	require.EqualValues(t, 1, claimed.Attempts)
+	require.Equal(t, "sink down", claimed.LastError.String)
+
+	require.NoError(t, store.MarkOutboxEventSent(ctx, event.ID))
+	err = store.MarkOutboxEventFailed(ctx, MarkOutboxEventFailedParams{ID: event.ID, NextAttemptAt: time.Now().Add(-time.Second)})
+	require.NoError(t, err)
+	// sent events are never claimed again
+	require.NotContains(t, claimIDs(t, store, time.Now().Add(time.Minute)), event.ID)
+}
+
+// claimUntil claims batches until id shows up, other tests leave pending events behind
+func claimUntil(t *testing.T, store Store, id int64, leaseUntil time.Time) Outbox {
+	for {
+		events, err := store.ClaimOutboxEvents(context.Background(), ClaimOutboxEventsParams{LeaseUntil: leaseUntil, BatchSize: 100})
+		require.NoError(t, err)
+		require.NotEmpty(t, events, "event %d was not claimed", id)
+		for _, event := range events {
+			if event.ID == id {
+				return event
+			}
+		}
+	}
+}
+
+func claimIDs(t *testing.T, store Store, leaseUntil time.Time) []int64 {
+	var ids []int64
+	for {
+		events, err := store.ClaimOutboxEvents(context.Background(), ClaimOutboxEventsParams{LeaseUntil: leaseUntil, BatchSize: 100})
+		require.NoError(t, err)
+		if len(events) == 0 {
+			return ids
+		}
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+	}
+}
@@ -6,24 +6,191 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddLoginChallengeAttempt(ctx context.Context, id uuid.UUID) (LoginChallenge, error)
+	// counts one more failure, starting over when the last one is older than window_start
+	AddLoginFailure(ctx context.Context, arg AddLoginFailureParams) (LoginFailure, error)
+	// waits for a scheduler executing the row, which then no longer matches
+	CancelScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	// waits for a worker running the order, the occurrence it settles is kept
+	CancelStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
+	// locks the oldest due row until the claiming transaction ends, other schedulers skip it. a row that failed
+	// for another reason than a rejection waits for its retry_at
+	ClaimDueScheduledTransfer(ctx context.Context) (ScheduledTransfer, error)
+	// locks the most overdue order until the claiming transaction ends, other workers skip it
+	ClaimDueStandingOrder(ctx context.Context) (StandingOrder, error)
+	// leases due jobs of the given kinds until lease_until and counts the attempt, so a job that
+	// keeps crashing its worker still runs out of attempts. SKIP LOCKED lets workers claim disjoint batches
+	ClaimJobs(ctx context.Context, arg ClaimJobsParams) ([]Job, error)
+	// leases due events until lease_until, SKIP LOCKED lets several dispatchers claim disjoint batches
+	ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]Outbox, error)
+	// leases due deliveries until lease_until, SKIP LOCKED lets several deliverers claim disjoint batches
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	CompleteLoginChallenge(ctx context.Context, id uuid.UUID) (LoginChallenge, error)
+	CountAccountsByOwner(ctx context.Context, owner string) (int64, error)
+	CountUnusedRecoveryCodes(ctx context.Context, username string) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountEvent(ctx context.Context, arg CreateAccountEventParams) (AccountEvent, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error)
+	CreateBalanceAdjustment(ctx context.Context, arg CreateBalanceAdjustmentParams) (BalanceAdjustment, error)
+	CreateEmailToken(ctx context.Context, arg CreateEmailTokenParams) (EmailToken, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	// returns no row when a pending job of the same kind already holds unique_key
+	CreateJob(ctx context.Context, arg CreateJobParams) (Job, error)
+	CreateKYCDocument(ctx context.Context, arg CreateKYCDocumentParams) (KycDocument, error)
+	CreateKYCEvent(ctx context.Context, arg CreateKYCEventParams) (KycEvent, error)
+	CreateLoginChallenge(ctx context.Context, arg CreateLoginChallengeParams) (LoginChallenge, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (Outbox, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error
+	// no row comes back when the transfer was reversed already
+	CreateReversal(ctx context.Context, arg CreateReversalParams) (Transfer, error)
+	CreateRiskAlert(ctx context.Context, arg CreateRiskAlertParams) (RiskAlert, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	// a match already on record for the user is left alone and no row comes back
+	CreateScreeningCase(ctx context.Context, arg CreateScreeningCaseParams) (ScreeningCase, error)
+	CreateStandingOrder(ctx context.Context, arg CreateStandingOrderParams) (StandingOrder, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferApproval(ctx context.Context, arg CreateTransferApprovalParams) (TransferApproval, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	// queues the event for every endpoint of owner subscribed to event_type
+	CreateWebhookDeliveries(ctx context.Context, arg CreateWebhookDeliveriesParams) (int64, error)
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteIdleRateLimitBuckets(ctx context.Context, idleSince time.Time) (int64, error)
+	DeleteLoginFailures(ctx context.Context, arg DeleteLoginFailuresParams) (int64, error)
+	DeleteRecoveryCodes(ctx context.Context, username string) error
+	DeleteWebhookEndpoint(ctx context.Context, id int64) error
+	DisableTOTP(ctx context.Context, username string) (User, error)
+	EnableTOTP(ctx context.Context, arg EnableTOTPParams) (User, error)
+	ExpireTransferApprovals(ctx context.Context) ([]TransferApproval, error)
+	// no row comes back for an account that is already frozen
+	FreezeAccount(ctx context.Context, id int64) (Account, error)
+	GetAPIKey(ctx context.Context, id int64) (ApiKey, error)
+	// the key of prefix with the current role of its user, what a request with the key acts as
+	GetAPIKeyCaller(ctx context.Context, prefix string) (GetAPIKeyCallerRow, error)
+	GetAPIKeyForUpdate(ctx context.Context, id int64) (ApiKey, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetAccountsForUpdate(ctx context.Context, id int64) (Account, error)
+	GetEmailTokenForUpdate(ctx context.Context, tokenHash string) (EmailToken, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetJob(ctx context.Context, id int64) (Job, error)
+	GetLoginChallengeForUpdate(ctx context.Context, id uuid.UUID) (LoginChallenge, error)
+	GetLoginFailureForUpdate(ctx context.Context, arg GetLoginFailureForUpdateParams) (LoginFailure, error)
+	// the allowance a user shares across their accounts in a currency: the tier of the user, then the currency default
+	GetOwnerTransferLimits(ctx context.Context, arg GetOwnerTransferLimitsParams) (GetOwnerTransferLimitsRow, error)
+	GetRiskAlert(ctx context.Context, id int64) (RiskAlert, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetScreeningCase(ctx context.Context, id int64) (ScreeningCase, error)
+	GetStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferApproval(ctx context.Context, id int64) (TransferApproval, error)
+	// holds the row until the reviewing transaction ends, so a request is approved or rejected once
+	GetTransferApprovalForUpdate(ctx context.Context, id int64) (TransferApproval, error)
+	// resolves each period on its own: the account override, then the tier of the owner, then the currency default
+	GetTransferLimits(ctx context.Context, id int64) (GetTransferLimitsRow, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserForUpdate(ctx context.Context, username string) (User, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	GetWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoint, error)
+	ListAPIKeys(ctx context.Context, username string) ([]ApiKey, error)
+	// events of an account committed after after_id, oldest first
+	ListAccountEvents(ctx context.Context, arg ListAccountEventsParams) ([]AccountEvent, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	// every filter is optional, newest first
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	ListBalanceAdjustments(ctx context.Context, arg ListBalanceAdjustmentsParams) ([]BalanceAdjustment, error)
+	ListBlockedOwners(ctx context.Context, arg ListBlockedOwnersParams) ([]string, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListKYCDocuments(ctx context.Context, username string) ([]KycDocument, error)
+	ListKYCEvents(ctx context.Context, username string) ([]KycEvent, error)
+	// the counters of a username and of the IP it logs in from
+	ListLoginFailures(ctx context.Context, arg ListLoginFailuresParams) ([]LoginFailure, error)
+	// the review queue, oldest first
+	ListPendingTransferApprovals(ctx context.Context, arg ListPendingTransferApprovalsParams) ([]TransferApproval, error)
+	// the alert queue, oldest first
+	ListRiskAlerts(ctx context.Context, arg ListRiskAlertsParams) ([]RiskAlert, error)
+	// the history the risk rules judge a transfer by: what the account sent since, and what the counterparty sent it
+	ListRiskTransfers(ctx context.Context, arg ListRiskTransfersParams) ([]Transfer, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error)
+	// the case queue, oldest first
+	ListScreeningCases(ctx context.Context, arg ListScreeningCasesParams) ([]ScreeningCase, error)
+	ListStandingOrderOccurrences(ctx context.Context, arg ListStandingOrderOccurrencesParams) ([]StandingOrderOccurrence, error)
+	ListStandingOrders(ctx context.Context, arg ListStandingOrdersParams) ([]StandingOrder, error)
+	ListTransferApprovals(ctx context.Context, arg ListTransferApprovalsParams) ([]TransferApproval, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	// the KYC queue of admins, the users who have waited longest first
+	ListUsersByKYCStatus(ctx context.Context, arg ListUsersByKYCStatusParams) ([]User, error)
+	// pages through every user by username, after is the last username of the previous page
+	ListUsersForScreening(ctx context.Context, arg ListUsersForScreeningParams) ([]ListUsersForScreeningRow, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhookEndpoints(ctx context.Context, owner string) ([]WebhookEndpoint, error)
+	LockLoginSubject(ctx context.Context, arg LockLoginSubjectParams) (LoginFailure, error)
+	// status stays pending for a retry at run_at, or becomes dead once the job is out of attempts.
+	// fenced by the attempts of the claim like MarkJobSucceeded
+	MarkJobFailed(ctx context.Context, arg MarkJobFailedParams) (int64, error)
+	// attempts is that of the claim, a worker whose lease ran out and whose job was claimed again updates no row
+	MarkJobSucceeded(ctx context.Context, arg MarkJobSucceededParams) (int64, error)
+	MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error
+	MarkOutboxEventSent(ctx context.Context, id int64) error
+	// only a row still scheduled is failed, a concurrent scheduler may have executed it in the meantime
+	MarkScheduledTransferFailed(ctx context.Context, arg MarkScheduledTransferFailedParams) (ScheduledTransfer, error)
+	MarkScheduledTransferSucceeded(ctx context.Context, arg MarkScheduledTransferSucceededParams) (ScheduledTransfer, error)
+	// status stays pending for another try at next_attempt_at, or becomes dead
+	MarkWebhookDeliveryFailed(ctx context.Context, arg MarkWebhookDeliveryFailedParams) error
+	MarkWebhookDeliverySucceeded(ctx context.Context, arg MarkWebhookDeliverySucceededParams) error
+	// queues a delivery again with a fresh set of retries, whatever its status
+	RedeliverWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	// derives the sanctions status of a user from their cases
+	RefreshSanctionsStatus(ctx context.Context, username string) (User, error)
+	// only an open alert is resolved, one resolved in the meantime no longer matches
+	ResolveRiskAlert(ctx context.Context, arg ResolveRiskAlertParams) (RiskAlert, error)
+	// only an open case is resolved, one resolved in the meantime no longer matches
+	ResolveScreeningCase(ctx context.Context, arg ResolveScreeningCaseParams) (ScreeningCase, error)
+	// counts a try that failed for another reason than a rejection, the row is not claimed again before retry_at
+	RetryScheduledTransfer(ctx context.Context, arg RetryScheduledTransferParams) (ScheduledTransfer, error)
+	// only a pending request is reviewed, one reviewed or expired in the meantime no longer matches
+	ReviewTransferApproval(ctx context.Context, arg ReviewTransferApprovalParams) (TransferApproval, error)
+	RevokeAPIKey(ctx context.Context, id int64) (ApiKey, error)
+	// uses up the tokens of a user that are still good for purpose
+	RevokeEmailTokens(ctx context.Context, arg RevokeEmailTokensParams) error
+	SetAPIKeyExpiry(ctx context.Context, arg SetAPIKeyExpiryParams) (ApiKey, error)
+	// sets or replaces the override of one account, a NULL period falls back to the tier or the default
+	SetAccountTransferLimit(ctx context.Context, arg SetAccountTransferLimitParams) (TransferLimit, error)
+	// enrolling again before confirming replaces the secret, once two-factor authentication is on nothing is updated
+	SetTOTPSecret(ctx context.Context, arg SetTOTPSecretParams) (User, error)
+	// reversals are corrections booked by staff, they use up no limit
+	SumOutgoingTransfers(ctx context.Context, arg SumOutgoingTransfersParams) (SumOutgoingTransfersRow, error)
+	// money the user moves between their own accounts never leaves them and reversals are corrections booked by
+	// staff, neither uses up the allowance
+	SumOwnerOutgoingTransfers(ctx context.Context, arg SumOwnerOutgoingTransfersParams) (SumOwnerOutgoingTransfersRow, error)
+	// refills the bucket of key for the time since its last use and takes a token from it. when less than a
+	// token is left nothing is taken and allowed is false. a new bucket starts full
+	TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (TakeRateLimitTokenRow, error)
+	// records a use of the key, at most once per key before used_before so that busy keys do not write on
+	// every request
+	TouchAPIKey(ctx context.Context, arg TouchAPIKeyParams) error
+	// no row comes back for an account that is not frozen
+	UnfreezeAccount(ctx context.Context, id int64) (Account, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateKYCDetails(ctx context.Context, arg UpdateKYCDetailsParams) (User, error)
+	UpdateKYCStatus(ctx context.Context, arg UpdateKYCStatusParams) (User, error)
+	UpdateStandingOrderSchedule(ctx context.Context, arg UpdateStandingOrderScheduleParams) (StandingOrder, error)
+	UpdateTOTPLastStep(ctx context.Context, arg UpdateTOTPLastStepParams) error
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	// a retried occurrence keeps its row, each attempt updates it
+	UpsertStandingOrderOccurrence(ctx context.Context, arg UpsertStandingOrderOccurrenceParams) (StandingOrderOccurrence, error)
+	UseEmailToken(ctx context.Context, id int64) (EmailToken, error)
+	// marks an unused code as used, no rows when the code is unknown or was already used
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error)
+	VerifyUserEmail(ctx context.Context, username string) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeRateLimitToken(t *testing.T) {
+	// a slow refill, the test does not wait for it
+	arg := TakeRateLimitTokenParams{
+		Key:   "test:" + util.RandomString(12),
+		Burst: 2,
+		Rate:  0.001,
+	}
+
+	row, err := testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, row.Allowed)
+	require.InDelta(t, 1, row.Tokens, 0.01)
+
+	row, err = testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, row.Allowed)
+	require.InDelta(t, 0, row.Tokens, 0.01)
+
+	// nothing is taken from an empty bucket
+	row, err = testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, row.Allowed)
+	require.GreaterOrEqual(t, row.Tokens, 0.0)
+	require.Less(t, row.Tokens, 1.0)
+}
+
+func TestDeleteIdleRateLimitBuckets(t *testing.T) {
+	arg := TakeRateLimitTokenParams{Key: "test:" + util.RandomString(12), Burst: 1, Rate: 1}
+	_, err := testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	deleted, err := testQueries.DeleteIdleRateLimitBuckets(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	// the bucket starts over full
+	row, err := testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, row.Allowed)
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ReverseTransferTx moves the amount of a transfer back to where it came from and links the reversal to it.
+// funds, a frozen account and a user blocked by sanctions screening stop it as they stop any transfer. it is a
+// correction booked by staff, not money a customer sends, so transfer limits and risk rules do not apply and the
+// limits do not count it. a transfer is reversed once and a reversal is not reversed, both return
+// apperror.ErrConflict
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, transferID int64) (result TransferTxResult, err error) {
+	ctx, span := startTxSpan(ctx, "ReverseTransferTx", attribute.Int64("transfer.id", transferID))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		original, err := q.GetTransfer(ctx, transferID)
+		if err != nil {
+			return err
+		}
+		if original.ReversalOf.Valid {
+			return apperror.Conflict(fmt.Sprintf("transfer [%d] is a reversal itself", transferID))
+		}
+
+		// the reversal sends from the account that received
+		fromAccount, toAccount, err := lockAccounts(ctx, q, original.ToAccountID, original.FromAccountID)
+		if err != nil {
+			return err
+		}
+		if fromAccount.Balance < original.Amount {
+			return apperror.InsufficientFunds(fmt.Sprintf("account [%d] has insufficient funds", fromAccount.ID))
+		}
+		if err = checkFrozen(fromAccount, toAccount); err != nil {
+			return err
+		}
+		if err = checkSanctions(ctx, q, fromAccount.ID, toAccount.ID); err != nil {
+			return err
+		}
+
+		reversal, err := q.CreateReversal(ctx, CreateReversalParams{
+			FromAccountID: original.ToAccountID,
+			ToAccountID:   original.FromAccountID,
+			Amount:        original.Amount,
+			ReversalOf:    sql.NullInt64{Int64: original.ID, Valid: true},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperror.Conflict(fmt.Sprintf("transfer [%d] is already reversed", transferID))
+		}
+		if err != nil {
+			return err
+		}
+		result, err = bookTransfer(ctx, q, reversal)
+		if err != nil {
+			return err
+		}
+		if err = recordAudit(ctx, q, AuditTransferReversed, AuditTargetTransfer, auditID(original.ID), original, reversal); err != nil {
+			return err
+		}
+		return publishTransfer(ctx, q, result, EventTransferReversed)
+	})
+	return result, err
+}
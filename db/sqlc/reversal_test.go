@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createRandomAccount(t)
+
+	transferred, err := store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.NoError(t, err)
+
+	reversed, err := store.ReverseTransferTx(ctx, transferred.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.ID, reversed.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, reversed.Transfer.ToAccountID)
+	require.Equal(t, transferred.Transfer.ID, reversed.Transfer.ReversalOf.Int64)
+	require.Equal(t, account1.Balance, reversed.ToAccount.Balance)
+	require.Equal(t, account2.Balance, reversed.FromAccount.Balance)
+
+	// a transfer is reversed once, and a reversal is never reversed
+	_, err = store.ReverseTransferTx(ctx, transferred.Transfer.ID)
+	require.ErrorIs(t, err, apperror.ErrConflict)
+	_, err = store.ReverseTransferTx(ctx, reversed.Transfer.ID)
+	require.ErrorIs(t, err, apperror.ErrConflict)
+}
+
+func TestReverseTransferTxRefused(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	transferred, err := store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.NoError(t, err)
+
+	// a frozen account stops the reversal as it stops any transfer
+	_, err = store.FreezeAccountTx(ctx, account1.ID)
+	require.NoError(t, err)
+	_, err = store.ReverseTransferTx(ctx, transferred.Transfer.ID)
+	require.ErrorIs(t, err, apperror.ErrForbidden)
+	_, err = store.UnfreezeAccountTx(ctx, account1.ID)
+	require.NoError(t, err)
+
+	// and so does a user the sanctions screening blocked since
+	_, _, err = store.RecordScreeningTx(ctx, account2.Owner, []CreateScreeningCaseParams{{
+		FullName:  util.RandomOwner(),
+		EntryID:   util.RandomString(8),
+		EntryName: util.RandomOwner(),
+		Program:   "SDGT",
+		Score:     0.97,
+		Decision:  "block",
+		Source:    ScreeningSourceRescreen,
+	}})
+	require.NoError(t, err)
+	_, err = store.ReverseTransferTx(ctx, transferred.Transfer.ID)
+	require.ErrorIs(t, err, apperror.ErrTransferBlocked)
+
+	// nothing was reversed
+	reversed, err := store.GetAccount(ctx, account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), reversed.Balance-account2.Balance)
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/risk"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// statuses of a risk alert
+const (
+	RiskAlertOpen = "open"
+	// RiskAlertCleared is an alert found to be a false positive
+	RiskAlertCleared   = "cleared"
+	RiskAlertConfirmed = "confirmed"
+)
+
+// TransferBlockedDetails are the details of the error returned for a transfer the risk rules blocked
+type TransferBlockedDetails struct {
+	RiskAlertID int64 `json:"risk_alert_id"`
+}
+
+// blockedTransfer is returned from inside a transaction for a transfer the risk rules blocked.
+// the alert must outlive the rollback, so execTx records it once the transaction is over
+type blockedTransfer struct {
+	alert CreateRiskAlertParams
+}
+
+func (b *blockedTransfer) Error() string {
+	return fmt.Sprintf("transfer from account [%d] blocked by risk screening", b.alert.FromAccountID)
+}
+
+// recordBlockedTransfer records the alert of a blocked transfer and returns the error the caller sees
+func (store *SQLStore) recordBlockedTransfer(ctx context.Context, blocked *blockedTransfer) error {
+	alert, err := store.Queries.CreateRiskAlert(ctx, blocked.alert)
+	if err != nil {
+		return err
+	}
+	return apperror.TransferBlocked("transfer blocked by risk screening").
+		WithDetails(TransferBlockedDetails{RiskAlertID: alert.ID})
+}
+
+// screenTransfer runs the risk rules over a transfer out of fromAccount, which the caller holds the lock on.
+// the alert is what to record unless the decision is to allow the transfer
+func (store *SQLStore) screenTransfer(ctx context.Context, q *Queries, fromAccount Account, arg TransferTxParams) (risk.Decision, CreateRiskAlertParams, error) {
+	now := time.Now()
+	history, err := q.ListRiskTransfers(ctx, ListRiskTransfersParams{
+		Since:          now.Add(-store.risk.Lookback()),
+		AccountID:      fromAccount.ID,
+		CounterpartyID: arg.ToAccountID,
+	})
+	if err != nil {
+		return risk.Allow, CreateRiskAlertParams{}, err
+	}
+
+	transfer := risk.Transfer{
+		FromAccountID:     fromAccount.ID,
+		ToAccountID:       arg.ToAccountID,
+		Amount:            arg.Amount,
+		Currency:          fromAccount.Currency,
+		FromAccountOpened: fromAccount.CreatedAt,
+		At:                now,
+	}
+	for _, past := range history {
+		pastTransfer := risk.PastTransfer{ToAccountID: past.ToAccountID, Amount: past.Amount, At: past.CreatedAt}
+		if past.FromAccountID == fromAccount.ID {
+			transfer.Outgoing = append(transfer.Outgoing, pastTransfer)
+		} else {
+			transfer.Returning = append(transfer.Returning, pastTransfer)
+		}
+	}
+
+	result := store.risk.Evaluate(transfer)
+	if result.Decision == risk.Allow {
+		return risk.Allow, CreateRiskAlertParams{}, nil
+	}
+	findings, err := json.Marshal(result.Findings)
+	if err != nil {
+		return risk.Allow, CreateRiskAlertParams{}, err
+	}
+	return result.Decision, CreateRiskAlertParams{
+		Decision:      string(result.Decision),
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Currency:      fromAccount.Currency,
+		Findings:      findings,
+	}, nil
+}
+
+// ResolveRiskAlertTx closes an open alert as cleared or confirmed and records who did in the audit log.
+// it returns apperror.ErrConflict for an alert that is no longer open
+func (store *SQLStore) ResolveRiskAlertTx(ctx context.Context, arg ResolveRiskAlertParams) (alert RiskAlert, err error) {
+	ctx, span := startTxSpan(ctx, "ResolveRiskAlertTx", attribute.Int64("risk_alert.id", arg.ID))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetRiskAlert(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		alert, err = q.ResolveRiskAlert(ctx, arg)
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperror.Conflict(fmt.Sprintf("risk alert [%d] is already resolved", arg.ID))
+		}
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditRiskAlertResolved, AuditTargetRiskAlert, auditID(arg.ID), before, alert)
+	})
+	return alert, err
+}
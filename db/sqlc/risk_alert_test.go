@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/risk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferTxScreening(t *testing.T) {
+	// one amount just under 100 is reviewed, the second within the hour is blocked
+	store := NewStore(testDB, WithRiskEngine(risk.NewEngine(time.Hour,
+		risk.Structuring{Threshold: 100, Margin: 10, Window: time.Hour, Repeat: 2},
+	)))
+	ctx := context.Background()
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+	_, err := testQueries.UpdateAccount(ctx, UpdateAccountParams{ID: account1.ID, Balance: 1000})
+	require.NoError(t, err)
+
+	arg := TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 95}
+	reviewed, err := store.TransferTx(ctx, arg)
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(ctx, arg)
+	require.ErrorIs(t, err, apperror.ErrTransferBlocked)
+	details, ok := apperror.As(err).Details.(TransferBlockedDetails)
+	require.True(t, ok)
+
+	// the blocked transfer was rolled back, its alert was not
+	account, err := store.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(905), account.Balance)
+
+	blocked, err := store.GetRiskAlert(ctx, details.RiskAlertID)
+	require.NoError(t, err)
+	require.Equal(t, string(risk.Block), blocked.Decision)
+	require.Equal(t, RiskAlertOpen, blocked.Status)
+	require.False(t, blocked.TransferID.Valid)
+	var findings []risk.Finding
+	require.NoError(t, json.Unmarshal(blocked.Findings, &findings))
+	require.Len(t, findings, 1)
+	require.Equal(t, "structuring", findings[0].Rule)
+
+	// the reviewed one went through and its alert points at it
+	alerts, err := store.ListRiskAlerts(ctx, ListRiskAlertsParams{Status: RiskAlertOpen, Limit: 1000})
+	require.NoError(t, err)
+	var found bool
+	for _, alert := range alerts {
+		if alert.TransferID.Int64 == reviewed.Transfer.ID {
+			found = true
+			require.Equal(t, string(risk.Review), alert.Decision)
+		}
+	}
+	require.True(t, found)
+}
+
+func TestResolveRiskAlert(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+	admin := createRandomUser(t)
+
+	alert, err := testQueries.CreateRiskAlert(context.Background(), CreateRiskAlertParams{
+		Decision:      string(risk.Review),
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Currency:      account1.Currency,
+		Findings:      json.RawMessage(`[]`),
+	})
+	require.NoError(t, err)
+
+	arg := ResolveRiskAlertParams{
+		ID:         alert.ID,
+		Status:     RiskAlertConfirmed,
+		ResolvedBy: sql.NullString{String: admin.Username, Valid: true},
+	}
+	resolved, err := testQueries.ResolveRiskAlert(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, RiskAlertConfirmed, resolved.Status)
+	require.True(t, resolved.ResolvedAt.Valid)
+
+	// resolved once only
+	_, err = testQueries.ResolveRiskAlert(context.Background(), arg)
+	require.Error(t, err)
+}
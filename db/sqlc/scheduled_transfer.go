@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/util"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// statuses of a scheduled transfer
+const (
+	ScheduledTransferScheduled = "scheduled"
+	ScheduledTransferSucceeded = "succeeded"
+	// ScheduledTransferFailed is a transfer that was rejected when it was due, FailureReason says why
+	ScheduledTransferFailed    = "failed"
+	ScheduledTransferCancelled = "cancelled"
+)
+
+// a scheduled transfer that fails for another reason than a rejection is tried again after a growing delay,
+// and failed once it has been tried MaxScheduledTransferAttempts times
+const (
+	MaxScheduledTransferAttempts = 5
+	minScheduledRetryDelay       = time.Minute
+	maxScheduledRetryDelay       = time.Hour
+)
+
+// ExecuteScheduledTransferTx executes the oldest due scheduled transfer. the row stays locked while the money moves,
+// so other schedulers skip it and a cancel waits for the outcome. a transfer that is rejected (an account is gone,
+// the currencies differ, funds are short) is marked failed with the reason. other errors are returned with the
+// transfer, which is retried later so the transfers due after it are not held up; the transfer is left out when
+// even the retry could not be recorded. it returns apperror.ErrNotFound when nothing is due
+func (store *SQLStore) ExecuteScheduledTransferTx(ctx context.Context) (scheduled ScheduledTransfer, err error) {
+	ctx, span := startTxSpan(ctx, "ExecuteScheduledTransferTx")
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		var err error
+		scheduled, err = q.ClaimDueScheduledTransfer(ctx)
+		if err != nil {
+			return err
+		}
+		if err = checkAccounts(ctx, q, scheduled.Currency, scheduled.FromAccountID, scheduled.ToAccountID); err != nil {
+			return err
+		}
+
+		result, err := store.transfer(ctx, q, TransferTxParams{
+			FromAccountID: scheduled.FromAccountID,
+			ToAccountID:   scheduled.ToAccountID,
+			Amount:        scheduled.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		scheduled, err = q.MarkScheduledTransferSucceeded(ctx, MarkScheduledTransferSucceededParams{
+			ID:         scheduled.ID,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+	if scheduled.ID != 0 {
+		span.SetAttributes(attribute.Int64("scheduled_transfer.id", scheduled.ID))
+	}
+	if err == nil || scheduled.ID == 0 {
+		return scheduled, err
+	}
+	// only a domain error is a rejection, a lost connection or a deadlock past the retries is tried again
+	var rejected *apperror.Error
+	if !errors.As(err, &rejected) {
+		return store.retryScheduledTransfer(ctx, scheduled, err)
+	}
+
+	// the rollback released the row, another scheduler may have picked it up since
+	failed, err := store.Queries.MarkScheduledTransferFailed(ctx, MarkScheduledTransferFailedParams{
+		ID:            scheduled.ID,
+		FailureReason: sql.NullString{String: rejected.Message, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.GetScheduledTransfer(ctx, scheduled.ID)
+	}
+	return failed, TranslateError(err)
+}
+
+// retryScheduledTransfer counts the try of scheduled that failed with cause and puts the next one off, or fails the
+// transfer once it is out of attempts
+func (store *SQLStore) retryScheduledTransfer(ctx context.Context, scheduled ScheduledTransfer, cause error) (ScheduledTransfer, error) {
+	attempts := scheduled.Attempts + 1
+	retried, err := store.Queries.RetryScheduledTransfer(ctx, RetryScheduledTransferParams{
+		ID:      scheduled.ID,
+		RetryAt: sql.NullTime{Time: time.Now().Add(util.Backoff(attempts, minScheduledRetryDelay, maxScheduledRetryDelay)), Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// cancelled or executed by another scheduler since the rollback
+		return store.GetScheduledTransfer(ctx, scheduled.ID)
+	}
+	if err != nil {
+		return ScheduledTransfer{}, errors.Join(cause, TranslateError(err))
+	}
+	if retried.Attempts < MaxScheduledTransferAttempts {
+		return retried, cause
+	}
+
+	failed, err := store.Queries.MarkScheduledTransferFailed(ctx, MarkScheduledTransferFailedParams{
+		ID:            scheduled.ID,
+		FailureReason: sql.NullString{String: fmt.Sprintf("not executed after %d attempts", retried.Attempts), Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.GetScheduledTransfer(ctx, scheduled.ID)
+	}
+	return failed, TranslateError(err)
+}
+
+// checkAccounts checks the accounts of a transfer decided on earlier still exist and hold its currency
+func checkAccounts(ctx context.Context, q *Queries, currency string, accountIDs ...int64) error {
+	for _, accountID := range accountIDs {
+		account, err := q.GetAccount(ctx, accountID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperror.Wrap(apperror.CodeNotFound, err, fmt.Sprintf("account [%d] not found", accountID))
+		}
+		if err != nil {
+			return err
+		}
+		if account.Currency != currency {
+			return apperror.CurrencyMismatch(fmt.Sprintf("account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/stretchr/testify/require"
+)
+
+func createDueScheduledTransfer(t *testing.T, from, to Account, amount int64) ScheduledTransfer {
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), CreateScheduledTransferParams{
+		Owner:         from.Owner,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		Currency:      from.Currency,
+		ExecuteAt:     time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferScheduled, scheduled.Status)
+	return scheduled
+}
+
+func createAccountInCurrency(t *testing.T, currency string) Account {
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    createRandomUser(t).Username,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+// executeUntil runs due transfers until id was executed, other tests leave due transfers behind
+func executeUntil(t *testing.T, store Store, id int64) ScheduledTransfer {
+	for {
+		scheduled, err := store.ExecuteScheduledTransferTx(context.Background())
+		require.False(t, errors.Is(err, apperror.ErrNotFound), "scheduled transfer %d was not executed", id)
+		require.NoError(t, err)
+		if scheduled.ID == id {
+			return scheduled
+		}
+	}
+}
+
+func TestExecuteScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	account1, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: account1.ID, Balance: 100})
+	require.NoError(t, err)
+
+	scheduled := createDueScheduledTransfer(t, account1, account2, 10)
+	executed := executeUntil(t, store, scheduled.ID)
+	require.Equal(t, ScheduledTransferSucceeded, executed.Status)
+	require.True(t, executed.TransferID.Valid)
+	require.True(t, executed.ExecutedAt.Valid)
+
+	transfer, err := store.GetTransfer(context.Background(), executed.TransferID.Int64)
+	require.NoError(t, err)
+	require.Equal(t, scheduled.Amount, transfer.Amount)
+
+	// executed transfers are never claimed again and can no longer be cancelled
+	_, err = store.CancelScheduledTransfer(context.Background(), scheduled.ID)
+	require.ErrorIs(t, err, apperror.ErrNotFound)
+}
+
+func TestExecuteScheduledTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	scheduled := createDueScheduledTransfer(t, account1, account2, account1.Balance+1)
+	executed := executeUntil(t, store, scheduled.ID)
+	require.Equal(t, ScheduledTransferFailed, executed.Status)
+	require.False(t, executed.TransferID.Valid)
+	require.NotEmpty(t, executed.FailureReason.String)
+
+	// the rollback left the balance alone
+	account, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, account.Balance)
+}
+
+// interruptScheduledTransfer makes the execution of scheduled, due before any other transfer, fail with a plain
+// database error: the from account is held by another transaction and the waiting statement is cancelled
+func interruptScheduledTransfer(t *testing.T, store Store, scheduled ScheduledTransfer) (ScheduledTransfer, error) {
+	ctx := context.Background()
+	locker, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer locker.Rollback()
+	var lockerPID int
+	require.NoError(t, locker.QueryRowContext(ctx, "SELECT pg_backend_pid()").Scan(&lockerPID))
+	_, err = New(locker).GetAccountsForUpdate(ctx, scheduled.FromAccountID)
+	require.NoError(t, err)
+
+	type outcome struct {
+		scheduled ScheduledTransfer
+		err       error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		scheduled, err := store.ExecuteScheduledTransferTx(ctx)
+		done <- outcome{scheduled, err}
+	}()
+
+	var waitingPID int
+	require.Eventually(t, func() bool {
+		err := testDB.QueryRowContext(ctx, `SELECT pid FROM pg_stat_activity
+			WHERE datname = current_database() AND wait_event_type = 'Lock' AND pid <> $1 LIMIT 1`, lockerPID).Scan(&waitingPID)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = testDB.ExecContext(ctx, "SELECT pg_cancel_backend($1)", waitingPID)
+	require.NoError(t, err)
+
+	interrupted := <-done
+	require.Equal(t, scheduled.ID, interrupted.scheduled.ID)
+	return interrupted.scheduled, interrupted.err
+}
+
+// drainScheduledTransfers runs what other tests left due, so the next claim is the transfer of the calling test
+func drainScheduledTransfers(t *testing.T, store Store) {
+	for {
+		_, err := store.ExecuteScheduledTransferTx(context.Background())
+		if errors.Is(err, apperror.ErrNotFound) {
+			return
+		}
+		require.NoError(t, err)
+	}
+}
+
+// makeRetryDue moves the retry of a scheduled transfer to now
+func makeRetryDue(t *testing.T, id int64) {
+	_, err := testDB.ExecContext(context.Background(), "UPDATE scheduled_transfers SET retry_at = now() WHERE id = $1", id)
+	require.NoError(t, err)
+}
+
+func TestExecuteScheduledTransferTxInterrupted(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	drainScheduledTransfers(t, store)
+	scheduled := createDueScheduledTransfer(t, account1, account2, 10)
+
+	// a plain database error is no rejection, the transfer stays scheduled and is retried later
+	interrupted, err := interruptScheduledTransfer(t, store, scheduled)
+	require.Error(t, err)
+	require.Equal(t, apperror.CodeInternal, apperror.CodeOf(err))
+	require.Equal(t, ScheduledTransferScheduled, interrupted.Status)
+	require.False(t, interrupted.FailureReason.Valid)
+	require.Equal(t, int32(1), interrupted.Attempts)
+	require.True(t, interrupted.RetryAt.Valid)
+	require.True(t, interrupted.RetryAt.Time.After(time.Now()))
+
+	// the scheduler goes on without it until the retry is due
+	_, err = store.ExecuteScheduledTransferTx(ctx)
+	require.ErrorIs(t, err, apperror.ErrNotFound)
+
+	makeRetryDue(t, scheduled.ID)
+	executed := executeUntil(t, store, scheduled.ID)
+	require.Equal(t, ScheduledTransferSucceeded, executed.Status)
+}
+
+func TestExecuteScheduledTransferTxOutOfAttempts(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	drainScheduledTransfers(t, store)
+	scheduled := createDueScheduledTransfer(t, account1, account2, 10)
+
+	// a transfer that keeps failing with a database error is failed after the last attempt
+	for attempt := 1; attempt < MaxScheduledTransferAttempts; attempt++ {
+		interrupted, err := interruptScheduledTransfer(t, store, scheduled)
+		require.Error(t, err)
+		require.Equal(t, ScheduledTransferScheduled, interrupted.Status)
+		require.Equal(t, int32(attempt), interrupted.Attempts)
+		makeRetryDue(t, scheduled.ID)
+	}
+	failed, err := interruptScheduledTransfer(t, store, scheduled)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferFailed, failed.Status)
+	require.Equal(t, int32(MaxScheduledTransferAttempts), failed.Attempts)
+	require.Contains(t, failed.FailureReason.String, "attempts")
+
+	// the money never moved
+	account, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, account.Balance)
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), CreateScheduledTransferParams{
+		Owner:         account1.Owner,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Currency:      account1.Currency,
+		ExecuteAt:     time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	cancelled, err := testQueries.CancelScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferCancelled, cancelled.Status)
+}
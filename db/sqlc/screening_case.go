@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// sanctions statuses of a user
+const (
+	SanctionsClear   = "clear"
+	SanctionsReview  = "review"
+	SanctionsBlocked = "blocked"
+)
+
+// statuses of a screening case
+const (
+	ScreeningCaseOpen = "open"
+	// ScreeningCaseCleared is a match found to be someone else
+	ScreeningCaseCleared   = "cleared"
+	ScreeningCaseConfirmed = "confirmed"
+)
+
+// where a screening case came from
+const (
+	ScreeningSourceOnboarding = "onboarding"
+	ScreeningSourceRescreen   = "rescreen"
+)
+
+// CreateUserTxParams is a new user and the cases of the weak matches its name had against the sanctions list
+type CreateUserTxParams struct {
+	CreateUserParams
+	Cases []CreateScreeningCaseParams
+}
+
+// CreateUserTx creates a user with the cases of its screening, its sanctions status follows from them.
+// a user signing up is the actor of their own creation in the audit log
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (user User, err error) {
+	ctx, span := startTxSpan(ctx, "CreateUserTx", attribute.String("user.username", arg.Username))
+	defer func() { endTxSpan(span, err) }()
+
+	ctx = actingUser(ctx, arg.Username)
+	err = store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+		user, _, err = recordScreening(ctx, q, user.Username, arg.Cases)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditUserCreated, AuditTargetUser, user.Username, nil, newAuditedUser(user))
+	})
+	return user, err
+}
+
+// RecordScreeningTx records the matches of an existing user, matches already on record are skipped.
+// it returns the user with its sanctions status brought up to date and the cases that are new
+func (store *SQLStore) RecordScreeningTx(ctx context.Context, username string, cases []CreateScreeningCaseParams) (user User, created []ScreeningCase, err error) {
+	ctx, span := startTxSpan(ctx, "RecordScreeningTx", attribute.String("user.username", username))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, created, err = recordScreening(ctx, q, username, cases)
+		return err
+	})
+	return user, created, err
+}
+
+func recordScreening(ctx context.Context, q *Queries, username string, cases []CreateScreeningCaseParams) (User, []ScreeningCase, error) {
+	created := []ScreeningCase{}
+	for _, arg := range cases {
+		arg.Username = sql.NullString{String: username, Valid: true}
+		screeningCase, err := q.CreateScreeningCase(ctx, arg)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return User{}, nil, err
+		}
+		created = append(created, screeningCase)
+	}
+	user, err := q.RefreshSanctionsStatus(ctx, username)
+	return user, created, err
+}
+
+// ResolveScreeningCaseTx closes an open case and updates the sanctions status of its user, a confirmed match
+// blocks the user for good. it returns apperror.ErrConflict for a case that is no longer open
+func (store *SQLStore) ResolveScreeningCaseTx(ctx context.Context, arg ResolveScreeningCaseParams) (screeningCase ScreeningCase, err error) {
+	ctx, span := startTxSpan(ctx, "ResolveScreeningCaseTx", attribute.Int64("screening_case.id", arg.ID))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetScreeningCase(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		screeningCase, err = q.ResolveScreeningCase(ctx, arg)
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperror.Conflict(fmt.Sprintf("screening case [%d] is already resolved", arg.ID))
+		}
+		if err != nil {
+			return err
+		}
+		if screeningCase.Username.Valid {
+			if _, err = q.RefreshSanctionsStatus(ctx, screeningCase.Username.String); err != nil {
+				return err
+			}
+		}
+		return recordAudit(ctx, q, AuditScreeningCaseResolved, AuditTargetScreeningCase, auditID(arg.ID), before, screeningCase)
+	})
+	return screeningCase, err
+}
+
+// checkSanctions refuses a transfer to or from a user the sanctions screening blocked.
+// the error does not say which side, that would tip off the sender about the recipient
+func checkSanctions(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64) error {
+	blocked, err := q.ListBlockedOwners(ctx, ListBlockedOwnersParams{FromAccountID: fromAccountID, ToAccountID: toAccountID})
+	if err != nil || len(blocked) == 0 {
+		return err
+	}
+	return apperror.TransferBlocked("transfer blocked by sanctions screening")
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScreeningCases(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	admin := createRandomUser(t)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+	match := CreateScreeningCaseParams{
+		FullName:  "Ivan Petrov",
+		EntryID:   util.RandomString(8),
+		EntryName: "PETROV, Ivan Sergeyevich",
+		Program:   "UKRAINE-EO13660",
+		Score:     0.93,
+		Decision:  "review",
+		Source:    ScreeningSourceOnboarding,
+	}
+	user, err := store.CreateUserTx(ctx, CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       util.RandomOwner(),
+			HashedPassword: hashedPassword,
+			FullName:       match.FullName,
+			Email:          util.RandomEmail(),
+		},
+		Cases: []CreateScreeningCaseParams{match},
+	})
+	require.NoError(t, err)
+	require.Equal(t, SanctionsReview, user.SanctionsStatus)
+
+	// the same match again is not a new case
+	_, created, err := store.RecordScreeningTx(ctx, user.Username, []CreateScreeningCaseParams{match})
+	require.NoError(t, err)
+	require.Empty(t, created)
+
+	// a strong match blocks the user and their transfers
+	strong := match
+	strong.EntryID = util.RandomString(8)
+	strong.Decision = "block"
+	strong.Source = ScreeningSourceRescreen
+	user, created, err = store.RecordScreeningTx(ctx, user.Username, []CreateScreeningCaseParams{strong})
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	require.Equal(t, SanctionsBlocked, user.SanctionsStatus)
+
+	account1, err := store.CreateAccount(ctx, CreateAccountParams{Owner: user.Username, Currency: util.USD})
+	require.NoError(t, err)
+	account2 := createAccountInCurrency(t, util.USD)
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account2.ID, ToAccountID: account1.ID, Amount: 1})
+	require.ErrorIs(t, err, apperror.ErrTransferBlocked)
+
+	// clearing the strong match leaves the weak one open
+	_, err = store.ResolveScreeningCaseTx(ctx, ResolveScreeningCaseParams{
+		ID:         created[0].ID,
+		Status:     ScreeningCaseCleared,
+		ResolvedBy: sql.NullString{String: admin.Username, Valid: true},
+	})
+	require.NoError(t, err)
+	user, err = store.GetUser(ctx, user.Username)
+	require.NoError(t, err)
+	require.Equal(t, SanctionsReview, user.SanctionsStatus)
+
+	_, err = store.ResolveScreeningCaseTx(ctx, ResolveScreeningCaseParams{ID: created[0].ID, Status: ScreeningCaseConfirmed})
+	require.ErrorIs(t, err, apperror.ErrConflict)
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/util"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// statuses of a standing order
+const (
+	StandingOrderActive    = "active"
+	StandingOrderCompleted = "completed"
+	StandingOrderCancelled = "cancelled"
+)
+
+// what a standing order does with an occurrence the account cannot pay, for short funds or a transfer limit alike
+const (
+	StandingOrderSkip  = "skip"
+	StandingOrderRetry = "retry"
+)
+
+// statuses of a standing order occurrence
+const (
+	OccurrenceSucceeded = "succeeded"
+	OccurrenceSkipped   = "skipped"
+	OccurrenceRetrying  = "retrying"
+	// OccurrenceFailed is an occurrence that was still short of funds after its last retry
+	OccurrenceFailed = "failed"
+)
+
+// minRetryDelay and maxRetryDelay bound the wait before an occurrence short of funds is tried again
+const (
+	minRetryDelay = time.Hour
+	maxRetryDelay = 24 * time.Hour
+)
+
+// OccurrenceDates returns the calendar date of occurrence n (counting from 0) and the business day it runs on
+func (order StandingOrder) OccurrenceDates(n int32) (scheduled time.Time, run time.Time) {
+	scheduled = util.OccurrenceDate(order.StartDate, order.Frequency, int(n))
+	return scheduled, util.AdjustBusinessDay(scheduled, order.BusinessDayAdjustment)
+}
+
+// Ended reports whether occurrence n is past the end date or the maximum number of occurrences
+func (order StandingOrder) Ended(n int32) bool {
+	if order.MaxOccurrences.Valid && n >= order.MaxOccurrences.Int32 {
+		return true
+	}
+	scheduled, _ := order.OccurrenceDates(n)
+	return order.EndDate.Valid && scheduled.After(order.EndDate.Time)
+}
+
+// ExecuteStandingOrderTxResult is the occurrence that was run and the order after it
+type ExecuteStandingOrderTxResult struct {
+	Order      StandingOrder           `json:"order"`
+	Occurrence StandingOrderOccurrence `json:"occurrence"`
+}
+
+// ExecuteStandingOrderTx runs the next occurrence of the most overdue standing order. the transfer, the occurrence
+// history and the move to the next occurrence commit together, so an occurrence is paid at most once.
+// it returns apperror.ErrNotFound when no order is due
+func (store *SQLStore) ExecuteStandingOrderTx(ctx context.Context) (result ExecuteStandingOrderTxResult, err error) {
+	ctx, span := startTxSpan(ctx, "ExecuteStandingOrderTx")
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		order, err := q.ClaimDueStandingOrder(ctx)
+		if err != nil {
+			return err
+		}
+		span.SetAttributes(attribute.Int64("standing_order.id", order.ID))
+
+		scheduledDate, runDate := order.OccurrenceDates(order.Occurrences)
+		occurrence := UpsertStandingOrderOccurrenceParams{
+			StandingOrderID: order.ID,
+			Sequence:        order.Occurrences,
+			ScheduledDate:   scheduledDate,
+			RunDate:         runDate,
+			Attempts:        order.Attempts + 1,
+		}
+
+		transferResult, err := store.transfer(ctx, q, TransferTxParams{
+			FromAccountID: order.FromAccountID,
+			ToAccountID:   order.ToAccountID,
+			Amount:        order.Amount,
+		})
+		reason, err := refusal(ctx, q, err)
+		if err != nil {
+			return err
+		}
+		switch {
+		case reason == "":
+			occurrence.Status = OccurrenceSucceeded
+			occurrence.TransferID = sql.NullInt64{Int64: transferResult.Transfer.ID, Valid: true}
+		case order.OnInsufficientFunds == StandingOrderRetry && order.Attempts < order.MaxRetries:
+			occurrence.Status = OccurrenceRetrying
+		case order.OnInsufficientFunds == StandingOrderRetry:
+			occurrence.Status = OccurrenceFailed
+		default:
+			occurrence.Status = OccurrenceSkipped
+		}
+		if occurrence.Status != OccurrenceSucceeded {
+			occurrence.FailureReason = sql.NullString{String: reason, Valid: true}
+		}
+		result.Occurrence, err = q.UpsertStandingOrderOccurrence(ctx, occurrence)
+		if err != nil {
+			return err
+		}
+
+		next := UpdateStandingOrderScheduleParams{
+			ID:          order.ID,
+			Status:      StandingOrderActive,
+			Occurrences: order.Occurrences + 1,
+		}
+		switch {
+		case occurrence.Status == OccurrenceRetrying:
+			next.Occurrences = order.Occurrences
+			next.Attempts = occurrence.Attempts
+			next.NextRunAt = sql.NullTime{Time: time.Now().Add(util.Backoff(occurrence.Attempts, minRetryDelay, maxRetryDelay)), Valid: true}
+		case order.Ended(next.Occurrences):
+			next.Status = StandingOrderCompleted
+		default:
+			_, nextRun := order.OccurrenceDates(next.Occurrences)
+			next.NextRunAt = sql.NullTime{Time: nextRun, Valid: true}
+		}
+		result.Order, err = q.UpdateStandingOrderSchedule(ctx, next)
+		return err
+	})
+	return result, err
+}
+
+// refusal turns the error of a transfer the order could not pay into the reason the occurrence records: short funds,
+// a frozen account, sanctions, a transfer limit or the risk rules. transfer refuses before it writes anything, so the
+// transaction goes on and the alert of a blocked transfer commits with the occurrence. it returns "" for no error
+// and other errors as they are
+func refusal(ctx context.Context, q *Queries, err error) (string, error) {
+	var blocked *blockedTransfer
+	switch {
+	case err == nil:
+		return "", nil
+	case errors.As(err, &blocked):
+		alert, err := q.CreateRiskAlert(ctx, blocked.alert)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("transfer blocked by risk screening, alert [%d]", alert.ID), nil
+	case errors.Is(err, apperror.ErrInsufficientFunds), errors.Is(err, apperror.ErrForbidden),
+		errors.Is(err, apperror.ErrTransferBlocked), errors.Is(err, apperror.ErrLimitExceeded):
+		return apperror.As(err).Message, nil
+	}
+	return "", err
+}
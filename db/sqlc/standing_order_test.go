@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createDueStandingOrder(t *testing.T, from, to Account, amount int64, policy string, maxOccurrences int32) StandingOrder {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	order, err := testQueries.CreateStandingOrder(context.Background(), CreateStandingOrderParams{
+		Owner:                 from.Owner,
+		FromAccountID:         from.ID,
+		ToAccountID:           to.ID,
+		Amount:                amount,
+		Currency:              from.Currency,
+		Frequency:             util.Monthly,
+		StartDate:             today,
+		MaxOccurrences:        sql.NullInt32{Int32: maxOccurrences, Valid: maxOccurrences > 0},
+		BusinessDayAdjustment: util.BusinessDayNone,
+		OnInsufficientFunds:   policy,
+		MaxRetries:            1,
+		NextRunAt:             sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true},
+	})
+	require.NoError(t, err)
+	return order
+}
+
+// runOccurrenceUntil runs due standing orders until one of id ran, other tests leave due orders behind
+func runOccurrenceUntil(t *testing.T, store Store, id int64) ExecuteStandingOrderTxResult {
+	for {
+		result, err := store.ExecuteStandingOrderTx(context.Background())
+		require.False(t, errors.Is(err, apperror.ErrNotFound), "standing order %d did not run", id)
+		require.NoError(t, err)
+		if result.Order.ID == id {
+			return result
+		}
+	}
+}
+
+func TestExecuteStandingOrderTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	account1, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: account1.ID, Balance: 100})
+	require.NoError(t, err)
+
+	order := createDueStandingOrder(t, account1, account2, 10, StandingOrderSkip, 2)
+	result := runOccurrenceUntil(t, store, order.ID)
+
+	require.Equal(t, OccurrenceSucceeded, result.Occurrence.Status)
+	require.Zero(t, result.Occurrence.Sequence)
+	require.True(t, result.Occurrence.TransferID.Valid)
+	transfer, err := store.GetTransfer(context.Background(), result.Occurrence.TransferID.Int64)
+	require.NoError(t, err)
+	require.Equal(t, order.Amount, transfer.Amount)
+
+	// the next occurrence is a month later
+	require.Equal(t, StandingOrderActive, result.Order.Status)
+	require.EqualValues(t, 1, result.Order.Occurrences)
+	_, nextRun := order.OccurrenceDates(1)
+	require.WithinDuration(t, nextRun, result.Order.NextRunAt.Time, time.Second)
+
+	occurrences, err := store.ListStandingOrderOccurrences(context.Background(), ListStandingOrderOccurrencesParams{
+		StandingOrderID: order.ID,
+		Limit:           10,
+	})
+	require.NoError(t, err)
+	require.Len(t, occurrences, 1)
+}
+
+func TestExecuteStandingOrderTxSkip(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	// the only occurrence is skipped, which completes the order
+	order := createDueStandingOrder(t, account1, account2, account1.Balance+1, StandingOrderSkip, 1)
+	result := runOccurrenceUntil(t, store, order.ID)
+
+	require.Equal(t, OccurrenceSkipped, result.Occurrence.Status)
+	require.False(t, result.Occurrence.TransferID.Valid)
+	require.NotEmpty(t, result.Occurrence.FailureReason.String)
+	require.Equal(t, StandingOrderCompleted, result.Order.Status)
+	require.False(t, result.Order.NextRunAt.Valid)
+}
+
+func TestExecuteStandingOrderTxOverLimit(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createAccountInCurrency(t, account1.Currency)
+	_, err := testQueries.SetAccountTransferLimit(context.Background(), SetAccountTransferLimitParams{
+		AccountID:  account1.ID,
+		DailyLimit: sql.NullInt64{Int64: 5, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// the limit error of the transfer becomes the reason of the skipped occurrence, nothing moved
+	order := createDueStandingOrder(t, account1, account2, 10, StandingOrderSkip, 1)
+	result := runOccurrenceUntil(t, store, order.ID)
+
+	require.Equal(t, OccurrenceSkipped, result.Occurrence.Status)
+	require.Contains(t, result.Occurrence.FailureReason.String, "daily limit")
+	account, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, account.Balance)
+}
+
+func TestExecuteStandingOrderTxRetry(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	order := createDueStandingOrder(t, account1, account2, account1.Balance+1, StandingOrderRetry, 0)
+	result := runOccurrenceUntil(t, store, order.ID)
+
+	// the occurrence waits for its retry instead of moving on
+	require.Equal(t, OccurrenceRetrying, result.Occurrence.Status)
+	require.Zero(t, result.Order.Occurrences)
+	require.EqualValues(t, 1, result.Order.Attempts)
+	require.True(t, result.Order.NextRunAt.Time.After(time.Now()))
+
+	// due again, the last retry fails the occurrence in the same history row
+	_, err := testQueries.UpdateStandingOrderSchedule(context.Background(), UpdateStandingOrderScheduleParams{
+		ID:          order.ID,
+		Status:      StandingOrderActive,
+		Attempts:    result.Order.Attempts,
+		Occurrences: result.Order.Occurrences,
+		NextRunAt:   sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true},
+	})
+	require.NoError(t, err)
+	result2 := runOccurrenceUntil(t, store, order.ID)
+	require.Equal(t, result.Occurrence.ID, result2.Occurrence.ID)
+	require.Equal(t, OccurrenceFailed, result2.Occurrence.Status)
+	require.EqualValues(t, 2, result2.Occurrence.Attempts)
+	require.EqualValues(t, 1, result2.Order.Occurrences)
+	require.Zero(t, result2.Order.Attempts)
+}
@@ -3,7 +3,17 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/metrics"
+	"github.com/ShubhKanodia/GoBank/risk"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Store provides all functions to execute db queries and transactions
@@ -15,6 +25,36 @@ import (
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error)
+	FreezeAccountTx(ctx context.Context, id int64) (Account, error)
+	UnfreezeAccountTx(ctx context.Context, id int64) (Account, error)
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (User, error)
+	RecordScreeningTx(ctx context.Context, username string, cases []CreateScreeningCaseParams) (User, []ScreeningCase, error)
+	ResolveScreeningCaseTx(ctx context.Context, arg ResolveScreeningCaseParams) (ScreeningCase, error)
+	ResolveRiskAlertTx(ctx context.Context, arg ResolveRiskAlertParams) (RiskAlert, error)
+	SubmitKYCTx(ctx context.Context, arg SubmitKYCTxParams) (SubmitKYCTxResult, error)
+	TransitionKYCTx(ctx context.Context, arg TransitionKYCTxParams) (User, KycEvent, error)
+	ReverseTransferTx(ctx context.Context, transferID int64) (TransferTxResult, error)
+	AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (AdjustBalanceTxResult, error)
+	ExecuteScheduledTransferTx(ctx context.Context) (ScheduledTransfer, error)
+	ExecuteStandingOrderTx(ctx context.Context) (ExecuteStandingOrderTxResult, error)
+	ApproveTransferTx(ctx context.Context, arg ApproveTransferTxParams) (ApproveTransferTxResult, error)
+	GetTransferLimitUsage(ctx context.Context, accountID int64) (TransferLimitUsage, error)
+	RejectTransfer(ctx context.Context, arg RejectTransferParams) (TransferApproval, error)
+	ConfirmTOTPTx(ctx context.Context, arg ConfirmTOTPTxParams) (User, error)
+	DisableTOTPTx(ctx context.Context, arg VerifySecondFactorTxParams) (User, error)
+	VerifySecondFactorTx(ctx context.Context, arg VerifySecondFactorTxParams) error
+	StartLoginChallengeTx(ctx context.Context, arg CreateLoginChallengeParams) (LoginChallenge, error)
+	CompleteLoginChallengeTx(ctx context.Context, arg CompleteLoginChallengeTxParams) (User, error)
+	VerifyEmailTx(ctx context.Context, tokenHash string) (User, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error)
+	RecordLoginFailureTx(ctx context.Context, arg RecordLoginFailureTxParams) (RecordLoginFailureTxResult, error)
+	UnlockUserTx(ctx context.Context, username string) (User, error)
+	CreateAPIKeyTx(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
+	RevokeAPIKeyTx(ctx context.Context, id int64) (ApiKey, error)
+	RotateAPIKeyTx(ctx context.Context, arg RotateAPIKeyTxParams) (RotateAPIKeyTxResult, error)
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (version uint, dirty bool, err error)
 }
 
 // this is called composition over inheritance
@@ -22,14 +62,30 @@ type Store interface {
 // and also we can use all func methods from queries in store
 type SQLStore struct {
 	*Queries
-	db *sql.DB
+	db   *sql.DB
+	risk *risk.Engine
+}
+
+// StoreOption configures a store
+type StoreOption func(*SQLStore)
+
+// WithRiskEngine screens transfers with engine instead of the built-in rules
+func WithRiskEngine(engine *risk.Engine) StoreOption {
+	return func(store *SQLStore) {
+		store.risk = engine
+	}
 }
 
-func NewStore(db *sql.DB) Store {
-	return &SQLStore{
+func NewStore(db *sql.DB, opts ...StoreOption) Store {
+	store := &SQLStore{
 		db:      db,
-		Queries: New(db),
+		Queries: New(instrument(db)),
+		risk:    risk.NewDefaultEngine(),
 	}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store
 }
 
 // below is the method that executes    a transaction
@@ -41,7 +97,55 @@ func NewStore(db *sql.DB) Store {
 // it then returns the error
 // execTx executes a function within a database transaction
 // it takes a context and a function that takes a queries pointer and returns an er ror
+// deadlocks and serialization failures are safe to retry since the whole transaction was rolled back,
+// so fn is run again up to maxTxAttempts times. fn must therefore not have side effects outside q
 func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+	start := time.Now()
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = store.runTx(ctx, fn)
+		reason, retryable := retryReason(err)
+		if !retryable || attempt == maxTxAttempts {
+			break
+		}
+		metrics.DBTxRetries.WithLabelValues(reason).Inc()
+		trace.SpanFromContext(ctx).AddEvent("tx retry", trace.WithAttributes(
+			attribute.String("reason", reason),
+			attribute.Int("attempt", attempt),
+		))
+	}
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	metrics.DBTxDuration.WithLabelValues(status).Observe(time.Since(start).Seconds())
+
+	var blocked *blockedTransfer
+	if errors.As(err, &blocked) {
+		err = store.recordBlockedTransfer(ctx, blocked)
+	}
+	return TranslateError(err)
+}
+
+// maxTxAttempts is how many times execTx runs a transaction that keeps hitting deadlocks or serialization failures
+const maxTxAttempts = 3
+
+// retryReason reports whether err is a transient postgres error worth retrying the transaction for
+func retryReason(err error) (string, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return "", false
+	}
+	switch pqErr.Code.Name() {
+	case "deadlock_detected", "serialization_failure":
+		return pqErr.Code.Name(), true
+	}
+	return "", false
+}
+
+// runTx runs fn once inside a single transaction
+func (store *SQLStore) runTx(ctx context.Context, fn func(*Queries) error) error {
 	tx, err := store.db.BeginTx(ctx, nil)
 	//BeginTx tells the database “start a transaction.”
 	// From now on, changes are temporary and isolated.
@@ -50,7 +154,7 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) erro
 		return err
 	}
 
-	q := New(tx) // create a new queries pointer with the transaction
+	q := New(instrument(tx)) // create a new queries pointer with the transaction
 	// this is the queries pointer that will be used to execute the queries
 
 	// execute the function with the queries pointer
@@ -90,71 +194,168 @@ type TransferTxResult struct {
 // var txKey = struct{}{} // define a key for the transaction name in the context
 
 // TransferTx performs a money transfer from one account to another
-func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
-	var result TransferTxResult
+func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (result TransferTxResult, err error) {
 
-	err := store.execTx(ctx, func(q *Queries) error {
-		var err error
+	// the span wraps the whole transaction so every query below shows up as its child
+	ctx, span := startTxSpan(ctx, "TransferTx",
+		attribute.Int64("transfer.from_account_id", arg.FromAccountID),
+		attribute.Int64("transfer.to_account_id", arg.ToAccountID),
+		attribute.Int64("transfer.amount", arg.Amount),
+	)
+	defer func() { endTxSpan(span, err) }()
 
-		// txName := ctx.Value(txKey) //get value of txKey from the context
+	err = store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = store.transfer(ctx, q, arg)
+		return err
+	})
+	return result, err
+}
 
-		// fmt.Println(txName, " Create Transfer")
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+// transfer moves the money within the transaction of q, the exported transactions that move money share it.
+// it refuses transfers the locked from account can't cover, transfers involving a frozen account or a user
+// blocked by sanctions screening, transfers over the daily or monthly limit of the from account and transfers
+// the risk rules block, and raises an alert for those the rules want reviewed
+func (store *SQLStore) transfer(ctx context.Context, q *Queries, arg TransferTxParams) (result TransferTxResult, err error) {
+	// the locks are held from the checks to the balance updates, so the history checked is the history that commits
+	fromAccount, toAccount, err := lockAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+	if err != nil {
+		return result, err
+	}
+	if fromAccount.Balance < arg.Amount {
+		return result, apperror.InsufficientFunds(fmt.Sprintf("account [%d] has insufficient funds", arg.FromAccountID))
+	}
+	if err = checkFrozen(fromAccount, toAccount); err != nil {
+		return result, err
+	}
+	if err = checkSanctions(ctx, q, arg.FromAccountID, arg.ToAccountID); err != nil {
+		return result, err
+	}
+	if err = checkTransferLimits(ctx, q, fromAccount, toAccount, arg.Amount); err != nil {
+		return result, err
+	}
+	decision, alert, err := store.screenTransfer(ctx, q, fromAccount, arg)
+	if err != nil {
+		return result, err
+	}
+	if decision == risk.Block {
+		return result, &blockedTransfer{alert: alert}
+	}
 
-		// create entries for the transfer transaction
+	transfer, err := q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+	result, err = bookTransfer(ctx, q, transfer)
+	if err != nil {
+		return result, err
+	}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
+	if decision == risk.Review {
+		alert.TransferID = sql.NullInt64{Int64: result.Transfer.ID, Valid: true}
+		if _, err = q.CreateRiskAlert(ctx, alert); err != nil {
+			return result, err
 		}
-		// fmt.Println(txName, " Creating entry 2")
-		// create entry for the to account
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
+	}
+	return result, publishTransfer(ctx, q, result, EventTransferCreated)
+}
+
+// lockAccounts locks both accounts of a transfer in id order, the order addMoney updates them in, so checking
+// the balance first cannot deadlock with other transfers. it returns the from and the to account
+func lockAccounts(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64) (fromAccount Account, toAccount Account, err error) {
+	ids := []int64{fromAccountID, toAccountID}
+	if toAccountID < fromAccountID {
+		ids[0], ids[1] = toAccountID, fromAccountID
+	}
+	for _, id := range ids {
+		account, err := q.GetAccountsForUpdate(ctx, id)
 		if err != nil {
-			return err
+			return fromAccount, toAccount, err
 		}
-		// fmt.Println(txName, " get account 1")
-
-		//replace get accout for update and updte account -> addAccountBalance singele query
-		// get the from account for update
-		// acc1, err := q.GetAccountsForUpdate(ctx, arg.FromAccountID)
-		// if err != nil {
-		// 	return fmt.Errorf("failed to get from account: %w", err)
-		// }
-		// fmt.Println(txName, "update account 1 balance")
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+		if id == fromAccountID {
+			fromAccount = account
 		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+			toAccount = account
 		}
+	}
+	return fromAccount, toAccount, nil
+}
 
-		if err != nil {
-			return err
+// checkFrozen refuses a transfer from or to a frozen account
+func checkFrozen(accounts ...Account) error {
+	for _, account := range accounts {
+		if account.FrozenAt.Valid {
+			return apperror.Forbidden(fmt.Sprintf("account [%d] is frozen", account.ID))
 		}
+	}
+	return nil
+}
 
-		return nil
+// bookTransfer writes the entries of a transfer, moves the balances and records the account events
+func bookTransfer(ctx context.Context, q *Queries, transfer Transfer) (result TransferTxResult, err error) {
+	result.Transfer = transfer
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: transfer.FromAccountID,
+		Amount:    -transfer.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: transfer.ToAccountID,
+		Amount:    transfer.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
 
-		//for amount
-		//get account -> update account ka balance
-		//need a locking mechanism here gng
+	// balances are updated in id order, the order lockAccounts locks them in
+	if transfer.FromAccountID < transfer.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, transfer.FromAccountID, -transfer.Amount, transfer.ToAccountID, transfer.Amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, transfer.ToAccountID, transfer.Amount, transfer.FromAccountID, -transfer.Amount)
+	}
+	if err != nil {
+		return result, err
+	}
 
-		//ex
+	// both accounts are locked by now, so the events land in the same order as the balance changes
+	if err = createEntryEvent(ctx, q, result.FromEntry, result.Transfer.ID, result.FromAccount.Balance); err != nil {
+		return result, err
+	}
+	err = createEntryEvent(ctx, q, result.ToEntry, result.Transfer.ID, result.ToAccount.Balance)
+	return result, err
+}
 
+// publishTransfer queues the webhooks of a booked transfer and its outbox event of eventType
+func publishTransfer(ctx context.Context, q *Queries, result TransferTxResult, eventType string) error {
+	if err := queueWebhooks(ctx, q, result); err != nil {
+		return err
+	}
+	// downstream systems learn about the transfer only if this transaction commits
+	return enqueueEvent(ctx, q, AggregateTransfer, result.Transfer.ID, eventType, TransferCreatedPayload{
+		Transfer:  result.Transfer,
+		Currency:  result.FromAccount.Currency,
+		FromEntry: result.FromEntry,
+		ToEntry:   result.ToEntry,
 	})
-	return result, err
+}
+
+// startTxSpan opens the span for one of the exported transactions
+func startTxSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+func endTxSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
 }
 
 func addMoney(
@@ -2,16 +2,25 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 
+	"github.com/ShubhKanodia/GoBank/apperror"
 	"github.com/stretchr/testify/require"
 )
 
+// fundAccount sets the balance of account so the transfers of a test can't run short
+func fundAccount(t *testing.T, account Account, balance int64) Account {
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID, Balance: balance})
+	require.NoError(t, err)
+	return account
+}
+
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
+	account1 := fundAccount(t, createRandomAccount(t), 1000)
 	account2 := createRandomAccount(t)
 
 	// key for context to identify the transaction name
@@ -114,13 +123,34 @@ func TestTransferTx(t *testing.T) {
 	require.Equal(t, account1.Balance-int64(n)*amount, updateAccount1.Balance)
 	require.Equal(t, account2.Balance+int64(n)*amount, updateAccount2.Balance)
 
+	// every transfer left an event on both accounts, in commit order
+	for _, account := range []Account{account1, account2} {
+		events, err := store.ListAccountEvents(context.Background(), ListAccountEventsParams{
+			AccountID: account.ID,
+			AfterID:   0,
+			Limit:     int32(n + 1),
+		})
+		require.NoError(t, err)
+		require.Len(t, events, n)
+		for i, event := range events {
+			require.Equal(t, AccountEventEntryCreated, event.Type)
+			if i > 0 {
+				require.Greater(t, event.ID, events[i-1].ID)
+			}
+
+			var data EntryCreatedEvent
+			require.NoError(t, json.Unmarshal(event.Data, &data))
+			require.Equal(t, account.ID, data.Entry.AccountID)
+			require.NotZero(t, data.TransferID)
+		}
+	}
 }
 
 func TestTransferTxDeadlock(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := fundAccount(t, createRandomAccount(t), 1000)
+	account2 := fundAccount(t, createRandomAccount(t), 1000)
 
 	// key for context to identify the transaction name
 
@@ -169,3 +199,60 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account2.Balance, updateAccount2.Balance)
 
 }
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	// enough for half of the transfers, the locked row decides which ones
+	account1 := fundAccount(t, createRandomAccount(t), 50)
+	account2 := createRandomAccount(t)
+
+	n := 10
+	amount := int64(10)
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, apperror.ErrInsufficientFunds)
+	}
+	require.Equal(t, 5, succeeded)
+
+	updateAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, updateAccount1.Balance)
+	updateAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+account1.Balance, updateAccount2.Balance)
+}
+
+func TestTransferTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createRandomAccount(t)
+
+	_, err := store.FreezeAccount(ctx, account2.ID)
+	require.NoError(t, err)
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.ErrorIs(t, err, apperror.ErrForbidden)
+
+	_, err = store.UnfreezeAccount(ctx, account2.ID)
+	require.NoError(t, err)
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.NoError(t, err)
+}
@@ -7,8 +7,49 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
+const createReversal = `-- name: CreateReversal :one
+INSERT INTO transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    reversal_of
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (reversal_of) WHERE reversal_of IS NOT NULL DO NOTHING
+RETURNING id, from_account_id, to_account_id, amount, created_at, reversal_of
+`
+
+type CreateReversalParams struct {
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Amount        int64         `json:"amount"`
+	ReversalOf    sql.NullInt64 `json:"reversal_of"`
+}
+
+// no row comes back when the transfer was reversed already
+func (q *Queries) CreateReversal(ctx context.Context, arg CreateReversalParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, createReversal,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ReversalOf,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversalOf,
+	)
+	return i, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers(
     from_account_id, 
@@ -16,7 +57,7 @@ INSERT INTO transfers(
     amount
 ) VALUES (
     $1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+) RETURNING id, from_account_id, to_account_id, amount, created_at, reversal_of
 `
 
 type CreateTransferParams struct {
@@ -34,12 +75,13 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ReversalOf,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, reversal_of FROM transfers
 WHERE id=$1 LIMIT 1
 `
 
@@ -52,12 +94,13 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ReversalOf,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, reversal_of FROM transfers
 WHERE 
     from_account_id = $1 OR
     to_account_id = $2
@@ -93,6 +136,7 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ReversalOf,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// statuses of a transfer approval
+const (
+	TransferApprovalPending  = "pending"
+	TransferApprovalApproved = "approved"
+	// TransferApprovalRejected is a request turned down by a reviewer, RejectionReason says why
+	TransferApprovalRejected = "rejected"
+	// TransferApprovalExpired is a request nobody reviewed before ExpiresAt
+	TransferApprovalExpired = "expired"
+)
+
+// ApproveTransferTxParams contains the input parameters of the approve transfer transaction
+type ApproveTransferTxParams struct {
+	ID       int64  `json:"id"`
+	Reviewer string `json:"reviewer"`
+}
+
+// ApproveTransferTxResult is the approved request and the transfer it executed
+type ApproveTransferTxResult struct {
+	Approval TransferApproval `json:"approval"`
+	Transfer TransferTxResult `json:"transfer"`
+}
+
+// ApproveTransferTx approves a pending transfer request and executes the transfer, moving the money exactly as
+// TransferTx does, in the same transaction, so an approved request always has its transfer and vice versa.
+// the reviewer must not be the initiator. a request that is no longer pending or has expired is a conflict,
+// and a transfer that is rejected (an account is gone, the currencies differ, funds are short) leaves it pending
+func (store *SQLStore) ApproveTransferTx(ctx context.Context, arg ApproveTransferTxParams) (result ApproveTransferTxResult, err error) {
+	ctx, span := startTxSpan(ctx, "ApproveTransferTx", attribute.Int64("transfer_approval.id", arg.ID))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		approval, err := q.GetTransferApprovalForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if err = checkReviewable(approval, arg.Reviewer); err != nil {
+			return err
+		}
+		if err = checkAccounts(ctx, q, approval.Currency, approval.FromAccountID, approval.ToAccountID); err != nil {
+			return err
+		}
+
+		result.Transfer, err = store.transfer(ctx, q, TransferTxParams{
+			FromAccountID: approval.FromAccountID,
+			ToAccountID:   approval.ToAccountID,
+			Amount:        approval.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Approval, err = q.ReviewTransferApproval(ctx, ReviewTransferApprovalParams{
+			ID:         approval.ID,
+			Status:     TransferApprovalApproved,
+			Reviewer:   sql.NullString{String: arg.Reviewer, Valid: true},
+			TransferID: sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditTransferApproved, AuditTargetTransferApproval, auditID(approval.ID), approval, result.Approval)
+	})
+	return result, err
+}
+
+// RejectTransferParams contains the input parameters of RejectTransfer
+type RejectTransferParams struct {
+	ID       int64  `json:"id"`
+	Reviewer string `json:"reviewer"`
+	Reason   string `json:"reason"`
+}
+
+// RejectTransfer turns down a pending transfer request, with the same checks on the reviewer as ApproveTransferTx
+func (store *SQLStore) RejectTransfer(ctx context.Context, arg RejectTransferParams) (rejected TransferApproval, err error) {
+	ctx, span := startTxSpan(ctx, "RejectTransfer", attribute.Int64("transfer_approval.id", arg.ID))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		approval, err := q.GetTransferApprovalForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if err = checkReviewable(approval, arg.Reviewer); err != nil {
+			return err
+		}
+
+		rejected, err = q.ReviewTransferApproval(ctx, ReviewTransferApprovalParams{
+			ID:              arg.ID,
+			Status:          TransferApprovalRejected,
+			Reviewer:        sql.NullString{String: arg.Reviewer, Valid: true},
+			RejectionReason: sql.NullString{String: arg.Reason, Valid: arg.Reason != ""},
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditTransferRejected, AuditTargetTransferApproval, auditID(approval.ID), approval, rejected)
+	})
+	return rejected, err
+}
+
+// checkReviewable checks reviewer may decide on approval now
+func checkReviewable(approval TransferApproval, reviewer string) error {
+	if approval.Initiator == reviewer {
+		return apperror.Forbidden("a transfer can't be approved or rejected by the user who requested it")
+	}
+	if approval.Status != TransferApprovalPending {
+		return transferApprovalReviewed(approval.Status)
+	}
+	if !time.Now().Before(approval.ExpiresAt) {
+		return transferApprovalReviewed(TransferApprovalExpired)
+	}
+	return nil
+}
+
+func transferApprovalReviewed(status string) error {
+	return apperror.Conflict(fmt.Sprintf("transfer approval was already %s", status)).WithDetails(map[string]string{"status": status})
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/stretchr/testify/require"
+)
+
+// createPendingApproval holds a transfer of amount from account1 to account2, requested by the owner of account1
+func createPendingApproval(t *testing.T, account1 Account, account2 Account, amount int64, expiresAt time.Time) TransferApproval {
+	approval, err := testQueries.CreateTransferApproval(context.Background(), CreateTransferApprovalParams{
+		Initiator:     account1.Owner,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+		Currency:      account1.Currency,
+		ExpiresAt:     expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferApprovalPending, approval.Status)
+	return approval
+}
+
+func createApprover(t *testing.T) User {
+	user := createRandomUser(t)
+	require.Equal(t, UserRoleCustomer, user.Role)
+	_, err := testDB.Exec("UPDATE users SET role = $1 WHERE username = $2", UserRoleApprover, user.Username)
+	require.NoError(t, err)
+	return user
+}
+
+func TestApproveTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+	account1, err := testQueries.UpdateAccount(ctx, UpdateAccountParams{ID: account1.ID, Balance: 100})
+	require.NoError(t, err)
+
+	approval := createPendingApproval(t, account1, account2, 60, time.Now().Add(time.Hour))
+
+	// four eyes, the initiator can't approve
+	_, err = store.ApproveTransferTx(ctx, ApproveTransferTxParams{ID: approval.ID, Reviewer: account1.Owner})
+	require.ErrorIs(t, err, apperror.ErrForbidden)
+
+	approver := createApprover(t)
+	result, err := store.ApproveTransferTx(ctx, ApproveTransferTxParams{ID: approval.ID, Reviewer: approver.Username})
+	require.NoError(t, err)
+	require.Equal(t, TransferApprovalApproved, result.Approval.Status)
+	require.Equal(t, approver.Username, result.Approval.Reviewer.String)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Approval.TransferID.Int64)
+	require.Equal(t, int64(40), result.Transfer.FromAccount.Balance)
+
+	// approved once only
+	_, err = store.ApproveTransferTx(ctx, ApproveTransferTxParams{ID: approval.ID, Reviewer: approver.Username})
+	require.ErrorIs(t, err, apperror.ErrConflict)
+	_, err = store.RejectTransfer(ctx, RejectTransferParams{ID: approval.ID, Reviewer: approver.Username, Reason: "too late"})
+	require.ErrorIs(t, err, apperror.ErrConflict)
+}
+
+func TestApproveTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	approval := createPendingApproval(t, account1, account2, account1.Balance+1, time.Now().Add(time.Hour))
+	_, err := store.ApproveTransferTx(ctx, ApproveTransferTxParams{ID: approval.ID, Reviewer: createApprover(t).Username})
+	require.ErrorIs(t, err, apperror.ErrInsufficientFunds)
+
+	// still pending, the rollback left the balance alone
+	pending, err := store.GetTransferApproval(ctx, approval.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferApprovalPending, pending.Status)
+	account, err := store.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, account.Balance)
+}
+
+func TestRejectTransfer(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+	approver := createApprover(t)
+
+	approval := createPendingApproval(t, account1, account2, 10, time.Now().Add(time.Hour))
+	rejected, err := store.RejectTransfer(ctx, RejectTransferParams{ID: approval.ID, Reviewer: approver.Username, Reason: "unknown beneficiary"})
+	require.NoError(t, err)
+	require.Equal(t, TransferApprovalRejected, rejected.Status)
+	require.Equal(t, "unknown beneficiary", rejected.RejectionReason.String)
+	require.True(t, rejected.ReviewedAt.Valid)
+
+	_, err = store.ApproveTransferTx(ctx, ApproveTransferTxParams{ID: approval.ID, Reviewer: approver.Username})
+	require.ErrorIs(t, err, apperror.ErrConflict)
+}
+
+func TestExpireTransferApprovals(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+	approver := createApprover(t)
+
+	stale := createPendingApproval(t, account1, account2, 10, time.Now().Add(-time.Second))
+	fresh := createPendingApproval(t, account1, account2, 10, time.Now().Add(time.Hour))
+
+	// approving checks the expiry before the sweep gets to it
+	_, err := store.ApproveTransferTx(ctx, ApproveTransferTxParams{ID: stale.ID, Reviewer: approver.Username})
+	require.ErrorIs(t, err, apperror.ErrConflict)
+
+	expired, err := store.ExpireTransferApprovals(ctx)
+	require.NoError(t, err)
+	var ids []int64
+	for _, approval := range expired {
+		require.Equal(t, TransferApprovalExpired, approval.Status)
+		ids = append(ids, approval.ID)
+	}
+	require.Contains(t, ids, stale.ID)
+	require.NotContains(t, ids, fresh.ID)
+}
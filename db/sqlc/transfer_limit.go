@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+)
+
+// periods a transfer limit applies to, both are calendar periods in UTC
+const (
+	LimitDaily   = "daily"
+	LimitMonthly = "monthly"
+)
+
+// LimitUsage is how much of the limit of one period an account has used
+type LimitUsage struct {
+	Period string `json:"period"`
+	// Limit and Remaining are null when the period has no limit
+	Limit     *int64    `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining *int64    `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// TransferLimitUsage is the daily and monthly usage of the limits of an account, and of the allowance its owner
+// shares across their accounts in the currency. a transfer has to fit into both
+type TransferLimitUsage struct {
+	AccountID int64           `json:"account_id"`
+	Currency  string          `json:"currency"`
+	Daily     LimitUsage      `json:"daily"`
+	Monthly   LimitUsage      `json:"monthly"`
+	Owner     OwnerLimitUsage `json:"owner"`
+}
+
+// OwnerLimitUsage is how much of the allowance of their KYC tier a user has used, over all their accounts in a currency
+type OwnerLimitUsage struct {
+	Username string     `json:"username"`
+	Daily    LimitUsage `json:"daily"`
+	Monthly  LimitUsage `json:"monthly"`
+}
+
+// LimitExceededDetails are the details of the error returned for a transfer over a limit, Username is set when
+// the allowance of the owner was the one exceeded
+type LimitExceededDetails struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username,omitempty"`
+	LimitUsage
+}
+
+// GetTransferLimitUsage returns the limits of an account and how much of them its outgoing transfers used so far
+func (store *SQLStore) GetTransferLimitUsage(ctx context.Context, accountID int64) (TransferLimitUsage, error) {
+	limits, err := store.Queries.GetTransferLimits(ctx, accountID)
+	if err != nil {
+		return TransferLimitUsage{}, TranslateError(err)
+	}
+	usage, err := transferLimitUsage(ctx, store.Queries, limits, time.Now())
+	return usage, TranslateError(err)
+}
+
+func transferLimitUsage(ctx context.Context, q *Queries, limits GetTransferLimitsRow, now time.Time) (TransferLimitUsage, error) {
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	dayEnd, monthEnd := dayStart.AddDate(0, 0, 1), monthStart.AddDate(0, 1, 0)
+
+	used, err := q.SumOutgoingTransfers(ctx, SumOutgoingTransfersParams{
+		AccountID:  limits.AccountID,
+		DayStart:   dayStart,
+		MonthStart: monthStart,
+	})
+	if err != nil {
+		return TransferLimitUsage{}, err
+	}
+	ownerLimits, err := q.GetOwnerTransferLimits(ctx, GetOwnerTransferLimitsParams{
+		Currency: limits.Currency,
+		Owner:    limits.Owner,
+	})
+	if err != nil {
+		return TransferLimitUsage{}, err
+	}
+	ownerUsed, err := q.SumOwnerOutgoingTransfers(ctx, SumOwnerOutgoingTransfersParams{
+		Owner:      limits.Owner,
+		Currency:   limits.Currency,
+		DayStart:   dayStart,
+		MonthStart: monthStart,
+	})
+	if err != nil {
+		return TransferLimitUsage{}, err
+	}
+
+	return TransferLimitUsage{
+		AccountID: limits.AccountID,
+		Currency:  limits.Currency,
+		Daily:     newLimitUsage(LimitDaily, limits.DailyLimit, used.Daily, dayEnd),
+		Monthly:   newLimitUsage(LimitMonthly, limits.MonthlyLimit, used.Monthly, monthEnd),
+		Owner: OwnerLimitUsage{
+			Username: limits.Owner,
+			Daily:    newLimitUsage(LimitDaily, ownerLimits.DailyLimit, ownerUsed.Daily, dayEnd),
+			Monthly:  newLimitUsage(LimitMonthly, ownerLimits.MonthlyLimit, ownerUsed.Monthly, monthEnd),
+		},
+	}, nil
+}
+
+func newLimitUsage(period string, limit sql.NullInt64, used int64, resetsAt time.Time) LimitUsage {
+	usage := LimitUsage{Period: period, Used: used, ResetsAt: resetsAt}
+	if limit.Valid {
+		remaining := max(limit.Int64-used, 0)
+		usage.Limit = &limit.Int64
+		usage.Remaining = &remaining
+	}
+	return usage
+}
+
+// checkTransferLimits returns a limit exceeded error, with the remaining allowance in its details, when moving
+// amount out of fromAccount would go over its daily or monthly limit, or over the allowance its owner shares
+// across their accounts in the currency. an account override never lifts the account above that allowance, a
+// transfer to another account of the same owner does not count against it.
+// the caller must hold the lock on the account, the owner is locked here, so that concurrent transfers cannot
+// both fit into the same allowance
+func checkTransferLimits(ctx context.Context, q *Queries, fromAccount Account, toAccount Account, amount int64) error {
+	accountID := fromAccount.ID
+	limits, err := q.GetTransferLimits(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if _, err = q.GetUserForUpdate(ctx, limits.Owner); err != nil {
+		return err
+	}
+	usage, err := transferLimitUsage(ctx, q, limits, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, period := range []LimitUsage{usage.Daily, usage.Monthly} {
+		if period.Remaining != nil && amount > *period.Remaining {
+			return apperror.LimitExceeded(fmt.Sprintf("transfer exceeds the %s limit of account [%d]", period.Period, accountID)).
+				WithDetails(LimitExceededDetails{AccountID: accountID, LimitUsage: period})
+		}
+	}
+	if toAccount.Owner == fromAccount.Owner {
+		return nil
+	}
+	for _, period := range []LimitUsage{usage.Owner.Daily, usage.Owner.Monthly} {
+		if period.Remaining != nil && amount > *period.Remaining {
+			return apperror.LimitExceeded(fmt.Sprintf("transfer exceeds the %s %s limit of user [%s]", period.Period, usage.Currency, limits.Owner)).
+				WithDetails(LimitExceededDetails{AccountID: accountID, Username: limits.Owner, LimitUsage: period})
+		}
+	}
+	return nil
+}
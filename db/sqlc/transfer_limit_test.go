@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferLimits(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+	_, err := testQueries.UpdateAccount(ctx, UpdateAccountParams{ID: account1.ID, Balance: 1000})
+	require.NoError(t, err)
+
+	// the limits of the owner's tier until the account has its own
+	usage, err := store.GetTransferLimitUsage(ctx, account1.ID)
+	require.NoError(t, err)
+	require.NotNil(t, usage.Daily.Limit)
+	require.Zero(t, usage.Daily.Used)
+
+	_, err = store.SetAccountTransferLimit(ctx, SetAccountTransferLimitParams{
+		AccountID:  account1.ID,
+		DailyLimit: sql.NullInt64{Int64: 100, Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 60})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 50})
+	require.ErrorIs(t, err, apperror.ErrLimitExceeded)
+	details, ok := apperror.As(err).Details.(LimitExceededDetails)
+	require.True(t, ok)
+	require.Equal(t, LimitDaily, details.Period)
+	require.Equal(t, int64(40), *details.Remaining)
+
+	usage, err = store.GetTransferLimitUsage(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), *usage.Daily.Limit)
+	require.Equal(t, int64(60), usage.Daily.Used)
+	require.Equal(t, int64(40), *usage.Daily.Remaining)
+	// the override left the monthly period to the default
+	require.NotNil(t, usage.Monthly.Limit)
+	require.Equal(t, int64(60), usage.Monthly.Used)
+	require.True(t, usage.Monthly.ResetsAt.After(usage.Daily.ResetsAt) || usage.Monthly.ResetsAt.Equal(usage.Daily.ResetsAt))
+}
+
+func TestTransferLimitsOwner(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	usage, err := store.GetTransferLimitUsage(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Owner, usage.Owner.Username)
+	require.NotNil(t, usage.Owner.Daily.Limit)
+	allowance := *usage.Owner.Daily.Limit
+	fundAccount(t, account1, 3*allowance)
+
+	// an override above the tier of the owner does not lift the account above the owner's allowance
+	_, err = store.SetAccountTransferLimit(ctx, SetAccountTransferLimitParams{
+		AccountID:  account1.ID,
+		DailyLimit: sql.NullInt64{Int64: 2 * allowance, Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: allowance})
+	require.NoError(t, err)
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 1})
+	require.ErrorIs(t, err, apperror.ErrLimitExceeded)
+	details, ok := apperror.As(err).Details.(LimitExceededDetails)
+	require.True(t, ok)
+	require.Equal(t, account1.Owner, details.Username)
+	require.Zero(t, *details.Remaining)
+
+	usage, err = store.GetTransferLimitUsage(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, allowance, *usage.Daily.Remaining)
+	require.Equal(t, allowance, usage.Owner.Daily.Used)
+	require.Zero(t, *usage.Owner.Daily.Remaining)
+}
+
+func TestTransferLimitsOwnerOwnAccounts(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account1 := createRandomAccount(t)
+	currency := util.USD
+	if account1.Currency == currency {
+		currency = util.EUR
+	}
+	// accounts are one per currency, so the owner's second account is in another one
+	account2, err := testQueries.CreateAccount(ctx, CreateAccountParams{Owner: account1.Owner, Currency: currency})
+	require.NoError(t, err)
+
+	usage, err := store.GetTransferLimitUsage(ctx, account1.ID)
+	require.NoError(t, err)
+	allowance := *usage.Owner.Daily.Limit
+	fundAccount(t, account1, 2*allowance)
+	_, err = store.SetAccountTransferLimit(ctx, SetAccountTransferLimitParams{
+		AccountID:  account1.ID,
+		DailyLimit: sql.NullInt64{Int64: 2 * allowance, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// moving money between their own accounts does not use up the owner's allowance, the account's limit still applies
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: allowance + 1})
+	require.NoError(t, err)
+
+	usage, err = store.GetTransferLimitUsage(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, allowance+1, usage.Daily.Used)
+	require.Zero(t, usage.Owner.Daily.Used)
+}
+
+func TestTransferLimitsReversal(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	transferred, err := store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.NoError(t, err)
+	_, err = store.ReverseTransferTx(ctx, transferred.Transfer.ID)
+	require.NoError(t, err)
+
+	// the reversal moved money out of account2, but it is a correction and uses up none of its limits
+	usage, err := store.GetTransferLimitUsage(ctx, account2.ID)
+	require.NoError(t, err)
+	require.Zero(t, usage.Daily.Used)
+	require.Zero(t, usage.Owner.Daily.Used)
+
+	usage, err = store.GetTransferLimitUsage(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), usage.Daily.Used)
+	require.Equal(t, int64(10), usage.Owner.Daily.Used)
+}
@@ -0,0 +1,289 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/totp"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// MaxLoginChallengeAttempts is how many wrong codes a login challenge takes before it is closed, the user has
+// to enter their password again for a new one
+const MaxLoginChallengeAttempts = 5
+
+// MaxSecondFactorFailures wrong codes of a step-up or of turning two-factor authentication off within
+// SecondFactorLockout lock the second factor of the user for SecondFactorLockout
+const (
+	MaxSecondFactorFailures = 5
+	SecondFactorLockout     = 15 * time.Minute
+)
+
+// SecondFactor proves the second factor of a user: a code of their authenticator app or, when they lost the
+// device, one of their recovery codes
+type SecondFactor struct {
+	Code         string
+	RecoveryCode string
+}
+
+// ConfirmTOTPTxParams confirms the enrollment of a user with a first code of their app
+type ConfirmTOTPTxParams struct {
+	Username string
+	Code     string
+	// RecoveryCodeHashes replace the recovery codes the user had, hashed with totp.HashRecoveryCode
+	RecoveryCodeHashes []string
+}
+
+// ConfirmTOTPTx turns two-factor authentication on once the user proved their app shows the right codes.
+// a user who did not enroll or already confirmed gets apperror.ErrConflict, a wrong code apperror.ErrUnauthenticated
+func (store *SQLStore) ConfirmTOTPTx(ctx context.Context, arg ConfirmTOTPTxParams) (user User, err error) {
+	ctx, span := startTxSpan(ctx, "ConfirmTOTPTx", attribute.String("user.username", arg.Username))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		if !before.TotpSecret.Valid {
+			return apperror.Conflict("enroll in two-factor authentication first")
+		}
+		if before.TotpEnabledAt.Valid {
+			return apperror.Conflict("two-factor authentication is already enabled")
+		}
+		step, ok := totp.Validate(before.TotpSecret.String, arg.Code, time.Now(), before.TotpLastStep)
+		if !ok {
+			return apperror.Unauthenticated("invalid two-factor code")
+		}
+
+		user, err = q.EnableTOTP(ctx, EnableTOTPParams{Username: arg.Username, TotpLastStep: step})
+		if err != nil {
+			return err
+		}
+		if err := replaceRecoveryCodes(ctx, q, arg.Username, arg.RecoveryCodeHashes); err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditTwoFactorEnabled, AuditTargetUser, user.Username, newAuditedUser(before), newAuditedUser(user))
+	})
+	return user, err
+}
+
+// VerifySecondFactorTxParams is a second factor of a user
+type VerifySecondFactorTxParams struct {
+	Username string
+	SecondFactor
+}
+
+// DisableTOTPTx turns two-factor authentication off and drops the recovery codes, the user proves the second
+// factor one last time. a wrong code is counted as in VerifySecondFactorTx
+func (store *SQLStore) DisableTOTPTx(ctx context.Context, arg VerifySecondFactorTxParams) (user User, err error) {
+	ctx, span := startTxSpan(ctx, "DisableTOTPTx", attribute.String("user.username", arg.Username))
+	defer func() { endTxSpan(span, err) }()
+
+	// a wrong code must not roll back the failure it counted, so it is reported once the transaction committed
+	var factorErr error
+	err = store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		factorErr, err = checkSecondFactor(ctx, q, before, arg.SecondFactor)
+		if err != nil || factorErr != nil {
+			return err
+		}
+
+		user, err = q.DisableTOTP(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		if err := q.DeleteRecoveryCodes(ctx, arg.Username); err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditTwoFactorDisabled, AuditTargetUser, user.Username, newAuditedUser(before), newAuditedUser(user))
+	})
+	if err == nil && factorErr != nil {
+		return User{}, factorErr
+	}
+	return user, err
+}
+
+// VerifySecondFactorTx checks a second factor for a step-up, the code or recovery code is used up. a wrong code
+// is counted against the user, MaxSecondFactorFailures of them lock the second factor and return
+// apperror.ErrTooManyRequests until the lock is over
+func (store *SQLStore) VerifySecondFactorTx(ctx context.Context, arg VerifySecondFactorTxParams) (err error) {
+	ctx, span := startTxSpan(ctx, "VerifySecondFactorTx", attribute.String("user.username", arg.Username))
+	defer func() { endTxSpan(span, err) }()
+
+	var factorErr error
+	err = store.execTx(ctx, func(q *Queries) error {
+		user, err := q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		factorErr, err = checkSecondFactor(ctx, q, user, arg.SecondFactor)
+		return err
+	})
+	if err == nil {
+		err = factorErr
+	}
+	return err
+}
+
+// StartLoginChallengeTx opens the second step of logging in for a user whose password was accepted. while the
+// second factor of the user is locked it returns apperror.ErrTooManyRequests, so that new challenges do not
+// give more codes to guess
+func (store *SQLStore) StartLoginChallengeTx(ctx context.Context, arg CreateLoginChallengeParams) (challenge LoginChallenge, err error) {
+	ctx, span := startTxSpan(ctx, "StartLoginChallengeTx", attribute.String("user.username", arg.Username))
+	defer func() { endTxSpan(span, err) }()
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		if err := checkSecondFactorLock(ctx, q, arg.Username, time.Now()); err != nil {
+			return err
+		}
+		challenge, err = q.CreateLoginChallenge(ctx, arg)
+		return err
+	})
+	return challenge, err
+}
+
+// CompleteLoginChallengeTxParams answers the login challenge the password opened
+type CompleteLoginChallengeTxParams struct {
+	ChallengeID uuid.UUID
+	SecondFactor
+}
+
+// CompleteLoginChallengeTx completes a login challenge with a second factor and returns the user to issue the
+// access token for. an unknown, expired, completed or closed challenge and a wrong code all return
+// apperror.ErrUnauthenticated, the wrong code is counted against the challenge and, as in VerifySecondFactorTx,
+// against the user, so that opening new challenges does not give more guesses. while the second factor of the
+// user is locked apperror.ErrTooManyRequests is returned
+func (store *SQLStore) CompleteLoginChallengeTx(ctx context.Context, arg CompleteLoginChallengeTxParams) (user User, err error) {
+	ctx, span := startTxSpan(ctx, "CompleteLoginChallengeTx", attribute.String("login_challenge.id", arg.ChallengeID.String()))
+	defer func() { endTxSpan(span, err) }()
+
+	// a wrong code must not roll back the attempt it cost, so it is reported once the transaction committed
+	var factorErr error
+	err = store.execTx(ctx, func(q *Queries) error {
+		factorErr = nil
+		challenge, err := q.GetLoginChallengeForUpdate(ctx, arg.ChallengeID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return apperror.Unauthenticated("invalid or expired login challenge")
+			}
+			return err
+		}
+		if challenge.CompletedAt.Valid || !challenge.ExpiresAt.After(time.Now()) || challenge.Attempts >= MaxLoginChallengeAttempts {
+			return apperror.Unauthenticated("invalid or expired login challenge")
+		}
+
+		// the user is not authenticated yet, events of the second step are their own
+		ctx := actingUser(ctx, challenge.Username)
+		user, err = q.GetUserForUpdate(ctx, challenge.Username)
+		if err != nil {
+			return err
+		}
+		factorErr, err = checkSecondFactor(ctx, q, user, arg.SecondFactor)
+		if err != nil {
+			return err
+		}
+		if factorErr != nil {
+			_, err = q.AddLoginChallengeAttempt(ctx, challenge.ID)
+			return err
+		}
+		_, err = q.CompleteLoginChallenge(ctx, challenge.ID)
+		return err
+	})
+	if err == nil && factorErr != nil {
+		return User{}, factorErr
+	}
+	return user, err
+}
+
+// checkSecondFactor verifies factor while the second factor of user is not locked, and keeps count of the wrong
+// ones. a wrong factor is returned as factorErr so the transaction commits the count, err fails the transaction
+func checkSecondFactor(ctx context.Context, q *Queries, user User, factor SecondFactor) (factorErr error, err error) {
+	now := time.Now()
+	if err := checkSecondFactorLock(ctx, q, user.Username, now); err != nil {
+		return nil, err
+	}
+
+	factorErr = verifySecondFactor(ctx, q, user, factor)
+	if factorErr == nil {
+		_, err = q.DeleteLoginFailures(ctx, DeleteLoginFailuresParams{Scope: LoginScopeSecondFactor, Subject: user.Username})
+		return nil, err
+	}
+	if apperror.CodeOf(factorErr) != apperror.CodeUnauthenticated {
+		return nil, factorErr
+	}
+
+	failure, locked, err := countLoginFailure(ctx, q, LoginScopeSecondFactor, user.Username, now.Add(-SecondFactorLockout), MaxSecondFactorFailures, now.Add(SecondFactorLockout))
+	if err != nil {
+		return nil, err
+	}
+	if locked {
+		if err := recordAudit(ctx, q, AuditSecondFactorLocked, AuditTargetUser, user.Username, nil, failure); err != nil {
+			return nil, err
+		}
+	}
+	return factorErr, nil
+}
+
+// checkSecondFactorLock returns apperror.ErrTooManyRequests while the second factor of username is locked, the
+// count of its failures stays locked by the transaction of q
+func checkSecondFactorLock(ctx context.Context, q *Queries, username string, now time.Time) error {
+	failure, err := q.GetLoginFailureForUpdate(ctx, GetLoginFailureForUpdateParams{Scope: LoginScopeSecondFactor, Subject: username})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if failure.LockedUntil.Valid && failure.LockedUntil.Time.After(now) {
+		return apperror.TooManyRequests("too many wrong two-factor codes, try again later", failure.LockedUntil.Time.Sub(now))
+	}
+	return nil
+}
+
+// verifySecondFactor checks factor against user, who must be locked by the transaction of q. an accepted code
+// cannot be replayed and a recovery code is used up
+func verifySecondFactor(ctx context.Context, q *Queries, user User, factor SecondFactor) error {
+	if !user.TotpEnabledAt.Valid {
+		return apperror.Forbidden("two-factor authentication is not enabled")
+	}
+
+	if factor.RecoveryCode != "" {
+		code, err := q.UseRecoveryCode(ctx, UseRecoveryCodeParams{
+			Username: user.Username,
+			CodeHash: totp.HashRecoveryCode(factor.RecoveryCode),
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return apperror.Unauthenticated("invalid recovery code")
+			}
+			return err
+		}
+		return recordAudit(ctx, q, AuditRecoveryCodeUsed, AuditTargetUser, user.Username, nil, map[string]int64{"recovery_code_id": code.ID})
+	}
+
+	step, ok := totp.Validate(user.TotpSecret.String, factor.Code, time.Now(), user.TotpLastStep)
+	if !ok {
+		return apperror.Unauthenticated("invalid two-factor code")
+	}
+	return q.UpdateTOTPLastStep(ctx, UpdateTOTPLastStepParams{Username: user.Username, TotpLastStep: step})
+}
+
+func replaceRecoveryCodes(ctx context.Context, q *Queries, username string, hashes []string) error {
+	if err := q.DeleteRecoveryCodes(ctx, username); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if err := q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{Username: username, CodeHash: hash}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/totp"
+	"github.com/stretchr/testify/require"
+)
+
+// enrollRandomUser creates a user with two-factor authentication on and returns its secret and recovery codes
+func enrollRandomUser(t *testing.T, store Store) (User, string, []string) {
+	user := createRandomUser(t)
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	_, err = store.SetTOTPSecret(context.Background(), SetTOTPSecretParams{
+		Username:   user.Username,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	})
+	require.NoError(t, err)
+
+	codes, err := totp.GenerateRecoveryCodes()
+	require.NoError(t, err)
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = totp.HashRecoveryCode(code)
+	}
+	// the code of the previous step, so that the tests can still use the current one
+	code, err := totp.Code(secret, totp.Step(time.Now())-1)
+	require.NoError(t, err)
+	user, err = store.ConfirmTOTPTx(context.Background(), ConfirmTOTPTxParams{
+		Username:           user.Username,
+		Code:               code,
+		RecoveryCodeHashes: hashes,
+	})
+	require.NoError(t, err)
+	require.True(t, user.TotpEnabledAt.Valid)
+	return user, secret, codes
+}
+
+func TestConfirmTOTPTx(t *testing.T) {
+	store := NewStore(testDB)
+	user, _, _ := enrollRandomUser(t, store)
+
+	count, err := store.CountUnusedRecoveryCodes(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.EqualValues(t, totp.RecoveryCodeCount, count)
+
+	// enrolling again would replace the secret of a user who relies on it
+	_, err = store.SetTOTPSecret(context.Background(), SetTOTPSecretParams{Username: user.Username, TotpSecret: sql.NullString{String: "JBSWY3DPEHPK3PXP", Valid: true}})
+	require.ErrorIs(t, err, apperror.ErrNotFound)
+	_, err = store.ConfirmTOTPTx(context.Background(), ConfirmTOTPTxParams{Username: user.Username, Code: "000000"})
+	require.ErrorIs(t, err, apperror.ErrConflict)
+}
+
+func TestVerifySecondFactorTx(t *testing.T) {
+	store := NewStore(testDB)
+	user, secret, codes := enrollRandomUser(t, store)
+	ctx := context.Background()
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	arg := VerifySecondFactorTxParams{Username: user.Username, SecondFactor: SecondFactor{Code: code}}
+	require.NoError(t, store.VerifySecondFactorTx(ctx, arg))
+	// a code is accepted once
+	require.ErrorIs(t, store.VerifySecondFactorTx(ctx, arg), apperror.ErrUnauthenticated)
+
+	arg = VerifySecondFactorTxParams{Username: user.Username, SecondFactor: SecondFactor{RecoveryCode: codes[0]}}
+	require.NoError(t, store.VerifySecondFactorTx(ctx, arg))
+	require.ErrorIs(t, store.VerifySecondFactorTx(ctx, arg), apperror.ErrUnauthenticated)
+
+	other := createRandomUser(t)
+	err = store.VerifySecondFactorTx(ctx, VerifySecondFactorTxParams{Username: other.Username, SecondFactor: SecondFactor{Code: code}})
+	require.ErrorIs(t, err, apperror.ErrForbidden)
+}
+
+func TestVerifySecondFactorTxLockout(t *testing.T) {
+	store := NewStore(testDB)
+	user, secret, codes := enrollRandomUser(t, store)
+	ctx := context.Background()
+
+	// a wrong code is counted although the step-up fails, a right one forgets the count
+	wrong := VerifySecondFactorTxParams{Username: user.Username, SecondFactor: SecondFactor{Code: "000000"}}
+	require.ErrorIs(t, store.VerifySecondFactorTx(ctx, wrong), apperror.ErrUnauthenticated)
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	require.NoError(t, store.VerifySecondFactorTx(ctx, VerifySecondFactorTxParams{Username: user.Username, SecondFactor: SecondFactor{Code: code}}))
+
+	for i := 0; i < MaxSecondFactorFailures; i++ {
+		require.ErrorIs(t, store.VerifySecondFactorTx(ctx, wrong), apperror.ErrUnauthenticated)
+	}
+	failure, err := store.GetLoginFailureForUpdate(ctx, GetLoginFailureForUpdateParams{Scope: LoginScopeSecondFactor, Subject: user.Username})
+	require.NoError(t, err)
+	require.EqualValues(t, MaxSecondFactorFailures, failure.Failures)
+	require.True(t, failure.LockedUntil.Valid)
+
+	// while locked even a right factor is refused, for the step-up and for turning the factor off
+	err = store.VerifySecondFactorTx(ctx, VerifySecondFactorTxParams{Username: user.Username, SecondFactor: SecondFactor{RecoveryCode: codes[0]}})
+	require.ErrorIs(t, err, apperror.ErrTooManyRequests)
+	require.Positive(t, apperror.As(err).RetryAfter)
+	_, err = store.DisableTOTPTx(ctx, VerifySecondFactorTxParams{Username: user.Username, SecondFactor: SecondFactor{RecoveryCode: codes[0]}})
+	require.ErrorIs(t, err, apperror.ErrTooManyRequests)
+
+	count, err := store.CountUnusedRecoveryCodes(ctx, user.Username)
+	require.NoError(t, err)
+	require.EqualValues(t, totp.RecoveryCodeCount, count)
+}
+
+func TestDisableTOTPTx(t *testing.T) {
+	store := NewStore(testDB)
+	user, _, codes := enrollRandomUser(t, store)
+	ctx := context.Background()
+
+	_, err := store.DisableTOTPTx(ctx, VerifySecondFactorTxParams{Username: user.Username, SecondFactor: SecondFactor{RecoveryCode: "wrong"}})
+	require.ErrorIs(t, err, apperror.ErrUnauthenticated)
+	failure, err := store.GetLoginFailureForUpdate(ctx, GetLoginFailureForUpdateParams{Scope: LoginScopeSecondFactor, Subject: user.Username})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, failure.Failures)
+
+	disabled, err := store.DisableTOTPTx(ctx, VerifySecondFactorTxParams{Username: user.Username, SecondFactor: SecondFactor{RecoveryCode: codes[0]}})
+	require.NoError(t, err)
+	require.False(t, disabled.TotpEnabledAt.Valid)
+	_, err = store.GetLoginFailureForUpdate(ctx, GetLoginFailureForUpdateParams{Scope: LoginScopeSecondFactor, Subject: user.Username})
+	require.ErrorIs(t, err, apperror.ErrNotFound)
+}
+
+func TestCompleteLoginChallengeTx(t *testing.T) {
+	store := NewStore(testDB)
+	user, secret, _ := enrollRandomUser(t, store)
+	ctx := context.Background()
+
+	challenge, err := store.CreateLoginChallenge(ctx, CreateLoginChallengeParams{
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	// a wrong code is counted even though the call fails
+	_, err = store.CompleteLoginChallengeTx(ctx, CompleteLoginChallengeTxParams{ChallengeID: challenge.ID, SecondFactor: SecondFactor{Code: "000000"}})
+	require.ErrorIs(t, err, apperror.ErrUnauthenticated)
+	locked, err := store.GetLoginChallengeForUpdate(ctx, challenge.ID)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, locked.Attempts)
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	got, err := store.CompleteLoginChallengeTx(ctx, CompleteLoginChallengeTxParams{ChallengeID: challenge.ID, SecondFactor: SecondFactor{Code: code}})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, got.Username)
+
+	// a completed challenge cannot be used again
+	_, err = store.CompleteLoginChallengeTx(ctx, CompleteLoginChallengeTxParams{ChallengeID: challenge.ID, SecondFactor: SecondFactor{Code: code}})
+	require.ErrorIs(t, err, apperror.ErrUnauthenticated)
+}
+
+func TestCompleteLoginChallengeTxClosed(t *testing.T) {
+	store := NewStore(testDB)
+	user, secret, _ := enrollRandomUser(t, store)
+	ctx := context.Background()
+
+	challenge, err := store.CreateLoginChallenge(ctx, CreateLoginChallengeParams{
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	for i := 0; i < MaxLoginChallengeAttempts; i++ {
+		_, err = store.CompleteLoginChallengeTx(ctx, CompleteLoginChallengeTxParams{ChallengeID: challenge.ID, SecondFactor: SecondFactor{Code: "000000"}})
+		require.ErrorIs(t, err, apperror.ErrUnauthenticated)
+	}
+
+	// the right code comes too late, the challenge is closed
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	_, err = store.CompleteLoginChallengeTx(ctx, CompleteLoginChallengeTxParams{ChallengeID: challenge.ID, SecondFactor: SecondFactor{Code: code}})
+	require.ErrorIs(t, err, apperror.ErrUnauthenticated)
+	require.ErrorContains(t, err, "invalid or expired login challenge")
+}
+
+func TestCompleteLoginChallengeTxLockout(t *testing.T) {
+	store := NewStore(testDB)
+	user, secret, _ := enrollRandomUser(t, store)
+	ctx := context.Background()
+
+	// every challenge takes a wrong code, the user's failures add up across them
+	for i := 0; i < MaxSecondFactorFailures; i++ {
+		challenge, err := store.StartLoginChallengeTx(ctx, CreateLoginChallengeParams{
+			Username:  user.Username,
+			ExpiresAt: time.Now().Add(time.Minute),
+		})
+		require.NoError(t, err)
+		_, err = store.CompleteLoginChallengeTx(ctx, CompleteLoginChallengeTxParams{ChallengeID: challenge.ID, SecondFactor: SecondFactor{Code: "000000"}})
+		require.ErrorIs(t, err, apperror.ErrUnauthenticated)
+	}
+
+	// no new challenge is opened while the second factor is locked
+	_, err := store.StartLoginChallengeTx(ctx, CreateLoginChallengeParams{
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.ErrorIs(t, err, apperror.ErrTooManyRequests)
+
+	// and one opened before the lock does not take the right code either
+	challenge, err := store.CreateLoginChallenge(ctx, CreateLoginChallengeParams{
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	_, err = store.CompleteLoginChallengeTx(ctx, CompleteLoginChallengeTxParams{ChallengeID: challenge.ID, SecondFactor: SecondFactor{Code: code}})
+	require.ErrorIs(t, err, apperror.ErrTooManyRequests)
+}
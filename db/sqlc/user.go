@@ -0,0 +1,12 @@
+package db
+
+// roles of a user, rbac has what each of them may do
+const (
+	UserRoleCustomer = "customer"
+	// UserRoleTeller sees any account and opens accounts for customers
+	UserRoleTeller = "teller"
+	// UserRoleApprover reviews transfers held for approval, besides everything a customer does
+	UserRoleApprover = "approver"
+	// UserRoleAdmin works the review queues and corrects accounts, besides everything a teller does
+	UserRoleAdmin = "admin"
+)
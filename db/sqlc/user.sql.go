@@ -18,7 +18,7 @@ INSERT INTO users
     email
 ) VALUES (
     $1, $2, $3, $4
-) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at
+) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role, kyc_tier, sanctions_status, kyc_status, date_of_birth, address_line, city, postal_code, country, totp_secret, totp_enabled_at, totp_last_step, email_verified_at
 `
 
 type CreateUserParams struct {
@@ -43,12 +43,25 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.KycTier,
+		&i.SanctionsStatus,
+		&i.KycStatus,
+		&i.DateOfBirth,
+		&i.AddressLine,
+		&i.City,
+		&i.PostalCode,
+		&i.Country,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, kyc_tier, sanctions_status, kyc_status, date_of_birth, address_line, city, postal_code, country, totp_secret, totp_enabled_at, totp_last_step, email_verified_at FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -62,6 +75,158 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.KycTier,
+		&i.SanctionsStatus,
+		&i.KycStatus,
+		&i.DateOfBirth,
+		&i.AddressLine,
+		&i.City,
+		&i.PostalCode,
+		&i.Country,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, kyc_tier, sanctions_status, kyc_status, date_of_birth, address_line, city, postal_code, country, totp_secret, totp_enabled_at, totp_last_step, email_verified_at FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.KycTier,
+		&i.SanctionsStatus,
+		&i.KycStatus,
+		&i.DateOfBirth,
+		&i.AddressLine,
+		&i.City,
+		&i.PostalCode,
+		&i.Country,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const getUserForUpdate = `-- name: GetUserForUpdate :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, kyc_tier, sanctions_status, kyc_status, date_of_birth, address_line, city, postal_code, country, totp_secret, totp_enabled_at, totp_last_step, email_verified_at FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetUserForUpdate(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserForUpdate, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.KycTier,
+		&i.SanctionsStatus,
+		&i.KycStatus,
+		&i.DateOfBirth,
+		&i.AddressLine,
+		&i.City,
+		&i.PostalCode,
+		&i.Country,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2,
+    password_changed_at = now()
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role, kyc_tier, sanctions_status, kyc_status, date_of_birth, address_line, city, postal_code, country, totp_secret, totp_enabled_at, totp_last_step, email_verified_at
+`
+
+type UpdateUserPasswordParams struct {
+	Username       string `json:"username"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.Username, arg.HashedPassword)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.KycTier,
+		&i.SanctionsStatus,
+		&i.KycStatus,
+		&i.DateOfBirth,
+		&i.AddressLine,
+		&i.City,
+		&i.PostalCode,
+		&i.Country,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users
+SET email_verified_at = now()
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role, kyc_tier, sanctions_status, kyc_status, date_of_birth, address_line, city, postal_code, country, totp_secret, totp_enabled_at, totp_last_step, email_verified_at
+`
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserEmail, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.KycTier,
+		&i.SanctionsStatus,
+		&i.KycStatus,
+		&i.DateOfBirth,
+		&i.AddressLine,
+		&i.City,
+		&i.PostalCode,
+		&i.Country,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// event types customers can subscribe their webhook endpoints to
+const (
+	WebhookEventTransferReceived = "transfer.received"
+	WebhookEventTransferSent     = "transfer.sent"
+)
+
+// WebhookEventTypes lists every event type an endpoint can subscribe to
+func WebhookEventTypes() []string {
+	return []string{WebhookEventTransferReceived, WebhookEventTransferSent}
+}
+
+// statuses of a webhook delivery
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	// WebhookDeliveryDead is a delivery that used up its retries, only a manual redeliver sends it again
+	WebhookDeliveryDead = "dead"
+)
+
+// TransferWebhookPayload is the payload of transfer.received and transfer.sent, from the point of view of AccountID
+type TransferWebhookPayload struct {
+	Transfer  Transfer `json:"transfer"`
+	Currency  string   `json:"currency"`
+	AccountID int64    `json:"account_id"`
+	Entry     Entry    `json:"entry"`
+}
+
+// queueWebhooks queues deliveries of a transfer to the endpoints of both account owners, in the transfer's transaction
+func queueWebhooks(ctx context.Context, q *Queries, result TransferTxResult) error {
+	sides := []struct {
+		eventType string
+		account   Account
+		entry     Entry
+	}{
+		{WebhookEventTransferSent, result.FromAccount, result.FromEntry},
+		{WebhookEventTransferReceived, result.ToAccount, result.ToEntry},
+	}
+	for _, side := range sides {
+		payload, err := json.Marshal(TransferWebhookPayload{
+			Transfer:  result.Transfer,
+			Currency:  side.account.Currency,
+			AccountID: side.account.ID,
+			Entry:     side.entry,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.CreateWebhookDeliveries(ctx, CreateWebhookDeliveriesParams{
+			Owner:     side.account.Owner,
+			EventType: side.eventType,
+			Payload:   payload,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhookEndpoint(t *testing.T, owner string, eventTypes ...string) WebhookEndpoint {
+	endpoint, err := testQueries.CreateWebhookEndpoint(context.Background(), CreateWebhookEndpointParams{
+		Owner:      owner,
+		Url:        "https://example.com/hooks",
+		Secret:     "whsec_test_secret",
+		EventTypes: eventTypes,
+	})
+	require.NoError(t, err)
+	require.Equal(t, eventTypes, endpoint.EventTypes)
+	return endpoint
+}
+
+func TestTransferTxQueuesWebhooks(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createRandomAccount(t)
+	account2.Currency = account1.Currency
+
+	sent := createRandomWebhookEndpoint(t, account1.Owner, WebhookEventTransferSent)
+	// subscribed to the other event only, nothing is queued for it
+	other := createRandomWebhookEndpoint(t, account1.Owner, WebhookEventTransferReceived)
+	received := createRandomWebhookEndpoint(t, account2.Owner, WebhookEventTransferReceived, WebhookEventTransferSent)
+
+	result, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	deliveries, err := store.ListWebhookDeliveries(ctx, ListWebhookDeliveriesParams{EndpointID: sent.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, WebhookEventTransferSent, deliveries[0].EventType)
+	require.Equal(t, WebhookDeliveryPending, deliveries[0].Status)
+
+	var payload TransferWebhookPayload
+	require.NoError(t, json.Unmarshal(deliveries[0].Payload, &payload))
+	require.Equal(t, result.Transfer.ID, payload.Transfer.ID)
+	require.Equal(t, account1.ID, payload.AccountID)
+	require.Equal(t, result.FromEntry.ID, payload.Entry.ID)
+
+	deliveries, err = store.ListWebhookDeliveries(ctx, ListWebhookDeliveriesParams{EndpointID: other.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+
+	deliveries, err = store.ListWebhookDeliveries(ctx, ListWebhookDeliveriesParams{EndpointID: received.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, WebhookEventTransferReceived, deliveries[0].EventType)
+
+	// deleting the endpoint takes its delivery log with it
+	require.NoError(t, store.DeleteWebhookEndpoint(ctx, received.ID))
+	_, err = store.GetWebhookDelivery(ctx, deliveries[0].ID)
+	require.Error(t, err)
+}
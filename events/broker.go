@@ -0,0 +1,129 @@
+// Package events wakes up the account event streams of this replica when Postgres reports new account_events rows.
+// every replica LISTENs on the same channel, so a transfer committed through any of them reaches all streams
+package events
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Channel is the NOTIFY channel the account_events trigger publishes on, the payload is the account id
+const Channel = "account_events"
+
+// pingInterval is how often an idle listener checks its connection, as lib/pq recommends
+const pingInterval = 90 * time.Second
+
+// Broker fans notifications out to the subscribers of each account.
+// a notification is only a wake up call, subscribers read the events themselves so nothing is lost when one is dropped
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan struct{}]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: map[int64]map[chan struct{}]struct{}{}}
+}
+
+// Subscribe returns a channel that receives a value whenever the account may have new events.
+// call unsubscribe once done, the channel is never closed
+func (b *Broker) Subscribe(accountID int64) (notifications <-chan struct{}, unsubscribe func()) {
+	// one buffered slot: notifications arriving while the subscriber is busy collapse into one
+	ch := make(chan struct{}, 1)
+
+	b.mu.Lock()
+	if b.subscribers[accountID] == nil {
+		b.subscribers[accountID] = map[chan struct{}]struct{}{}
+	}
+	b.subscribers[accountID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers[accountID], ch)
+			if len(b.subscribers[accountID]) == 0 {
+				delete(b.subscribers, accountID)
+			}
+		})
+	}
+}
+
+// Publish wakes up the subscribers of accountID
+func (b *Broker) Publish(accountID int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers[accountID] {
+		notify(ch)
+	}
+}
+
+// PublishAll wakes up every subscriber, used after notifications may have been missed
+func (b *Broker) PublishAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, subscribers := range b.subscribers {
+		for ch := range subscribers {
+			notify(ch)
+		}
+	}
+}
+
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// NewListener creates a lib/pq listener on dataSource that reconnects on its own
+func NewListener(dataSource string) *pq.Listener {
+	return pq.NewListener(dataSource, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Error("event listener connection problem", slog.String("error", err.Error()))
+		}
+	})
+}
+
+// Listen forwards the notifications of listener to the subscribers until ctx is done, then closes listener
+func (b *Broker) Listen(ctx context.Context, listener *pq.Listener) error {
+	defer listener.Close()
+	if err := listener.Listen(Channel); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			b.handle(n)
+		case <-ticker.C:
+			if err := listener.Ping(); err != nil {
+				slog.Warn("event listener ping failed", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// handle routes one notification, nil means the connection was re-established and anything may have been missed
+func (b *Broker) handle(n *pq.Notification) {
+	if n == nil {
+		b.PublishAll()
+		return
+	}
+	accountID, err := strconv.ParseInt(n.Extra, 10, 64)
+	if err != nil {
+		slog.Warn("ignoring malformed account event notification", slog.String("payload", n.Extra))
+		return
+	}
+	b.Publish(accountID)
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrokerHandle(t *testing.T) {
+	broker := NewBroker()
+
+	account1, unsubscribe1 := broker.Subscribe(1)
+	defer unsubscribe1()
+	account2, unsubscribe2 := broker.Subscribe(2)
+	defer unsubscribe2()
+
+	broker.handle(&pq.Notification{Channel: Channel, Extra: "1"})
+	requireNotified(t, account1)
+	requireNotNotified(t, account2)
+
+	// notifications collapse while the subscriber is busy
+	broker.handle(&pq.Notification{Channel: Channel, Extra: "1"})
+	broker.handle(&pq.Notification{Channel: Channel, Extra: "1"})
+	requireNotified(t, account1)
+	requireNotNotified(t, account1)
+
+	broker.handle(&pq.Notification{Channel: Channel, Extra: "not a number"})
+	requireNotNotified(t, account1)
+	requireNotNotified(t, account2)
+
+	// a reconnect may have lost notifications, so everyone checks again
+	broker.handle(nil)
+	requireNotified(t, account1)
+	requireNotified(t, account2)
+}
+
+func TestBrokerUnsubscribe(t *testing.T) {
+	broker := NewBroker()
+
+	notifications, unsubscribe := broker.Subscribe(1)
+	unsubscribe()
+	unsubscribe()
+
+	broker.Publish(1)
+	requireNotNotified(t, notifications)
+	require.Empty(t, broker.subscribers)
+}
+
+func requireNotified(t *testing.T, ch <-chan struct{}) {
+	select {
+	case <-ch:
+	default:
+		t.Fatal("expected a notification")
+	}
+}
+
+func requireNotNotified(t *testing.T, ch <-chan struct{}) {
+	select {
+	case <-ch:
+		t.Fatal("unexpected notification")
+	default:
+	}
+}
@@ -0,0 +1,63 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// codeByAppCode maps domain error codes to gRPC codes, the gRPC counterpart of the HTTP status table in api/errors.go
+var codeByAppCode = map[apperror.Code]codes.Code{
+	apperror.CodeNotFound:          codes.NotFound,
+	apperror.CodeConflict:          codes.AlreadyExists,
+	apperror.CodeInsufficientFunds: codes.FailedPrecondition,
+	apperror.CodeCurrencyMismatch:  codes.InvalidArgument,
+	apperror.CodeLimitExceeded:     codes.ResourceExhausted,
+	apperror.CodeTransferBlocked:   codes.PermissionDenied,
+	apperror.CodeForbidden:         codes.PermissionDenied,
+	apperror.CodeUnauthenticated:   codes.Unauthenticated,
+	apperror.CodeValidation:        codes.InvalidArgument,
+	apperror.CodeTooManyRequests:   codes.ResourceExhausted,
+	apperror.CodeInternal:          codes.Internal,
+}
+
+// toStatus converts err into a gRPC status error, internal causes are logged and never sent to the client
+func toStatus(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	appErr := apperror.As(db.TranslateError(err))
+	code, ok := codeByAppCode[appErr.Code]
+	if !ok {
+		code = codes.Unknown
+	}
+	if code == codes.Internal {
+		slog.ErrorContext(ctx, "rpc failed", slog.String("error", err.Error()))
+	}
+	st := status.New(code, appErr.Message)
+	if fields, ok := appErr.Details.([]*errdetails.BadRequest_FieldViolation); ok {
+		if withDetails, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: fields}); err == nil {
+			st = withDetails
+		}
+	}
+	if appErr.RetryAfter > 0 {
+		if withDetails, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(appErr.RetryAfter)}); err == nil {
+			st = withDetails
+		}
+	}
+	return st.Err()
+}
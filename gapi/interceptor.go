@@ -0,0 +1,210 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apikey"
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/logging"
+	"github.com/ShubhKanodia/GoBank/pb"
+	"github.com/ShubhKanodia/GoBank/ratelimit"
+	"github.com/ShubhKanodia/GoBank/rbac"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+	// authorizationTypeAPIKey carries an API key instead of an access token, as in the HTTP API
+	authorizationTypeAPIKey = "apikey"
+	// requestIDHeader matches the HTTP header so ids can be followed across both APIs
+	requestIDHeader = "x-request-id"
+)
+
+// publicMethods can be called without an access token
+var publicMethods = map[string]bool{
+	pb.GoBank_CreateUser_FullMethodName: true,
+	pb.GoBank_LoginUser_FullMethodName:  true,
+}
+
+// methodPermissions is the permission each authenticated method needs, a method missing here is refused
+var methodPermissions = map[string]rbac.Permission{
+	pb.GoBank_CreateAccount_FullMethodName:  rbac.AccountsCreate,
+	pb.GoBank_GetAccount_FullMethodName:     rbac.AccountsRead,
+	pb.GoBank_ListAccounts_FullMethodName:   rbac.AccountsRead,
+	pb.GoBank_CreateTransfer_FullMethodName: rbac.TransfersCreate,
+}
+
+// methodRateLimitGroups puts methods in a route group of their own, the others are in ratelimit.GroupDefault.
+// the groups are shared with the HTTP API, its routes and these methods take from the same buckets
+var methodRateLimitGroups = map[string]string{
+	pb.GoBank_CreateUser_FullMethodName:     ratelimit.GroupAuth,
+	pb.GoBank_LoginUser_FullMethodName:      ratelimit.GroupAuth,
+	pb.GoBank_CreateTransfer_FullMethodName: ratelimit.GroupTransfers,
+}
+
+type payloadKey struct{}
+
+// authPayload returns the payload stored by authInterceptor, only call it from authenticated rpcs
+func authPayload(ctx context.Context) *token.Payload {
+	return ctx.Value(payloadKey{}).(*token.Payload)
+}
+
+// authInterceptor rejects calls without a valid bearer token or API key in the metadata, or whose role lacks the
+// permission of the method, within the scopes of the key for callers with one, and stores the payload of the
+// caller in the context. the caller and their address go into the context too, for the audit events the call
+// writes
+func authInterceptor(tokenMaker token.Maker, apiKeys *apikey.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		auditor := db.Auditor{IP: peerIP(ctx)}
+		if publicMethods[info.FullMethod] {
+			return handler(db.WithAuditor(ctx, auditor), req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get(authorizationHeaderKey)
+		if len(values) == 0 {
+			return nil, apperror.Unauthenticated("authorization header is not provided")
+		}
+
+		fields := strings.Fields(values[0])
+		if len(fields) != 2 {
+			return nil, apperror.Unauthenticated("invalid authorization header format")
+		}
+
+		var payload *token.Payload
+		var err error
+		switch authorizationType := strings.ToLower(fields[0]); authorizationType {
+		case authorizationTypeBearer:
+			payload, err = tokenMaker.VerifyToken(fields[1])
+			if err != nil {
+				return nil, apperror.Wrap(apperror.CodeUnauthenticated, err, err.Error())
+			}
+		case authorizationTypeAPIKey:
+			payload, err = apiKeys.Authenticate(ctx, fields[1], auditor.IP)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, apperror.Unauthenticated("unsupported authorization type " + authorizationType)
+		}
+		permission, ok := methodPermissions[info.FullMethod]
+		if !ok || !rbac.Permits(payload, permission) {
+			return nil, apperror.Forbidden(fmt.Sprintf("the %s role cannot call %s", payload.Role, info.FullMethod))
+		}
+		auditor.Actor = payload.Username
+		ctx = db.WithAuditor(ctx, auditor)
+		return handler(context.WithValue(ctx, payloadKey{}, payload), req)
+	}
+}
+
+// rateLimitInterceptor takes a token from the caller's bucket of the route group of the method and refuses the
+// call while the bucket is empty. it runs after authInterceptor, so that users are limited by who they are
+// rather than by their IP
+func rateLimitInterceptor(limiter ratelimit.Limiter, limits ratelimit.Limits) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		group, ok := methodRateLimitGroups[info.FullMethod]
+		if !ok {
+			group = ratelimit.GroupDefault
+		}
+		caller, role := "ip:"+peerIP(ctx), ""
+		if payload, ok := ctx.Value(payloadKey{}).(*token.Payload); ok {
+			caller, role = "user:"+payload.Username, payload.Role
+		}
+		limit, ok := limits.For(group, role)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		result, err := limiter.Take(ctx, group+":"+caller, limit)
+		if err != nil {
+			// a broken limiter lets calls through rather than taking the API down with it
+			slog.WarnContext(ctx, "rate limiter failed", slog.String("error", err.Error()))
+			return handler(ctx, req)
+		}
+		// the headers of the HTTP API, as response metadata
+		_ = grpc.SetHeader(ctx, metadata.Pairs(
+			"ratelimit-limit", strconv.Itoa(limit.Burst),
+			"ratelimit-remaining", strconv.Itoa(result.Remaining),
+			"ratelimit-reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))),
+		))
+		if !result.Allowed {
+			return nil, apperror.TooManyRequests("rate limit exceeded, slow down", result.RetryAfter)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// peerIP is the address the call came from, without the port
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// errorInterceptor turns the domain errors returned by handlers into gRPC status errors
+func errorInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return resp, nil
+}
+
+// loggingInterceptor writes one log line per rpc, tagged with the caller's request id or a generated one
+func loggingInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestID := ""
+	if values := md.Get(requestIDHeader); len(values) > 0 {
+		requestID = values[0]
+	}
+	if requestID == "" || len(requestID) > 128 {
+		requestID = uuid.NewString()
+	}
+	ctx = logging.WithRequestID(ctx, requestID)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID))
+
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK:
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.String("method", info.FullMethod),
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	slog.LogAttrs(ctx, level, "grpc request", attrs...)
+	return resp, err
+}
@@ -0,0 +1,83 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/pb"
+	"github.com/ShubhKanodia/GoBank/ratelimit"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient serves a server backed by store over an in-memory connection and returns a client for it
+func newTestClient(t *testing.T, store db.Store) (pb.GoBankClient, token.Maker) {
+	config := util.Config{
+		TokenSymmetricKey:   util.RandomString(32),
+		AccessTokenDuration: time.Minute,
+		// above what the transfer cases move, except the ones needing approval or a second factor
+		TransferApprovalThresholds: "USD:50",
+		TransferStepUpThresholds:   "USD:30",
+		LoginMaxFailures:           5,
+		LoginMaxFailuresPerIP:      50,
+		LoginFailureWindow:         15 * time.Minute,
+		LoginLockoutDuration:       15 * time.Minute,
+		LoginBaseDelay:             time.Second,
+	}
+
+	server, err := NewServer(config, store, ratelimit.NewMemory())
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(server.Shutdown)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewGoBankClient(conn), server.tokenMaker
+}
+
+// newContextWithBearerToken returns a context carrying an access token for username in the outgoing metadata
+func newContextWithBearerToken(t *testing.T, tokenMaker token.Maker, username string, role string, duration time.Duration) context.Context {
+	accessToken, _, err := tokenMaker.CreateToken(username, role, duration)
+	require.NoError(t, err)
+
+	bearerToken := fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken)
+	md := metadata.MD{
+		authorizationHeaderKey: []string{bearerToken},
+	}
+	return metadata.NewOutgoingContext(context.Background(), md)
+}
+
+// newContextWithAPIKey returns a context carrying an API key in the outgoing metadata
+func newContextWithAPIKey(key string) context.Context {
+	md := metadata.MD{
+		authorizationHeaderKey: []string{authorizationTypeAPIKey + " " + key},
+	}
+	return metadata.NewOutgoingContext(context.Background(), md)
+}
+
+func randomAccount(owner string) db.Account {
+	return db.Account{
+		ID:       util.RandomInt(1, 1000),
+		Owner:    owner,
+		Balance:  util.RandomMoney(),
+		Currency: util.RandomCurrency(),
+	}
+}
@@ -0,0 +1,67 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/pb"
+)
+
+func (server *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	var v violations
+	v.check("currency", validateCurrency(req.GetCurrency()))
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	account, err := server.store.CreateAccountTx(ctx, db.CreateAccountParams{
+		Owner:    authPayload(ctx).Username,
+		Currency: req.GetCurrency(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	var v violations
+	v.check("id", validateMin(req.GetId(), 1))
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if account.Owner != authPayload(ctx).Username {
+		return nil, apperror.Forbidden("account doesn't belong to the authenticated user")
+	}
+	return &pb.GetAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	var v violations
+	v.check("page_id", validateMin(int64(req.GetPageId()), 1))
+	v.check("page_size", validateRange(int64(req.GetPageSize()), 5, 10))
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	accounts, err := server.store.ListAccounts(ctx, db.ListAccountsParams{
+		Owner:  authPayload(ctx).Username,
+		Limit:  req.GetPageSize(),
+		Offset: (req.GetPageId() - 1) * req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.ListAccountsResponse{Accounts: make([]*pb.Account, 0, len(accounts))}
+	for _, account := range accounts {
+		rsp.Accounts = append(rsp.Accounts, convertAccount(account))
+	}
+	return rsp, nil
+}
@@ -0,0 +1,249 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apikey"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/pb"
+	"github.com/ShubhKanodia/GoBank/rbac"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetAccountRPC(t *testing.T) {
+	account := randomAccount(util.RandomOwner())
+	key, err := apikey.Generate()
+	require.NoError(t, err)
+	// keyCaller is the key of the account owner as the store finds it
+	keyCaller := func(scopes ...rbac.Scope) db.GetAPIKeyCallerRow {
+		caller := db.GetAPIKeyCallerRow{
+			ID:         util.RandomInt(1, 1000),
+			Username:   account.Owner,
+			Prefix:     key.Prefix,
+			SecretHash: key.SecretHash,
+			AllowedIps: []string{},
+			Role:       db.UserRoleCustomer,
+		}
+		for _, scope := range scopes {
+			caller.Scopes = append(caller.Scopes, string(scope))
+		}
+		return caller
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.GetAccountRequest
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.GetAccountResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, account.Owner, db.UserRoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, rsp.GetAccount().GetId())
+				require.Equal(t, account.Owner, rsp.GetAccount().GetOwner())
+				require.Equal(t, account.Balance, rsp.GetAccount().GetBalance())
+				require.Equal(t, account.Currency, rsp.GetAccount().GetCurrency())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "ExpiredToken",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, account.Owner, db.UserRoleCustomer, -time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, "unauthorized", db.UserRoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "UnknownRole",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, account.Owner, "auditor", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "APIKey",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithAPIKey(key.Key)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyCaller(gomock.Any(), gomock.Eq(key.Prefix)).Times(1).Return(keyCaller(rbac.ScopeAccountsRead), nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, rsp.GetAccount().GetId())
+			},
+		},
+		{
+			name: "APIKeyMissingScope",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithAPIKey(key.Key)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyCaller(gomock.Any(), gomock.Eq(key.Prefix)).Times(1).Return(keyCaller(rbac.ScopeTransfersWrite), nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "NotFound",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, account.Owner, db.UserRoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, account.Owner, db.UserRoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+				// the cause is logged, never sent to the client
+				require.NotContains(t, status.Convert(err).Message(), sql.ErrConnDone.Error())
+			},
+		},
+		{
+			name: "InvalidID",
+			req:  &pb.GetAccountRequest{Id: 0},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, account.Owner, db.UserRoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+				requireFieldViolations(t, err, "id")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			client, tokenMaker := newTestClient(t, store)
+			rsp, err := client.GetAccount(tc.buildContext(t, tokenMaker), tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestListAccountsRPC(t *testing.T) {
+	owner := util.RandomOwner()
+	accounts := []db.Account{randomAccount(owner), randomAccount(owner)}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	arg := db.ListAccountsParams{
+		Owner:  owner,
+		Limit:  5,
+		Offset: 5,
+	}
+	store.EXPECT().ListAccounts(gomock.Any(), gomock.Eq(arg)).Times(1).Return(accounts, nil)
+
+	client, tokenMaker := newTestClient(t, store)
+	ctx := newContextWithBearerToken(t, tokenMaker, owner, db.UserRoleCustomer, time.Minute)
+	rsp, err := client.ListAccounts(ctx, &pb.ListAccountsRequest{PageId: 2, PageSize: 5})
+	require.NoError(t, err)
+	require.Len(t, rsp.GetAccounts(), len(accounts))
+	for i, account := range rsp.GetAccounts() {
+		require.Equal(t, accounts[i].ID, account.GetId())
+	}
+}
+
+// requireFieldViolations checks err carries BadRequest details naming exactly fields
+func requireFieldViolations(t *testing.T, err error, fields ...string) {
+	st := status.Convert(err)
+	var got []string
+	for _, detail := range st.Details() {
+		badRequest, ok := detail.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, violation := range badRequest.GetFieldViolations() {
+			got = append(got, violation.GetField())
+		}
+	}
+	require.ElementsMatch(t, fields, got)
+}
@@ -0,0 +1,87 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/metrics"
+	"github.com/ShubhKanodia/GoBank/pb"
+)
+
+// CreateTransfer runs the same checks as the HTTP handler before moving the money. transfers that need
+// approval or a second factor are refused instead of held
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (rsp *pb.CreateTransferResponse, err error) {
+	defer func() {
+		if err != nil {
+			metrics.TransfersFailed.WithLabelValues(string(apperror.CodeOf(db.TranslateError(err)))).Inc()
+		}
+	}()
+
+	var v violations
+	v.check("from_account_id", validateMin(req.GetFromAccountId(), 1))
+	v.check("to_account_id", validateMin(req.GetToAccountId(), 1))
+	v.check("amount", validateMin(req.GetAmount(), 1))
+	v.check("currency", validateCurrency(req.GetCurrency()))
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+	if fromAccount.Owner != authPayload(ctx).Username {
+		return nil, apperror.Forbidden("from account doesn't belong to the authenticated user")
+	}
+	if _, err := server.validAccount(ctx, req.GetToAccountId(), req.GetCurrency()); err != nil {
+		return nil, err
+	}
+	if fromAccount.Balance < req.GetAmount() {
+		return nil, apperror.InsufficientFunds(fmt.Sprintf("account [%d] has insufficient funds", req.GetFromAccountId()))
+	}
+	// the response has no room for a held transfer, those go through the HTTP API
+	if threshold, ok := server.approvalThresholds[req.GetCurrency()]; ok && req.GetAmount() > threshold {
+		return nil, apperror.Forbidden(fmt.Sprintf("transfers above %d %s need approval, request them through the HTTP API", threshold, req.GetCurrency()))
+	}
+	// the request has no room for a second factor either
+	if threshold, ok := server.stepUpThresholds[req.GetCurrency()]; ok && req.GetAmount() > threshold {
+		return nil, apperror.Forbidden(fmt.Sprintf("transfers above %d %s need a two-factor code, make them through the HTTP API", threshold, req.GetCurrency()))
+	}
+
+	result, err := server.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.TransfersCreated.WithLabelValues(req.GetCurrency()).Inc()
+	metrics.TransferAmount.WithLabelValues(req.GetCurrency()).Add(float64(req.GetAmount()))
+
+	return &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry),
+		ToEntry:     convertEntry(result.ToEntry),
+	}, nil
+}
+
+// validAccount checks the account exists and holds the transfer currency
+func (server *Server) validAccount(ctx context.Context, accountID int64, currency string) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(db.TranslateError(err), apperror.ErrNotFound) {
+			return account, apperror.Wrap(apperror.CodeNotFound, err, fmt.Sprintf("account [%d] not found", accountID))
+		}
+		return account, err
+	}
+	if account.Currency != currency {
+		return account, apperror.CurrencyMismatch(fmt.Sprintf("account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency))
+	}
+	return account, nil
+}
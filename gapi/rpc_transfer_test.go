@@ -0,0 +1,166 @@
+package gapi
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/pb"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateTransferRPC(t *testing.T) {
+	amount := int64(10)
+
+	account1 := randomAccount(util.RandomOwner())
+	account2 := randomAccount(util.RandomOwner())
+	account3 := randomAccount(util.RandomOwner())
+	account1.Currency = util.USD
+	account1.Balance = 100
+	account2.Currency = util.USD
+	account3.Currency = util.EUR
+
+	testCases := []struct {
+		name          string
+		req           *pb.CreateTransferRequest
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name:     "OK",
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: amount, Currency: util.USD},
+			username: account1.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+				}
+				result := db.TransferTxResult{
+					Transfer: db.Transfer{ID: 1, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: amount},
+				}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, amount, rsp.GetTransfer().GetAmount())
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: amount, Currency: util.USD},
+			username: account2.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name:     "CurrencyMismatch",
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account3.ID, Amount: amount, Currency: util.USD},
+			username: account1.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name:     "InsufficientFunds",
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: account1.Balance + 1, Currency: util.USD},
+			username: account1.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name:     "NeedsApproval",
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 60, Currency: util.USD},
+			username: account1.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name:     "NeedsSecondFactor",
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 40, Currency: util.USD},
+			username: account1.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name:     "InvalidArguments",
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: -1, Currency: "XYZ"},
+			username: account1.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+				requireFieldViolations(t, err, "amount", "currency")
+			},
+		},
+		{
+			name:     "TransferTxError",
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: amount, Currency: util.USD},
+			username: account1.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, sql.ErrTxDone)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+				require.NotContains(t, status.Convert(err).Message(), sql.ErrTxDone.Error())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			client, tokenMaker := newTestClient(t, store)
+			ctx := newContextWithBearerToken(t, tokenMaker, tc.username, db.UserRoleCustomer, time.Minute)
+			rsp, err := client.CreateTransfer(ctx, tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
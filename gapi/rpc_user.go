@@ -0,0 +1,78 @@
+package gapi
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/pb"
+	"github.com/ShubhKanodia/GoBank/util"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	var v violations
+	v.check("username", validateUsername(req.GetUsername()))
+	v.check("password", validatePassword(req.GetPassword()))
+	v.check("full_name", validateRequired(req.GetFullName()))
+	v.check("email", validateEmail(req.GetEmail()))
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := util.HashPassword(req.GetPassword())
+	if err != nil {
+		return nil, err
+	}
+
+	// a strong match against the sanctions list refuses the user, a weak one puts it in review
+	user, err := server.screener.Onboard(ctx, server.store, db.CreateUserParams{
+		Username:       req.GetUsername(),
+		HashedPassword: hashedPassword,
+		FullName:       req.GetFullName(),
+		Email:          req.GetEmail(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the user exists either way, they can ask for the verification email again
+	if err := server.tokenSender.SendVerification(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "queueing the verification email failed",
+			slog.String("username", user.Username), slog.String("error", err.Error()))
+	}
+	return &pb.CreateUserResponse{User: convertUser(user)}, nil
+}
+
+func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	var v violations
+	v.check("username", validateUsername(req.GetUsername()))
+	v.check("password", validatePassword(req.GetPassword()))
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	// the same answers for a wrong password and a username nobody has, so usernames cannot be enumerated
+	user, err := server.loginGuard.Authenticate(ctx, req.GetUsername(), req.GetPassword(), peerIP(ctx))
+	if err != nil {
+		return nil, err
+	}
+	// the response has no room for the second step, those users log in through the HTTP API
+	if user.TotpEnabledAt.Valid {
+		return nil, apperror.Forbidden("two-factor authentication is enabled, log in through the HTTP API")
+	}
+
+	if err := server.loginGuard.Succeed(ctx, user.Username); err != nil {
+		return nil, err
+	}
+	accessToken, payload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.LoginUserResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: timestamppb.New(payload.ExpiredAt),
+		User:                 convertUser(user),
+	}, nil
+}
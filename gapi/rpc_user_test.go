@@ -0,0 +1,232 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/pb"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateUserRPC(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		req           *pb.CreateUserRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.CreateUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: password,
+				FullName: user.FullName,
+				Email:    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NoError(t, util.CheckPassword(password, arg.HashedPassword))
+						return user, nil
+					})
+				// and the verification email is queued for the job worker
+				store.EXPECT().CreateEmailToken(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CreateJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, rsp.GetUser().GetUsername())
+				require.Equal(t, user.Email, rsp.GetUser().GetEmail())
+			},
+		},
+		{
+			name: "DuplicateUsername",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: password,
+				FullName: user.FullName,
+				Email:    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.User{}, &pq.Error{Code: "23505", Constraint: "users_pkey"})
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateUserResponse, err error) {
+				require.Equal(t, codes.AlreadyExists, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidArguments",
+			req: &pb.CreateUserRequest{
+				Username: "invalid-user#1",
+				Password: "123",
+				FullName: user.FullName,
+				Email:    "invalid-email",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+				requireFieldViolations(t, err, "username", "password", "email")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			client, _ := newTestClient(t, store)
+			rsp, err := client.CreateUser(context.Background(), tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestLoginUserRPC(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		req           *pb.LoginUserRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.LoginUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().DeleteLoginFailures(gomock.Any(), gomock.Eq(db.DeleteLoginFailuresParams{
+					Scope:   db.LoginScopeUsername,
+					Subject: user.Username,
+				})).Times(1)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.GetAccessToken())
+				require.Equal(t, user.Username, rsp.GetUser().GetUsername())
+			},
+		},
+		{
+			name: "TwoFactorEnabled",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				enrolled := user
+				enrolled.TotpSecret = sql.NullString{String: "JBSWY3DPEHPK3PXP", Valid: true}
+				enrolled.TotpEnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(enrolled, nil)
+				store.EXPECT().DeleteLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.LoginUserResponse, err error) {
+				// no token without the second step, which only the HTTP API has
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+				require.Empty(t, rsp.GetAccessToken())
+			},
+		},
+		{
+			name: "UserNotFound",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().RecordLoginFailureTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+				require.Equal(t, "invalid username or password", status.Convert(err).Message())
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: "incorrect"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().RecordLoginFailureTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RecordLoginFailureTxParams) (db.RecordLoginFailureTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.EqualValues(t, 5, arg.MaxFailures)
+						return db.RecordLoginFailureTxResult{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, rsp *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+				require.Equal(t, "invalid username or password", status.Convert(err).Message())
+			},
+		},
+		{
+			name: "LockedOut",
+			req:  &pb.LoginUserRequest{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).Times(1).Return([]db.LoginFailure{{
+					Scope:        db.LoginScopeIP,
+					Subject:      "bufconn",
+					Failures:     50,
+					LastFailedAt: time.Now(),
+					LockedUntil:  sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+				}}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.ResourceExhausted, status.Code(err))
+				// how long to wait travels as a RetryInfo detail
+				details := status.Convert(err).Details()
+				require.Len(t, details, 1)
+				retryInfo, ok := details[0].(*errdetails.RetryInfo)
+				require.True(t, ok)
+				require.InDelta(t, time.Minute.Seconds(), retryInfo.GetRetryDelay().AsDuration().Seconds(), 1)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			client, _ := newTestClient(t, store)
+			rsp, err := client.LoginUser(context.Background(), tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func randomUser(t *testing.T) (user db.User, password string) {
+	password = util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user = db.User{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+	return
+}
@@ -0,0 +1,117 @@
+// Package gapi serves the GoBank gRPC API, backed by the same store and token maker as the HTTP API.
+package gapi
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/ShubhKanodia/GoBank/apikey"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/loginguard"
+	"github.com/ShubhKanodia/GoBank/mail"
+	"github.com/ShubhKanodia/GoBank/pb"
+	"github.com/ShubhKanodia/GoBank/ratelimit"
+	"github.com/ShubhKanodia/GoBank/rbac"
+	"github.com/ShubhKanodia/GoBank/sanctions"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/ShubhKanodia/GoBank/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server serves gRPC requests for our banking service.
+type Server struct {
+	pb.UnimplementedGoBankServer
+	config     util.Config
+	store      db.Store
+	tokenMaker token.Maker
+	grpcServer *grpc.Server
+	// approvalThresholds maps a currency to the largest transfer that runs without a second person's approval
+	approvalThresholds map[string]int64
+	// stepUpThresholds maps a currency to the largest transfer that runs without a second factor
+	stepUpThresholds map[string]int64
+	// screener screens the names of new users against the sanctions list
+	screener *sanctions.Screener
+	// tokenSender mails the links that verify an email
+	tokenSender *mail.TokenSender
+	// loginGuard checks passwords and locks out usernames and IPs that keep getting them wrong
+	loginGuard *loginguard.Guard
+	// apiKeys authenticates the callers with an API key instead of an access token
+	apiKeys *apikey.Authenticator
+}
+
+// NewServer creates a new gRPC server with the GoBank service registered. limiter keeps the token buckets of
+// the callers, share it with the HTTP API.
+func NewServer(config util.Config, store db.Store, limiter ratelimit.Limiter) (*Server, error) {
+	tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	approvalThresholds, err := util.ParseCurrencyAmounts(config.TransferApprovalThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRANSFER_APPROVAL_THRESHOLDS: %w", err)
+	}
+
+	stepUpThresholds, err := util.ParseCurrencyAmounts(config.TransferStepUpThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRANSFER_STEP_UP_THRESHOLDS: %w", err)
+	}
+
+	rateLimits, err := ratelimit.ParseLimits(config.RateLimits, rbac.Roles())
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMITS: %w", err)
+	}
+
+	sanctionsList, err := sanctions.LoadFile(config.SanctionsListPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load sanctions list: %w", err)
+	}
+
+	server := &Server{
+		config:             config,
+		store:              store,
+		tokenMaker:         tokenMaker,
+		approvalThresholds: approvalThresholds,
+		stepUpThresholds:   stepUpThresholds,
+		screener:           sanctions.NewScreener(sanctionsList, config.SanctionsBlockScore, config.SanctionsReviewScore),
+		tokenSender:        mail.NewTokenSender(store, config),
+		loginGuard:         loginguard.NewGuard(store, config),
+		apiKeys:            apikey.NewAuthenticator(store),
+	}
+
+	// logging runs first so it sees the status produced by the error interceptor
+	server.grpcServer = grpc.NewServer(grpc.ChainUnaryInterceptor(
+		loggingInterceptor,
+		errorInterceptor,
+		authInterceptor(tokenMaker, server.apiKeys),
+		rateLimitInterceptor(limiter, rateLimits),
+	))
+	pb.RegisterGoBankServer(server.grpcServer, server)
+	// lets clients like grpcurl discover the service
+	reflection.Register(server.grpcServer)
+
+	return server, nil
+}
+
+// Start listens on address and serves until Shutdown is called.
+func (server *Server) Start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("cannot create listener: %w", err)
+	}
+	return server.Serve(listener)
+}
+
+// Serve serves gRPC requests on listener, it returns nil once the server is stopped.
+func (server *Server) Serve(listener net.Listener) error {
+	if err := server.grpcServer.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting new rpcs and waits for the in-flight ones to finish.
+func (server *Server) Shutdown() {
+	server.grpcServer.GracefulStop()
+}
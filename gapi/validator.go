@@ -0,0 +1,87 @@
+package gapi
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	"github.com/ShubhKanodia/GoBank/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// the rules mirror the binding tags of the HTTP request structs
+
+var isAlphanum = regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString
+
+func validateUsername(value string) error {
+	if value == "" {
+		return fmt.Errorf("is required")
+	}
+	if !isAlphanum(value) {
+		return fmt.Errorf("must contain only letters and digits")
+	}
+	return nil
+}
+
+func validatePassword(value string) error {
+	if len(value) < 6 {
+		return fmt.Errorf("must be at least 6 characters")
+	}
+	return nil
+}
+
+func validateEmail(value string) error {
+	if _, err := mail.ParseAddress(value); err != nil {
+		return fmt.Errorf("is not a valid email address")
+	}
+	return nil
+}
+
+func validateRequired(value string) error {
+	if value == "" {
+		return fmt.Errorf("is required")
+	}
+	return nil
+}
+
+func validateCurrency(value string) error {
+	if !util.IsSupportedCurrency(value) {
+		return fmt.Errorf("must be one of %v", util.SupportedCurrencies())
+	}
+	return nil
+}
+
+func validateMin(value int64, min int64) error {
+	if value < min {
+		return fmt.Errorf("must be at least %d", min)
+	}
+	return nil
+}
+
+func validateRange(value int64, min int64, max int64) error {
+	if value < min || value > max {
+		return fmt.Errorf("must be between %d and %d", min, max)
+	}
+	return nil
+}
+
+// violations collects the failed checks of a request, keyed by field name
+type violations []*errdetails.BadRequest_FieldViolation
+
+func (v *violations) check(field string, err error) {
+	if err != nil {
+		*v = append(*v, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: err.Error(),
+		})
+	}
+}
+
+// err returns nil when every check passed, otherwise a validation error that toStatus turns into BadRequest details
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return apperror.Validation("invalid request").WithDetails([]*errdetails.BadRequest_FieldViolation(v))
+}
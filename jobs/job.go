@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+)
+
+// defaultMaxAttempts is how often a job runs before it is given up as dead, unless enqueued WithMaxAttempts
+const defaultMaxAttempts = 10
+
+// ErrDuplicate is returned by Enqueue when a pending job of the same kind already holds the unique key
+var ErrDuplicate = errors.New("a pending job with this unique key already exists")
+
+// Creator inserts job rows. both db.Store and the *db.Queries of a transaction satisfy it, the latter
+// makes the job part of the transaction that needs it
+type Creator interface {
+	CreateJob(ctx context.Context, arg db.CreateJobParams) (db.Job, error)
+}
+
+// Kind names a job type and fixes the type of its payload, so the code enqueueing a job
+// and the handler running it cannot disagree on what the payload holds
+type Kind[T any] struct {
+	Name string
+}
+
+// NewKind returns the job type called name, names are stored in the jobs table and must stay stable
+func NewKind[T any](name string) Kind[T] {
+	return Kind[T]{Name: name}
+}
+
+// Enqueue adds a job of this kind carrying payload, to run as soon as a worker is free
+// unless options say otherwise
+func (k Kind[T]) Enqueue(ctx context.Context, creator Creator, payload T, options ...Option) (db.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return db.Job{}, fmt.Errorf("encode %s payload: %w", k.Name, err)
+	}
+
+	arg := db.CreateJobParams{
+		Kind:        k.Name,
+		Payload:     data,
+		MaxAttempts: defaultMaxAttempts,
+		RunAt:       time.Now(),
+	}
+	for _, option := range options {
+		option(&arg)
+	}
+
+	job, err := creator.CreateJob(ctx, arg)
+	// ON CONFLICT DO NOTHING returns no row for a duplicate key
+	if errors.Is(db.TranslateError(err), apperror.ErrNotFound) {
+		return db.Job{}, ErrDuplicate
+	}
+	return job, err
+}
+
+// Handle wraps fn as the handler of this kind, for Worker.Register
+func (k Kind[T]) Handle(fn func(ctx context.Context, payload T) error) Handler {
+	return Handler{
+		kind: k.Name,
+		run: func(ctx context.Context, data json.RawMessage) error {
+			var payload T
+			if err := json.Unmarshal(data, &payload); err != nil {
+				return fmt.Errorf("decode %s payload: %w", k.Name, err)
+			}
+			return fn(ctx, payload)
+		},
+	}
+}
+
+// Handler runs the jobs of one kind, build it with Kind.Handle
+type Handler struct {
+	kind string
+	run  func(ctx context.Context, payload json.RawMessage) error
+}
+
+// Option adjusts a job being enqueued
+type Option func(arg *db.CreateJobParams)
+
+// WithUniqueKey makes the job unique among the pending jobs of its kind, enqueueing another
+// one with the same key returns ErrDuplicate until this one succeeded or died
+func WithUniqueKey(key string) Option {
+	return func(arg *db.CreateJobParams) {
+		arg.UniqueKey = sql.NullString{String: key, Valid: true}
+	}
+}
+
+// WithRunAt delays the first attempt until runAt
+func WithRunAt(runAt time.Time) Option {
+	return func(arg *db.CreateJobParams) {
+		arg.RunAt = runAt
+	}
+}
+
+// WithMaxAttempts sets how often the job runs before it is given up as dead
+func WithMaxAttempts(n int32) Option {
+	return func(arg *db.CreateJobParams) {
+		arg.MaxAttempts = n
+	}
+}
@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/metrics"
+	"github.com/ShubhKanodia/GoBank/util"
+)
+
+const (
+	// leaseMargin keeps a job leased a little past its visibility timeout, so recording the outcome of
+	// a job that used all of its time does not race another worker claiming it again
+	leaseMargin = 30 * time.Second
+	// minBackoff and maxBackoff bound the delay before retrying a failed job
+	minBackoff = time.Second
+	maxBackoff = time.Hour
+)
+
+// Worker runs the jobs of the kinds registered with it on a pool of goroutines. several workers, in one
+// or many replicas, can run against the same table, each claims its own jobs
+type Worker struct {
+	store        db.Store
+	handlers     map[string]Handler
+	concurrency  int
+	pollInterval time.Duration
+	// visibilityTimeout is how long a job may run, and so how long it stays hidden from other workers
+	visibilityTimeout time.Duration
+	now               func() time.Time
+}
+
+func NewWorker(store db.Store, concurrency int, pollInterval time.Duration, visibilityTimeout time.Duration) *Worker {
+	return &Worker{
+		store:             store,
+		handlers:          map[string]Handler{},
+		concurrency:       concurrency,
+		pollInterval:      pollInterval,
+		visibilityTimeout: visibilityTimeout,
+		now:               time.Now,
+	}
+}
+
+// Register adds handlers, the worker only claims jobs of registered kinds. it panics when a kind is
+// registered twice, that is a wiring mistake and not something to find out in production
+func (w *Worker) Register(handlers ...Handler) {
+	for _, handler := range handlers {
+		if _, ok := w.handlers[handler.kind]; ok {
+			panic("jobs: handler for " + handler.kind + " registered twice")
+		}
+		w.handlers[handler.kind] = handler
+	}
+}
+
+// Run claims and runs jobs until ctx is done. it claims only as many jobs as it has idle goroutines,
+// so a claimed job starts straight away and its lease is not spent waiting. jobs that are running when
+// ctx is done finish (each bounded by the visibility timeout) before Run returns
+func (w *Worker) Run(ctx context.Context) {
+	if len(w.handlers) == 0 {
+		slog.InfoContext(ctx, "no job handlers registered, job worker not started")
+		return
+	}
+
+	slots := make(chan struct{}, w.concurrency)
+	var running sync.WaitGroup
+	defer running.Wait()
+	// a job is not cut off by the shutdown signal, it would only run again from the start
+	jobCtx := context.WithoutCancel(ctx)
+
+	for {
+		// only this loop fills slots, so idle can only grow while it is being used
+		idle := w.concurrency - len(slots)
+		claimed := 0
+		if idle > 0 {
+			jobs, err := w.claim(ctx, int32(idle))
+			if err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "claiming jobs failed", slog.String("error", err.Error()))
+			}
+			for _, job := range jobs {
+				slots <- struct{}{}
+				running.Add(1)
+				go func(job db.Job) {
+					defer func() {
+						<-slots
+						running.Done()
+					}()
+					if err := w.process(jobCtx, job); err != nil {
+						slog.ErrorContext(jobCtx, "recording job outcome failed",
+							slog.Int64("job_id", job.ID),
+							slog.String("error", err.Error()),
+						)
+					}
+				}(job)
+			}
+			claimed = len(jobs)
+		}
+		if idle > 0 && claimed == idle {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
+
+// claim leases up to n due jobs of the registered kinds
+func (w *Worker) claim(ctx context.Context, n int32) ([]db.Job, error) {
+	kinds := make([]string, 0, len(w.handlers))
+	for kind := range w.handlers {
+		kinds = append(kinds, kind)
+	}
+	return w.store.ClaimJobs(ctx, db.ClaimJobsParams{
+		LeaseUntil: w.now().Add(w.visibilityTimeout + leaseMargin),
+		Kinds:      kinds,
+		BatchSize:  n,
+	})
+}
+
+// process runs one claimed job and records the outcome, only errors recording it are returned.
+// a failed job is retried with backoff until it used max_attempts, then it is dead. the outcome is only recorded
+// while the job is still held by this claim, errLeaseLost is returned otherwise
+func (w *Worker) process(ctx context.Context, job db.Job) error {
+	runErr := w.run(ctx, job)
+	if runErr == nil {
+		metrics.Jobs.WithLabelValues(job.Kind, db.JobSucceeded).Inc()
+		return recorded(w.store.MarkJobSucceeded(ctx, db.MarkJobSucceededParams{ID: job.ID, Attempts: job.Attempts}))
+	}
+
+	// attempts already counts this run, it is incremented by the claim
+	arg := db.MarkJobFailedParams{
+		ID:        job.ID,
+		Attempts:  job.Attempts,
+		Status:    db.JobPending,
+		RunAt:     w.now().Add(util.Backoff(job.Attempts, minBackoff, maxBackoff)),
+		LastError: sql.NullString{String: runErr.Error(), Valid: true},
+	}
+	attrs := []any{
+		slog.Int64("job_id", job.ID),
+		slog.String("kind", job.Kind),
+		slog.Int("attempt", int(job.Attempts)),
+		slog.String("error", runErr.Error()),
+	}
+	if job.Attempts >= job.MaxAttempts {
+		arg.Status = db.JobDead
+		arg.RunAt = w.now()
+		metrics.Jobs.WithLabelValues(job.Kind, db.JobDead).Inc()
+		slog.ErrorContext(ctx, "job failed for the last time", attrs...)
+	} else {
+		metrics.Jobs.WithLabelValues(job.Kind, "retrying").Inc()
+		slog.WarnContext(ctx, "job failed", append(attrs, slog.Time("retry_at", arg.RunAt))...)
+	}
+	return recorded(w.store.MarkJobFailed(ctx, arg))
+}
+
+// errLeaseLost is returned for the outcome of a job whose lease ran out and which another worker claimed again,
+// the outcome of that claim is the one that counts
+var errLeaseLost = errors.New("job lease ran out and the job was claimed again, outcome not recorded")
+
+// recorded turns an outcome update that matched no row into errLeaseLost
+func recorded(rows int64, err error) error {
+	if err == nil && rows == 0 {
+		return errLeaseLost
+	}
+	return err
+}
+
+// run calls the handler of job within the visibility timeout, a panic counts as a failed attempt
+func (w *Worker) run(ctx context.Context, job db.Job) (err error) {
+	handler, ok := w.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler registered for %s", job.Kind)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, w.visibilityTimeout)
+	defer cancel()
+	return handler.run(ctx, job.Payload)
+}
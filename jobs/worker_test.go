@@ -0,0 +1,252 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type greeting struct {
+	Name string `json:"name"`
+}
+
+var greet = NewKind[greeting]("test.greet")
+
+func TestEnqueue(t *testing.T) {
+	runAt := time.Now().Add(time.Hour)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateJob(gomock.Any(), gomock.Eq(db.CreateJobParams{
+		Kind:        greet.Name,
+		Payload:     json.RawMessage(`{"name":"alice"}`),
+		UniqueKey:   sql.NullString{String: "alice", Valid: true},
+		MaxAttempts: 3,
+		RunAt:       runAt,
+	})).Times(1).Return(db.Job{ID: 1}, nil)
+
+	job, err := greet.Enqueue(context.Background(), store, greeting{Name: "alice"},
+		WithUniqueKey("alice"), WithMaxAttempts(3), WithRunAt(runAt))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), job.ID)
+}
+
+func TestEnqueueDuplicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateJob(gomock.Any(), gomock.Any()).Times(1).Return(db.Job{}, sql.ErrNoRows)
+
+	_, err := greet.Enqueue(context.Background(), store, greeting{Name: "alice"}, WithUniqueKey("alice"))
+	require.ErrorIs(t, err, ErrDuplicate)
+}
+
+func TestProcess(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		attempts   int32
+		handle     func(ctx context.Context, payload greeting) error
+		buildStubs func(store *mockdb.MockStore, job db.Job)
+		err        error
+	}{
+		{
+			name:     "Succeeded",
+			attempts: 1,
+			handle: func(ctx context.Context, payload greeting) error {
+				if payload.Name != "alice" {
+					return errors.New("wrong payload")
+				}
+				return nil
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().MarkJobSucceeded(gomock.Any(), gomock.Eq(db.MarkJobSucceededParams{ID: job.ID, Attempts: job.Attempts})).Times(1).Return(int64(1), nil)
+			},
+		},
+		{
+			// the lease ran out and another worker claimed the job again, its outcome is not overwritten
+			name:     "LeaseLost",
+			attempts: 1,
+			handle: func(ctx context.Context, payload greeting) error {
+				return nil
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().MarkJobSucceeded(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+			},
+			err: errLeaseLost,
+		},
+		{
+			name:     "Retrying",
+			attempts: 2,
+			handle: func(ctx context.Context, payload greeting) error {
+				return errors.New("mail server down")
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().MarkJobFailed(gomock.Any(), gomock.Eq(db.MarkJobFailedParams{
+					ID:        job.ID,
+					Attempts:  job.Attempts,
+					Status:    db.JobPending,
+					RunAt:     now.Add(util.Backoff(2, minBackoff, maxBackoff)),
+					LastError: sql.NullString{String: "mail server down", Valid: true},
+				})).Times(1).Return(int64(1), nil)
+			},
+		},
+		{
+			name:     "Dead",
+			attempts: 3,
+			handle: func(ctx context.Context, payload greeting) error {
+				return errors.New("mail server down")
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().MarkJobFailed(gomock.Any(), gomock.Eq(db.MarkJobFailedParams{
+					ID:        job.ID,
+					Attempts:  job.Attempts,
+					Status:    db.JobDead,
+					RunAt:     now,
+					LastError: sql.NullString{String: "mail server down", Valid: true},
+				})).Times(1).Return(int64(1), nil)
+			},
+		},
+		{
+			name:     "Panicked",
+			attempts: 1,
+			handle: func(ctx context.Context, payload greeting) error {
+				panic("boom")
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().MarkJobFailed(gomock.Any(), gomock.Eq(db.MarkJobFailedParams{
+					ID:        job.ID,
+					Attempts:  job.Attempts,
+					Status:    db.JobPending,
+					RunAt:     now.Add(minBackoff),
+					LastError: sql.NullString{String: "handler panicked: boom", Valid: true},
+				})).Times(1).Return(int64(1), nil)
+			},
+		},
+		{
+			name:     "TimedOut",
+			attempts: 1,
+			handle: func(ctx context.Context, payload greeting) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			buildStubs: func(store *mockdb.MockStore, job db.Job) {
+				store.EXPECT().MarkJobFailed(gomock.Any(), gomock.Eq(db.MarkJobFailedParams{
+					ID:        job.ID,
+					Attempts:  job.Attempts,
+					Status:    db.JobPending,
+					RunAt:     now.Add(minBackoff),
+					LastError: sql.NullString{String: context.DeadlineExceeded.Error(), Valid: true},
+				})).Times(1).Return(int64(1), nil)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			job := db.Job{
+				ID:          7,
+				Kind:        greet.Name,
+				Payload:     json.RawMessage(`{"name":"alice"}`),
+				Status:      db.JobPending,
+				Attempts:    tc.attempts,
+				MaxAttempts: 3,
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store, job)
+
+			worker := NewWorker(store, 1, time.Second, 10*time.Millisecond)
+			worker.now = func() time.Time { return now }
+			worker.Register(greet.Handle(tc.handle))
+
+			err := worker.process(context.Background(), job)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestClaim(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ClaimJobs(gomock.Any(), gomock.Eq(db.ClaimJobsParams{
+		LeaseUntil: now.Add(time.Minute + leaseMargin),
+		Kinds:      []string{greet.Name},
+		BatchSize:  4,
+	})).Times(1).Return([]db.Job{}, nil)
+
+	worker := NewWorker(store, 4, time.Second, time.Minute)
+	worker.now = func() time.Time { return now }
+	worker.Register(greet.Handle(func(ctx context.Context, payload greeting) error { return nil }))
+
+	jobs, err := worker.claim(context.Background(), 4)
+	require.NoError(t, err)
+	require.Empty(t, jobs)
+}
+
+func TestRegisterTwice(t *testing.T) {
+	worker := NewWorker(nil, 1, time.Second, time.Minute)
+	handler := greet.Handle(func(ctx context.Context, payload greeting) error { return nil })
+	worker.Register(handler)
+	require.Panics(t, func() { worker.Register(handler) })
+}
+
+func TestRunStopsAfterRunningJobs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	job := db.Job{ID: 1, Kind: greet.Name, Payload: json.RawMessage(`{}`), Attempts: 1, MaxAttempts: 3}
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ClaimJobs(gomock.Any(), gomock.Any()).Times(1).Return([]db.Job{job}, nil)
+	store.EXPECT().MarkJobSucceeded(gomock.Any(), gomock.Eq(db.MarkJobSucceededParams{ID: job.ID, Attempts: job.Attempts})).Times(1).Return(int64(1), nil)
+
+	// one goroutine, so after the first claim the only one is busy and Run waits
+	worker := NewWorker(store, 1, time.Hour, time.Minute)
+	worker.Register(greet.Handle(func(ctx context.Context, payload greeting) error {
+		close(started)
+		<-release
+		// the shutdown signal does not reach a running job
+		return ctx.Err()
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		worker.Run(ctx)
+	}()
+
+	<-started
+	cancel()
+	select {
+	case <-done:
+		t.Fatal("Run returned while a job was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-done
+}
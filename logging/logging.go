@@ -0,0 +1,109 @@
+// Package logging sets up the structured JSON logger and carries the request id through contexts.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces the value of every sensitive attribute
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are matched case-insensitively against attribute keys and query parameter names
+var sensitiveKeys = []string{
+	"password",
+	"secret",
+	"token",
+	"authorization",
+	"api_key",
+	"cookie",
+}
+
+// IsSensitive reports whether a field with this name must never be logged in clear
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// New returns a JSON logger that redacts sensitive attributes and adds the request id found in the context
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if IsSensitive(attr.Key) {
+				return slog.String(attr.Key, Redacted)
+			}
+			return attr
+		},
+	})
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// ParseLevel turns a LOG_LEVEL value (debug, info, warn, error) into a slog level, defaulting to info
+func ParseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// RedactQuery returns the raw query string with the values of sensitive parameters replaced
+func RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		// do not risk logging something we could not parse
+		return Redacted
+	}
+	for key := range values {
+		if IsSensitive(key) {
+			values[key] = []string{Redacted}
+		}
+	}
+	return values.Encode()
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request id carried by ctx, or "" outside of a request
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// contextHandler adds the request id to every record logged with a request context,
+// so store and api logs of the same request can be joined
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerRedactsSensitiveFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+
+	logger.Info("login",
+		"username", "alice",
+		"password", "hunter2",
+		"access_token", "v2.local.abc",
+		"Authorization", "Bearer abc",
+	)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "alice", line["username"])
+	require.Equal(t, Redacted, line["password"])
+	require.Equal(t, Redacted, line["access_token"])
+	require.Equal(t, Redacted, line["Authorization"])
+	require.NotContains(t, buf.String(), "hunter2")
+}
+
+func TestLoggerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	logger.InfoContext(ctx, "hello")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "req-123", line["request_id"])
+
+	buf.Reset()
+	logger.Info("no request")
+	require.NotContains(t, buf.String(), "request_id")
+}
+
+func TestRedactQuery(t *testing.T) {
+	require.Equal(t, "", RedactQuery(""))
+	require.Equal(t, "page_id=1&token=%5BREDACTED%5D", RedactQuery("page_id=1&token=abc"))
+}
+
+func TestParseLevel(t *testing.T) {
+	require.Equal(t, slog.LevelDebug, ParseLevel("debug"))
+	require.Equal(t, slog.LevelWarn, ParseLevel("WARN"))
+	require.Equal(t, slog.LevelInfo, ParseLevel("nonsense"))
+}
@@ -0,0 +1,158 @@
+// Package loginguard slows down the guessing of passwords. failed logins are counted per username and per IP
+// in the store, every failure of a username makes its next attempt wait twice as long, and enough failures lock
+// the username or the IP out for a while. usernames nobody has are treated the same, so that no answer tells
+// whether a username exists
+package loginguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/jobs"
+	"github.com/ShubhKanodia/GoBank/mail"
+	"github.com/ShubhKanodia/GoBank/util"
+)
+
+// Guard checks the passwords of logins and keeps count of the failures
+type Guard struct {
+	store            db.Store
+	maxFailures      int32
+	maxFailuresPerIP int32
+	window           time.Duration
+	lockout          time.Duration
+	baseDelay        time.Duration
+}
+
+func NewGuard(store db.Store, config util.Config) *Guard {
+	return &Guard{
+		store:            store,
+		maxFailures:      config.LoginMaxFailures,
+		maxFailuresPerIP: config.LoginMaxFailuresPerIP,
+		window:           config.LoginFailureWindow,
+		lockout:          config.LoginLockoutDuration,
+		baseDelay:        config.LoginBaseDelay,
+	}
+}
+
+// errInvalidCredentials is the answer to a wrong password and to a username nobody has alike
+var errInvalidCredentials = apperror.Unauthenticated("invalid username or password")
+
+// decoyHash is checked against the password of a username nobody has, so that the answer takes as long as
+// for a user with a wrong password
+var decoyHash = sync.OnceValue(func() string {
+	hash, err := util.HashPassword("decoy password of no user")
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// Authenticate returns the user of username when password is theirs. a username or an IP that must wait
+// returns apperror.ErrTooManyRequests, the password is not even checked then. a wrong password is counted
+// as a failure of both. call Succeed once the user is logged in
+func (guard *Guard) Authenticate(ctx context.Context, username string, password string, ip string) (db.User, error) {
+	if err := guard.Check(ctx, username, ip); err != nil {
+		return db.User{}, err
+	}
+
+	user, err := guard.store.GetUser(ctx, username)
+	if err != nil {
+		if !errors.Is(db.TranslateError(err), apperror.ErrNotFound) {
+			return db.User{}, err
+		}
+		_ = util.CheckPassword(password, decoyHash())
+	} else if util.CheckPassword(password, user.HashedPassword) == nil {
+		return user, nil
+	}
+
+	if err := guard.Fail(ctx, username, ip); err != nil {
+		return db.User{}, err
+	}
+	return db.User{}, errInvalidCredentials
+}
+
+// Delay is how long after its last failure a username with failures waits before its next attempt
+func (guard *Guard) Delay(failures int32) time.Duration {
+	if failures <= 0 || guard.baseDelay <= 0 {
+		return 0
+	}
+	return util.Backoff(failures, guard.baseDelay, guard.lockout)
+}
+
+// Check returns apperror.ErrTooManyRequests, with how long to wait, while username or ip is locked out or
+// waiting after a failure
+func (guard *Guard) Check(ctx context.Context, username string, ip string) error {
+	failures, err := guard.store.ListLoginFailures(ctx, db.ListLoginFailuresParams{Username: username, Ip: ip})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var until time.Time
+	for _, failure := range failures {
+		if failure.LockedUntil.Valid && failure.LockedUntil.Time.After(until) {
+			until = failure.LockedUntil.Time
+		}
+		// IPs are shared by many users, only the failures of a username slow it down
+		if failure.Scope != db.LoginScopeUsername || failure.LastFailedAt.Before(now.Add(-guard.window)) {
+			continue
+		}
+		if next := failure.LastFailedAt.Add(guard.Delay(failure.Failures)); next.After(until) {
+			until = next
+		}
+	}
+	if !until.After(now) {
+		return nil
+	}
+	return apperror.TooManyRequests("too many failed logins, try again later", until.Sub(now))
+}
+
+// Fail counts a failed login of username from ip. when it locks out a user, they are mailed about it
+func (guard *Guard) Fail(ctx context.Context, username string, ip string) error {
+	now := time.Now()
+	result, err := guard.store.RecordLoginFailureTx(ctx, db.RecordLoginFailureTxParams{
+		Username:         username,
+		IP:               ip,
+		WindowStart:      now.Add(-guard.window),
+		MaxFailures:      guard.maxFailures,
+		MaxFailuresPerIP: guard.maxFailuresPerIP,
+		LockedUntil:      now.Add(guard.lockout),
+	})
+	if err != nil {
+		return err
+	}
+	if !result.Locked || result.User.Username == "" {
+		return nil
+	}
+
+	// the lockout holds either way, a failure is only logged
+	if err := guard.notify(ctx, result.User, result.Username); err != nil {
+		slog.ErrorContext(ctx, "queueing the lockout email failed",
+			slog.String("username", username), slog.String("error", err.Error()))
+	}
+	return nil
+}
+
+// notify mails user that their username is locked out
+func (guard *Guard) notify(ctx context.Context, user db.User, failure db.LoginFailure) error {
+	message := mail.Message{
+		To:      user.Email,
+		Subject: "Your account is locked",
+		Body: fmt.Sprintf("Hello %s,\n\nafter %d failed attempts to log in to your account, logging in is locked "+
+			"until %s.\n\nIf it was not you, someone may be guessing your password. Reset it once the lock "+
+			"is over, or contact us to lift it sooner.\n", user.FullName, failure.Failures, failure.LockedUntil.Time.UTC().Format(time.RFC1123)),
+	}
+	return mail.Enqueue(ctx, guard.store, message, jobs.WithUniqueKey("lockout:"+user.Username))
+}
+
+// Succeed forgets the failures of username, call it once the user is logged in
+func (guard *Guard) Succeed(ctx context.Context, username string) error {
+	_, err := guard.store.DeleteLoginFailures(ctx, db.DeleteLoginFailuresParams{Scope: db.LoginScopeUsername, Subject: username})
+	return err
+}
@@ -0,0 +1,26 @@
+package loginguard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelay(t *testing.T) {
+	guard := NewGuard(nil, util.Config{
+		LoginBaseDelay:       time.Second,
+		LoginLockoutDuration: 10 * time.Second,
+	})
+
+	require.Zero(t, guard.Delay(0))
+	require.Equal(t, time.Second, guard.Delay(1))
+	require.Equal(t, 2*time.Second, guard.Delay(2))
+	require.Equal(t, 8*time.Second, guard.Delay(4))
+	// never longer than a lockout
+	require.Equal(t, 10*time.Second, guard.Delay(5))
+	require.Equal(t, 10*time.Second, guard.Delay(1000))
+
+	require.Zero(t, NewGuard(nil, util.Config{}).Delay(3))
+}
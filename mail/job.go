@@ -0,0 +1,27 @@
+package mail
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ShubhKanodia/GoBank/jobs"
+)
+
+// SendJob sends one email. the message, links and tokens included, stays in the jobs table, which is why
+// the tokens it carries are short lived and single use
+var SendJob = jobs.NewKind[Message]("mail.send")
+
+// Handler runs SendJob with mailer, register it with the job worker
+func Handler(mailer Mailer) jobs.Handler {
+	return SendJob.Handle(mailer.Send)
+}
+
+// Enqueue queues message for the job worker. with jobs.WithUniqueKey, a message queued while another with the
+// same key is still waiting to be sent is dropped
+func Enqueue(ctx context.Context, creator jobs.Creator, message Message, options ...jobs.Option) error {
+	_, err := SendJob.Enqueue(ctx, creator, message, options...)
+	if errors.Is(err, jobs.ErrDuplicate) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+// Package mail sends the emails of the bank to its users: the links that verify an email or reset a password.
+// emails are sent by the job worker, so that no request waits on the mail server
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/ShubhKanodia/GoBank/util"
+)
+
+// supported values of MAILER
+const (
+	MailerLog  = "log"
+	MailerFile = "file"
+	MailerSMTP = "smtp"
+)
+
+// Message is one plain text email
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Mailer sends an email. sending is at least once, a job that failed after the mail server accepted the
+// message sends it again
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// LogMailer logs emails instead of sending them, for development
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, message Message) error {
+	slog.InfoContext(ctx, "email",
+		slog.String("to", message.To),
+		slog.String("subject", message.Subject),
+		slog.String("body", message.Body),
+	)
+	return nil
+}
+
+// WriterMailer writes emails as JSON lines, used for files
+type WriterMailer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterMailer(w io.Writer) *WriterMailer {
+	return &WriterMailer{w: w}
+}
+
+func (mailer *WriterMailer) Send(ctx context.Context, message Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+	_, err = mailer.w.Write(append(data, '\n'))
+	return err
+}
+
+// NewMailer builds the mailer config.Mailer names, closer releases the file it opened
+func NewMailer(config util.Config) (Mailer, io.Closer, error) {
+	switch config.Mailer {
+	case MailerLog, "":
+		return LogMailer{}, nothing{}, nil
+	case MailerFile:
+		file, err := os.OpenFile(config.MailFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot open mail file: %w", err)
+		}
+		return NewWriterMailer(file), file, nil
+	case MailerSMTP:
+		if config.SMTPHost == "" || config.MailFrom == "" {
+			return nil, nil, errors.New("SMTP_HOST and MAIL_FROM are required by the smtp mailer")
+		}
+		return NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailFrom), nothing{}, nil
+	}
+	return nil, nil, fmt.Errorf("unknown mailer %q", config.Mailer)
+}
+
+// nothing is the closer of mailers that hold nothing open
+type nothing struct{}
+
+func (nothing) Close() error { return nil }
@@ -0,0 +1,117 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterMailer(t *testing.T) {
+	var buf bytes.Buffer
+	message := Message{To: "alice@example.com", Subject: "Hello", Body: "line one\nline two\n"}
+	require.NoError(t, NewWriterMailer(&buf).Send(context.Background(), message))
+
+	var got Message
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	require.Equal(t, message, got)
+}
+
+func TestNewMailer(t *testing.T) {
+	mailer, closer, err := NewMailer(util.Config{Mailer: MailerLog})
+	require.NoError(t, err)
+	require.IsType(t, LogMailer{}, mailer)
+	require.NoError(t, closer.Close())
+
+	_, _, err = NewMailer(util.Config{Mailer: MailerSMTP})
+	require.ErrorContains(t, err, "SMTP_HOST")
+
+	_, _, err = NewMailer(util.Config{Mailer: "pigeon"})
+	require.ErrorContains(t, err, "unknown mailer")
+}
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	data, err := format("bank@example.com", Message{To: "alice@example.com", Subject: "Grüße", Body: "a\nb"}, date)
+	require.NoError(t, err)
+
+	text := string(data)
+	require.Contains(t, text, "To: alice@example.com\r\n")
+	require.Contains(t, text, "Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=\r\n")
+	require.Contains(t, text, "Date: Fri, 02 Jan 2026 15:04:05 +0000\r\n")
+	require.True(t, strings.HasSuffix(text, "\r\n\r\na\r\nb"))
+
+	// a line break would let the value add headers
+	_, err = format("bank@example.com", Message{To: "alice@example.com\r\nBcc: eve@example.com"}, date)
+	require.Error(t, err)
+}
+
+func TestSMTPMailer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan []string, 1)
+	go serveSMTP(listener, received)
+
+	addr := listener.Addr().(*net.TCPAddr)
+	mailer := NewSMTPMailer("127.0.0.1", addr.Port, "", "", "bank@example.com")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = mailer.Send(ctx, Message{To: "alice@example.com", Subject: "Hello", Body: "hi"})
+	require.NoError(t, err)
+
+	commands := <-received
+	require.Contains(t, commands, "MAIL FROM:<bank@example.com>")
+	require.Contains(t, commands, "RCPT TO:<alice@example.com>")
+	require.Contains(t, commands, "Subject: Hello")
+	require.Contains(t, commands, "hi")
+}
+
+// serveSMTP answers one SMTP conversation without STARTTLS or authentication and sends the lines it read
+func serveSMTP(listener net.Listener, received chan<- []string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	var lines []string
+	reply("220 localhost ESMTP")
+	inData := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			received <- lines
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+		switch {
+		case inData && line == ".":
+			inData = false
+			reply("250 queued")
+		case inData:
+		case strings.HasPrefix(line, "EHLO"):
+			reply("250 localhost")
+		case line == "DATA":
+			inData = true
+			reply("354 go ahead")
+		case line == "QUIT":
+			reply("221 bye")
+			received <- lines
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPMailer sends emails through a mail server, upgrading the connection with STARTTLS when the server
+// offers it. credentials are only sent over TLS
+type SMTPMailer struct {
+	addr string
+	host string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer returns a mailer sending as from through host:port, without authentication when username is empty
+func NewSMTPMailer(host string, port int, username string, password string, from string) *SMTPMailer {
+	mailer := &SMTPMailer{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		host: host,
+		from: from,
+	}
+	if username != "" {
+		mailer.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return mailer
+}
+
+func (mailer *SMTPMailer) Send(ctx context.Context, message Message) error {
+	data, err := format(mailer.from, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", mailer.addr)
+	if err != nil {
+		return err
+	}
+	// net/smtp knows nothing of contexts, the deadline of the job bounds the whole conversation
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, mailer.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: mailer.host}); err != nil {
+			return err
+		}
+	}
+	if mailer.auth != nil {
+		if err := client.Auth(mailer.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(mailer.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(message.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// format writes message as an RFC 5322 email
+func format(from string, message Message, date time.Time) ([]byte, error) {
+	// a line break in a header would let whoever chose the value add headers of their own
+	for _, value := range []string{from, message.To, message.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, errors.New("line break in an email header")
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes(), nil
+}
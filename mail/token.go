@@ -0,0 +1,77 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/jobs"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/ShubhKanodia/GoBank/util"
+)
+
+// TokenSender issues the single-use tokens that verify an email or reset a password, and queues the emails
+// with the links carrying them
+type TokenSender struct {
+	store db.Store
+	// linkBaseURL is where the links of the emails point, the page there posts the token to the API
+	linkBaseURL string
+	verifyTTL   time.Duration
+	resetTTL    time.Duration
+}
+
+func NewTokenSender(store db.Store, config util.Config) *TokenSender {
+	return &TokenSender{
+		store:       store,
+		linkBaseURL: config.MailLinkBaseURL,
+		verifyTTL:   config.EmailVerificationTTL,
+		resetTTL:    config.PasswordResetTTL,
+	}
+}
+
+// SendVerification mails user a link that verifies their email
+func (sender *TokenSender) SendVerification(ctx context.Context, user db.User) error {
+	return sender.send(ctx, user, db.EmailTokenVerifyEmail, sender.verifyTTL, func(link string) Message {
+		return Message{
+			To:      user.Email,
+			Subject: "Verify your email",
+			Body: fmt.Sprintf("Hello %s,\n\nconfirm this is your email by opening the link below within %s:\n\n%s\n\n"+
+				"If you did not sign up, ignore this email.\n", user.FullName, sender.verifyTTL, link),
+		}
+	})
+}
+
+// SendPasswordReset mails user a link that sets a new password
+func (sender *TokenSender) SendPasswordReset(ctx context.Context, user db.User) error {
+	return sender.send(ctx, user, db.EmailTokenResetPassword, sender.resetTTL, func(link string) Message {
+		return Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("Hello %s,\n\nchoose a new password by opening the link below within %s:\n\n%s\n\n"+
+				"If you did not ask for it, ignore this email, your password stays as it is.\n", user.FullName, sender.resetTTL, link),
+		}
+	})
+}
+
+func (sender *TokenSender) send(ctx context.Context, user db.User, purpose string, ttl time.Duration, message func(link string) Message) error {
+	raw, err := token.NewOpaque()
+	if err != nil {
+		return err
+	}
+	_, err = sender.store.CreateEmailToken(ctx, db.CreateEmailTokenParams{
+		Username:  user.Username,
+		Purpose:   purpose,
+		TokenHash: token.HashOpaque(raw),
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/%s?token=%s", sender.linkBaseURL, purpose, url.QueryEscape(raw))
+	// one email of a purpose waits in the queue per user, asking again does not flood their inbox
+	return Enqueue(ctx, sender.store, message(link), jobs.WithUniqueKey(purpose+":"+user.Username))
+}
@@ -0,0 +1,56 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSendPasswordReset(t *testing.T) {
+	user := db.User{Username: "alice", FullName: "Alice", Email: "alice@example.com"}
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	var stored db.CreateEmailTokenParams
+	store.EXPECT().CreateEmailToken(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateEmailTokenParams) (db.EmailToken, error) {
+			stored = arg
+			return db.EmailToken{ID: 1}, nil
+		})
+	var job db.CreateJobParams
+	store.EXPECT().CreateJob(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateJobParams) (db.Job, error) {
+			job = arg
+			return db.Job{ID: 1}, nil
+		})
+
+	sender := NewTokenSender(store, util.Config{MailLinkBaseURL: "https://bank.example.com", PasswordResetTTL: time.Hour})
+	require.NoError(t, sender.SendPasswordReset(context.Background(), user))
+
+	require.Equal(t, db.EmailTokenResetPassword, stored.Purpose)
+	require.Equal(t, user.Email, stored.Email)
+	require.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+
+	require.Equal(t, SendJob.Name, job.Kind)
+	require.Equal(t, "reset_password:alice", job.UniqueKey.String)
+	var message Message
+	require.NoError(t, json.Unmarshal(job.Payload, &message))
+	require.Equal(t, user.Email, message.To)
+
+	// the link carries the token whose hash was stored
+	link := regexp.MustCompile(`https://\S+`).FindString(message.Body)
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	require.Equal(t, "/reset_password", parsed.Path)
+	require.Equal(t, stored.TokenHash, token.HashOpaque(parsed.Query().Get("token")))
+}
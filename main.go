@@ -1,11 +1,27 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/ShubhKanodia/GoBank/api"
 	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/events"
+	"github.com/ShubhKanodia/GoBank/gapi"
+	"github.com/ShubhKanodia/GoBank/jobs"
+	"github.com/ShubhKanodia/GoBank/logging"
+	"github.com/ShubhKanodia/GoBank/mail"
+	"github.com/ShubhKanodia/GoBank/metrics"
+	"github.com/ShubhKanodia/GoBank/outbox"
+	"github.com/ShubhKanodia/GoBank/ratelimit"
+	"github.com/ShubhKanodia/GoBank/scheduler"
+	"github.com/ShubhKanodia/GoBank/tracing"
 	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/ShubhKanodia/GoBank/webhook"
 	_ "github.com/lib/pq"
 )
 
@@ -14,6 +30,7 @@ func main() {
 	if err != nil {
 		panic("Cannot load config: " + err.Error())
 	}
+	slog.SetDefault(logging.New(os.Stdout, logging.ParseLevel(config.LogLevel)))
 	// This is the main function for the application.
 	// It is currently empty because we are focusing on testing the database queries.
 	// In a real application, you would typically start your server or run your application logic here.
@@ -22,10 +39,132 @@ func main() {
 		panic("Cannot con  nect to db: " + err.Error())
 	}
 
+	if err := metrics.RegisterDBStats(conn, "simple_bank"); err != nil {
+		panic("Cannot register db metrics: " + err.Error())
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), config)
+	if err != nil {
+		panic("Cannot set up tracing: " + err.Error())
+	}
+
 	store := db.NewStore(conn)
-	server := api.NewServer(store)
+	broker := events.NewBroker()
+	// both APIs take from the same buckets
+	limiter, err := ratelimit.New(config, store)
+	if err != nil {
+		panic("Cannot create rate limiter: " + err.Error())
+	}
+	server, err := api.NewServer(config, store, broker, limiter)
+	if err != nil {
+		panic("Cannot create server: " + err.Error())
+	}
+	grpcServer, err := gapi.NewServer(config, store, limiter)
+	if err != nil {
+		panic("Cannot create gRPC server: " + err.Error())
+	}
+
+	// SIGTERM is what kubernetes sends before killing the pod
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	outboxSink, outboxCloser, err := outbox.NewSink(config)
+	if err != nil {
+		panic("Cannot create outbox sink: " + err.Error())
+	}
+	defer outboxCloser.Close()
+	dispatcherDone := make(chan struct{})
+	go func() {
+		defer close(dispatcherDone)
+		if outboxSink == nil {
+			slog.Warn("no outbox sink configured, outbox events are not dispatched")
+			return
+		}
+		outbox.NewDispatcher(store, outboxSink, config.OutboxBatchSize, config.OutboxPollInterval).Run(ctx)
+	}()
+
+	delivererDone := make(chan struct{})
+	go func() {
+		defer close(delivererDone)
+		client := webhook.NewClient(config.WebhookTimeout, config.WebhookAllowInsecure)
+		webhook.NewDeliverer(store, client, config.WebhookBatchSize, config.WebhookPollInterval).Run(ctx)
+	}()
+
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		scheduler.NewScheduler(store, config.SchedulerPollInterval).Run(ctx)
+	}()
+
+	mailer, mailerCloser, err := mail.NewMailer(config)
+	if err != nil {
+		panic("Cannot create mailer: " + err.Error())
+	}
+	defer mailerCloser.Close()
+
+	worker := jobs.NewWorker(store, config.JobConcurrency, config.JobPollInterval, config.JobVisibilityTimeout)
+	worker.Register(mail.Handler(mailer))
+	jobsDone := make(chan struct{})
+	go func() {
+		defer close(jobsDone)
+		worker.Run(ctx)
+	}()
 
-	if err := server.Start(config.ServerAddress); err != nil {
-		panic("Cannot start server: " + err.Error())
+	// wakes up the account event streams of this replica when any replica commits a transfer
+	go func() {
+		if err := broker.Listen(ctx, events.NewListener(config.DBSource)); err != nil {
+			slog.Error("Cannot listen for account events", slog.String("error", err.Error()))
+		}
+	}()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Start(config.ServerAddress)
+	}()
+	grpcServerErr := make(chan error, 1)
+	go func() {
+		slog.Info("starting gRPC server", slog.String("address", config.GRPCServerAddress))
+		grpcServerErr <- grpcServer.Start(config.GRPCServerAddress)
+	}()
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			panic("Cannot start server: " + err.Error())
+		}
+		return
+	case err := <-grpcServerErr:
+		if err != nil {
+			panic("Cannot start gRPC server: " + err.Error())
+		}
+		return
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownDrainPeriod+config.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx, config.ShutdownDrainPeriod); err != nil {
+		slog.Error("Cannot shut down server gracefully", slog.String("error", err.Error()))
+	}
+	if err := <-serverErr; err != nil {
+		slog.Error("server stopped with error", slog.String("error", err.Error()))
+	}
+	// GracefulStop has no deadline of its own, in-flight rpcs are bounded by their client deadlines
+	grpcServer.Shutdown()
+	if err := <-grpcServerErr; err != nil {
+		slog.Error("gRPC server stopped with error", slog.String("error", err.Error()))
+	}
+	// the dispatcher stops with the signal, an event cut off mid delivery is sent again once its lease runs out
+	<-dispatcherDone
+	// same for webhook deliveries, receivers dedupe on the delivery id header
+	<-delivererDone
+	// scheduled transfers and standing order occurrences each run in one transaction, one cut off is rolled back
+	<-schedulerDone
+	// running jobs are left to finish, each within the visibility timeout. one killed anyway runs again once its lease runs out
+	<-jobsDone
+	// flush the spans of the requests that were drained above
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Cannot flush traces", slog.String("error", err.Error()))
 	}
 }
@@ -0,0 +1,128 @@
+// Package metrics holds the prometheus collectors shared by the api and db packages.
+// everything is registered on the default registry, which is what promhttp.Handler serves
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "gobank"
+
+var (
+	// HTTPRequestDuration tracks latency of every HTTP request by route template (not raw path, to keep cardinality bounded)
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// DBQueryDuration tracks latency of every sqlc query by query name
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of database queries.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"query", "status"})
+
+	// DBTxDuration tracks how long execTx holds a transaction open, including retries
+	DBTxDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "tx_duration_seconds",
+		Help:      "Duration of database transactions.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"status"})
+
+	// DBTxRetries counts transactions retried after a deadlock or serialization failure
+	DBTxRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "tx_retries_total",
+		Help:      "Number of database transactions retried.",
+	}, []string{"reason"})
+
+	// TransfersCreated counts successful transfers per currency
+	TransfersCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "business",
+		Name:      "transfers_created_total",
+		Help:      "Number of transfers created.",
+	}, []string{"currency"})
+
+	// TransferAmount sums the amount moved per currency, in minor units
+	TransferAmount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "business",
+		Name:      "transfer_amount_total",
+		Help:      "Total amount moved by transfers, in minor units.",
+	}, []string{"currency"})
+
+	// TransfersFailed counts rejected or failed transfers by reason, the reason is the apperror code
+	// so the label values stay a closed set
+	TransfersFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "business",
+		Name:      "transfers_failed_total",
+		Help:      "Number of transfers that failed, by reason.",
+	}, []string{"reason"})
+
+	// TransferApprovals counts transfers held for approval as they reach each status (pending, approved, rejected or expired)
+	TransferApprovals = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "business",
+		Name:      "transfer_approvals_total",
+		Help:      "Number of transfers held for approval, by the status they reached.",
+	}, []string{"status"})
+
+	// OutboxEvents counts outbox delivery attempts by event type and outcome (sent or failed)
+	OutboxEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "events_total",
+		Help:      "Number of outbox delivery attempts, by event type and outcome.",
+	}, []string{"event_type", "outcome"})
+
+	// WebhookDeliveries counts customer webhook attempts by event type and resulting status (succeeded, pending or dead)
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "deliveries_total",
+		Help:      "Number of customer webhook delivery attempts, by event type and resulting status.",
+	}, []string{"event_type", "status"})
+
+	// ScheduledTransfers counts executed scheduled transfers by resulting status (succeeded or failed)
+	ScheduledTransfers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "transfers_total",
+		Help:      "Number of scheduled transfers executed, by resulting status.",
+	}, []string{"status"})
+
+	// StandingOrderOccurrences counts standing order occurrences run by resulting status (succeeded, skipped, retrying or failed)
+	StandingOrderOccurrences = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "standing_order_occurrences_total",
+		Help:      "Number of standing order occurrences run, by resulting status.",
+	}, []string{"status"})
+
+	// Jobs counts background job attempts by kind and outcome (succeeded, retrying or dead)
+	Jobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "attempts_total",
+		Help:      "Number of background job attempts, by kind and outcome.",
+	}, []string{"kind", "status"})
+)
+
+// RegisterDBStats exports the connection pool stats of conn (open, in use, idle, wait count and duration)
+func RegisterDBStats(conn *sql.DB, dbName string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(conn, dbName))
+}
@@ -0,0 +1,108 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sort"
+	"time"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/metrics"
+	"github.com/ShubhKanodia/GoBank/util"
+)
+
+const (
+	// lease is how long a claimed event is hidden from other dispatchers while it is being sent.
+	// if this process dies mid delivery the event becomes due again once the lease runs out
+	lease = time.Minute
+	// minBackoff and maxBackoff bound the delay before retrying a failed delivery
+	minBackoff = time.Second
+	maxBackoff = 10 * time.Minute
+)
+
+// Dispatcher polls the outbox and hands due events to a sink. several dispatchers, in one or
+// many replicas, can run against the same table, each claims its own batch
+type Dispatcher struct {
+	store        db.Store
+	sink         Sink
+	batchSize    int32
+	pollInterval time.Duration
+	now          func() time.Time
+}
+
+func NewDispatcher(store db.Store, sink Sink, batchSize int32, pollInterval time.Duration) *Dispatcher {
+	return &Dispatcher{
+		store:        store,
+		sink:         sink,
+		batchSize:    batchSize,
+		pollInterval: pollInterval,
+		now:          time.Now,
+	}
+}
+
+// Run dispatches until ctx is done. a full batch is followed straight away by the next one,
+// otherwise it waits pollInterval
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		n, err := d.DispatchBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "outbox dispatch failed", slog.String("error", err.Error()))
+		}
+		if n == int(d.batchSize) && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.pollInterval):
+		}
+	}
+}
+
+// DispatchBatch claims up to batchSize due events, sends them and records the outcome.
+// it returns how many events were claimed
+func (d *Dispatcher) DispatchBatch(ctx context.Context) (int, error) {
+	events, err := d.store.ClaimOutboxEvents(ctx, db.ClaimOutboxEventsParams{
+		LeaseUntil: d.now().Add(lease),
+		BatchSize:  d.batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+	// UPDATE ... RETURNING has no order, deliver oldest first
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	for _, event := range events {
+		if err := d.deliver(ctx, event); err != nil {
+			return len(events), err
+		}
+	}
+	return len(events), nil
+}
+
+// deliver sends one event, only errors recording the outcome are returned
+func (d *Dispatcher) deliver(ctx context.Context, event db.Outbox) error {
+	sendErr := d.sink.Send(ctx, newMessage(event))
+	if sendErr == nil {
+		metrics.OutboxEvents.WithLabelValues(event.EventType, "sent").Inc()
+		return d.store.MarkOutboxEventSent(ctx, event.ID)
+	}
+
+	metrics.OutboxEvents.WithLabelValues(event.EventType, "failed").Inc()
+	// attempts counts the deliveries before this one
+	delay := util.Backoff(event.Attempts+1, minBackoff, maxBackoff)
+	slog.WarnContext(ctx, "outbox delivery failed",
+		slog.Int64("outbox_id", event.ID),
+		slog.String("event_type", event.EventType),
+		slog.Int("attempt", int(event.Attempts)+1),
+		slog.Duration("retry_in", delay),
+		slog.String("error", sendErr.Error()),
+	)
+	return d.store.MarkOutboxEventFailed(ctx, db.MarkOutboxEventFailedParams{
+		ID:            event.ID,
+		NextAttemptAt: d.now().Add(delay),
+		LastError:     sql.NullString{String: sendErr.Error(), Valid: true},
+	})
+}
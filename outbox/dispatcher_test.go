@@ -0,0 +1,94 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// recordingSink remembers what it was sent and fails the ids listed in fail
+type recordingSink struct {
+	sent []Message
+	fail map[int64]bool
+}
+
+func (sink *recordingSink) Send(ctx context.Context, message Message) error {
+	if sink.fail[message.ID] {
+		return errors.New("sink unavailable")
+	}
+	sink.sent = append(sink.sent, message)
+	return nil
+}
+
+func TestDispatchBatch(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	event1 := randomOutboxEvent(1, 0)
+	event2 := randomOutboxEvent(2, 3)
+	event3 := randomOutboxEvent(3, 0)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ClaimOutboxEvents(gomock.Any(), gomock.Eq(db.ClaimOutboxEventsParams{
+		LeaseUntil: now.Add(lease),
+		BatchSize:  10,
+	})).Times(1).Return([]db.Outbox{event3, event1, event2}, nil)
+	store.EXPECT().MarkOutboxEventSent(gomock.Any(), gomock.Eq(event1.ID)).Times(1)
+	store.EXPECT().MarkOutboxEventSent(gomock.Any(), gomock.Eq(event3.ID)).Times(1)
+	store.EXPECT().MarkOutboxEventFailed(gomock.Any(), gomock.Eq(db.MarkOutboxEventFailedParams{
+		ID:            event2.ID,
+		NextAttemptAt: now.Add(util.Backoff(4, minBackoff, maxBackoff)),
+		LastError:     sql.NullString{String: "sink unavailable", Valid: true},
+	})).Times(1)
+
+	sink := &recordingSink{fail: map[int64]bool{event2.ID: true}}
+	dispatcher := NewDispatcher(store, sink, 10, time.Second)
+	dispatcher.now = func() time.Time { return now }
+
+	n, err := dispatcher.DispatchBatch(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	// oldest first, whatever order the database returned
+	require.Len(t, sink.sent, 2)
+	require.Equal(t, event1.ID, sink.sent[0].ID)
+	require.Equal(t, event3.ID, sink.sent[1].ID)
+	require.Equal(t, event1.EventType, sink.sent[0].EventType)
+	require.JSONEq(t, string(event1.Payload), string(sink.sent[0].Payload))
+}
+
+func TestDispatchBatchClaimError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ClaimOutboxEvents(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+
+	sink := &recordingSink{}
+	n, err := NewDispatcher(store, sink, 10, time.Second).DispatchBatch(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Zero(t, n)
+	require.Empty(t, sink.sent)
+}
+
+func randomOutboxEvent(id int64, attempts int32) db.Outbox {
+	return db.Outbox{
+		ID:            id,
+		AggregateType: db.AggregateAccount,
+		AggregateID:   "42",
+		EventType:     db.EventAccountCreated,
+		Payload:       json.RawMessage(`{"id":42}`),
+		Attempts:      attempts,
+		CreatedAt:     time.Now(),
+	}
+}
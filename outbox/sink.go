@@ -0,0 +1,173 @@
+// Package outbox delivers the domain events written to the outbox table to downstream sinks.
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+)
+
+// supported values in OUTBOX_SINKS
+const (
+	SinkStdout  = "stdout"
+	SinkFile    = "file"
+	SinkWebhook = "webhook"
+)
+
+// Message is what sinks receive for an outbox row
+type Message struct {
+	ID            int64           `json:"id"`
+	EventType     string          `json:"event_type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+func newMessage(event db.Outbox) Message {
+	return Message{
+		ID:            event.ID,
+		EventType:     event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		Payload:       event.Payload,
+		CreatedAt:     event.CreatedAt,
+	}
+}
+
+// Sink delivers one message. delivery is at least once, so a sink may see a message again
+// after a crash or a failure; consumers deduplicate on Message.ID
+type Sink interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// WriterSink writes messages as JSON lines, used for stdout and files
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (sink *WriterSink) Send(ctx context.Context, message Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	_, err = sink.w.Write(append(data, '\n'))
+	return err
+}
+
+// WebhookSink POSTs each message as JSON to a URL, any status outside 2xx is a failure
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string, client *http.Client) *WebhookSink {
+	return &WebhookSink{url: url, client: client}
+}
+
+func (sink *WebhookSink) Send(ctx context.Context, message Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// lets the receiver drop redeliveries
+	req.Header.Set("Idempotency-Key", strconv.FormatInt(message.ID, 10))
+
+	rsp, err := sink.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(rsp.Body, 4096))
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", rsp.Status)
+	}
+	return nil
+}
+
+// MultiSink sends every message to all of its sinks, a failure of any of them fails the message
+// and it is sent to all of them again later
+type MultiSink []Sink
+
+func (sinks MultiSink) Send(ctx context.Context, message Message) error {
+	var errs []error
+	for _, sink := range sinks {
+		if err := sink.Send(ctx, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewSink builds the sinks listed in config.OutboxSinks (comma separated), closer releases the files it opened.
+// an empty list returns a nil sink, meaning the dispatcher should not run
+func NewSink(config util.Config) (sink Sink, closer io.Closer, err error) {
+	var sinks MultiSink
+	var files closers
+	for _, name := range strings.Split(config.OutboxSinks, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case SinkStdout:
+			sinks = append(sinks, NewWriterSink(os.Stdout))
+		case SinkFile:
+			file, err := os.OpenFile(config.OutboxFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				files.Close()
+				return nil, nil, fmt.Errorf("cannot open outbox file: %w", err)
+			}
+			files = append(files, file)
+			sinks = append(sinks, NewWriterSink(file))
+		case SinkWebhook:
+			if config.OutboxWebhookURL == "" {
+				files.Close()
+				return nil, nil, fmt.Errorf("OUTBOX_WEBHOOK_URL is required by the webhook sink")
+			}
+			sinks = append(sinks, NewWebhookSink(config.OutboxWebhookURL, &http.Client{Timeout: 10 * time.Second}))
+		default:
+			files.Close()
+			return nil, nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+	}
+
+	switch len(sinks) {
+	case 0:
+		return nil, files, nil
+	case 1:
+		return sinks[0], files, nil
+	}
+	return sinks, files, nil
+}
+
+type closers []io.Closer
+
+func (c closers) Close() error {
+	var errs []error
+	for _, closer := range c {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSink(t *testing.T) {
+	message := Message{ID: 7, EventType: "account.created", Payload: json.RawMessage(`{"id":1}`), CreatedAt: time.Now().UTC()}
+
+	testCases := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "OK", status: http.StatusNoContent},
+		{name: "ServerError", status: http.StatusServiceUnavailable, wantErr: true},
+		{name: "ClientError", status: http.StatusBadRequest, wantErr: true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			var got Message
+			var idempotencyKey string
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				idempotencyKey = r.Header.Get("Idempotency-Key")
+				body, _ := io.ReadAll(r.Body)
+				json.Unmarshal(body, &got)
+				w.WriteHeader(tc.status)
+			}))
+			defer receiver.Close()
+
+			err := NewWebhookSink(receiver.URL, receiver.Client()).Send(context.Background(), message)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, "7", idempotencyKey)
+			require.Equal(t, message.ID, got.ID)
+			require.Equal(t, message.EventType, got.EventType)
+		})
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+	require.NoError(t, sink.Send(context.Background(), Message{ID: 1, EventType: "a", Payload: json.RawMessage(`{}`)}))
+	require.NoError(t, sink.Send(context.Background(), Message{ID: 2, EventType: "b", Payload: json.RawMessage(`{}`)}))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var second Message
+	require.NoError(t, json.Unmarshal(lines[1], &second))
+	require.EqualValues(t, 2, second.ID)
+}
+
+func TestNewSink(t *testing.T) {
+	dir := t.TempDir()
+
+	sink, closer, err := NewSink(util.Config{})
+	require.NoError(t, err)
+	require.Nil(t, sink)
+	require.NoError(t, closer.Close())
+
+	sink, closer, err = NewSink(util.Config{OutboxSinks: "stdout, file", OutboxFilePath: filepath.Join(dir, "outbox.jsonl")})
+	require.NoError(t, err)
+	require.IsType(t, MultiSink{}, sink)
+	require.NoError(t, closer.Close())
+
+	_, _, err = NewSink(util.Config{OutboxSinks: "webhook"})
+	require.Error(t, err)
+
+	_, _, err = NewSink(util.Config{OutboxSinks: "kafka"})
+	require.Error(t, err)
+}
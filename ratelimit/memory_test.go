@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	now := time.Now()
+	limiter := NewMemory()
+	limiter.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 3}
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		result, err := limiter.Take(context.Background(), "a", limit)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, remaining, result.Remaining)
+	}
+	result, err := limiter.Take(context.Background(), "a", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, time.Second, result.RetryAfter)
+	require.Equal(t, 3*time.Second, result.Reset)
+
+	// other keys have buckets of their own
+	result, err = limiter.Take(context.Background(), "b", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	now = now.Add(1500 * time.Millisecond)
+	result, err = limiter.Take(context.Background(), "a", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+}
+
+func TestMemorySweep(t *testing.T) {
+	now := time.Now()
+	limiter := NewMemory()
+	limiter.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 1}
+
+	_, err := limiter.Take(context.Background(), "a", limit)
+	require.NoError(t, err)
+	require.Len(t, limiter.buckets, 1)
+
+	// refilled buckets are dropped
+	now = now.Add(sweepInterval)
+	_, err = limiter.Take(context.Background(), "b", limit)
+	require.NoError(t, err)
+	require.Len(t, limiter.buckets, 1)
+	require.Contains(t, limiter.buckets, "b")
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+)
+
+// idleTimeout is how long a bucket goes unused before the Postgres limiter deletes it. limits that take
+// longer to refill start over early
+const idleTimeout = 24 * time.Hour
+
+// Postgres keeps the buckets in the database, shared by every replica. each request costs a round trip
+type Postgres struct {
+	store db.Store
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgres(store db.Store) *Postgres {
+	return &Postgres{store: store}
+}
+
+// Take takes a token from the bucket of key
+func (p *Postgres) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	p.sweep(ctx)
+
+	row, err := p.store.TakeRateLimitToken(ctx, db.TakeRateLimitTokenParams{
+		Key:   key,
+		Burst: float64(limit.Burst),
+		Rate:  limit.Rate,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(row.Allowed, row.Tokens, limit), nil
+}
+
+// sweep deletes the idle buckets, once every sweepInterval per replica
+func (p *Postgres) sweep(ctx context.Context) {
+	p.mu.Lock()
+	due := time.Since(p.lastSweep) >= sweepInterval
+	if due {
+		p.lastSweep = time.Now()
+	}
+	p.mu.Unlock()
+	if !due {
+		return
+	}
+
+	// idle buckets only take room, a failure waits for the next sweep
+	if _, err := p.store.DeleteIdleRateLimitBuckets(ctx, time.Now().Add(-idleTimeout)); err != nil {
+		slog.WarnContext(ctx, "deleting idle rate limit buckets failed", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,170 @@
+// Package ratelimit limits how fast callers use the APIs with token buckets. every caller, the user of the
+// access token or else the client IP, has a bucket per route group holding at most Burst tokens and refilled
+// at Rate. a request takes a token, and is turned away while the bucket is empty. buckets live in memory,
+// or in Postgres so that the replicas of a deployment share them
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+)
+
+// backends keeping the buckets
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// route groups, the APIs put each route in one of them
+const (
+	// GroupDefault holds every route that is in no other group, its limits apply to the groups without their own
+	GroupDefault = "default"
+	// GroupAuth holds the routes that log in or prove an email or a password without an access token
+	GroupAuth = "auth"
+	// GroupTransfers holds the routes that move money, they lock account rows
+	GroupTransfers = "transfers"
+)
+
+// Groups are the route groups limits can be given for
+func Groups() []string {
+	return []string{GroupDefault, GroupAuth, GroupTransfers}
+}
+
+// Limit is a token bucket: Rate tokens a second refill it up to Burst
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result is the state of a bucket after taking a token from it
+type Result struct {
+	// Allowed reports that there was a token to take
+	Allowed bool
+	// Remaining is the whole tokens left
+	Remaining int
+	// RetryAfter is how long until the next token, zero when Allowed
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// newResult is the Result of a bucket holding tokens after a request was allowed or not
+func newResult(allowed bool, tokens float64, limit Limit) Result {
+	result := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Max(0, s) * float64(time.Second))
+}
+
+// Limiter keeps the buckets
+type Limiter interface {
+	// Take takes a token from the bucket of key, a bucket never used before starts full
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// New returns the limiter of the configured backend
+func New(config util.Config, store db.Store) (Limiter, error) {
+	switch config.RateLimitBackend {
+	case BackendMemory, "":
+		return NewMemory(), nil
+	case BackendPostgres:
+		return NewPostgres(store), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", config.RateLimitBackend)
+	}
+}
+
+// Limits are the limits of the route groups, for every role or for one
+type Limits map[string]Limit
+
+// ParseLimits parses comma separated limits of the form group=count/unit or group=count/unit:burst, with a
+// unit of s, m or h. group@role gives the limit of one role. the burst is count unless given, e.g.
+// "default=20/s:40,auth=10/m,transfers@teller=5/s:10"
+func ParseLimits(s string, roles []string) (Limits, error) {
+	limits := Limits{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate limit %q is not group=count/unit", entry)
+		}
+		group, role, hasRole := strings.Cut(key, "@")
+		if !slices.Contains(Groups(), group) {
+			return nil, fmt.Errorf("unknown route group %q", group)
+		}
+		if hasRole && !slices.Contains(roles, role) {
+			return nil, fmt.Errorf("unknown role %q", role)
+		}
+		if _, ok := limits[key]; ok {
+			return nil, fmt.Errorf("rate limit of %q is given twice", key)
+		}
+
+		limit, err := parseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit of %q: %w", key, err)
+		}
+		limits[key] = limit
+	}
+	return limits, nil
+}
+
+var units = map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+
+func parseLimit(s string) (Limit, error) {
+	rate, burstValue, hasBurst := strings.Cut(s, ":")
+	countValue, unitValue, ok := strings.Cut(rate, "/")
+	unit, known := units[unitValue]
+	if !ok || !known {
+		return Limit{}, fmt.Errorf("%q is not count/unit with a unit of s, m or h", rate)
+	}
+	count, err := strconv.Atoi(countValue)
+	if err != nil || count < 1 {
+		return Limit{}, fmt.Errorf("count %q is not a positive number", countValue)
+	}
+
+	burst := count
+	if hasBurst {
+		burst, err = strconv.Atoi(burstValue)
+		if err != nil || burst < 1 {
+			return Limit{}, fmt.Errorf("burst %q is not a positive number", burstValue)
+		}
+	}
+	return Limit{Rate: float64(count) / unit.Seconds(), Burst: burst}, nil
+}
+
+// For returns the limit of group for role, role is empty for callers without an access token. a group
+// without a limit of its own has the limit of GroupDefault, and a role without one the limit of every role.
+// it returns false when nothing limits the group
+func (limits Limits) For(group string, role string) (Limit, bool) {
+	for _, g := range []string{group, GroupDefault} {
+		if role != "" {
+			if limit, ok := limits[g+"@"+role]; ok {
+				return limit, true
+			}
+		}
+		if limit, ok := limits[g]; ok {
+			return limit, true
+		}
+	}
+	return Limit{}, false
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var roles = []string{"customer", "admin"}
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits("default=20/s:40, auth=10/m,transfers@admin=360/h", roles)
+	require.NoError(t, err)
+	require.Equal(t, Limits{
+		"default":         {Rate: 20, Burst: 40},
+		"auth":            {Rate: 10.0 / 60, Burst: 10},
+		"transfers@admin": {Rate: 0.1, Burst: 360},
+	}, limits)
+
+	limits, err = ParseLimits("", roles)
+	require.NoError(t, err)
+	require.Empty(t, limits)
+
+	for _, s := range []string{
+		"default",
+		"default=20",
+		"default=20/d",
+		"default=0/s",
+		"default=20/s:0",
+		"default=x/s",
+		"unknown=1/s",
+		"default@auditor=1/s",
+		"auth=1/s,auth=2/s",
+	} {
+		_, err := ParseLimits(s, roles)
+		require.Error(t, err, s)
+	}
+}
+
+func TestLimitsFor(t *testing.T) {
+	limits, err := ParseLimits("default=10/s,default@admin=20/s,transfers=1/s,transfers@admin=5/s", roles)
+	require.NoError(t, err)
+
+	limit, ok := limits.For(GroupTransfers, "admin")
+	require.True(t, ok)
+	require.Equal(t, 5.0, limit.Rate)
+	limit, _ = limits.For(GroupTransfers, "customer")
+	require.Equal(t, 1.0, limit.Rate)
+	// a group without limits of its own falls back to the default ones
+	limit, _ = limits.For(GroupAuth, "admin")
+	require.Equal(t, 20.0, limit.Rate)
+	limit, _ = limits.For(GroupAuth, "")
+	require.Equal(t, 10.0, limit.Rate)
+
+	_, ok = Limits{}.For(GroupDefault, "admin")
+	require.False(t, ok)
+}
+
+func TestNewResult(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 10}
+
+	result := newResult(true, 3.5, limit)
+	require.Equal(t, Result{Allowed: true, Remaining: 3, Reset: 3250 * time.Millisecond}, result)
+
+	result = newResult(false, 0.5, limit)
+	require.False(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+	require.Equal(t, 250*time.Millisecond, result.RetryAfter)
+}
@@ -0,0 +1,82 @@
+// Package rbac holds the permission matrix, what each role of a user may do. the HTTP and gRPC APIs check the
+// role claimed by the access token against it before a handler runs
+package rbac
+
+import (
+	"slices"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+)
+
+// Permission is one thing a caller may do
+type Permission string
+
+// permissions over the caller's own resources, every role holds them
+const (
+	AccountsRead    Permission = "accounts:read"
+	AccountsCreate  Permission = "accounts:create"
+	TransfersRead   Permission = "transfers:read"
+	TransfersCreate Permission = "transfers:create"
+	WebhooksManage  Permission = "webhooks:manage"
+	KYCSubmit       Permission = "kyc:submit"
+	// TwoFactorManage is turning two-factor authentication on and off
+	TwoFactorManage Permission = "two_factor:manage"
+	// EmailVerify is asking for another link that verifies the email
+	EmailVerify Permission = "email:verify"
+	// APIKeysManage is issuing, rotating and revoking API keys for services acting as the user
+	APIKeysManage Permission = "api_keys:manage"
+)
+
+// staff permissions, over the resources of any user
+const (
+	// AccountsReadAny is reading the accounts of any user
+	AccountsReadAny Permission = "accounts:read_any"
+	// AccountsCreateAny is opening an account for another user
+	AccountsCreateAny Permission = "accounts:create_any"
+	AccountsFreeze    Permission = "accounts:freeze"
+	// AccountsAdjust is correcting a balance with an adjustment entry
+	AccountsAdjust   Permission = "accounts:adjust"
+	TransfersReverse Permission = "transfers:reverse"
+	// TransfersApprove is reviewing transfers held for approval
+	TransfersApprove     Permission = "transfers:approve"
+	RiskAlertsReview     Permission = "risk_alerts:review"
+	ScreeningCasesReview Permission = "screening_cases:review"
+	KYCReview            Permission = "kyc:review"
+	// UsersUnlock is lifting the lockout of a user after failed logins
+	UsersUnlock Permission = "users:unlock"
+	// AuditEventsRead is searching the audit log
+	AuditEventsRead Permission = "audit_events:read"
+)
+
+var own = []Permission{AccountsRead, AccountsCreate, TransfersRead, TransfersCreate, WebhooksManage, KYCSubmit, TwoFactorManage, EmailVerify, APIKeysManage}
+
+var teller = []Permission{AccountsReadAny, AccountsCreateAny}
+
+// matrix is what each role may do. tellers serve customers at the counter, approvers are the checkers of
+// maker-checker, admins do what tellers do and correct what went wrong. approving stays with approvers, so
+// that an admin reversing a transfer is never the one who let it through
+var matrix = map[string][]Permission{
+	db.UserRoleCustomer: own,
+	db.UserRoleTeller:   slices.Concat(own, teller),
+	db.UserRoleApprover: slices.Concat(own, []Permission{TransfersApprove}),
+	db.UserRoleAdmin: slices.Concat(own, teller, []Permission{
+		AccountsFreeze,
+		AccountsAdjust,
+		TransfersReverse,
+		RiskAlertsReview,
+		ScreeningCasesReview,
+		KYCReview,
+		UsersUnlock,
+		AuditEventsRead,
+	}),
+}
+
+// Can reports whether role holds permission, an unknown role holds nothing
+func Can(role string, permission Permission) bool {
+	return slices.Contains(matrix[role], permission)
+}
+
+// Roles are the roles of the matrix
+func Roles() []string {
+	return []string{db.UserRoleCustomer, db.UserRoleTeller, db.UserRoleApprover, db.UserRoleAdmin}
+}
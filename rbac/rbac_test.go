@@ -0,0 +1,61 @@
+package rbac
+
+import (
+	"testing"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCan(t *testing.T) {
+	for _, role := range Roles() {
+		for _, permission := range own {
+			require.True(t, Can(role, permission), "%s %s", role, permission)
+		}
+	}
+
+	require.False(t, Can(db.UserRoleCustomer, AccountsReadAny))
+	require.True(t, Can(db.UserRoleTeller, AccountsReadAny))
+	require.True(t, Can(db.UserRoleTeller, AccountsCreateAny))
+	require.False(t, Can(db.UserRoleTeller, AccountsFreeze))
+	require.True(t, Can(db.UserRoleApprover, TransfersApprove))
+	require.False(t, Can(db.UserRoleApprover, KYCReview))
+	require.True(t, Can(db.UserRoleAdmin, AccountsFreeze))
+	require.True(t, Can(db.UserRoleAdmin, AccountsAdjust))
+	require.False(t, Can(db.UserRoleTeller, AccountsAdjust))
+	require.True(t, Can(db.UserRoleAdmin, TransfersReverse))
+	require.True(t, Can(db.UserRoleAdmin, UsersUnlock))
+	require.False(t, Can(db.UserRoleTeller, UsersUnlock))
+	// approving is kept away from admins
+	require.False(t, Can(db.UserRoleAdmin, TransfersApprove))
+
+	require.False(t, Can("", AccountsRead))
+	require.False(t, Can("auditor", AccountsRead))
+}
+
+func TestPermits(t *testing.T) {
+	payload := &token.Payload{Username: "user", Role: db.UserRoleCustomer}
+	require.True(t, Permits(payload, TwoFactorManage))
+
+	// a key only holds what its scopes grant
+	payload.APIKeyID = 1
+	payload.Scopes = []string{string(ScopeAccountsRead), string(ScopeTransfersWrite)}
+	require.True(t, Permits(payload, AccountsRead))
+	require.True(t, Permits(payload, TransfersCreate))
+	require.False(t, Permits(payload, AccountsCreate))
+	require.False(t, Permits(payload, TwoFactorManage))
+	require.False(t, Permits(payload, APIKeysManage))
+	// and never more than the role of its user
+	require.False(t, Permits(payload, AccountsReadAny))
+	payload.Role = db.UserRoleTeller
+	require.True(t, Permits(payload, AccountsReadAny))
+
+	payload.Scopes = nil
+	require.False(t, Permits(payload, AccountsRead))
+
+	for _, scope := range Scopes() {
+		require.True(t, IsScope(string(scope)))
+	}
+	require.False(t, IsScope("accounts:freeze"))
+}
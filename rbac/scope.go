@@ -0,0 +1,53 @@
+package rbac
+
+import (
+	"slices"
+
+	"github.com/ShubhKanodia/GoBank/token"
+)
+
+// Scope is a slice of permissions an API key is issued with. a key only ever narrows what its user's role may
+// do, and permissions no scope grants, like managing keys or two-factor authentication, are never open to keys
+type Scope string
+
+const (
+	ScopeAccountsRead   Scope = "accounts:read"
+	ScopeAccountsWrite  Scope = "accounts:write"
+	ScopeTransfersRead  Scope = "transfers:read"
+	ScopeTransfersWrite Scope = "transfers:write"
+	ScopeWebhooksManage Scope = "webhooks:manage"
+)
+
+// scopes are the permissions each scope grants, the staff ones only help a key of a staff user
+var scopes = map[Scope][]Permission{
+	ScopeAccountsRead:   {AccountsRead, AccountsReadAny},
+	ScopeAccountsWrite:  {AccountsCreate, AccountsCreateAny},
+	ScopeTransfersRead:  {TransfersRead},
+	ScopeTransfersWrite: {TransfersCreate},
+	ScopeWebhooksManage: {WebhooksManage},
+}
+
+// Scopes are the scopes keys can be issued with
+func Scopes() []Scope {
+	return []Scope{ScopeAccountsRead, ScopeAccountsWrite, ScopeTransfersRead, ScopeTransfersWrite, ScopeWebhooksManage}
+}
+
+// IsScope reports whether s names a scope
+func IsScope(s string) bool {
+	_, ok := scopes[Scope(s)]
+	return ok
+}
+
+// Permits reports whether the caller of payload holds permission. their role must hold it, and when they call
+// with an API key one of the scopes of the key must grant it as well
+func Permits(payload *token.Payload, permission Permission) bool {
+	if !Can(payload.Role, permission) {
+		return false
+	}
+	if payload.APIKeyID == 0 {
+		return true
+	}
+	return slices.ContainsFunc(payload.Scopes, func(scope string) bool {
+		return slices.Contains(scopes[Scope(scope)], permission)
+	})
+}
@@ -0,0 +1,103 @@
+// Package risk screens transfers for fraud and money laundering patterns before they commit.
+// each rule looks at a transfer and the recent history of its accounts and answers allow, review or block,
+// the strictest answer wins
+package risk
+
+import "time"
+
+// Decision is what should happen to a transfer
+type Decision string
+
+const (
+	Allow Decision = "allow"
+	// Review lets the transfer through and raises an alert for someone to look at
+	Review Decision = "review"
+	// Block refuses the transfer and raises an alert
+	Block Decision = "block"
+)
+
+// stricter reports whether d weighs more than other
+func (d Decision) stricter(other Decision) bool {
+	severity := map[Decision]int{Allow: 0, Review: 1, Block: 2}
+	return severity[d] > severity[other]
+}
+
+// Transfer is a transfer about to commit and the history the rules judge it by
+type Transfer struct {
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        int64
+	Currency      string
+	// FromAccountOpened is when the from account was created
+	FromAccountOpened time.Time
+	At                time.Time
+	// Outgoing are the earlier transfers out of the from account, newest first
+	Outgoing []PastTransfer
+	// Returning are the earlier transfers from the to account into the from account, newest first
+	Returning []PastTransfer
+}
+
+// PastTransfer is an earlier transfer, within the lookback of the engine
+type PastTransfer struct {
+	ToAccountID int64
+	Amount      int64
+	At          time.Time
+}
+
+// Finding is what one rule said about a transfer
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason"`
+}
+
+// Rule is one check, rules must only look at the transfer they are given
+type Rule interface {
+	Name() string
+	// Evaluate returns Allow, or another decision and the reason for it
+	Evaluate(t Transfer) (Decision, string)
+}
+
+// Result is the decision on a transfer and the findings of the rules that did not allow it
+type Result struct {
+	Decision Decision
+	Findings []Finding
+}
+
+// Engine runs a set of rules over transfers
+type Engine struct {
+	lookback time.Duration
+	rules    []Rule
+}
+
+// NewEngine returns an engine running rules. lookback is how much history the rules get to see,
+// rules with a longer window only see that much of it
+func NewEngine(lookback time.Duration, rules ...Rule) *Engine {
+	return &Engine{lookback: lookback, rules: rules}
+}
+
+// NewDefaultEngine returns an engine running the built-in rules with their default settings
+func NewDefaultEngine() *Engine {
+	return NewEngine(24*time.Hour, DefaultRules()...)
+}
+
+// Lookback is how far back the history in a Transfer must reach
+func (e *Engine) Lookback() time.Duration {
+	return e.lookback
+}
+
+// Evaluate runs every rule over t
+func (e *Engine) Evaluate(t Transfer) Result {
+	result := Result{Decision: Allow}
+	for _, rule := range e.rules {
+		decision, reason := rule.Evaluate(t)
+		if decision == Allow {
+			continue
+		}
+		result.Findings = append(result.Findings, Finding{Rule: rule.Name(), Decision: decision, Reason: reason})
+		if decision.stricter(result.Decision) {
+			result.Decision = decision
+		}
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package risk
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultRules are the built-in rules with their default settings, amounts are in minor units of any currency
+func DefaultRules() []Rule {
+	return []Rule{
+		NewAccountOutflow{MaxAge: 7 * 24 * time.Hour, Amount: 100000},
+		FanOut{Window: time.Hour, MaxRecipients: 5},
+		Structuring{Threshold: 1000000, Margin: 100000, Window: 24 * time.Hour, Repeat: 3},
+		RoundTrip{Window: 24 * time.Hour, Tolerance: 10},
+	}
+}
+
+// NewAccountOutflow reviews large transfers out of accounts opened recently, the typical mule account
+// receives money and passes it on within days of being opened
+type NewAccountOutflow struct {
+	MaxAge time.Duration
+	// Amount is the smallest transfer that counts as large
+	Amount int64
+}
+
+func (NewAccountOutflow) Name() string { return "new_account_outflow" }
+
+func (r NewAccountOutflow) Evaluate(t Transfer) (Decision, string) {
+	age := t.At.Sub(t.FromAccountOpened)
+	if age >= r.MaxAge || t.Amount < r.Amount {
+		return Allow, ""
+	}
+	return Review, fmt.Sprintf("account opened %s ago sends %d", age.Round(time.Minute), t.Amount)
+}
+
+// FanOut reviews an account that pays many different recipients in a short time, how a mule spreads money out
+type FanOut struct {
+	Window        time.Duration
+	MaxRecipients int
+}
+
+func (FanOut) Name() string { return "fan_out" }
+
+func (r FanOut) Evaluate(t Transfer) (Decision, string) {
+	recipients := map[int64]bool{t.ToAccountID: true}
+	for _, past := range since(t.Outgoing, t.At.Add(-r.Window)) {
+		recipients[past.ToAccountID] = true
+	}
+	if len(recipients) <= r.MaxRecipients {
+		return Allow, ""
+	}
+	return Review, fmt.Sprintf("%d recipients within %s", len(recipients), r.Window)
+}
+
+// Structuring looks for amounts just under a reporting threshold. one is reviewed, Repeat of them within the window
+// are blocked, splitting a sum to stay under the threshold is how structuring works
+type Structuring struct {
+	Threshold int64
+	// Margin is how far under the threshold an amount counts as just under
+	Margin int64
+	Window time.Duration
+	Repeat int
+}
+
+func (Structuring) Name() string { return "structuring" }
+
+func (r Structuring) Evaluate(t Transfer) (Decision, string) {
+	if !r.justUnder(t.Amount) {
+		return Allow, ""
+	}
+	count := 1
+	for _, past := range since(t.Outgoing, t.At.Add(-r.Window)) {
+		if r.justUnder(past.Amount) {
+			count++
+		}
+	}
+	if count >= r.Repeat {
+		return Block, fmt.Sprintf("%d transfers just under %d within %s", count, r.Threshold, r.Window)
+	}
+	return Review, fmt.Sprintf("%d is just under %d", t.Amount, r.Threshold)
+}
+
+func (r Structuring) justUnder(amount int64) bool {
+	return amount < r.Threshold && amount >= r.Threshold-r.Margin
+}
+
+// RoundTrip reviews money sent back to the account it came from, at about the same amount, a way of layering funds
+type RoundTrip struct {
+	Window time.Duration
+	// Tolerance is how many percent the amounts may differ by
+	Tolerance int64
+}
+
+func (RoundTrip) Name() string { return "round_trip" }
+
+func (r RoundTrip) Evaluate(t Transfer) (Decision, string) {
+	for _, past := range since(t.Returning, t.At.Add(-r.Window)) {
+		diff := past.Amount - t.Amount
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff*100 <= past.Amount*r.Tolerance {
+			return Review, fmt.Sprintf("account [%d] sent %d to this account %s ago", t.ToAccountID, past.Amount, t.At.Sub(past.At).Round(time.Minute))
+		}
+	}
+	return Allow, ""
+}
+
+// since returns the transfers at or after start, transfers are newest first
+func since(transfers []PastTransfer, start time.Time) []PastTransfer {
+	for i, past := range transfers {
+		if past.At.Before(start) {
+			return transfers[:i]
+		}
+	}
+	return transfers
+}
@@ -0,0 +1,119 @@
+package risk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-365 * 24 * time.Hour)
+
+	testCases := []struct {
+		name     string
+		transfer Transfer
+		decision Decision
+		rules    []string
+	}{
+		{
+			name:     "Ordinary",
+			transfer: Transfer{ToAccountID: 2, Amount: 5000, FromAccountOpened: old, At: now},
+			decision: Allow,
+		},
+		{
+			name:     "NewAccountLargeOutflow",
+			transfer: Transfer{ToAccountID: 2, Amount: 200000, FromAccountOpened: now.Add(-time.Hour), At: now},
+			decision: Review,
+			rules:    []string{"new_account_outflow"},
+		},
+		{
+			name:     "NewAccountSmallOutflow",
+			transfer: Transfer{ToAccountID: 2, Amount: 5000, FromAccountOpened: now.Add(-time.Hour), At: now},
+			decision: Allow,
+		},
+		{
+			name: "FanOut",
+			transfer: Transfer{ToAccountID: 6, Amount: 100, FromAccountOpened: old, At: now, Outgoing: []PastTransfer{
+				{ToAccountID: 5, Amount: 100, At: now.Add(-time.Minute)},
+				{ToAccountID: 4, Amount: 100, At: now.Add(-2 * time.Minute)},
+				{ToAccountID: 3, Amount: 100, At: now.Add(-3 * time.Minute)},
+				{ToAccountID: 2, Amount: 100, At: now.Add(-4 * time.Minute)},
+				{ToAccountID: 1, Amount: 100, At: now.Add(-5 * time.Minute)},
+			}},
+			decision: Review,
+			rules:    []string{"fan_out"},
+		},
+		{
+			name: "FanOutOutsideWindow",
+			transfer: Transfer{ToAccountID: 6, Amount: 100, FromAccountOpened: old, At: now, Outgoing: []PastTransfer{
+				{ToAccountID: 5, Amount: 100, At: now.Add(-time.Minute)},
+				{ToAccountID: 4, Amount: 100, At: now.Add(-2 * time.Minute)},
+				{ToAccountID: 3, Amount: 100, At: now.Add(-2 * time.Hour)},
+				{ToAccountID: 2, Amount: 100, At: now.Add(-3 * time.Hour)},
+				{ToAccountID: 1, Amount: 100, At: now.Add(-4 * time.Hour)},
+			}},
+			decision: Allow,
+		},
+		{
+			name:     "JustUnderThreshold",
+			transfer: Transfer{ToAccountID: 2, Amount: 950000, FromAccountOpened: old, At: now},
+			decision: Review,
+			rules:    []string{"structuring"},
+		},
+		{
+			name: "RepeatedlyJustUnderThreshold",
+			transfer: Transfer{ToAccountID: 2, Amount: 950000, FromAccountOpened: old, At: now, Outgoing: []PastTransfer{
+				{ToAccountID: 3, Amount: 990000, At: now.Add(-time.Hour)},
+				{ToAccountID: 4, Amount: 920000, At: now.Add(-2 * time.Hour)},
+			}},
+			decision: Block,
+			rules:    []string{"structuring"},
+		},
+		{
+			name:     "AtThreshold",
+			transfer: Transfer{ToAccountID: 2, Amount: 1000000, FromAccountOpened: old, At: now},
+			decision: Allow,
+		},
+		{
+			name: "RoundTrip",
+			transfer: Transfer{ToAccountID: 2, Amount: 48000, FromAccountOpened: old, At: now, Returning: []PastTransfer{
+				{ToAccountID: 1, Amount: 50000, At: now.Add(-time.Hour)},
+			}},
+			decision: Review,
+			rules:    []string{"round_trip"},
+		},
+		{
+			name: "PartialRepayment",
+			transfer: Transfer{ToAccountID: 2, Amount: 10000, FromAccountOpened: old, At: now, Returning: []PastTransfer{
+				{ToAccountID: 1, Amount: 50000, At: now.Add(-time.Hour)},
+			}},
+			decision: Allow,
+		},
+		{
+			name: "StrictestWins",
+			transfer: Transfer{ToAccountID: 2, Amount: 950000, FromAccountOpened: now.Add(-time.Hour), At: now, Outgoing: []PastTransfer{
+				{ToAccountID: 3, Amount: 990000, At: now.Add(-time.Hour)},
+				{ToAccountID: 4, Amount: 920000, At: now.Add(-2 * time.Hour)},
+			}},
+			decision: Block,
+			rules:    []string{"new_account_outflow", "structuring"},
+		},
+	}
+
+	engine := NewDefaultEngine()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := engine.Evaluate(tc.transfer)
+			require.Equal(t, tc.decision, result.Decision)
+
+			var rules []string
+			for _, finding := range result.Findings {
+				require.NotEmpty(t, finding.Reason)
+				rules = append(rules, finding.Rule)
+			}
+			require.Equal(t, tc.rules, rules)
+		})
+	}
+}
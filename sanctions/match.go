@@ -0,0 +1,110 @@
+package sanctions
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// tokens normalizes a name into its words: lower case, accents and punctuation dropped. the order of the words
+// is kept but does not matter to the score, lists write "SMITH, John" where users write "John Smith"
+func tokens(name string) []string {
+	stripAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	plain, _, err := transform.String(stripAccents, name)
+	if err != nil {
+		plain = name
+	}
+	return strings.FieldsFunc(strings.ToLower(plain), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func prefixOf(token string) string {
+	r := []rune(token)
+	if len(r) > 2 {
+		r = r[:2]
+	}
+	return string(r)
+}
+
+// score is how alike two names are, from 0 to 1. every word of each name is paired with its closest word in the
+// other by Jaro-Winkler, the score averages both directions, so extra words on either side weigh it down
+func score(a []string, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	return (closest(a, b) + closest(b, a)) / 2
+}
+
+// closest averages, over the words of a, the similarity of the closest word in b
+func closest(a []string, b []string) float64 {
+	var sum float64
+	for _, wordA := range a {
+		var best float64
+		for _, wordB := range b {
+			best = max(best, jaroWinkler(wordA, wordB))
+		}
+		sum += best
+	}
+	return sum / float64(len(a))
+}
+
+// jaroWinkler is the Jaro similarity of a and b raised for a common prefix of up to four characters
+func jaroWinkler(a string, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	jaro := jaroSimilarity(ra, rb)
+
+	prefix := 0
+	for prefix < min(4, len(ra), len(rb)) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+func jaroSimilarity(a []rune, b []rune) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	// characters match when equal and no further apart than half the longer word
+	window := max(max(len(a), len(b))/2-1, 0)
+	matchedA := make([]bool, len(a))
+	matchedB := make([]bool, len(b))
+	matches := 0
+	for i := range a {
+		for j := max(0, i-window); j < min(len(b), i+window+1); j++ {
+			if !matchedB[j] && a[i] == b[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// transpositions are matched characters out of order, counted in halves
+	transpositions, j := 0, 0
+	for i := range a {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	return (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+}
@@ -0,0 +1,59 @@
+package sanctions
+
+import (
+	"context"
+
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+)
+
+// rescreenPageSize is how many users are read from the database at once
+const rescreenPageSize = 500
+
+// RescreenStore is what screening existing users needs from the database, db.Store implements it
+type RescreenStore interface {
+	ListUsersForScreening(ctx context.Context, arg db.ListUsersForScreeningParams) ([]db.ListUsersForScreeningRow, error)
+	RecordScreeningTx(ctx context.Context, username string, cases []db.CreateScreeningCaseParams) (db.User, []db.ScreeningCase, error)
+}
+
+// RescreenResult counts what a rescreen found
+type RescreenResult struct {
+	Screened int
+	// Matched is how many users matched at least one entry, NewCases how many matches were not on record yet
+	Matched  int
+	NewCases int
+	// Blocked is how many of the matched users are blocked now
+	Blocked int
+}
+
+// Rescreen screens every user again, run it whenever the list changes. matches already on record, open or
+// resolved, are left alone. a user who no longer matches keeps their open cases until someone clears them
+func (s *Screener) Rescreen(ctx context.Context, store RescreenStore) (RescreenResult, error) {
+	var result RescreenResult
+	after := ""
+	for {
+		users, err := store.ListUsersForScreening(ctx, db.ListUsersForScreeningParams{After: after, PageSize: rescreenPageSize})
+		if err != nil {
+			return result, err
+		}
+		for _, user := range users {
+			result.Screened++
+			screening := s.Screen(user.FullName)
+			if screening.Decision == Clear {
+				continue
+			}
+			result.Matched++
+			screened, created, err := store.RecordScreeningTx(ctx, user.Username, screening.cases(user.FullName, db.ScreeningSourceRescreen))
+			if err != nil {
+				return result, err
+			}
+			result.NewCases += len(created)
+			if screened.SanctionsStatus == db.SanctionsBlocked {
+				result.Blocked++
+			}
+		}
+		if len(users) < rescreenPageSize {
+			return result, nil
+		}
+		after = users[len(users)-1].Username
+	}
+}
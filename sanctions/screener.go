@@ -0,0 +1,128 @@
+package sanctions
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+)
+
+// Decision is what screening a name concluded
+type Decision string
+
+const (
+	Clear Decision = "clear"
+	// Review is a weak match, someone has to look at it
+	Review Decision = "review"
+	// Block is a strong match
+	Block Decision = "block"
+)
+
+// Match is an entry of the list a name resembles
+type Match struct {
+	Entry    Entry
+	Score    float64
+	Decision Decision
+}
+
+// Result is the decision on a name and the entries it matched, best match first
+type Result struct {
+	Decision Decision
+	Matches  []Match
+}
+
+// Screener matches names against a list. a score of at least blockScore is a strong match,
+// one of at least reviewScore a weak one
+type Screener struct {
+	list        *List
+	blockScore  float64
+	reviewScore float64
+}
+
+func NewScreener(list *List, blockScore float64, reviewScore float64) *Screener {
+	return &Screener{
+		list:        list,
+		blockScore:  blockScore,
+		reviewScore: reviewScore,
+	}
+}
+
+// Screen matches name against every entry sharing the first two letters of one of its words,
+// names without a word starting alike practically never reach the review score
+func (s *Screener) Screen(name string) Result {
+	result := Result{Decision: Clear}
+	words := tokens(name)
+	seen := map[int]bool{}
+	for _, word := range words {
+		for _, i := range s.list.byPrefix[prefixOf(word)] {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+
+			entry := s.list.entries[i]
+			match := Match{Entry: entry, Score: score(words, entry.tokens)}
+			switch {
+			case match.Score >= s.blockScore:
+				match.Decision = Block
+				result.Decision = Block
+			case match.Score >= s.reviewScore:
+				match.Decision = Review
+				if result.Decision == Clear {
+					result.Decision = Review
+				}
+			default:
+				continue
+			}
+			result.Matches = append(result.Matches, match)
+		}
+	}
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		return result.Matches[i].Score > result.Matches[j].Score
+	})
+	return result
+}
+
+// cases turns the matches of fullName into screening cases, the username is filled in when recorded
+func (r Result) cases(fullName string, source string) []db.CreateScreeningCaseParams {
+	cases := make([]db.CreateScreeningCaseParams, 0, len(r.Matches))
+	for _, match := range r.Matches {
+		cases = append(cases, db.CreateScreeningCaseParams{
+			FullName:  fullName,
+			EntryID:   match.Entry.ID,
+			EntryName: match.Entry.Name,
+			Program:   match.Entry.Program,
+			Score:     match.Score,
+			Decision:  string(match.Decision),
+			Source:    source,
+		})
+	}
+	return cases
+}
+
+// OnboardingStore is what onboarding needs from the database, db.Store implements it
+type OnboardingStore interface {
+	CreateUserTx(ctx context.Context, arg db.CreateUserTxParams) (db.User, error)
+	CreateScreeningCase(ctx context.Context, arg db.CreateScreeningCaseParams) (db.ScreeningCase, error)
+}
+
+// Onboard screens the name of a new user before creating it. a strong match refuses the user and records the
+// case without a username. otherwise the user is created with the cases of its weak matches, in review if there
+// are any, in the transaction that also audits its creation
+func (s *Screener) Onboard(ctx context.Context, store OnboardingStore, arg db.CreateUserParams) (db.User, error) {
+	result := s.Screen(arg.FullName)
+	if result.Decision == Block {
+		for _, screeningCase := range result.cases(arg.FullName, db.ScreeningSourceOnboarding) {
+			if _, err := store.CreateScreeningCase(ctx, screeningCase); err != nil {
+				return db.User{}, err
+			}
+		}
+		// nothing about the match, the applicant must not learn they are on a list
+		return db.User{}, apperror.Forbidden("the user cannot be created")
+	}
+	return store.CreateUserTx(ctx, db.CreateUserTxParams{
+		CreateUserParams: arg,
+		Cases:            result.cases(arg.FullName, db.ScreeningSourceOnboarding),
+	})
+}
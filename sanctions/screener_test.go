@@ -0,0 +1,163 @@
+package sanctions
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestScreener(t *testing.T) *Screener {
+	list, err := LoadFile("testdata/sdn.csv")
+	require.NoError(t, err)
+	return NewScreener(list, 0.97, 0.88)
+}
+
+func TestLoadSDN(t *testing.T) {
+	list, err := LoadFile("testdata/sdn.csv")
+	require.NoError(t, err)
+	// the vessel is skipped
+	require.Equal(t, 4, list.Len())
+
+	empty, err := LoadFile("")
+	require.NoError(t, err)
+	require.Zero(t, empty.Len())
+}
+
+func TestJaroWinkler(t *testing.T) {
+	require.Equal(t, 1.0, jaroWinkler("smith", "smith"))
+	require.Zero(t, jaroWinkler("abc", "xyz"))
+	require.InDelta(t, 0.961, jaroWinkler("martha", "marhta"), 0.001)
+	require.InDelta(t, 0.813, jaroWinkler("dixon", "dicksonx"), 0.001)
+}
+
+func TestScreen(t *testing.T) {
+	screener := newTestScreener(t)
+
+	testCases := []struct {
+		name     string
+		fullName string
+		decision Decision
+		entryID  string
+	}{
+		{name: "ListOrder", fullName: "Ivan Sergeyevich Petrov", decision: Block, entryID: "6365"},
+		{name: "Accents", fullName: "Hans Jurgen Muller", decision: Block, entryID: "2674"},
+		{name: "Misspelled", fullName: "Ivan Sergeevich Petrow", decision: Block, entryID: "6365"},
+		{name: "SimilarName", fullName: "Ivana Petrova", decision: Review, entryID: "6365"},
+		{name: "PartialName", fullName: "Ivan Petrov", decision: Review, entryID: "6365"},
+		{name: "Entity", fullName: "Abu Nidal Organisation", decision: Block, entryID: "173"},
+		{name: "Unrelated", fullName: "Jane Doe", decision: Clear},
+		{name: "SameFirstName", fullName: "Ivan Smith", decision: Clear},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := screener.Screen(tc.fullName)
+			require.Equal(t, tc.decision, result.Decision)
+			if tc.decision == Clear {
+				require.Empty(t, result.Matches)
+				return
+			}
+			require.Equal(t, tc.entryID, result.Matches[0].Entry.ID)
+		})
+	}
+}
+
+func TestOnboard(t *testing.T) {
+	screener := newTestScreener(t)
+
+	testCases := []struct {
+		name       string
+		fullName   string
+		buildStubs func(store *mockdb.MockStore)
+		check      func(t *testing.T, user db.User, err error)
+	}{
+		{
+			name:     "Clear",
+			fullName: "Jane Doe",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.User, error) {
+						require.Empty(t, arg.Cases)
+						return db.User{Username: "jane"}, nil
+					})
+			},
+			check: func(t *testing.T, user db.User, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "jane", user.Username)
+			},
+		},
+		{
+			name:     "WeakMatch",
+			fullName: "Ivan Petrov",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.User, error) {
+						require.Len(t, arg.Cases, 1)
+						require.Equal(t, "6365", arg.Cases[0].EntryID)
+						require.Equal(t, string(Review), arg.Cases[0].Decision)
+						require.Equal(t, db.ScreeningSourceOnboarding, arg.Cases[0].Source)
+						return db.User{Username: arg.Username, SanctionsStatus: db.SanctionsReview}, nil
+					})
+			},
+			check: func(t *testing.T, user db.User, err error) {
+				require.NoError(t, err)
+				require.Equal(t, db.SanctionsReview, user.SanctionsStatus)
+			},
+		},
+		{
+			name:     "StrongMatch",
+			fullName: "Petrov, Ivan Sergeyevich",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateScreeningCase(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateScreeningCaseParams) (db.ScreeningCase, error) {
+						require.False(t, arg.Username.Valid)
+						require.Equal(t, string(Block), arg.Decision)
+						return db.ScreeningCase{ID: 1}, nil
+					})
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, user db.User, err error) {
+				require.ErrorIs(t, err, apperror.ErrForbidden)
+				require.NotContains(t, err.Error(), "sanction")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			user, err := screener.Onboard(context.Background(), store, db.CreateUserParams{
+				Username: "someone",
+				FullName: tc.fullName,
+			})
+			tc.check(t, user, err)
+		})
+	}
+}
+
+func TestRescreen(t *testing.T) {
+	screener := newTestScreener(t)
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().ListUsersForScreening(gomock.Any(), gomock.Eq(db.ListUsersForScreeningParams{PageSize: rescreenPageSize})).Times(1).
+		Return([]db.ListUsersForScreeningRow{
+			{Username: "ivan", FullName: "Ivan Sergeyevich Petrov"},
+			{Username: "jane", FullName: "Jane Doe"},
+		}, nil)
+	store.EXPECT().RecordScreeningTx(gomock.Any(), gomock.Eq("ivan"), gomock.Any()).Times(1).
+		Return(db.User{Username: "ivan", SanctionsStatus: db.SanctionsBlocked}, []db.ScreeningCase{{ID: 1, Username: sql.NullString{String: "ivan", Valid: true}}}, nil)
+
+	result, err := screener.Rescreen(context.Background(), store)
+	require.NoError(t, err)
+	require.Equal(t, RescreenResult{Screened: 2, Matched: 1, NewCases: 1, Blocked: 1}, result)
+}
@@ -0,0 +1,139 @@
+// Package scheduler executes scheduled transfers and standing orders once they are due,
+// and expires transfers held for approval that nobody reviewed in time
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/metrics"
+)
+
+// Scheduler polls for due scheduled transfers and standing orders. several schedulers, in one or many replicas,
+// can run against the same tables, each due transfer is executed by exactly one of them
+type Scheduler struct {
+	store        db.Store
+	pollInterval time.Duration
+}
+
+func NewScheduler(store db.Store, pollInterval time.Duration) *Scheduler {
+	return &Scheduler{
+		store:        store,
+		pollInterval: pollInterval,
+	}
+}
+
+// Run executes due transfers until ctx is done, waiting pollInterval whenever nothing is due
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		if _, err := s.ExecuteDue(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "scheduled transfers failed", slog.String("error", err.Error()))
+		}
+		if _, err := s.ExecuteDueStandingOrders(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "standing orders failed", slog.String("error", err.Error()))
+		}
+		if _, err := s.ExpireTransferApprovals(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "expiring transfer approvals failed", slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+// ExecuteDue executes every transfer that is due, one transaction each, and returns how many it executed.
+// a transfer that fails for another reason than a rejection is retried later and the run goes on with the next one.
+// it stops at the first error that comes without a transfer, when even the retry could not be recorded
+func (s *Scheduler) ExecuteDue(ctx context.Context) (int, error) {
+	for n := 0; ; {
+		if ctx.Err() != nil {
+			return n, ctx.Err()
+		}
+		scheduled, err := s.store.ExecuteScheduledTransferTx(ctx)
+		if errors.Is(db.TranslateError(err), apperror.ErrNotFound) {
+			return n, nil
+		}
+		if err != nil && scheduled.ID == 0 {
+			return n, err
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "scheduled transfer failed, retrying later",
+				slog.Int64("scheduled_transfer_id", scheduled.ID),
+				slog.Int("attempts", int(scheduled.Attempts)),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		n++
+
+		metrics.ScheduledTransfers.WithLabelValues(scheduled.Status).Inc()
+		attrs := []any{
+			slog.Int64("scheduled_transfer_id", scheduled.ID),
+			slog.String("status", scheduled.Status),
+		}
+		if scheduled.Status != db.ScheduledTransferSucceeded {
+			slog.WarnContext(ctx, "scheduled transfer not executed", append(attrs, slog.String("reason", scheduled.FailureReason.String))...)
+			continue
+		}
+		metrics.TransfersCreated.WithLabelValues(scheduled.Currency).Inc()
+		metrics.TransferAmount.WithLabelValues(scheduled.Currency).Add(float64(scheduled.Amount))
+		slog.InfoContext(ctx, "scheduled transfer executed", append(attrs, slog.Int64("transfer_id", scheduled.TransferID.Int64))...)
+	}
+}
+
+// ExecuteDueStandingOrders runs the next occurrence of every standing order that is due, one transaction each,
+// and returns how many occurrences it ran. an order behind by several occurrences catches up one run at a time
+func (s *Scheduler) ExecuteDueStandingOrders(ctx context.Context) (int, error) {
+	for n := 0; ; n++ {
+		if ctx.Err() != nil {
+			return n, ctx.Err()
+		}
+		result, err := s.store.ExecuteStandingOrderTx(ctx)
+		if errors.Is(db.TranslateError(err), apperror.ErrNotFound) {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		occurrence := result.Occurrence
+		metrics.StandingOrderOccurrences.WithLabelValues(occurrence.Status).Inc()
+		attrs := []any{
+			slog.Int64("standing_order_id", result.Order.ID),
+			slog.Int("sequence", int(occurrence.Sequence)),
+			slog.String("status", occurrence.Status),
+			slog.String("order_status", result.Order.Status),
+		}
+		if occurrence.Status != db.OccurrenceSucceeded {
+			slog.WarnContext(ctx, "standing order occurrence not paid", append(attrs, slog.String("reason", occurrence.FailureReason.String))...)
+			continue
+		}
+		metrics.TransfersCreated.WithLabelValues(result.Order.Currency).Inc()
+		metrics.TransferAmount.WithLabelValues(result.Order.Currency).Add(float64(result.Order.Amount))
+		slog.InfoContext(ctx, "standing order occurrence paid", append(attrs, slog.Int64("transfer_id", occurrence.TransferID.Int64))...)
+	}
+}
+
+// ExpireTransferApprovals expires the transfers held for approval whose time ran out and returns how many it expired.
+// approving checks the expiry itself, this only makes the status say so
+func (s *Scheduler) ExpireTransferApprovals(ctx context.Context) (int, error) {
+	expired, err := s.store.ExpireTransferApprovals(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, approval := range expired {
+		metrics.TransferApprovals.WithLabelValues(approval.Status).Inc()
+		slog.InfoContext(ctx, "transfer approval expired",
+			slog.Int64("transfer_approval_id", approval.ID),
+			slog.String("initiator", approval.Initiator),
+		)
+	}
+	return len(expired), nil
+}
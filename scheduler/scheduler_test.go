@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExecuteDue(t *testing.T) {
+	succeeded := randomScheduledTransfer(db.ScheduledTransferSucceeded)
+	succeeded.TransferID = sql.NullInt64{Int64: 1, Valid: true}
+	failed := randomScheduledTransfer(db.ScheduledTransferFailed)
+	failed.FailureReason = sql.NullString{String: "account [1] has insufficient funds", Valid: true}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	// a rejected transfer does not stop the run, running out of due transfers does
+	gomock.InOrder(
+		store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).Return(succeeded, nil),
+		store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).Return(failed, nil),
+		store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).Return(db.ScheduledTransfer{}, sql.ErrNoRows),
+	)
+
+	n, err := NewScheduler(store, time.Second).ExecuteDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+}
+
+func TestExecuteDueError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).Return(db.ScheduledTransfer{}, sql.ErrConnDone)
+
+	n, err := NewScheduler(store, time.Second).ExecuteDue(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.NotErrorIs(t, err, apperror.ErrNotFound)
+	require.Zero(t, n)
+}
+
+func TestExecuteDueRetrying(t *testing.T) {
+	broken := randomScheduledTransfer(db.ScheduledTransferScheduled)
+	broken.Attempts = 1
+	broken.RetryAt = sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}
+	succeeded := randomScheduledTransfer(db.ScheduledTransferSucceeded)
+	succeeded.TransferID = sql.NullInt64{Int64: 1, Valid: true}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	scheduler := NewScheduler(store, time.Second)
+	// a transfer that keeps failing with a database error does not hold up the ones due after it, run after run
+	for run := 0; run < 3; run++ {
+		gomock.InOrder(
+			store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).Return(broken, sql.ErrConnDone),
+			store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).Return(succeeded, nil),
+			store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).Return(db.ScheduledTransfer{}, sql.ErrNoRows),
+		)
+
+		n, err := scheduler.ExecuteDue(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+	}
+}
+
+func randomScheduledTransfer(status string) db.ScheduledTransfer {
+	return db.ScheduledTransfer{
+		ID:            util.RandomInt(1, 1000),
+		Owner:         util.RandomOwner(),
+		FromAccountID: util.RandomInt(1, 1000),
+		ToAccountID:   util.RandomInt(1, 1000),
+		Amount:        util.RandomMoney(),
+		Currency:      util.RandomCurrency(),
+		ExecuteAt:     time.Now(),
+		Status:        status,
+	}
+}
+
+func TestExecuteDueStandingOrders(t *testing.T) {
+	paid := db.ExecuteStandingOrderTxResult{
+		Order:      db.StandingOrder{ID: 1, Currency: util.USD, Amount: 10, Status: db.StandingOrderActive},
+		Occurrence: db.StandingOrderOccurrence{StandingOrderID: 1, Status: db.OccurrenceSucceeded},
+	}
+	retrying := db.ExecuteStandingOrderTxResult{
+		Order:      db.StandingOrder{ID: 2, Currency: util.USD, Amount: 10, Status: db.StandingOrderActive},
+		Occurrence: db.StandingOrderOccurrence{StandingOrderID: 2, Status: db.OccurrenceRetrying},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any()).Times(1).Return(paid, nil),
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any()).Times(1).Return(retrying, nil),
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any()).Times(1).Return(db.ExecuteStandingOrderTxResult{}, apperror.NotFound("record not found")),
+	)
+
+	n, err := NewScheduler(store, time.Second).ExecuteDueStandingOrders(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+}
+
+func TestExpireTransferApprovals(t *testing.T) {
+	expired := []db.TransferApproval{
+		{ID: 1, Initiator: util.RandomOwner(), Status: db.TransferApprovalExpired},
+		{ID: 2, Initiator: util.RandomOwner(), Status: db.TransferApprovalExpired},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ExpireTransferApprovals(gomock.Any()).Times(1).Return(expired, nil)
+
+	n, err := NewScheduler(store, time.Second).ExpireTransferApprovals(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+}
@@ -0,0 +1,87 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// minSecretKeySize keeps HS256 keys at least as long as the hash output
+const minSecretKeySize = 32
+
+// JWTMaker is a JSON Web Token maker using HS256
+type JWTMaker struct {
+	secretKey string
+}
+
+// jwtClaims maps Payload onto the registered JWT claims
+type jwtClaims struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// NewJWTMaker creates a new JWTMaker
+func NewJWTMaker(secretKey string) (Maker, error) {
+	if len(secretKey) < minSecretKeySize {
+		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
+	}
+	return &JWTMaker{secretKey}, nil
+}
+
+// CreateToken creates a new token for a specific username, role and duration
+func (maker *JWTMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	claims := jwtClaims{
+		Username: payload.Username,
+		Role:     payload.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        payload.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(payload.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(payload.ExpiredAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(maker.secretKey))
+	return token, payload, err
+}
+
+// VerifyToken checks if the token is valid and returns the payload it carries
+func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			// rejects "alg": "none" and asymmetric algorithms signed with our key as public key
+			return nil, ErrInvalidToken
+		}
+		return []byte(maker.secretKey), nil
+	}
+
+	var claims jwtClaims
+	_, err := jwt.ParseWithClaims(token, &claims, keyFunc, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	tokenID, err := uuid.Parse(claims.ID)
+	// tokens issued before roles were claimed have none, they must log in again
+	if err != nil || claims.IssuedAt == nil || claims.ExpiresAt == nil || claims.Role == "" {
+		return nil, ErrInvalidToken
+	}
+	payload := &Payload{
+		ID:        tokenID,
+		Username:  claims.Username,
+		Role:      claims.Role,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiredAt: claims.ExpiresAt.Time,
+	}
+	return payload, payload.Valid()
+}
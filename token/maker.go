@@ -0,0 +1,12 @@
+// Package token creates and verifies the access tokens used by the HTTP and gRPC APIs.
+package token
+
+import "time"
+
+// Maker is an interface for managing tokens
+type Maker interface {
+	// CreateToken creates a new token for a specific username, role and duration
+	CreateToken(username string, role string, duration time.Duration) (string, *Payload, error)
+	// VerifyToken checks if the token is valid and returns the payload it carries
+	VerifyToken(token string) (*Payload, error)
+}
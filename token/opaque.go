@@ -0,0 +1,27 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// opaqueSize is the length of an opaque token in random bytes
+const opaqueSize = 32
+
+// NewOpaque returns a random token that carries nothing, for links sent by email. only its HashOpaque is
+// stored, so that a leak of the database does not leak usable tokens
+func NewOpaque() (string, error) {
+	raw := make([]byte, opaqueSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// HashOpaque is how an opaque token is stored and looked up
+func HashOpaque(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,20 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpaque(t *testing.T) {
+	token1, err := NewOpaque()
+	require.NoError(t, err)
+	token2, err := NewOpaque()
+	require.NoError(t, err)
+
+	require.Len(t, token1, 43)
+	require.NotEqual(t, token1, token2)
+	require.Equal(t, HashOpaque(token1), HashOpaque(token1))
+	require.NotEqual(t, HashOpaque(token1), HashOpaque(token2))
+	require.NotContains(t, HashOpaque(token1), token1)
+}
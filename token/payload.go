@@ -0,0 +1,54 @@
+package token
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Different types of error returned by the VerifyToken function
+var (
+	ErrInvalidToken = errors.New("token is invalid")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// Payload contains the payload data of the token
+type Payload struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	// Role is the role of the user when the token was issued, a change of role applies from their next login
+	Role      string    `json:"role"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+	// APIKeyID is the API key the caller authenticated with, zero for access tokens
+	APIKeyID int64 `json:"api_key_id,omitempty"`
+	// Scopes are the scopes of the API key, see rbac.Permits
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// NewPayload creates a new token payload with a specific username, role and duration
+func NewPayload(username string, role string, duration time.Duration) (*Payload, error) {
+	tokenID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	payload := &Payload{
+		ID:        tokenID,
+		Username:  username,
+		Role:      role,
+		IssuedAt:  now,
+		ExpiredAt: now.Add(duration),
+	}
+	return payload, nil
+}
+
+// Valid checks if the token payload has expired
+func (payload *Payload) Valid() error {
+	if time.Now().After(payload.ExpiredAt) {
+		return ErrExpiredToken
+	}
+	return nil
+}
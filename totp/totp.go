@@ -0,0 +1,137 @@
+// Package totp implements the time-based one-time passwords of RFC 6238 that authenticator apps show, and the
+// recovery codes users keep for when they lose the device holding the secret
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period is how long a code stays on the screen of the app
+	Period = 30 * time.Second
+	// Digits is the length of a code
+	Digits = 6
+	// skew is how many periods a code may be late or early, clocks of phones drift
+	skew = 1
+	// secretSize is the length of a secret in bytes, the 160 bits RFC 4226 recommends
+	secretSize = 20
+	// RecoveryCodeCount is how many recovery codes a user gets at once
+	RecoveryCodeCount = 10
+)
+
+// encoding is how secrets are written for the apps, base32 without padding
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ErrInvalidSecret is returned for a secret that is not base32
+var ErrInvalidSecret = errors.New("invalid totp secret")
+
+// GenerateSecret returns a new random secret, base32 encoded
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// URI is the otpauth URI of secret, the content of the QR code apps scan to add the account
+func URI(issuer string, account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Step is the time step t falls in, the counter codes are computed from
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// Code is the code of secret for a time step
+func Code(secret string, step int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", ErrInvalidSecret
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation of RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1_000_000), nil
+}
+
+// Validate checks code against secret at time t and returns the step it matched. a code is accepted one step
+// late or early, but never for a step at or before lastStep: each code can be used once
+func Validate(secret string, code string, t time.Time, lastStep int64) (int64, bool) {
+	if len(code) != Digits {
+		return 0, false
+	}
+	now := Step(t)
+	for step := now - skew; step <= now+skew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		want, err := Code(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// recoveryAlphabet leaves out the letters and digits that are easy to mix up when read from paper
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// GenerateRecoveryCodes returns RecoveryCodeCount new recovery codes, written as xxxxx-xxxxx
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		var code strings.Builder
+		for j, b := range raw {
+			if j == 5 {
+				code.WriteByte('-')
+			}
+			// 256 is not a multiple of the alphabet, the bias this leaves is too small to matter
+			code.WriteByte(recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+		}
+		codes[i] = code.String()
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode is how a recovery code is stored. the codes are random enough that a plain SHA-256 cannot
+// be reversed, and unlike a password hash it lets the code be looked up. case and dashes do not matter
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,92 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 key of the test vectors of RFC 6238
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// the vectors of RFC 6238 appendix B, cut to the last 6 of their 8 digits
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectors {
+		code, err := Code(rfcSecret, Step(time.Unix(v.unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, v.code, code, "at %d", v.unix)
+	}
+
+	_, err := Code("not base32!", 1)
+	require.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	now := time.Now()
+	step := Step(now)
+
+	code, err := Code(secret, step)
+	require.NoError(t, err)
+	got, ok := Validate(secret, code, now, 0)
+	require.True(t, ok)
+	require.Equal(t, step, got)
+	// the same code a second time is a replay
+	_, ok = Validate(secret, code, now, got)
+	require.False(t, ok)
+
+	late, err := Code(secret, step-1)
+	require.NoError(t, err)
+	_, ok = Validate(secret, late, now, 0)
+	require.True(t, ok)
+
+	tooLate, err := Code(secret, step-2)
+	require.NoError(t, err)
+	_, ok = Validate(secret, tooLate, now, 0)
+	require.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now, 0)
+	require.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("GoBank", "alice@example.com", "JBSWY3DPEHPK3PXP")
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", parsed.Scheme)
+	require.Equal(t, "totp", parsed.Host)
+	require.Equal(t, "/GoBank:alice@example.com", parsed.Path)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", parsed.Query().Get("secret"))
+	require.Equal(t, "GoBank", parsed.Query().Get("issuer"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, RecoveryCodeCount)
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		require.Regexp(t, `^[a-z2-9]{5}-[a-z2-9]{5}$`, code)
+		require.False(t, seen[code])
+		seen[code] = true
+	}
+
+	require.Equal(t, HashRecoveryCode(codes[0]), HashRecoveryCode(" "+codes[0][:5]+codes[0][6:]+" "))
+	require.NotEqual(t, HashRecoveryCode(codes[0]), HashRecoveryCode(codes[1]))
+}
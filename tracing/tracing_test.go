@@ -0,0 +1,39 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	config := util.Config{
+		TracingExporter:    ExporterFile,
+		TracingFilePath:    path,
+		TracingSampleRatio: 1,
+	}
+
+	shutdown, err := Setup(context.Background(), config)
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+
+	// spans are batched, shutdown flushes them to the file
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "test-span")
+}
+
+func TestSetupUnsupportedExporter(t *testing.T) {
+	_, err := Setup(context.Background(), util.Config{TracingExporter: "zipkin"})
+	require.Error(t, err)
+}
@@ -0,0 +1,16 @@
+package util
+
+import "time"
+
+// Backoff returns the delay before retrying after the given failed attempt (counting from 1):
+// min for the first, doubling each time, never more than max
+func Backoff(attempt int32, min time.Duration, max time.Duration) time.Duration {
+	delay := min
+	for i := int32(1); i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
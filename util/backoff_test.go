@@ -0,0 +1,19 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	min, max := time.Second, 10*time.Minute
+
+	require.Equal(t, min, Backoff(0, min, max))
+	require.Equal(t, min, Backoff(1, min, max))
+	require.Equal(t, 2*min, Backoff(2, min, max))
+	require.Equal(t, 8*min, Backoff(4, min, max))
+	require.Equal(t, max, Backoff(30, min, max))
+	require.Equal(t, max, Backoff(1000, min, max))
+}
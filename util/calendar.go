@@ -0,0 +1,72 @@
+package util
+
+import "time"
+
+// frequencies of a recurring calendar rule
+const (
+	Weekly  = "weekly"
+	Monthly = "monthly"
+	Yearly  = "yearly"
+)
+
+// business day conventions, they say where a date falling on a weekend moves to
+const (
+	BusinessDayNone      = "none"
+	BusinessDayFollowing = "following"
+	BusinessDayPreceding = "preceding"
+	// BusinessDayModifiedFollowing moves forward unless that leaves the month, then backward
+	BusinessDayModifiedFollowing = "modified_following"
+)
+
+// OccurrenceDate returns occurrence n (counting from 0) of a rule starting on start. monthly and yearly
+// rules keep the day of start and clamp it to short months, so the 31st gives Feb 28 and then Mar 31
+func OccurrenceDate(start time.Time, frequency string, n int) time.Time {
+	year, month, day := start.Date()
+	switch frequency {
+	case Weekly:
+		return start.AddDate(0, 0, 7*n)
+	case Monthly:
+		return clampedDate(year, month+time.Month(n), day, start.Location())
+	case Yearly:
+		return clampedDate(year+n, month, day, start.Location())
+	}
+	return start
+}
+
+// clampedDate is year-month-day, or the last day of that month when day is past it
+func clampedDate(year int, month time.Month, day int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// IsBusinessDay reports whether date is a weekday. there is no holiday calendar
+func IsBusinessDay(date time.Time) bool {
+	weekday := date.Weekday()
+	return weekday != time.Saturday && weekday != time.Sunday
+}
+
+// AdjustBusinessDay moves date to a business day following convention
+func AdjustBusinessDay(date time.Time, convention string) time.Time {
+	switch convention {
+	case BusinessDayFollowing:
+		return nextBusinessDay(date, 1)
+	case BusinessDayPreceding:
+		return nextBusinessDay(date, -1)
+	case BusinessDayModifiedFollowing:
+		if following := nextBusinessDay(date, 1); following.Month() == date.Month() {
+			return following
+		}
+		return nextBusinessDay(date, -1)
+	}
+	return date
+}
+
+func nextBusinessDay(date time.Time, step int) time.Time {
+	for !IsBusinessDay(date) {
+		date = date.AddDate(0, 0, step)
+	}
+	return date
+}
@@ -0,0 +1,67 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestOccurrenceDate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		start     time.Time
+		frequency string
+		n         int
+		want      time.Time
+	}{
+		{"WeeklyFirst", date(2024, 1, 3), Weekly, 0, date(2024, 1, 3)},
+		{"WeeklyAcrossYear", date(2024, 12, 25), Weekly, 2, date(2025, 1, 8)},
+		{"Monthly", date(2024, 1, 15), Monthly, 3, date(2024, 4, 15)},
+		{"MonthlyClampedLeapYear", date(2024, 1, 31), Monthly, 1, date(2024, 2, 29)},
+		{"MonthlyClamped", date(2023, 1, 31), Monthly, 1, date(2023, 2, 28)},
+		// clamping does not stick, the day of start comes back in longer months
+		{"MonthlyAfterClamp", date(2024, 1, 31), Monthly, 2, date(2024, 3, 31)},
+		{"MonthlyAcrossYear", date(2024, 11, 30), Monthly, 3, date(2025, 2, 28)},
+		{"Yearly", date(2024, 6, 1), Yearly, 2, date(2026, 6, 1)},
+		{"YearlyLeapDay", date(2024, 2, 29), Yearly, 1, date(2025, 2, 28)},
+		{"YearlyLeapDayAgain", date(2024, 2, 29), Yearly, 4, date(2028, 2, 29)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, OccurrenceDate(tc.start, tc.frequency, tc.n))
+		})
+	}
+}
+
+func TestAdjustBusinessDay(t *testing.T) {
+	saturday := date(2024, 6, 1)
+	monthEndSaturday := date(2024, 8, 31)
+	wednesday := date(2024, 6, 5)
+
+	testCases := []struct {
+		name       string
+		date       time.Time
+		convention string
+		want       time.Time
+	}{
+		{"None", saturday, BusinessDayNone, saturday},
+		{"BusinessDayUnchanged", wednesday, BusinessDayFollowing, wednesday},
+		{"Following", saturday, BusinessDayFollowing, date(2024, 6, 3)},
+		{"Preceding", saturday, BusinessDayPreceding, date(2024, 5, 31)},
+		{"ModifiedFollowing", saturday, BusinessDayModifiedFollowing, date(2024, 6, 3)},
+		// following would leave August, so it moves back to Friday the 30th
+		{"ModifiedFollowingMonthEnd", monthEndSaturday, BusinessDayModifiedFollowing, date(2024, 8, 30)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, AdjustBusinessDay(tc.date, tc.convention))
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package util
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 // Config stores all configuration of the application
 // the values are read by viper from config file or environment variables
@@ -14,7 +18,106 @@ type Config struct {
 	DBSource string `mapstructure:"DB_SOURCE"`
 	// ServerAddress is the address to listen on for HTTP requests
 	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+	// ShutdownDrainPeriod is how long readiness fails before the server stops accepting connections
+	ShutdownDrainPeriod time.Duration `mapstructure:"SHUTDOWN_DRAIN_PERIOD"`
+	// ShutdownTimeout bounds how long in-flight requests get to finish once draining is over
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	// LogLevel is the minimum level written by the JSON logger: debug, info, warn or error
+	LogLevel string `mapstructure:"LOG_LEVEL"`
+	// TracingExporter selects where spans go: none, otlp, stdout or file
+	TracingExporter string `mapstructure:"TRACING_EXPORTER"`
+	// TracingOTLPEndpoint is the host:port of the OTLP/HTTP collector
+	TracingOTLPEndpoint string `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	// TracingOTLPInsecure disables TLS towards the collector
+	TracingOTLPInsecure bool `mapstructure:"TRACING_OTLP_INSECURE"`
+	// TracingFilePath is where the file exporter appends spans as JSON
+	TracingFilePath string `mapstructure:"TRACING_FILE_PATH"`
+	// TracingSampleRatio is the fraction of new traces that are sampled, from 0 to 1
+	TracingSampleRatio float64 `mapstructure:"TRACING_SAMPLE_RATIO"`
+	// GRPCServerAddress is the address to listen on for gRPC requests
+	GRPCServerAddress string `mapstructure:"GRPC_SERVER_ADDRESS"`
 	// TokenSymmetricKey is the symmetric key used to sign tokens
+	TokenSymmetricKey string `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	// AccessTokenDuration is how long an access token stays valid
+	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	// OutboxSinks lists where outbox events are delivered, comma separated: stdout, file, webhook. empty disables the dispatcher
+	OutboxSinks string `mapstructure:"OUTBOX_SINKS"`
+	// OutboxWebhookURL receives a POST per event when the webhook sink is enabled
+	OutboxWebhookURL string `mapstructure:"OUTBOX_WEBHOOK_URL"`
+	// OutboxFilePath is where the file sink appends events as JSON lines
+	OutboxFilePath string `mapstructure:"OUTBOX_FILE_PATH"`
+	// OutboxPollInterval is how long the dispatcher waits when there was nothing to send
+	OutboxPollInterval time.Duration `mapstructure:"OUTBOX_POLL_INTERVAL"`
+	// OutboxBatchSize is how many events one dispatch claims at most
+	OutboxBatchSize int32 `mapstructure:"OUTBOX_BATCH_SIZE"`
+	// WebhookPollInterval is how long the webhook deliverer waits when nothing was due
+	WebhookPollInterval time.Duration `mapstructure:"WEBHOOK_POLL_INTERVAL"`
+	// WebhookBatchSize is how many deliveries one batch claims at most
+	WebhookBatchSize int32 `mapstructure:"WEBHOOK_BATCH_SIZE"`
+	// WebhookTimeout bounds each call to a customer endpoint, a slow receiver counts as a failed attempt
+	WebhookTimeout time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
+	// WebhookAllowInsecure lets endpoints use plain http and loopback or private addresses, for local receivers only
+	WebhookAllowInsecure bool `mapstructure:"WEBHOOK_ALLOW_INSECURE"`
+	// SchedulerPollInterval is how often the scheduler looks for scheduled transfers that became due
+	SchedulerPollInterval time.Duration `mapstructure:"SCHEDULER_POLL_INTERVAL"`
+	// JobConcurrency is how many background jobs this replica runs at once
+	JobConcurrency int `mapstructure:"JOB_CONCURRENCY"`
+	// JobPollInterval is how long the job worker waits when nothing was due or all its goroutines were busy
+	JobPollInterval time.Duration `mapstructure:"JOB_POLL_INTERVAL"`
+	// JobVisibilityTimeout bounds each job attempt, a claimed job is hidden from other workers for that long
+	JobVisibilityTimeout time.Duration `mapstructure:"JOB_VISIBILITY_TIMEOUT"`
+	// TransferApprovalThresholds holds transfers above these amounts for a second person's approval,
+	// comma separated currency:amount pairs. a currency that is not listed never needs approval
+	TransferApprovalThresholds string `mapstructure:"TRANSFER_APPROVAL_THRESHOLDS"`
+	// TransferStepUpThresholds asks for a second factor again before transfers above these amounts, in the same
+	// format as TransferApprovalThresholds. a currency that is not listed never needs it
+	TransferStepUpThresholds string `mapstructure:"TRANSFER_STEP_UP_THRESHOLDS"`
+	// TOTPIssuer names the bank in the authenticator apps of users
+	TOTPIssuer string `mapstructure:"TOTP_ISSUER"`
+	// LoginMaxFailures and LoginMaxFailuresPerIP are the failed logins of a username or from an IP, within
+	// LoginFailureWindow, that lock them out for LoginLockoutDuration
+	LoginMaxFailures      int32         `mapstructure:"LOGIN_MAX_FAILURES"`
+	LoginMaxFailuresPerIP int32         `mapstructure:"LOGIN_MAX_FAILURES_PER_IP"`
+	LoginFailureWindow    time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	LoginLockoutDuration  time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	// LoginBaseDelay is the wait after the first failed login, it doubles with every further failure
+	LoginBaseDelay time.Duration `mapstructure:"LOGIN_BASE_DELAY"`
+	// RateLimitBackend keeps the buckets of the rate limiter: memory, or postgres for replicas to share them
+	RateLimitBackend string `mapstructure:"RATE_LIMIT_BACKEND"`
+	// RateLimits are the limits of the route groups and roles, see ratelimit.ParseLimits. nothing is limited
+	// when empty
+	RateLimits string `mapstructure:"RATE_LIMITS"`
+	// APIKeyRotationGrace is how long a rotated API key keeps working, for the clients to switch to the new one
+	APIKeyRotationGrace time.Duration `mapstructure:"API_KEY_ROTATION_GRACE"`
+	// TrustedProxies are the comma separated IPs and CIDRs of the proxies whose X-Forwarded-For and X-Real-IP
+	// headers give the client IP. empty trusts none and uses the address of the peer
+	TrustedProxies string `mapstructure:"TRUSTED_PROXIES"`
+	// Mailer is how emails are sent: log, file or smtp
+	Mailer string `mapstructure:"MAILER"`
+	// MailFrom is the sender address of the emails
+	MailFrom string `mapstructure:"MAIL_FROM"`
+	// MailFilePath is where the file mailer appends emails as JSON lines
+	MailFilePath string `mapstructure:"MAIL_FILE_PATH"`
+	// SMTPHost, SMTPPort, SMTPUsername and SMTPPassword reach the mail server of the smtp mailer, it sends
+	// without authentication when SMTPUsername is empty
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     int    `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	// MailLinkBaseURL is the web app the links of the emails open, e.g. https://bank.example.com
+	MailLinkBaseURL string `mapstructure:"MAIL_LINK_BASE_URL"`
+	// EmailVerificationTTL is how long the link that verifies an email stays valid
+	EmailVerificationTTL time.Duration `mapstructure:"EMAIL_VERIFICATION_TTL"`
+	// PasswordResetTTL is how long the link that resets a password stays valid
+	PasswordResetTTL time.Duration `mapstructure:"PASSWORD_RESET_TTL"`
+	// TransferApprovalTTL is how long a held transfer waits for a review before it expires
+	TransferApprovalTTL time.Duration `mapstructure:"TRANSFER_APPROVAL_TTL"`
+	// SanctionsListPath is the sanctions list in OFAC SDN CSV format, names are not screened when empty
+	SanctionsListPath string `mapstructure:"SANCTIONS_LIST_PATH"`
+	// SanctionsBlockScore and SanctionsReviewScore are the name similarities, from 0 to 1, from which a match
+	// blocks the user or opens a case for review
+	SanctionsBlockScore  float64 `mapstructure:"SANCTIONS_BLOCK_SCORE"`
+	SanctionsReviewScore float64 `mapstructure:"SANCTIONS_REVIEW_SCORE"`
 }
 
 // LoadConfig reads configuration from a file or environment variables
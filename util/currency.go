@@ -1,5 +1,11 @@
 package util
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 const (
 	USD = "USD"
 	EUR = "EUR"
@@ -13,3 +19,37 @@ func IsSupportedCurrency(currency string) bool {
 	}
 	return false
 }
+
+// SupportedCurrencies lists every currency accepted by IsSupportedCurrency
+func SupportedCurrencies() []string {
+	return []string{USD, EUR, CAD}
+}
+
+// ParseCurrencyAmounts parses a comma separated list of currency:amount pairs such as "USD:100000,EUR:90000",
+// the way per currency limits are configured. an empty string is an empty map
+func ParseCurrencyAmounts(s string) (map[string]int64, error) {
+	amounts := map[string]int64{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		currency, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("%q is not currency:amount", pair)
+		}
+		currency = strings.TrimSpace(currency)
+		if !IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("unsupported currency %q", currency)
+		}
+		if _, ok := amounts[currency]; ok {
+			return nil, fmt.Errorf("currency %s is listed twice", currency)
+		}
+		amount, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || amount < 0 {
+			return nil, fmt.Errorf("invalid amount %q for %s", value, currency)
+		}
+		amounts[currency] = amount
+	}
+	return amounts, nil
+}
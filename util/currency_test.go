@@ -0,0 +1,22 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCurrencyAmounts(t *testing.T) {
+	amounts, err := ParseCurrencyAmounts(" USD:100000, EUR: 90000,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{USD: 100000, EUR: 90000}, amounts)
+
+	amounts, err = ParseCurrencyAmounts("")
+	require.NoError(t, err)
+	require.Empty(t, amounts)
+
+	for _, invalid := range []string{"USD", "XYZ:10", "USD:ten", "USD:-1", "USD:1,USD:2"} {
+		_, err := ParseCurrencyAmounts(invalid)
+		require.Error(t, err, invalid)
+	}
+}
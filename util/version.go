@@ -0,0 +1,31 @@
+package util
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Commit is the git commit the binary was built from.
+// it is set at build time: go build -ldflags "-X github.com/ShubhKanodia/GoBank/util.Commit=$(git rev-parse HEAD)"
+var Commit = ""
+
+// BuildCommit returns the commit the binary was built from.
+// falls back to the vcs info stamped by the go toolchain when the ldflag was not set
+func BuildCommit() string {
+	if Commit != "" {
+		return Commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return "unknown"
+}
+
+// GoVersion returns the version of the go toolchain the binary was built with
+func GoVersion() string {
+	return runtime.Version()
+}
@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ShubhKanodia/GoBank/apperror"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/metrics"
+	"github.com/ShubhKanodia/GoBank/util"
+)
+
+const (
+	// lease hides a claimed delivery from other deliverers while it is being sent
+	lease = 2 * time.Minute
+	// MaxAttempts is how many times a delivery is tried before it is dead
+	MaxAttempts = 10
+	// minBackoff and maxBackoff bound the delay between attempts, ten attempts span about four hours
+	minBackoff = 30 * time.Second
+	maxBackoff = 2 * time.Hour
+)
+
+// Message is the JSON body of every delivery
+type Message struct {
+	ID        int64           `json:"id"`
+	EventType string          `json:"event_type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Deliverer sends due webhook deliveries, several can run against the same table
+type Deliverer struct {
+	store        db.Store
+	client       *http.Client
+	batchSize    int32
+	pollInterval time.Duration
+	now          func() time.Time
+}
+
+func NewDeliverer(store db.Store, client *http.Client, batchSize int32, pollInterval time.Duration) *Deliverer {
+	return &Deliverer{
+		store:        store,
+		client:       client,
+		batchSize:    batchSize,
+		pollInterval: pollInterval,
+		now:          time.Now,
+	}
+}
+
+// Run delivers until ctx is done, waiting pollInterval whenever a batch was not full
+func (d *Deliverer) Run(ctx context.Context) {
+	for {
+		n, err := d.DeliverBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "webhook delivery batch failed", slog.String("error", err.Error()))
+		}
+		if n == int(d.batchSize) && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.pollInterval):
+		}
+	}
+}
+
+// DeliverBatch claims up to batchSize due deliveries and sends them, it returns how many were claimed
+func (d *Deliverer) DeliverBatch(ctx context.Context) (int, error) {
+	deliveries, err := d.store.ClaimWebhookDeliveries(ctx, db.ClaimWebhookDeliveriesParams{
+		LeaseUntil: d.now().Add(lease),
+		BatchSize:  d.batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+
+	endpoints := map[int64]db.WebhookEndpoint{}
+	for _, delivery := range deliveries {
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			endpoint, err = d.store.GetWebhookEndpoint(ctx, delivery.EndpointID)
+			if errors.Is(db.TranslateError(err), apperror.ErrNotFound) {
+				// deleted after the claim, its deliveries went with it
+				continue
+			}
+			if err != nil {
+				return len(deliveries), err
+			}
+			endpoints[endpoint.ID] = endpoint
+		}
+		if err := d.deliver(ctx, endpoint, delivery); err != nil {
+			return len(deliveries), err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// deliver sends one delivery and records the outcome, only errors recording it are returned
+func (d *Deliverer) deliver(ctx context.Context, endpoint db.WebhookEndpoint, delivery db.WebhookDelivery) error {
+	statusCode, sendErr := d.send(ctx, endpoint, delivery)
+	responseStatus := sql.NullInt32{Int32: int32(statusCode), Valid: statusCode != 0}
+	if sendErr == nil {
+		metrics.WebhookDeliveries.WithLabelValues(delivery.EventType, db.WebhookDeliverySucceeded).Inc()
+		return d.store.MarkWebhookDeliverySucceeded(ctx, db.MarkWebhookDeliverySucceededParams{
+			ID:                 delivery.ID,
+			LastResponseStatus: responseStatus,
+		})
+	}
+
+	attempt := delivery.Attempts + 1
+	status := db.WebhookDeliveryPending
+	if attempt >= MaxAttempts {
+		status = db.WebhookDeliveryDead
+	}
+	metrics.WebhookDeliveries.WithLabelValues(delivery.EventType, status).Inc()
+	delay := util.Backoff(attempt, minBackoff, maxBackoff)
+	slog.WarnContext(ctx, "webhook delivery failed",
+		slog.Int64("delivery_id", delivery.ID),
+		slog.Int64("endpoint_id", endpoint.ID),
+		slog.Int("attempt", int(attempt)),
+		slog.String("status", status),
+		slog.String("error", sendErr.Error()),
+	)
+	return d.store.MarkWebhookDeliveryFailed(ctx, db.MarkWebhookDeliveryFailedParams{
+		ID:                 delivery.ID,
+		Status:             status,
+		NextAttemptAt:      d.now().Add(delay),
+		LastResponseStatus: responseStatus,
+		LastError:          sql.NullString{String: sendErr.Error(), Valid: true},
+	})
+}
+
+// send POSTs the signed message, statusCode is 0 when no response came back
+func (d *Deliverer) send(ctx context.Context, endpoint db.WebhookEndpoint, delivery db.WebhookDelivery) (statusCode int, err error) {
+	body, err := json.Marshal(Message{
+		ID:        delivery.ID,
+		EventType: delivery.EventType,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryID, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, timestamp, body))
+
+	rsp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer rsp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(rsp.Body, 4096))
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return rsp.StatusCode, fmt.Errorf("endpoint answered %s", rsp.Status)
+	}
+	return rsp.StatusCode, nil
+}
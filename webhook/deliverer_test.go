@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	mockdb "github.com/ShubhKanodia/GoBank/db/mock"
+	db "github.com/ShubhKanodia/GoBank/db/sqlc"
+	"github.com/ShubhKanodia/GoBank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDeliverBatch(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// the receiver checks every request the way a customer would and fails delivery 2
+	var received []Message
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		err = Verify("whsec_test", r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, 5*time.Minute, now)
+		require.NoError(t, err)
+
+		var message Message
+		require.NoError(t, json.Unmarshal(body, &message))
+		require.Equal(t, strconv.FormatInt(message.ID, 10), r.Header.Get(HeaderDeliveryID))
+		require.Equal(t, message.EventType, r.Header.Get(HeaderEvent))
+		if message.ID == 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		received = append(received, message)
+	}))
+	defer receiver.Close()
+
+	endpoint := db.WebhookEndpoint{ID: 7, Owner: util.RandomOwner(), Url: receiver.URL, Secret: "whsec_test"}
+	delivery1 := randomDelivery(1, endpoint.ID, 0)
+	delivery2 := randomDelivery(2, endpoint.ID, 3)
+	delivery3 := randomDelivery(3, endpoint.ID, 0)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Eq(db.ClaimWebhookDeliveriesParams{
+		LeaseUntil: now.Add(lease),
+		BatchSize:  10,
+	})).Times(1).Return([]db.WebhookDelivery{delivery3, delivery1, delivery2}, nil)
+	// endpoints are loaded once per batch
+	store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+	for _, id := range []int64{delivery1.ID, delivery3.ID} {
+		store.EXPECT().MarkWebhookDeliverySucceeded(gomock.Any(), gomock.Eq(db.MarkWebhookDeliverySucceededParams{
+			ID:                 id,
+			LastResponseStatus: sql.NullInt32{Int32: http.StatusOK, Valid: true},
+		})).Times(1)
+	}
+	store.EXPECT().MarkWebhookDeliveryFailed(gomock.Any(), gomock.Eq(db.MarkWebhookDeliveryFailedParams{
+		ID:                 delivery2.ID,
+		Status:             db.WebhookDeliveryPending,
+		NextAttemptAt:      now.Add(util.Backoff(4, minBackoff, maxBackoff)),
+		LastResponseStatus: sql.NullInt32{Int32: http.StatusInternalServerError, Valid: true},
+		LastError:          sql.NullString{String: "endpoint answered 500 Internal Server Error", Valid: true},
+	})).Times(1)
+
+	deliverer := NewDeliverer(store, receiver.Client(), 10, time.Second)
+	deliverer.now = func() time.Time { return now }
+
+	n, err := deliverer.DeliverBatch(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	require.Len(t, received, 2)
+	require.Equal(t, delivery1.ID, received[0].ID)
+	require.Equal(t, delivery3.ID, received[1].ID)
+	require.JSONEq(t, string(delivery1.Payload), string(received[0].Data))
+}
+
+func TestDeliverBatchDead(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	// nothing listens here, the request fails without a response
+	endpoint := db.WebhookEndpoint{ID: 7, Url: "http://127.0.0.1:1", Secret: "whsec_test"}
+	delivery := randomDelivery(1, endpoint.ID, MaxAttempts-1)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any()).Times(1).Return([]db.WebhookDelivery{delivery}, nil)
+	store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+	store.EXPECT().MarkWebhookDeliveryFailed(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.MarkWebhookDeliveryFailedParams) error {
+			require.Equal(t, db.WebhookDeliveryDead, arg.Status)
+			require.False(t, arg.LastResponseStatus.Valid)
+			require.True(t, arg.LastError.Valid)
+			return nil
+		})
+
+	deliverer := NewDeliverer(store, &http.Client{Timeout: time.Second}, 10, time.Second)
+	deliverer.now = func() time.Time { return now }
+
+	n, err := deliverer.DeliverBatch(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+func TestDeliverBatchEndpointDeleted(t *testing.T) {
+	delivery := randomDelivery(1, 7, 0)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any()).Times(1).Return([]db.WebhookDelivery{delivery}, nil)
+	store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(delivery.EndpointID)).Times(1).Return(db.WebhookEndpoint{}, sql.ErrNoRows)
+	store.EXPECT().MarkWebhookDeliverySucceeded(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().MarkWebhookDeliveryFailed(gomock.Any(), gomock.Any()).Times(0)
+
+	n, err := NewDeliverer(store, http.DefaultClient, 10, time.Second).DeliverBatch(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+func randomDelivery(id int64, endpointID int64, attempts int32) db.WebhookDelivery {
+	return db.WebhookDelivery{
+		ID:         id,
+		EndpointID: endpointID,
+		EventType:  db.WebhookEventTransferReceived,
+		Payload:    json.RawMessage(`{"account_id":42}`),
+		Status:     db.WebhookDeliveryPending,
+		Attempts:   attempts,
+		CreatedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,68 @@
+// Package webhook calls the endpoints customers register to hear about their accounts.
+// every request is signed so receivers can check it comes from us and was not replayed
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headers set on every delivery
+const (
+	HeaderDeliveryID = "X-GoBank-Delivery"
+	HeaderEvent      = "X-GoBank-Event"
+	HeaderTimestamp  = "X-GoBank-Timestamp"
+	HeaderSignature  = "X-GoBank-Signature"
+)
+
+// signatureVersion prefixes the signature so the scheme can change without breaking receivers
+const signatureVersion = "v1="
+
+var (
+	ErrInvalidSignature = errors.New("webhook signature does not match")
+	ErrTimestampExpired = errors.New("webhook timestamp is outside the tolerance")
+)
+
+// Sign returns the signature header value for body sent at timestamp (unix seconds):
+// v1= followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return signatureVersion + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the timestamp and signature headers of a delivery, as a receiver should.
+// tolerance bounds how old (or how far in the future) the timestamp may be, which stops replays
+func Verify(secret string, timestamp string, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+		return ErrTimestampExpired
+	}
+	if !strings.HasPrefix(signature, signatureVersion) {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// NewSecret generates a signing secret for an endpoint registered without one
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
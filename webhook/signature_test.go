@@ -0,0 +1,28 @@
+package webhook
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	body := []byte(`{"id":1}`)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign(secret, now.Unix(), body)
+
+	require.NoError(t, Verify(secret, timestamp, signature, body, 5*time.Minute, now))
+	require.NoError(t, Verify(secret, timestamp, signature, body, 5*time.Minute, now.Add(4*time.Minute)))
+
+	require.ErrorIs(t, Verify(secret, timestamp, signature, []byte(`{"id":2}`), 5*time.Minute, now), ErrInvalidSignature)
+	require.ErrorIs(t, Verify("whsec_other", timestamp, signature, body, 5*time.Minute, now), ErrInvalidSignature)
+	require.ErrorIs(t, Verify(secret, timestamp, signature[len(signatureVersion):], body, 5*time.Minute, now), ErrInvalidSignature)
+	require.ErrorIs(t, Verify(secret, "yesterday", signature, body, 5*time.Minute, now), ErrInvalidSignature)
+	// a replayed delivery carries its old timestamp, re-signing it needs the secret
+	require.ErrorIs(t, Verify(secret, timestamp, signature, body, 5*time.Minute, now.Add(6*time.Minute)), ErrTimestampExpired)
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenTarget is returned when an endpoint resolves to an address inside our network
+var ErrForbiddenTarget = errors.New("webhook target address is not allowed")
+
+// CheckURL refuses endpoint URLs that are not https or that name a loopback, private, link-local or unspecified
+// address. host names are only resolved when a delivery dials them, NewClient checks the address then.
+// allowInsecure lets local receivers and plain http through, for development and tests only
+func CheckURL(rawURL string, allowInsecure bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Hostname() == "" {
+		return errors.New("url has no host")
+	}
+	if allowInsecure {
+		return nil
+	}
+	if u.Scheme != "https" {
+		return errors.New("url must use https")
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenTarget
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && forbidden(addr) {
+		return ErrForbiddenTarget
+	}
+	return nil
+}
+
+// NewClient returns the client deliveries are sent with. redirects are never followed, a 3xx answer is a failed
+// attempt. unless allowInsecure is set, every connection is checked after DNS resolution so a name that resolves,
+// or later rebinds, to an address inside our network is refused
+func NewClient(timeout time.Duration, allowInsecure bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowInsecure {
+		dialer.Control = controlTarget
+	}
+	transport := &http.Transport{
+		// a proxy would be the address dialed and checked, endpoints are called directly
+		Proxy:               nil,
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// controlTarget runs on the resolved address of every connection before it is made
+func controlTarget(network string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, address)
+	}
+	if forbidden(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, addrPort.Addr())
+	}
+	return nil
+}
+
+func forbidden(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsUnspecified()
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckURL(t *testing.T) {
+	require.NoError(t, CheckURL("https://example.com/hooks", false))
+	require.Error(t, CheckURL("http://example.com/hooks", false))
+	require.ErrorIs(t, CheckURL("https://localhost:8443/hooks", false), ErrForbiddenTarget)
+	for _, host := range []string{"127.0.0.1", "10.1.2.3", "192.168.0.10", "169.254.169.254", "0.0.0.0", "[::1]", "[fe80::1]", "[::ffff:127.0.0.1]"} {
+		require.ErrorIs(t, CheckURL("https://"+host+"/hooks", false), ErrForbiddenTarget, host)
+	}
+
+	// local receivers for development
+	require.NoError(t, CheckURL("http://127.0.0.1:8080/hooks", true))
+	require.Error(t, CheckURL("/hooks", true))
+}
+
+func TestNewClient(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/hooks", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	post := func(client *http.Client, path string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, receiver.URL+path, nil)
+		require.NoError(t, err)
+		return client.Do(req)
+	}
+
+	// the receiver listens on loopback, whatever name the URL used
+	_, err := post(NewClient(time.Second, false), "/hooks")
+	require.ErrorIs(t, err, ErrForbiddenTarget)
+
+	client := NewClient(time.Second, true)
+	rsp, err := post(client, "/hooks")
+	require.NoError(t, err)
+	rsp.Body.Close()
+	require.Equal(t, http.StatusNoContent, rsp.StatusCode)
+
+	// a redirect is the answer, it is not followed
+	rsp, err = post(client, "/redirect")
+	require.NoError(t, err)
+	rsp.Body.Close()
+	require.Equal(t, http.StatusFound, rsp.StatusCode)
+}